@@ -24,11 +24,14 @@ package main
 // https://redhatinsights.github.io/insights-operator-controller/packages/controller.html
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 
@@ -40,20 +43,34 @@ import (
 // ConfigurationEnvVarName contains name of environment variable with configuration file name settiongs
 const ConfigurationEnvVarName = "INSIGHTS_CONTROLLER_CONFIG_FILE"
 
+// memoryDriver is name of pseudo-driver that selects in-memory storage
+const memoryDriver = "memory"
+
 // Configuration represents service configuration
 type Configuration struct {
-	UseHTTPS             bool
-	Address              string
-	TLSCert              string
-	TLSKey               string
-	DbDriver             string
-	StorageSpecification string
-	SplunkEnabled        bool
-	SplunkAddress        string
-	SplunkToken          string
-	SplunkSource         string
-	SplunkSourceType     string
-	SplunkIndex          string
+	UseHTTPS                      bool
+	Address                       string
+	TLSCert                       string
+	TLSKey                        string
+	DbDriver                      string
+	StorageSpecification          string
+	SplunkEnabled                 bool
+	SplunkAddress                 string
+	SplunkToken                   string
+	SplunkSource                  string
+	SplunkSourceType              string
+	SplunkIndex                   string
+	QueryTimeout                  time.Duration
+	DeletedRetention              time.Duration
+	TriggerSweepInterval          time.Duration
+	RolloutInterval               time.Duration
+	ConfigurationScheduleInterval time.Duration
+	StaleClusterWindow            time.Duration
+	StaleClusterInterval          time.Duration
+	AutoMigrate                   bool
+	Migrate                       bool
+	MigrationDryRun               bool
+	RepairConfigurations          bool
 }
 
 func initializeSplunk(cfg *Configuration) logging.Client {
@@ -65,6 +82,101 @@ func initializeSplunk(cfg *Configuration) logging.Client {
 		cfg.SplunkIndex)
 }
 
+// initializeStorage creates the storage instance for the selected driver.
+// Driver named "memory" selects storage that keeps all data in memory only.
+func initializeStorage(cfg *Configuration) (storage.Storage, error) {
+	if cfg.DbDriver == memoryDriver {
+		memoryStorage := storage.NewMemoryStorage()
+		// the same initial data as in local_storage/init_data_*.sql and migrations
+		err := memoryStorage.NewTriggerType(context.Background(), "must-gather", "Triggers must-gather operation on selected cluster",
+			storage.MustGatherParametersSchema)
+		if err != nil {
+			return nil, err
+		}
+		err = memoryStorage.SetTriggerTypeDefaultTTL(context.Background(), "must-gather", storage.MustGatherDefaultTTL)
+		if err != nil {
+			return nil, err
+		}
+		err = memoryStorage.SetTriggerTypeRequiresCase(context.Background(), "must-gather", true)
+		return memoryStorage, err
+	}
+	dbStorage, err := storage.New(cfg.DbDriver, cfg.StorageSpecification)
+	if err != nil {
+		return nil, err
+	}
+	return dbStorage.WithQueryTimeout(cfg.QueryTimeout), nil
+}
+
+// migrateStorage checks the database schema version and migrates the schema
+// to the latest version when it is requested. In-memory storage does not
+// have any schema so nothing is performed for it.
+func migrateStorage(storageInstance storage.Storage, cfg *Configuration) error {
+	dbStorage, ok := storageInstance.(storage.DBStorage)
+	if !ok {
+		return nil
+	}
+
+	if cfg.MigrationDryRun {
+		pending, err := dbStorage.PendingMigrations(context.Background())
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			log.Println("No pending migrations, database schema is up to date")
+		}
+		for _, migration := range pending {
+			log.Printf("Pending migration to schema version %d: %s", migration.Version, migration.Description)
+		}
+		return nil
+	}
+
+	if cfg.Migrate || cfg.AutoMigrate {
+		return dbStorage.MigrateToLatest(context.Background())
+	}
+
+	// schema has to be at the latest version even when migration is disabled
+	return dbStorage.CheckSchemaVersion(context.Background())
+}
+
+// repairStorage deactivates all but the newest active configuration of each
+// cluster when it is requested, so at most one configuration of each cluster
+// stays active.
+func repairStorage(storageInstance storage.Storage, cfg *Configuration) error {
+	if !cfg.RepairConfigurations {
+		return nil
+	}
+
+	repaired, err := storageInstance.RepairActiveConfigurations(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("Number of deactivated cluster configurations: %d", repaired)
+	return nil
+}
+
+// prepareStorage migrates the database schema (or checks its version) first,
+// so new database can be set up, then it checks that the storage is really
+// configured properly and repairs cluster configurations when requested.
+func prepareStorage(storageInstance storage.Storage, cfg *Configuration) error {
+	err := migrateStorage(storageInstance, cfg)
+	if err != nil {
+		return err
+	}
+
+	// dry run does not change the schema, so there might be nothing to check
+	if cfg.MigrationDryRun {
+		return nil
+	}
+
+	// try to check if storage is really configured properly
+	err = storageInstance.Ping(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return repairStorage(storageInstance, cfg)
+}
+
 func readConfigurationFile(envVar string) error {
 	configFile, specified := os.LookupEnv(envVar)
 	if specified {
@@ -97,6 +209,8 @@ func readConfiguration(envVar string) (Configuration, error) {
 	cfg.Address = serviceCfg.GetString("address")
 	cfg.TLSCert = serviceCfg.GetString("tls_cert")
 	cfg.TLSKey = serviceCfg.GetString("tls_key")
+	cfg.StaleClusterWindow = serviceCfg.GetDuration("stale_cluster_window")
+	cfg.StaleClusterInterval = serviceCfg.GetDuration("stale_cluster_check_interval")
 
 	splunkCfg := viper.Sub("splunk")
 	cfg.SplunkEnabled = splunkCfg.GetBool("enabled")
@@ -109,12 +223,25 @@ func readConfiguration(envVar string) (Configuration, error) {
 	storageCfg := viper.Sub("storage")
 	cfg.DbDriver = storageCfg.GetString("driver")
 	cfg.StorageSpecification = splunkCfg.GetString("source")
+	cfg.QueryTimeout = storageCfg.GetDuration("query_timeout")
+	cfg.DeletedRetention = storageCfg.GetDuration("deleted_retention")
+	cfg.TriggerSweepInterval = storageCfg.GetDuration("trigger_sweep_interval")
+	cfg.RolloutInterval = storageCfg.GetDuration("rollout_interval")
+	cfg.ConfigurationScheduleInterval = storageCfg.GetDuration("configuration_schedule_interval")
+	cfg.AutoMigrate = storageCfg.GetBool("auto_migrate")
 
 	// parse all command-line arguments
 	dbDriver := flag.String("dbdriver", "sqlite3", "database driver specification")
 	storageSpecification := flag.String("storage", "./controller.db", "storage specification")
+	migrate := flag.Bool("migrate", false, "migrate database schema to the latest version and exit")
+	migrationDryRun := flag.Bool("migrate-dry-run", false, "list pending database schema migrations and exit")
+	repairConfigurations := flag.Bool("repair-configurations", false, "deactivate all but the newest active configuration of each cluster and exit")
 	flag.Parse()
 
+	cfg.Migrate = *migrate
+	cfg.MigrationDryRun = *migrationDryRun
+	cfg.RepairConfigurations = *repairConfigurations
+
 	// override configuration by CLI parameter
 	if dbDriver != nil {
 		cfg.DbDriver = *dbDriver
@@ -130,7 +257,11 @@ func readConfiguration(envVar string) (Configuration, error) {
 
 // Entry point to the Insights operator controller.
 // It performs several tasks:
+// - check the database schema version and migrate it if needed
 // - connect to the storage with basic test if storage is accessible
+// - repair cluster configurations with more than one active configuration if requested
+// - start the sweeper that marks expired triggers
+// - start the monitor that counts stale clusters
 // - start the HTTP server with all required endpints
 // - TODO: initialize connection to the logging service
 func main() {
@@ -140,27 +271,53 @@ func main() {
 	}
 
 	// try to initialize the storage
-	storageInstance, err := storage.New(cfg.DbDriver, cfg.StorageSpecification)
+	storageInstance, err := initializeStorage(&cfg)
 	if err != nil {
 		panic(err)
 	}
 	defer storageInstance.Close()
 
-	// try to check if storage is really configured properly
-	err = storageInstance.Ping()
+	err = prepareStorage(storageInstance, &cfg)
 	if err != nil {
 		panic(err)
 	}
 
+	// only migration or repair has been requested
+	if cfg.Migrate || cfg.MigrationDryRun || cfg.RepairConfigurations {
+		return
+	}
+
+	// expired triggers are marked in background
+	if cfg.TriggerSweepInterval > 0 {
+		go storage.RunTriggerSweeper(context.Background(), storageInstance, cfg.TriggerSweepInterval)
+	}
+
+	// running configuration rollouts are advanced in background
+	if cfg.RolloutInterval > 0 {
+		go storage.RunRolloutScheduler(context.Background(), storageInstance, cfg.RolloutInterval)
+	}
+
+	// activation and expiry of configurations with time window are recorded in background
+	if cfg.ConfigurationScheduleInterval > 0 {
+		go storage.RunConfigurationScheduler(context.Background(), storageInstance, cfg.ConfigurationScheduleInterval)
+	}
+
 	splunk := initializeSplunk(&cfg)
 
 	s := server.Server{
-		Address:  cfg.Address,
-		UseHTTPS: cfg.UseHTTPS,
-		Storage:  storageInstance,
-		Splunk:   splunk,
-		TLSCert:  cfg.TLSCert,
-		TLSKey:   cfg.TLSKey,
+		Address:            cfg.Address,
+		UseHTTPS:           cfg.UseHTTPS,
+		Storage:            storageInstance,
+		Splunk:             splunk,
+		TLSCert:            cfg.TLSCert,
+		TLSKey:             cfg.TLSKey,
+		DeletedRetention:   cfg.DeletedRetention,
+		StaleClusterWindow: cfg.StaleClusterWindow,
+	}
+
+	// number of stale clusters is exposed as Prometheus metric
+	if cfg.StaleClusterWindow > 0 && cfg.StaleClusterInterval > 0 {
+		go s.RunStaleClustersMonitor(context.Background(), cfg.StaleClusterInterval)
 	}
 
 	s.Initialize()
@@ -20,10 +20,12 @@ package main_test
 // https://redhatinsights.github.io/insights-operator-controller/packages/controller_test.html
 
 import (
+	"context"
 	"os"
 	"testing"
 
 	main "github.com/RedHatInsights/insights-operator-controller"
+	"github.com/RedHatInsights/insights-operator-controller/storage"
 )
 
 const (
@@ -122,3 +124,164 @@ func TestReadConfigurationFromDefaultFile(t *testing.T) {
 		t.Fatal("Error is expected for non existing configuration file")
 	}
 }
+
+// TestInitializeMemoryStorage checks whether in-memory storage can be selected by driver name
+func TestInitializeMemoryStorage(t *testing.T) {
+	cfg := main.Configuration{}
+	cfg.DbDriver = "memory"
+
+	storageInstance, err := main.InitializeStorage(&cfg)
+	if err != nil {
+		t.Fatal("Error during storage initialization", err)
+	}
+	defer storageInstance.Close()
+
+	// trigger type must-gather needs to be available
+	_, err = storageInstance.GetTriggerID(context.Background(), "must-gather")
+	if err != nil {
+		t.Fatal("Trigger type must-gather should be registered", err)
+	}
+}
+
+// TestInitializeSQLStorage checks whether SQL storage can be selected by driver name
+func TestInitializeSQLStorage(t *testing.T) {
+	cfg := main.Configuration{}
+	cfg.DbDriver = "sqlite3"
+	cfg.StorageSpecification = ":memory:"
+
+	storageInstance, err := main.InitializeStorage(&cfg)
+	if err != nil {
+		t.Fatal("Error during storage initialization", err)
+	}
+	defer storageInstance.Close()
+
+	if _, ok := storageInstance.(storage.DBStorage); !ok {
+		t.Fatal("SQL storage is expected")
+	}
+}
+
+// TestMigrateSQLStorage checks whether SQL storage schema is migrated when requested
+func TestMigrateSQLStorage(t *testing.T) {
+	cfg := main.Configuration{}
+	cfg.DbDriver = "sqlite3"
+	cfg.StorageSpecification = ":memory:"
+
+	storageInstance, err := main.InitializeStorage(&cfg)
+	if err != nil {
+		t.Fatal("Error during storage initialization", err)
+	}
+	defer storageInstance.Close()
+
+	// empty database without migration should be refused
+	err = main.MigrateStorage(storageInstance, &cfg)
+	if _, ok := err.(*storage.SchemaVersionError); !ok {
+		t.Fatal("Schema version error is expected", err)
+	}
+
+	// dry run should not change anything
+	cfg.MigrationDryRun = true
+	err = main.MigrateStorage(storageInstance, &cfg)
+	if err != nil {
+		t.Fatal("Error during dry run", err)
+	}
+
+	cfg.MigrationDryRun = false
+	cfg.AutoMigrate = true
+	err = main.MigrateStorage(storageInstance, &cfg)
+	if err != nil {
+		t.Fatal("Error during migration", err)
+	}
+
+	err = storageInstance.(storage.DBStorage).CheckSchemaVersion(context.Background())
+	if err != nil {
+		t.Fatal("Schema should be migrated to the latest version", err)
+	}
+}
+
+// TestMigrateMemoryStorage checks that migration of in-memory storage is no-op
+func TestMigrateMemoryStorage(t *testing.T) {
+	cfg := main.Configuration{}
+	cfg.DbDriver = "memory"
+
+	storageInstance, err := main.InitializeStorage(&cfg)
+	if err != nil {
+		t.Fatal("Error during storage initialization", err)
+	}
+	defer storageInstance.Close()
+
+	err = main.MigrateStorage(storageInstance, &cfg)
+	if err != nil {
+		t.Fatal("Migration of in-memory storage should not fail", err)
+	}
+}
+
+// TestPrepareNewSQLStorage checks that new database is migrated before the
+// storage is checked, so it can be set up by the controller
+func TestPrepareNewSQLStorage(t *testing.T) {
+	cfg := main.Configuration{}
+	cfg.DbDriver = "sqlite3"
+	cfg.StorageSpecification = ":memory:"
+
+	storageInstance, err := main.InitializeStorage(&cfg)
+	if err != nil {
+		t.Fatal("Error during storage initialization", err)
+	}
+	defer storageInstance.Close()
+
+	// empty database without migration should be refused
+	err = main.PrepareStorage(storageInstance, &cfg)
+	if _, ok := err.(*storage.SchemaVersionError); !ok {
+		t.Fatal("Schema version error is expected", err)
+	}
+
+	// dry run does not need the schema
+	cfg.MigrationDryRun = true
+	err = main.PrepareStorage(storageInstance, &cfg)
+	if err != nil {
+		t.Fatal("Error during dry run", err)
+	}
+
+	cfg.MigrationDryRun = false
+	cfg.AutoMigrate = true
+	err = main.PrepareStorage(storageInstance, &cfg)
+	if err != nil {
+		t.Fatal("New database should be set up", err)
+	}
+}
+
+// TestRepairStorage checks that cluster configurations are repaired only when requested
+func TestRepairStorage(t *testing.T) {
+	cfg := main.Configuration{}
+	cfg.DbDriver = "sqlite3"
+	cfg.StorageSpecification = ":memory:"
+	cfg.AutoMigrate = true
+
+	storageInstance, err := main.InitializeStorage(&cfg)
+	if err != nil {
+		t.Fatal("Error during storage initialization", err)
+	}
+	defer storageInstance.Close()
+
+	err = main.MigrateStorage(storageInstance, &cfg)
+	if err != nil {
+		t.Fatal("Error during migration", err)
+	}
+
+	err = main.RepairStorage(storageInstance, &cfg)
+	if err != nil {
+		t.Fatal("Repair should not be performed", err)
+	}
+
+	cfg.RepairConfigurations = true
+	err = main.RepairStorage(storageInstance, &cfg)
+	if err != nil {
+		t.Fatal("Error during repair", err)
+	}
+
+	// storage needs to be accessible for repair
+	storageInstance.Close()
+	err = main.RepairStorage(storageInstance, &cfg)
+	if err == nil {
+		t.Fatal("Repair of closed storage should fail")
+	}
+}
@@ -33,6 +33,10 @@ package main
 // to see why this trick is needed.
 var (
 	InitializeSplunk      = initializeSplunk
+	InitializeStorage     = initializeStorage
+	MigrateStorage        = migrateStorage
+	PrepareStorage        = prepareStorage
+	RepairStorage         = repairStorage
 	ReadConfiguration     = readConfiguration
 	ReadConfigurationFile = readConfigurationFile
 	Main                  = main
@@ -19,4 +19,5 @@ require (
 	github.com/spf13/viper v1.7.2-0.20210415161207-7fdb267c730d
 	github.com/stretchr/testify v1.6.1
 	github.com/verdverm/frisby v0.0.0-20170604211311-b16556248a9a
+	github.com/xeipuuv/gojsonschema v1.2.0
 )
@@ -78,6 +78,22 @@ func (client Client) LogTriggerAction(action, user, cluster, trigger string) err
 	return nil
 }
 
+// LogTriggerBatchAction add a new message about trigger-related action
+// performed for batch of clusters into the Splunk log.
+func (client Client) LogTriggerBatchAction(action, user, batch, clusters, trigger string) error {
+	if client.ClientImpl != nil {
+		err := client.ClientImpl.Log(
+			map[string]string{
+				"action":   action,
+				"user":     user,
+				"batch":    batch,
+				"clusters": clusters,
+				"trigger":  trigger})
+		return err
+	}
+	return nil
+}
+
 // LogWithTime add a new message with timestamp into the Splunk log.
 func (client Client) LogWithTime(time int64, key, value string) error {
 	if client.ClientImpl != nil {
@@ -113,6 +113,24 @@ func TestLogTriggerActionOperationForDisabledClient(t *testing.T) {
 	}
 }
 
+// TestLogTriggerBatchActionOperationForEnabledClient checks the Splunt.LogTriggerBatchAction method
+func TestLogTriggerBatchActionOperationForEnabledClient(t *testing.T) {
+	c := constructEnabledClient()
+	err := c.LogTriggerBatchAction("action", "user", "batch", "cluster1,cluster2", "trigger")
+	if err == nil {
+		t.Fatal("Error should be returned for enabled client with improper address")
+	}
+}
+
+// TestLogTriggerBatchActionOperationForDisabledClient checks the Splunt.LogTriggerBatchAction method
+func TestLogTriggerBatchActionOperationForDisabledClient(t *testing.T) {
+	c := constructDisabledClient()
+	err := c.LogTriggerBatchAction("action", "user", "batch", "cluster1,cluster2", "trigger")
+	if err != nil {
+		t.Fatal("Error should not be returned for disabled client")
+	}
+}
+
 // TestLogWithTimeOperationForEnabledClient checks the Splunt.LogWithTime method
 func TestLogWithTimeOperationForEnabledClient(t *testing.T) {
 	c := constructEnabledClient()
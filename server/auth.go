@@ -49,6 +49,16 @@ type Token struct {
 	jwt.StandardClaims
 }
 
+// requestUsername returns name of user that sent the request. Login from
+// authentication token is used in production environment, "username" query
+// parameter otherwise.
+func requestUsername(request *http.Request) string {
+	if login, ok := request.Context().Value(contextKeyUser).(string); ok && login != "" {
+		return login
+	}
+	return request.URL.Query().Get("username")
+}
+
 // JWTAuthentication middleware for checking auth rights
 func (s *Server) JWTAuthentication(next http.Handler) http.Handler {
 
@@ -25,6 +25,7 @@ package server
 // https://redhatinsights.github.io/insights-operator-controller/packages/server/cluster.html
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -39,16 +40,30 @@ import (
 )
 
 // GetClusters method reads list of all clusters from database and return it to a client.
+// Deleted clusters are returned only when include_deleted=true is specified.
+// The list is paginated and sorted according to the page parameters.
 func (s *Server) GetClusters(writer http.ResponseWriter, request *http.Request) {
-	// try to retrieve list of clusters from storage
-	clusters, err := s.Storage.ListOfClusters()
+	includeDeleted, err := retrieveIncludeDeletedParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Value of include_deleted parameter needs to be boolean")
+		return
+	}
+
+	page, err := retrievePageParameters(request, storage.ClusterSortColumns)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to retrieve one page of clusters from storage
+	clusters, total, err := s.Storage.ListOfClustersPage(request.Context(), includeDeleted, page)
 
 	// check if the operation has been successful
 	if err != nil {
 		log.Println("Unable to get list of clusters", err)
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 	} else {
-		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("clusters", clusters))
+		TryToSendOKServerResponse(writer, buildPageResponse(request, "clusters", clusterList(clusters), total, page))
 	}
 }
 
@@ -77,19 +92,19 @@ func (s *Server) NewCluster(writer http.ResponseWriter, request *http.Request) {
 	// and check whether the Splunk operation was successful
 	checkSplunkOperation(err)
 
-	err = s.Storage.RegisterNewCluster(clusterName)
+	err = s.Storage.RegisterNewCluster(request.Context(), clusterName)
 	if err != nil {
 		log.Println("Cannot create new cluster", err)
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 	}
 
 	// try to retrieve list of clusters from storage
-	clusters, err := s.Storage.ListOfClusters()
+	clusters, err := s.Storage.ListOfClusters(request.Context(), false)
 
 	// check if the operation has been successful
 	if err != nil {
 		log.Println("Unable to get list of clusters", err)
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendCreatedServerResponse(writer, responses.BuildOkResponseWithData("clusters", clusters))
 	}
@@ -108,19 +123,33 @@ func (s *Server) GetClusterByID(writer http.ResponseWriter, request *http.Reques
 		log.Println("Cluster ID is not specified in a request", err)
 		TryToSendResponse(http.StatusBadRequest, writer, "Error reading cluster ID from request")
 	} else {
-		cluster, err := s.Storage.GetCluster(int(id))
-		if _, ok := err.(*storage.ItemNotFoundError); ok {
-			TryToSendResponse(http.StatusNotFound, writer, err.Error())
-		} else if err != nil {
+		cluster, err := s.Storage.GetCluster(request.Context(), int(id))
+		if err != nil {
 			log.Println("Unable to read cluster from database", err)
-			TryToSendInternalServerError(writer, err.Error())
+			TryToSendStorageError(writer, err)
 		} else {
-			TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("cluster", cluster))
+			// last heartbeat and metadata are sent together with the cluster
+			heartbeat, err := s.clusterHeartbeat(request.Context(), int(id))
+			if err != nil {
+				log.Println("Unable to read heartbeat of cluster from database", err)
+				TryToSendStorageError(writer, err)
+				return
+			}
+			metadata, err := s.clusterMetadata(request.Context(), int(id))
+			if err != nil {
+				log.Println("Unable to read metadata of cluster from database", err)
+				TryToSendStorageError(writer, err)
+				return
+			}
+			resp := responses.BuildOkResponseWithData("cluster", cluster)
+			resp["heartbeat"] = heartbeat
+			resp["metadata"] = metadata
+			TryToSendOKServerResponse(writer, resp)
 		}
 	}
 }
 
-// DeleteCluster method deletes a cluster
+// DeleteCluster method marks a cluster as deleted
 func (s *Server) DeleteCluster(writer http.ResponseWriter, request *http.Request) {
 	clusterID, err := retrieveIDRequestParameter(request)
 	if err != nil {
@@ -135,26 +164,24 @@ func (s *Server) DeleteCluster(writer http.ResponseWriter, request *http.Request
 	checkSplunkOperation(err)
 
 	// delete cluster in database
-	err = s.Storage.DeleteCluster(clusterID)
+	err = s.Storage.DeleteCluster(request.Context(), clusterID, requestUsername(request))
 
 	// check if the storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
+	if err != nil {
 		log.Println("Cannot delete cluster", err)
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 	} else {
-		clusters, err := s.Storage.ListOfClusters()
+		clusters, err := s.Storage.ListOfClusters(request.Context(), false)
 		if err != nil {
 			log.Println("Unable to get list of clusters", err)
-			TryToSendInternalServerError(writer, err.Error())
+			TryToSendStorageError(writer, err)
 		} else {
 			TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("clusters", clusters))
 		}
 	}
 }
 
-// DeleteClusterByName method deletes a cluster
+// DeleteClusterByName method marks a cluster as deleted
 func (s *Server) DeleteClusterByName(writer http.ResponseWriter, request *http.Request) {
 	// get the cluster name from request
 	clusterName, foundName := mux.Vars(request)["name"]
@@ -170,19 +197,49 @@ func (s *Server) DeleteClusterByName(writer http.ResponseWriter, request *http.R
 	checkSplunkOperation(err)
 
 	// delete cluster in database
-	err = s.Storage.DeleteClusterByName(clusterName)
+	err = s.Storage.DeleteClusterByName(request.Context(), clusterName, requestUsername(request))
 
 	// check if the storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
+	if err != nil {
 		log.Println("Cannot delete cluster", err)
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
+		TryToSendStorageError(writer, err)
 	} else {
-		clusters, err := s.Storage.ListOfClusters()
+		clusters, err := s.Storage.ListOfClusters(request.Context(), false)
 		if err != nil {
 			log.Println("Unable to get list of clusters", err)
-			TryToSendInternalServerError(writer, err.Error())
+			TryToSendStorageError(writer, err)
+		} else {
+			TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("clusters", clusters))
+		}
+	}
+}
+
+// RestoreCluster method restores a cluster that has been deleted
+func (s *Server) RestoreCluster(writer http.ResponseWriter, request *http.Request) {
+	clusterID, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		log.Println("Cluster ID is not provided or not an integer")
+		TryToSendResponse(http.StatusBadRequest, writer, "Cluster ID needs to be specified and to be an integer")
+		return
+	}
+
+	// try to record the action RestoreCluster into Splunk
+	err = s.Splunk.LogAction("RestoreCluster", "tester", fmt.Sprint(clusterID))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// restore cluster in database
+	err = s.Storage.RestoreCluster(request.Context(), clusterID)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		log.Println("Cannot restore cluster", err)
+		TryToSendStorageError(writer, err)
+	} else {
+		clusters, err := s.Storage.ListOfClusters(request.Context(), false)
+		if err != nil {
+			log.Println("Unable to get list of clusters", err)
+			TryToSendStorageError(writer, err)
 		} else {
 			TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("clusters", clusters))
 		}
@@ -190,65 +247,108 @@ func (s *Server) DeleteClusterByName(writer http.ResponseWriter, request *http.R
 }
 
 // SearchCluster method searchs for a cluster specified by its ID or name.
+// Clusters can be searched by their metadata and label selector too, all
+// matching clusters are returned in that case.
 func (s *Server) SearchCluster(writer http.ResponseWriter, request *http.Request) {
 	var (
 		req     storage.SearchClusterRequest
 		cluster *storage.Cluster
 	)
 
+	// search is not supported by all storage implementations
+	if s.ClusterQuery == nil {
+		TryToSendResponse(http.StatusNotImplemented, writer, "Cluster search is not supported by the configured storage")
+		return
+	}
+
 	err := utils.DecodeValidRequest(&req, SearchClusterTemplate, request.URL.Query())
 	if err != nil {
 		log.Println(err)
 		TryToSendResponse(http.StatusBadRequest, writer, err.Error())
 		return
 	}
-	// either cluster id or its name needs to be specified
-	cluster, err = s.ClusterQuery.QueryOne(request.Context(), req)
 
-	// check if the storage operation has been successful
-	if err == storage.ErrNoSuchObj {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
+	// clusters searched by metadata or labels are listed
+	if filtersClusters(req) {
+		clusters, err := s.ClusterQuery.QueryMany(request.Context(), req)
+		if err != nil {
+			log.Println("Unable to read clusters from database", err)
+			TryToSendStorageError(writer, err)
+			return
+		}
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("clusters", clusters))
 		return
 	}
 
+	// either cluster id or its name needs to be specified
+	cluster, err = s.ClusterQuery.QueryOne(request.Context(), req)
+
+	// check if the storage operation has been successful
 	if err != nil {
 		log.Println("Unable to read cluster from database", err)
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 		return
 	}
 
 	TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("cluster", cluster))
 }
 
+// filtersClusters checks whether the search request selects clusters by
+// their metadata or labels, ie. whether more clusters can be found
+func filtersClusters(req storage.SearchClusterRequest) bool {
+	return req.Selector != "" || req.OCPVersion != "" || req.Platform != "" ||
+		req.OperatorVersion != "" || req.Region != ""
+}
+
+// clusterMetadata reads metadata of cluster specified by its ID. Nil is
+// returned for clusters without metadata.
+func (s *Server) clusterMetadata(ctx context.Context, clusterID int) (*storage.ClusterMetadata, error) {
+	metadata, err := s.Storage.GetClusterMetadata(ctx, clusterID)
+	if _, ok := err.(*storage.ItemNotFoundError); ok {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
 // SearchClusterTemplate defines validation rules and messages for SearchCluster
 var SearchClusterTemplate = utils.MergeMaps(map[string]interface{}{
 	// all acceptable fields are listed
 	// case sensitive
-	"id":   "int~Error reading and decoding cluster ID from query",
-	"name": "",
-	"":     "oneOfIdOrName~Either cluster ID or name needs to be specified",
+	"id":               "int~Error reading and decoding cluster ID from query",
+	"name":             "",
+	"selector":         "",
+	"ocp_version":      "",
+	"platform":         "",
+	"operator_version": "",
+	"region":           "",
+	"":                 "oneOfClusterFilters~Cluster ID or name or selector or metadata need to be specified",
 }, utils.PaginationTemplate)
 
-// oneOfIDOrNameValidation validates that id or name is filled
-func oneOfIDOrNameValidation(i, context interface{}) bool {
-	// Tag oneOfIdOrName
+// clusterFilters contains all query parameters that select clusters
+var clusterFilters = []string{"id", "name", "selector", "ocp_version", "platform", "operator_version", "region"}
+
+// oneOfClusterFiltersValidation validates that at least one of filters is
+// filled
+func oneOfClusterFiltersValidation(i, context interface{}) bool {
+	// Tag oneOfClusterFilters
 	v, ok := context.(map[string]interface{})
 	if !ok {
 		return false
 	}
-	// the int validation is done next by validator, we are just checking
-	// if its filled-in
-	if id, ok := v["id"].(string); ok && id != "" {
-		return true
-	}
-	// check if name is filled-in
-	if name, ok := v["name"].(string); ok && name != "" {
-		return true
+	// the int validation of id is done next by validator, we are just
+	// checking if it is filled-in
+	for _, filter := range clusterFilters {
+		if value, ok := v[filter].(string); ok && value != "" {
+			return true
+		}
 	}
 	return false
 }
 
 // init function is called during module initialization
 func init() {
-	govalidator.CustomTypeTagMap.Set("oneOfIdOrName", govalidator.CustomTypeValidator(oneOfIDOrNameValidation))
+	govalidator.CustomTypeTagMap.Set("oneOfClusterFilters", govalidator.CustomTypeValidator(oneOfClusterFiltersValidation))
 }
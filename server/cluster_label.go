@@ -0,0 +1,164 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/cluster_label.html
+
+import (
+	"net/http"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/gorilla/mux"
+)
+
+// GetClusterLabels method returns all labels of cluster specified by its name
+func (s *Server) GetClusterLabels(writer http.ResponseWriter, request *http.Request) {
+	// cluster name needs to be specified in request parameter
+	cluster, found := mux.Vars(request)["cluster"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Cluster name needs to be specified")
+		return
+	}
+
+	// try to read labels of the cluster from storage
+	labels, err := s.Storage.ListClusterLabels(request.Context(), cluster)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("labels", labels))
+	}
+}
+
+// SetClusterLabel method sets value of cluster label, already existing label
+// gets the new value
+func (s *Server) SetClusterLabel(writer http.ResponseWriter, request *http.Request) {
+	// cluster name needs to be specified in request parameter
+	cluster, found := mux.Vars(request)["cluster"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Cluster name needs to be specified")
+		return
+	}
+
+	// label key needs to be specified in request parameter
+	key, found := mux.Vars(request)["key"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Label key needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// label value needs to be specified in request, but it can be empty
+	value, foundValue := request.URL.Query()["value"]
+	if !foundValue {
+		TryToSendBadRequestServerResponse(writer, "Label value needs to be specified\n")
+		return
+	}
+
+	// try to record the action SetClusterLabel into Splunk
+	err := s.Splunk.LogAction("SetClusterLabel", username[0], cluster+" "+key+"="+value[0])
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to set the label in storage
+	err = s.Storage.SetClusterLabel(request.Context(), cluster, key, value[0])
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// DeleteClusterLabel method removes label from cluster
+func (s *Server) DeleteClusterLabel(writer http.ResponseWriter, request *http.Request) {
+	// cluster name needs to be specified in request parameter
+	cluster, found := mux.Vars(request)["cluster"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Cluster name needs to be specified")
+		return
+	}
+
+	// label key needs to be specified in request parameter
+	key, found := mux.Vars(request)["key"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Label key needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// try to record the action DeleteClusterLabel into Splunk
+	err := s.Splunk.LogAction("DeleteClusterLabel", username[0], cluster+" "+key)
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to remove the label from storage
+	err = s.Storage.DeleteClusterLabel(request.Context(), cluster, key)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// SelectClusters method returns all clusters with labels that meet all
+// requirements of label selector, for example env=prod,tier!=free
+func (s *Server) SelectClusters(writer http.ResponseWriter, request *http.Request) {
+	// label selector needs to be specified in request
+	selectorParam, found := request.URL.Query()["selector"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Label selector needs to be specified\n")
+		return
+	}
+
+	selector, err := storage.ParseLabelSelector(selectorParam[0])
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to read selected clusters from storage
+	clusters, err := s.Storage.ListClustersBySelector(request.Context(), selector)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("clusters", clusters))
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/cluster_label_test.html
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestNonErrorsClusterLabelsWithData tests OK behaviour with mock data
+func TestNonErrorsClusterLabelsWithData(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	cluster := "00000000-0000-0000-0000-000000000000"
+	nonErrorTT := []testCase{
+		{"GetClusterLabels no labels OK", serv.GetClusterLabels, http.StatusOK, "GET", true, requestData{"cluster": cluster}, requestData{}, ""},
+		{"SetClusterLabel OK", serv.SetClusterLabel, http.StatusOK, "PUT", true, requestData{"cluster": cluster, "key": "env"}, requestData{"username": "tester", "value": "prod"}, ""},
+		{"SetClusterLabel prefixed key OK", serv.SetClusterLabel, http.StatusOK, "PUT", true, requestData{"cluster": cluster, "key": "example.com/tier"}, requestData{"username": "tester", "value": "free"}, ""},
+		{"SetClusterLabel again OK", serv.SetClusterLabel, http.StatusOK, "PUT", true, requestData{"cluster": cluster, "key": "env"}, requestData{"username": "tester", "value": "stage"}, ""},
+		{"GetClusterLabels OK", serv.GetClusterLabels, http.StatusOK, "GET", true, requestData{"cluster": cluster}, requestData{}, ""},
+		{"SelectClusters OK", serv.SelectClusters, http.StatusOK, "GET", true, requestData{}, requestData{"selector": "env=stage,example.com/tier!=paid"}, ""},
+		{"SelectClusters no cluster OK", serv.SelectClusters, http.StatusOK, "GET", true, requestData{}, requestData{"selector": "env=prod"}, ""},
+		{"DeleteClusterLabel OK", serv.DeleteClusterLabel, http.StatusOK, "DELETE", true, requestData{"cluster": cluster, "key": "env"}, requestData{"username": "tester"}, ""},
+		{"DeleteClusterLabel deleted label", serv.DeleteClusterLabel, http.StatusNotFound, "DELETE", true, requestData{"cluster": cluster, "key": "env"}, requestData{"username": "tester"}, ""},
+		{"GetClusterLabels unknown cluster", serv.GetClusterLabels, http.StatusNotFound, "GET", true, requestData{"cluster": "unknown"}, requestData{}, ""},
+		{"SetClusterLabel unknown cluster", serv.SetClusterLabel, http.StatusNotFound, "PUT", true, requestData{"cluster": "unknown", "key": "env"}, requestData{"username": "tester", "value": "prod"}, ""},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestDatabaseErrorClusterLabels tests unexpected behaviour by closing DB connection (consistency check)
+func TestDatabaseErrorClusterLabels(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+
+	cluster := "00000000-0000-0000-0000-000000000000"
+	dbErrorTT := []testCase{
+		{"GetClusterLabels DB error", serv.GetClusterLabels, http.StatusInternalServerError, "GET", true, requestData{"cluster": cluster}, requestData{}, ""},
+		{"SetClusterLabel DB error", serv.SetClusterLabel, http.StatusInternalServerError, "PUT", true, requestData{"cluster": cluster, "key": "env"}, requestData{"username": "tester", "value": "prod"}, ""},
+		{"DeleteClusterLabel DB error", serv.DeleteClusterLabel, http.StatusInternalServerError, "DELETE", true, requestData{"cluster": cluster, "key": "env"}, requestData{"username": "tester"}, ""},
+		{"SelectClusters DB error", serv.SelectClusters, http.StatusInternalServerError, "GET", true, requestData{}, requestData{"selector": "env=prod"}, ""},
+	}
+
+	serv.Storage.Close()
+
+	for _, tt := range dbErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestParameterErrorsClusterLabels tests wrong request parameters
+func TestParameterErrorsClusterLabels(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	cluster := "00000000-0000-0000-0000-000000000000"
+	paramErrorTT := []testCase{
+		{"GetClusterLabels no cluster", serv.GetClusterLabels, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
+		{"SetClusterLabel no cluster", serv.SetClusterLabel, http.StatusBadRequest, "PUT", true, requestData{"key": "env"}, requestData{"username": "tester", "value": "prod"}, ""},
+		{"SetClusterLabel no key", serv.SetClusterLabel, http.StatusBadRequest, "PUT", true, requestData{"cluster": cluster}, requestData{"username": "tester", "value": "prod"}, ""},
+		{"SetClusterLabel no username", serv.SetClusterLabel, http.StatusBadRequest, "PUT", true, requestData{"cluster": cluster, "key": "env"}, requestData{"value": "prod"}, ""},
+		{"SetClusterLabel no value", serv.SetClusterLabel, http.StatusBadRequest, "PUT", true, requestData{"cluster": cluster, "key": "env"}, requestData{"username": "tester"}, ""},
+		{"SetClusterLabel wrong key", serv.SetClusterLabel, http.StatusBadRequest, "PUT", true, requestData{"cluster": cluster, "key": "-env"}, requestData{"username": "tester", "value": "prod"}, ""},
+		{"SetClusterLabel wrong value", serv.SetClusterLabel, http.StatusBadRequest, "PUT", true, requestData{"cluster": cluster, "key": "env"}, requestData{"username": "tester", "value": "not valid"}, ""},
+		{"DeleteClusterLabel no cluster", serv.DeleteClusterLabel, http.StatusBadRequest, "DELETE", true, requestData{"key": "env"}, requestData{"username": "tester"}, ""},
+		{"DeleteClusterLabel no key", serv.DeleteClusterLabel, http.StatusBadRequest, "DELETE", true, requestData{"cluster": cluster}, requestData{"username": "tester"}, ""},
+		{"DeleteClusterLabel no username", serv.DeleteClusterLabel, http.StatusBadRequest, "DELETE", true, requestData{"cluster": cluster, "key": "env"}, requestData{}, ""},
+		{"SelectClusters no selector", serv.SelectClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
+		{"SelectClusters wrong selector", serv.SelectClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"selector": "env=not valid"}, ""},
+	}
+
+	for _, tt := range paramErrorTT {
+		testRequest(t, &tt)
+	}
+}
@@ -54,8 +54,37 @@ func TestNonErrorsClusterWithData(t *testing.T) {
 		{"NewCluster OK", serv.NewCluster, http.StatusCreated, "POST", false, requestData{"name": "test"}, requestData{}, ""},
 		{"GetClusterByID OK", serv.GetClusterByID, http.StatusOK, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"SearchCluster OK", serv.SearchCluster, http.StatusOK, "GET", true, requestData{}, requestData{"name": "test"}, ""},
+		{"SearchCluster by metadata OK", serv.SearchCluster, http.StatusOK, "GET", true, requestData{}, requestData{"platform": "AWS", "ocp_version": "4.12.0", "limit": "10"}, ""},
 		{"DeleteCluster OK", serv.DeleteCluster, http.StatusOK, "DELETE", false, requestData{"id": "1"}, requestData{}, ""},
 		{"DeleteCluster OK", serv.DeleteClusterByName, http.StatusNotFound, "DELETE", false, requestData{"name": "foobar"}, requestData{}, ""},
+		{"GetClusters including deleted OK", serv.GetClusters, http.StatusOK, "GET", true, requestData{}, requestData{"include_deleted": "true"}, ""},
+		{"GetClusterByID deleted", serv.GetClusterByID, http.StatusNotFound, "GET", true, requestData{"id": "1"}, requestData{}, ""},
+		{"RestoreCluster OK", serv.RestoreCluster, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"RestoreCluster not deleted", serv.RestoreCluster, http.StatusNotFound, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"GetClusterByID restored OK", serv.GetClusterByID, http.StatusOK, "GET", true, requestData{"id": "1"}, requestData{}, ""},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestNonErrorsClusterWithMemoryStorage tests OK behaviour with in-memory storage
+func TestNonErrorsClusterWithMemoryStorage(t *testing.T) {
+	serv := MockedIOCServerWithMemoryStorage(t)
+	defer serv.Storage.Close()
+
+	nonErrorTT := []testCase{
+		{"GetClusters OK", serv.GetClusters, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"NewCluster OK", serv.NewCluster, http.StatusCreated, "POST", false, requestData{"name": "test"}, requestData{}, ""},
+		{"GetClusterByID OK", serv.GetClusterByID, http.StatusOK, "GET", true, requestData{"id": "1"}, requestData{}, ""},
+		{"GetClusterByID Not found", serv.GetClusterByID, http.StatusNotFound, "GET", true, requestData{"id": "2"}, requestData{}, ""},
+		{"SearchCluster Not implemented", serv.SearchCluster, http.StatusNotImplemented, "GET", true, requestData{}, requestData{"name": "test"}, ""},
+		{"SearchCluster by metadata Not implemented", serv.SearchCluster, http.StatusNotImplemented, "GET", true, requestData{}, requestData{"platform": "AWS"}, ""},
+		{"DeleteCluster OK", serv.DeleteCluster, http.StatusOK, "DELETE", false, requestData{"id": "1"}, requestData{}, ""},
+		{"DeleteCluster Not found", serv.DeleteCluster, http.StatusNotFound, "DELETE", false, requestData{"id": "1"}, requestData{}, ""},
+		{"RestoreCluster OK", serv.RestoreCluster, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"RestoreCluster Not found", serv.RestoreCluster, http.StatusNotFound, "PUT", true, requestData{"id": "2"}, requestData{}, ""},
 	}
 
 	for _, tt := range nonErrorTT {
@@ -72,8 +101,10 @@ func TestDatabaseErrorCluster(t *testing.T) {
 		{"NewCluster DB error", serv.NewCluster, http.StatusInternalServerError, "POST", false, requestData{"name": "test"}, requestData{}, ""},
 		{"GetClusterByID DB error", serv.GetClusterByID, http.StatusInternalServerError, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"DeleteCluster DB error", serv.DeleteCluster, http.StatusInternalServerError, "DELETE", false, requestData{"id": "1"}, requestData{}, ""},
-		{"DeleteCluster DB error", serv.DeleteClusterByName, http.StatusNotFound, "DELETE", false, requestData{"name": "foobar"}, requestData{}, ""},
+		{"DeleteCluster DB error", serv.DeleteClusterByName, http.StatusInternalServerError, "DELETE", false, requestData{"name": "foobar"}, requestData{}, ""},
 		{"SearchCluster DB error", serv.SearchCluster, http.StatusInternalServerError, "GET", true, requestData{}, requestData{"name": "test"}, ""},
+		{"SearchCluster by metadata DB error", serv.SearchCluster, http.StatusInternalServerError, "GET", true, requestData{}, requestData{"platform": "AWS"}, ""},
+		{"RestoreCluster DB error", serv.RestoreCluster, http.StatusInternalServerError, "PUT", false, requestData{"id": "1"}, requestData{}, ""},
 	}
 
 	// close DB
@@ -100,6 +131,11 @@ func TestParameterErrorsCluster(t *testing.T) {
 		{"DeleteCluster by non-int id", serv.DeleteCluster, http.StatusBadRequest, "DELETE", false, requestData{"id": "non-int"}, requestData{}, ""},
 		{"SearchCluster no params", serv.SearchCluster, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
 		{"SearchCluster wrong data type", serv.SearchCluster, http.StatusBadRequest, "GET", true, requestData{"name": ""}, requestData{}, ""},
+		{"SearchCluster non-int id", serv.SearchCluster, http.StatusBadRequest, "GET", true, requestData{}, requestData{"id": "non-int"}, ""},
+		{"SearchCluster unknown filter", serv.SearchCluster, http.StatusBadRequest, "GET", true, requestData{}, requestData{"name": "test", "color": "red"}, ""},
+		{"SearchCluster wrong selector", serv.SearchCluster, http.StatusBadRequest, "GET", true, requestData{}, requestData{"selector": "env=not valid"}, ""},
+		{"GetClusters non-bool include_deleted", serv.GetClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"include_deleted": "maybe"}, ""},
+		{"RestoreCluster non-int id", serv.RestoreCluster, http.StatusBadRequest, "PUT", false, requestData{"id": "non-int"}, requestData{}, ""},
 	}
 
 	for _, tt := range paramErrorTT {
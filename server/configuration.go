@@ -26,10 +26,13 @@ import (
 	"fmt"
 
 	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-controller/utils"
 	"github.com/RedHatInsights/insights-operator-utils/responses"
 	"github.com/gorilla/mux"
 	"io"
+	"log"
 	"net/http"
+	"strconv"
 )
 
 // sendConfiguration is helper function to send cluster configuration to client
@@ -49,19 +52,20 @@ func (s *Server) GetConfiguration(writer http.ResponseWriter, request *http.Requ
 	}
 
 	// try to read cluster configuration specified by ID from storage
-	configuration, err := s.Storage.GetClusterConfigurationByID(id)
+	configuration, version, err := s.Storage.GetClusterConfigurationByID(request.Context(), id)
 
 	// check if storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
+		setETagHeader(writer, version)
 		sendConfiguration(writer, configuration)
 	}
 }
 
-// DeleteConfiguration method removes single configuration specified by its ID
+// DeleteConfiguration method marks single configuration specified by its ID
+// as deleted. ETag of the configuration needs to be specified in If-Match
+// header.
 func (s *Server) DeleteConfiguration(writer http.ResponseWriter, request *http.Request) {
 	// configuration ID needs to be specified in request
 	id, err := retrieveIDRequestParameter(request)
@@ -70,38 +74,132 @@ func (s *Server) DeleteConfiguration(writer http.ResponseWriter, request *http.R
 		return
 	}
 
+	// expected version of configuration needs to be specified in request
+	version, err := retrieveIfMatchVersion(request)
+	if err != nil {
+		sendIfMatchError(writer, err)
+		return
+	}
+
 	// try to record the action DeleteConfigurationById into Splunk
 	err = s.Splunk.LogAction("DeleteClusterConfigurationById", "tester", fmt.Sprint(id))
 	// and check whether the Splunk operation was successful
 	checkSplunkOperation(err)
 
 	// try to delete cluster configuration specified by its ID from storage
-	err = s.Storage.DeleteClusterConfigurationByID(id)
+	err = s.Storage.DeleteClusterConfigurationByID(request.Context(), id, version, requestUsername(request))
+
+	// check if storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// RestoreConfiguration method restores single configuration specified by its
+// ID that has been deleted.
+func (s *Server) RestoreConfiguration(writer http.ResponseWriter, request *http.Request) {
+	// configuration ID needs to be specified in request
+	id, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		TryToSendResponse(http.StatusBadRequest, writer, err.Error())
+		return
+	}
+
+	// try to record the action RestoreClusterConfigurationById into Splunk
+	err = s.Splunk.LogAction("RestoreClusterConfigurationById", "tester", fmt.Sprint(id))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to restore cluster configuration specified by its ID in storage
+	err = s.Storage.RestoreClusterConfiguration(request.Context(), id)
 
 	// check if storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
 	}
 }
 
-// GetAllConfigurations method reads and returns list of all configurations
+// GetAllConfigurations method reads and returns list of all configurations.
+// Deleted configurations are returned only when include_deleted=true is
+// specified. The list is paginated and sorted according to the page
+// parameters.
 func (s *Server) GetAllConfigurations(writer http.ResponseWriter, request *http.Request) {
-	// try to read list of all configurations from storage
-	configuration, err := s.Storage.ListAllClusterConfigurations()
+	includeDeleted, err := retrieveIncludeDeletedParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Value of include_deleted parameter needs to be boolean")
+		return
+	}
+
+	page, err := retrievePageParameters(request, storage.ConfigurationSortColumns)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to read one page of configurations from storage
+	configuration, total, err := s.Storage.ListAllClusterConfigurationsPage(request.Context(), includeDeleted, page)
 
 	// check if storage operation has been successful
 	if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
+		return
+	}
+	TryToSendOKServerResponse(writer, buildPageResponse(request, "configuration", configurationList(configuration), total, page))
+}
+
+// SearchConfigurations method returns list of cluster configurations
+// filtered by cluster, configuration profile, state, reason, user, or time
+// of the last change.
+func (s *Server) SearchConfigurations(writer http.ResponseWriter, request *http.Request) {
+	var req storage.SearchClusterConfigurationRequest
+
+	// search is not supported by all storage implementations
+	if s.ClusterConfigurationQuery == nil {
+		TryToSendResponse(http.StatusNotImplemented, writer, "Configuration search is not supported by the configured storage")
+		return
+	}
+
+	err := utils.DecodeValidRequest(&req, SearchConfigurationTemplate, request.URL.Query())
+	if err != nil {
+		log.Println(err)
+		TryToSendResponse(http.StatusBadRequest, writer, err.Error())
+		return
+	}
+
+	configuration, err := s.ClusterConfigurationQuery.QueryMany(request.Context(), req)
+	if err != nil {
+		log.Println("Unable to read configurations from database", err)
+		TryToSendStorageError(writer, err)
 		return
 	}
+
 	TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("configuration", configuration))
 }
 
-// GetClusterConfiguration method returns list of configuration for single cluster
+// SearchConfigurationTemplate defines validation rules and messages for SearchConfigurations
+var SearchConfigurationTemplate = utils.MergeMaps(map[string]interface{}{
+	// all acceptable fields are listed
+	// case sensitive
+	"id":            "int~Error reading and decoding configuration ID from query",
+	"cluster":       "",
+	"configuration": "int~Error reading and decoding configuration profile ID from query",
+	"active":        "in(0|1)~Active needs to be 0 or 1",
+	"reason":        "",
+	"changed_by":    "",
+	"changed_from":  "",
+	"changed_to":    "",
+	"order_by":      "in(id|cluster|configuration|changed_at|changed_by|active)~Configurations can not be ordered by the specified column",
+	"order":         "in(asc|desc)~Order needs to be asc or desc",
+	// all filters are optional, so the whole request is not validated
+	"": "",
+}, utils.PaginationTemplate)
+
+// GetClusterConfiguration method returns list of configuration for single cluster.
+// Deleted configurations are returned only when include_deleted=true is specified.
 func (s *Server) GetClusterConfiguration(writer http.ResponseWriter, request *http.Request) {
 	// cluster name needs to be specified it request
 	cluster, found := mux.Vars(request)["cluster"]
@@ -110,20 +208,32 @@ func (s *Server) GetClusterConfiguration(writer http.ResponseWriter, request *ht
 		return
 	}
 
-	// try to read list of cluster configurations from storage
-	configuration, err := s.Storage.ListClusterConfiguration(cluster)
+	includeDeleted, err := retrieveIncludeDeletedParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Value of include_deleted parameter needs to be boolean")
+		return
+	}
+
+	page, err := retrievePageParameters(request, storage.ConfigurationSortColumns)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to read one page of cluster configurations from storage
+	configuration, total, err := s.Storage.ListClusterConfigurationPage(request.Context(), cluster, includeDeleted, page)
 
 	// check if storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
-		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("configuration", configuration))
+		TryToSendOKServerResponse(writer, buildPageResponse(request, "configuration", configurationList(configuration), total, page))
 	}
 }
 
-// EnableOrDisableConfiguration method enables or disables single cluster configuration
+// EnableOrDisableConfiguration method enables or disables single cluster
+// configuration. ETag of the configuration needs to be specified in If-Match
+// header.
 func (s *Server) EnableOrDisableConfiguration(writer http.ResponseWriter, request *http.Request, active string) {
 	// configuration ID needs to be specified in request
 	id, err := retrieveIDRequestParameter(request)
@@ -132,6 +242,13 @@ func (s *Server) EnableOrDisableConfiguration(writer http.ResponseWriter, reques
 		return
 	}
 
+	// expected version of configuration needs to be specified in request
+	version, err := retrieveIfMatchVersion(request)
+	if err != nil {
+		sendIfMatchError(writer, err)
+		return
+	}
+
 	// "0" - disable
 	// "1" (or other value) - enable
 	if active == "0" {
@@ -147,13 +264,11 @@ func (s *Server) EnableOrDisableConfiguration(writer http.ResponseWriter, reques
 	}
 
 	// try to enable or disable cluster configuration specified by its ID in storage
-	err = s.Storage.EnableOrDisableClusterConfigurationByID(id, active)
+	err = s.Storage.EnableOrDisableClusterConfigurationByID(request.Context(), id, version, active)
 
 	// check if storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
 		if active == "0" {
 			sendConfiguration(writer, "disabled")
@@ -206,6 +321,13 @@ func (s *Server) NewClusterConfiguration(writer http.ResponseWriter, request *ht
 		return
 	}
 
+	// time window of the configuration is optional
+	window, err := retrieveConfigurationWindow(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
 	// try to read configuration from request body
 	configuration, err := io.ReadAll(request.Body)
 	if err != nil || len(configuration) == 0 {
@@ -213,10 +335,16 @@ func (s *Server) NewClusterConfiguration(writer http.ResponseWriter, request *ht
 		return
 	}
 
+	// configuration needs to conform to the schema of operator configuration
+	if err := s.validateConfiguration(request.Context(), string(configuration)); err != nil {
+		TryToSendStorageError(writer, err)
+		return
+	}
+
 	// try to create cluster configuration in storage
-	configurations, err := s.Storage.CreateClusterConfiguration(cluster, username[0], reason[0], description[0], string(configuration))
+	configurations, err := s.Storage.CreateScheduledClusterConfiguration(request.Context(), cluster, username[0], reason[0], description[0], string(configuration), window)
 	if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 		return
 	}
 
@@ -228,7 +356,135 @@ func (s *Server) NewClusterConfiguration(writer http.ResponseWriter, request *ht
 	TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("configurations", configurations))
 }
 
-// EnableClusterConfiguration method enables cluster configuration
+// AssignClusterConfiguration method assigns existing configuration profile
+// to single cluster. Cluster follows the latest revision of the profile
+// unless it is pinned to the revision specified in request.
+func (s *Server) AssignClusterConfiguration(writer http.ResponseWriter, request *http.Request) {
+	// cluster name needs to be specified in request
+	cluster, found := mux.Vars(request)["cluster"]
+	if !found {
+		TryToSendResponse(http.StatusBadRequest, writer, "Cluster ID needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+
+	// reason needs to be specified in request
+	reason, foundReason := request.URL.Query()["reason"]
+
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	if !foundReason {
+		TryToSendBadRequestServerResponse(writer, "Reason needs to be specified\n")
+		return
+	}
+
+	// profile ID needs to be specified in request
+	profile, err := retrieveProfileQueryParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// revision is optional, cluster follows the latest one when not specified
+	revision := storage.LatestRevision
+	if _, found := request.URL.Query()["revision"]; found {
+		revision, err = retrieveRevisionQueryParameter(request, "revision")
+		if err != nil {
+			TryToSendBadRequestServerResponse(writer, err.Error())
+			return
+		}
+	}
+
+	// try to write information about AssignClusterConfiguration operation into Splunk
+	err = s.Splunk.LogAction("AssignClusterConfiguration", username[0], fmt.Sprintf("%v %v/%v", cluster, profile, revision))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// perform storage operation, assign the profile
+	configurations, err := s.Storage.AssignClusterConfiguration(request.Context(), cluster, username[0], reason[0], profile, revision)
+
+	// check if storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("configurations", configurations))
+	}
+}
+
+// retrieveRollbackQueryParameter reads ID of cluster configuration to roll
+// back to from query parameter "to", the previous configuration is used when
+// the parameter is not specified or when it is set to "previous"
+func retrieveRollbackQueryParameter(request *http.Request) (int64, error) {
+	value, found := request.URL.Query()["to"]
+	if !found || value[0] == "previous" {
+		return storage.PreviousConfiguration, nil
+	}
+
+	id, err := strconv.ParseInt(value[0], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, fmt.Errorf("'to' param needs to be positive")
+	}
+	return id, nil
+}
+
+// RollbackClusterConfiguration method re-activates previous or any other
+// historical configuration of single cluster
+func (s *Server) RollbackClusterConfiguration(writer http.ResponseWriter, request *http.Request) {
+	// cluster name needs to be specified in request
+	cluster, found := mux.Vars(request)["cluster"]
+	if !found {
+		TryToSendResponse(http.StatusBadRequest, writer, "Cluster ID needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+
+	// reason needs to be specified in request
+	reason, foundReason := request.URL.Query()["reason"]
+
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	if !foundReason {
+		TryToSendBadRequestServerResponse(writer, "Reason needs to be specified\n")
+		return
+	}
+
+	id, err := retrieveRollbackQueryParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to write information about RollbackClusterConfiguration operation into Splunk
+	err = s.Splunk.LogAction("RollbackClusterConfiguration", username[0], fmt.Sprintf("%v %v", cluster, id))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// perform storage operation, re-activate the configuration
+	configurations, err := s.Storage.RollbackClusterConfiguration(request.Context(), cluster, username[0], reason[0], id)
+
+	// check if storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("configurations", configurations))
+	}
+}
+
+// EnableClusterConfiguration method enables cluster configuration. ETag of the
+// configuration needs to be specified in If-Match header.
 func (s *Server) EnableClusterConfiguration(writer http.ResponseWriter, request *http.Request) {
 	// cluster name needs to be specified in request
 	cluster, found := mux.Vars(request)["cluster"]
@@ -253,23 +509,31 @@ func (s *Server) EnableClusterConfiguration(writer http.ResponseWriter, request
 		return
 	}
 
+	// expected version of configuration needs to be specified in request
+	version, err := retrieveIfMatchVersion(request)
+	if err != nil {
+		sendIfMatchError(writer, err)
+		return
+	}
+
 	// try to write information about EnableClusterConfiguration operation into Splunk
-	err := s.Splunk.LogAction("EnableClusterConfiguration", username[0], cluster)
+	err = s.Splunk.LogAction("EnableClusterConfiguration", username[0], cluster)
 	// and check whether the Splunk operation was successful
 	checkSplunkOperation(err)
 
 	// perform storage operation, read the configuration
-	configurations, err := s.Storage.EnableClusterConfiguration(cluster, username[0], reason[0])
+	configurations, err := s.Storage.EnableClusterConfiguration(request.Context(), cluster, version, username[0], reason[0])
 
 	// check if storage operation has been successful
 	if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 		return
 	}
 	TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("configurations", configurations))
 }
 
-// DisableClusterConfiguration method disables cluster configuration
+// DisableClusterConfiguration method disables cluster configuration. ETag of
+// the configuration needs to be specified in If-Match header.
 func (s *Server) DisableClusterConfiguration(writer http.ResponseWriter, request *http.Request) {
 	// cluster name needs to be specified in request
 	cluster, found := mux.Vars(request)["cluster"]
@@ -294,17 +558,24 @@ func (s *Server) DisableClusterConfiguration(writer http.ResponseWriter, request
 		return
 	}
 
+	// expected version of configuration needs to be specified in request
+	version, err := retrieveIfMatchVersion(request)
+	if err != nil {
+		sendIfMatchError(writer, err)
+		return
+	}
+
 	// try to write information about DisableClusterConfiguration operation into Splunk
-	err := s.Splunk.LogAction("DisableClusterConfiguration", username[0], cluster)
+	err = s.Splunk.LogAction("DisableClusterConfiguration", username[0], cluster)
 	// and check whether the Splunk operation was successful
 	checkSplunkOperation(err)
 
 	// perform storage operation, read the configuration
-	configurations, err := s.Storage.DisableClusterConfiguration(cluster, username[0], reason[0])
+	configurations, err := s.Storage.DisableClusterConfiguration(request.Context(), cluster, version, username[0], reason[0])
 
 	// check if storage operation has been successful
 	if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 		return
 	}
 	TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("configurations", configurations))
@@ -0,0 +1,286 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/configuration_layer.html
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/gorilla/mux"
+)
+
+// retrieveProfileQueryParameter reads ID of configuration profile from query
+// parameter "profile"
+func retrieveProfileQueryParameter(request *http.Request) (int, error) {
+	value, found := request.URL.Query()["profile"]
+	if !found {
+		return 0, fmt.Errorf("'profile' param not found")
+	}
+
+	profile, err := strconv.Atoi(value[0])
+	if err != nil {
+		return 0, err
+	}
+	if profile < 0 {
+		return 0, fmt.Errorf("'profile' param cannot be negative")
+	}
+	return profile, nil
+}
+
+// effectiveClusterConfiguration reads all configuration layers of cluster
+// specified by its name and merges them together
+func (s *Server) effectiveClusterConfiguration(ctx context.Context, cluster string) (storage.EffectiveConfiguration, error) {
+	layers, err := storage.ClusterConfigurationLayers(ctx, s.Storage, cluster)
+	if err != nil {
+		return storage.EffectiveConfiguration{}, err
+	}
+	return storage.MergeConfigurationLayers(layers)
+}
+
+// clusterConfiguration returns configuration that is served to the operator
+// running on cluster specified by its name. Configuration of cluster without
+// default and group profiles is returned as it is stored.
+func (s *Server) clusterConfiguration(ctx context.Context, cluster string) (string, error) {
+	layers, err := storage.ClusterConfigurationLayers(ctx, s.Storage, cluster)
+	if err != nil {
+		return "", err
+	}
+	if len(layers) == 1 {
+		return layers[0].Configuration, nil
+	}
+
+	effective, err := storage.MergeConfigurationLayers(layers)
+	return effective.Configuration, err
+}
+
+// GetDefaultConfiguration method returns global default configuration
+// profile that is applied to all clusters
+func (s *Server) GetDefaultConfiguration(writer http.ResponseWriter, request *http.Request) {
+	// try to read the default profile from storage
+	defaultConfiguration, err := s.Storage.GetDefaultConfiguration(request.Context())
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("default", defaultConfiguration))
+	}
+}
+
+// SetDefaultConfiguration method sets global default configuration profile
+// that is applied to all clusters
+func (s *Server) SetDefaultConfiguration(writer http.ResponseWriter, request *http.Request) {
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// configuration profile needs to be specified in request
+	profile, err := retrieveProfileQueryParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to record the action SetDefaultConfiguration into Splunk
+	err = s.Splunk.LogAction("SetDefaultConfiguration", username[0], strconv.Itoa(profile))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to set the default profile in storage
+	err = s.Storage.SetDefaultConfiguration(request.Context(), profile, username[0])
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// DeleteDefaultConfiguration method unsets global default configuration
+// profile
+func (s *Server) DeleteDefaultConfiguration(writer http.ResponseWriter, request *http.Request) {
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// try to record the action DeleteDefaultConfiguration into Splunk
+	err := s.Splunk.LogAction("DeleteDefaultConfiguration", username[0], "")
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to unset the default profile in storage
+	err = s.Storage.DeleteDefaultConfiguration(request.Context())
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// GetConfigurationGroups method returns all configuration groups in the
+// order they are applied
+func (s *Server) GetConfigurationGroups(writer http.ResponseWriter, request *http.Request) {
+	// try to read all groups from storage
+	groups, err := s.Storage.ListConfigurationGroups(request.Context())
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("groups", groups))
+	}
+}
+
+// NewConfigurationGroup method creates new configuration group that applies
+// configuration profile to all clusters selected by label selector
+func (s *Server) NewConfigurationGroup(writer http.ResponseWriter, request *http.Request) {
+	// group name needs to be specified in request parameter
+	name, found := mux.Vars(request)["name"]
+	if !found || name == "" {
+		TryToSendBadRequestServerResponse(writer, "Group name needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// label selector needs to be specified in request
+	selector, foundSelector := request.URL.Query()["selector"]
+	if !foundSelector {
+		TryToSendBadRequestServerResponse(writer, "Label selector needs to be specified\n")
+		return
+	}
+
+	// configuration profile needs to be specified in request
+	profile, err := retrieveProfileQueryParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// priority is optional, groups with higher priority override the others
+	priority := 0
+	if value := request.URL.Query().Get("priority"); value != "" {
+		priority, err = strconv.Atoi(value)
+		if err != nil {
+			TryToSendBadRequestServerResponse(writer, err.Error())
+			return
+		}
+	}
+
+	// try to record the action NewConfigurationGroup into Splunk
+	err = s.Splunk.LogAction("NewConfigurationGroup", username[0], name+" "+selector[0])
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to create the group in storage
+	err = s.Storage.NewConfigurationGroup(request.Context(), name, selector[0], priority, profile, username[0])
+	if err != nil {
+		TryToSendStorageError(writer, err)
+		return
+	}
+
+	groups, err := s.Storage.ListConfigurationGroups(request.Context())
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendCreatedServerResponse(writer, responses.BuildOkResponseWithData("groups", groups))
+	}
+}
+
+// DeleteConfigurationGroup method deletes configuration group specified by
+// its name
+func (s *Server) DeleteConfigurationGroup(writer http.ResponseWriter, request *http.Request) {
+	// group name needs to be specified in request parameter
+	name, found := mux.Vars(request)["name"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Group name needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// try to record the action DeleteConfigurationGroup into Splunk
+	err := s.Splunk.LogAction("DeleteConfigurationGroup", username[0], name)
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to delete the group from storage
+	err = s.Storage.DeleteConfigurationGroup(request.Context(), name)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// ExplainClusterConfiguration method returns configuration of cluster
+// merged from all its layers together with the layers and name of layer
+// that each key of the configuration comes from
+func (s *Server) ExplainClusterConfiguration(writer http.ResponseWriter, request *http.Request) {
+	// cluster name needs to be specified in request parameter
+	cluster, found := mux.Vars(request)["cluster"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Cluster name needs to be specified")
+		return
+	}
+
+	// try to merge configuration layers of the cluster
+	effective, err := s.effectiveClusterConfiguration(request.Context(), cluster)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+		return
+	}
+
+	resp := responses.BuildOkResponse()
+	resp["configuration"] = effective.Configuration
+	resp["layers"] = effective.Layers
+	resp["origins"] = effective.Origins
+	TryToSendOKServerResponse(writer, resp)
+}
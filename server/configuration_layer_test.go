@@ -0,0 +1,177 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/configuration_layer_test.html
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNonErrorsConfigurationLayersWithData tests OK behaviour with mock data
+func TestNonErrorsConfigurationLayersWithData(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	cluster := "00000000-0000-0000-0000-000000000000"
+	withoutConfiguration := "00000000-0000-0000-0000-000000000003"
+	nonErrorTT := []testCase{
+		{"GetDefaultConfiguration not set", serv.GetDefaultConfiguration, http.StatusNotFound, "GET", true, requestData{}, requestData{}, ""},
+		{"ExplainClusterConfiguration no layer", serv.ExplainClusterConfiguration, http.StatusNotFound, "GET", true, requestData{"cluster": withoutConfiguration}, requestData{}, ""},
+		{"SetDefaultConfiguration OK", serv.SetDefaultConfiguration, http.StatusOK, "PUT", true, requestData{}, requestData{"username": "tester", "profile": "3"}, ""},
+		{"SetDefaultConfiguration unknown profile", serv.SetDefaultConfiguration, http.StatusNotFound, "PUT", true, requestData{}, requestData{"username": "tester", "profile": "42"}, ""},
+		{"GetDefaultConfiguration OK", serv.GetDefaultConfiguration, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"GetConfigurationGroups no group OK", serv.GetConfigurationGroups, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"NewConfigurationGroup OK", serv.NewConfigurationGroup, http.StatusCreated, "POST", true, requestData{"name": "prod"}, requestData{"username": "tester", "selector": "env=prod", "priority": "10", "profile": "1"}, ""},
+		{"NewConfigurationGroup no priority OK", serv.NewConfigurationGroup, http.StatusCreated, "POST", true, requestData{"name": "stage"}, requestData{"username": "tester", "selector": "env=stage", "profile": "0"}, ""},
+		{"NewConfigurationGroup already exists", serv.NewConfigurationGroup, http.StatusConflict, "POST", true, requestData{"name": "prod"}, requestData{"username": "tester", "selector": "env=prod", "profile": "1"}, ""},
+		{"NewConfigurationGroup unknown profile", serv.NewConfigurationGroup, http.StatusNotFound, "POST", true, requestData{"name": "other"}, requestData{"username": "tester", "selector": "env=other", "profile": "42"}, ""},
+		{"GetConfigurationGroups OK", serv.GetConfigurationGroups, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"ExplainClusterConfiguration OK", serv.ExplainClusterConfiguration, http.StatusOK, "GET", true, requestData{"cluster": cluster}, requestData{}, ""},
+		{"ExplainClusterConfiguration default only OK", serv.ExplainClusterConfiguration, http.StatusOK, "GET", true, requestData{"cluster": withoutConfiguration}, requestData{}, ""},
+		{"ExplainClusterConfiguration unknown cluster", serv.ExplainClusterConfiguration, http.StatusNotFound, "GET", true, requestData{"cluster": "unknown"}, requestData{}, ""},
+		{"DeleteConfigurationGroup OK", serv.DeleteConfigurationGroup, http.StatusOK, "DELETE", true, requestData{"name": "prod"}, requestData{"username": "tester"}, ""},
+		{"DeleteConfigurationGroup deleted group", serv.DeleteConfigurationGroup, http.StatusNotFound, "DELETE", true, requestData{"name": "prod"}, requestData{"username": "tester"}, ""},
+		{"DeleteDefaultConfiguration OK", serv.DeleteDefaultConfiguration, http.StatusOK, "DELETE", true, requestData{}, requestData{"username": "tester"}, ""},
+		{"DeleteDefaultConfiguration not set", serv.DeleteDefaultConfiguration, http.StatusNotFound, "DELETE", true, requestData{}, requestData{"username": "tester"}, ""},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestDatabaseErrorConfigurationLayers tests unexpected behaviour by closing DB connection (consistency check)
+func TestDatabaseErrorConfigurationLayers(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+
+	cluster := "00000000-0000-0000-0000-000000000000"
+	dbErrorTT := []testCase{
+		{"GetDefaultConfiguration DB error", serv.GetDefaultConfiguration, http.StatusInternalServerError, "GET", true, requestData{}, requestData{}, ""},
+		{"SetDefaultConfiguration DB error", serv.SetDefaultConfiguration, http.StatusInternalServerError, "PUT", true, requestData{}, requestData{"username": "tester", "profile": "3"}, ""},
+		{"DeleteDefaultConfiguration DB error", serv.DeleteDefaultConfiguration, http.StatusInternalServerError, "DELETE", true, requestData{}, requestData{"username": "tester"}, ""},
+		{"GetConfigurationGroups DB error", serv.GetConfigurationGroups, http.StatusInternalServerError, "GET", true, requestData{}, requestData{}, ""},
+		{"NewConfigurationGroup DB error", serv.NewConfigurationGroup, http.StatusInternalServerError, "POST", true, requestData{"name": "prod"}, requestData{"username": "tester", "selector": "env=prod", "profile": "1"}, ""},
+		{"DeleteConfigurationGroup DB error", serv.DeleteConfigurationGroup, http.StatusInternalServerError, "DELETE", true, requestData{"name": "prod"}, requestData{"username": "tester"}, ""},
+		{"ExplainClusterConfiguration DB error", serv.ExplainClusterConfiguration, http.StatusInternalServerError, "GET", true, requestData{"cluster": cluster}, requestData{}, ""},
+	}
+
+	serv.Storage.Close()
+
+	for _, tt := range dbErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestParameterErrorsConfigurationLayers tests wrong request parameters
+func TestParameterErrorsConfigurationLayers(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	paramErrorTT := []testCase{
+		{"SetDefaultConfiguration no username", serv.SetDefaultConfiguration, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"profile": "3"}, ""},
+		{"SetDefaultConfiguration no profile", serv.SetDefaultConfiguration, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester"}, ""},
+		{"SetDefaultConfiguration wrong profile", serv.SetDefaultConfiguration, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester", "profile": "x"}, ""},
+		{"SetDefaultConfiguration negative profile", serv.SetDefaultConfiguration, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester", "profile": "-1"}, ""},
+		{"DeleteDefaultConfiguration no username", serv.DeleteDefaultConfiguration, http.StatusBadRequest, "DELETE", true, requestData{}, requestData{}, ""},
+		{"NewConfigurationGroup no name", serv.NewConfigurationGroup, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "selector": "env=prod", "profile": "1"}, ""},
+		{"NewConfigurationGroup no username", serv.NewConfigurationGroup, http.StatusBadRequest, "POST", true, requestData{"name": "prod"}, requestData{"selector": "env=prod", "profile": "1"}, ""},
+		{"NewConfigurationGroup no selector", serv.NewConfigurationGroup, http.StatusBadRequest, "POST", true, requestData{"name": "prod"}, requestData{"username": "tester", "profile": "1"}, ""},
+		{"NewConfigurationGroup empty selector", serv.NewConfigurationGroup, http.StatusBadRequest, "POST", true, requestData{"name": "prod"}, requestData{"username": "tester", "selector": "", "profile": "1"}, ""},
+		{"NewConfigurationGroup wrong selector", serv.NewConfigurationGroup, http.StatusBadRequest, "POST", true, requestData{"name": "prod"}, requestData{"username": "tester", "selector": "env=not valid", "profile": "1"}, ""},
+		{"NewConfigurationGroup no profile", serv.NewConfigurationGroup, http.StatusBadRequest, "POST", true, requestData{"name": "prod"}, requestData{"username": "tester", "selector": "env=prod"}, ""},
+		{"NewConfigurationGroup wrong priority", serv.NewConfigurationGroup, http.StatusBadRequest, "POST", true, requestData{"name": "prod"}, requestData{"username": "tester", "selector": "env=prod", "profile": "1", "priority": "high"}, ""},
+		{"DeleteConfigurationGroup no name", serv.DeleteConfigurationGroup, http.StatusBadRequest, "DELETE", true, requestData{}, requestData{"username": "tester"}, ""},
+		{"DeleteConfigurationGroup no username", serv.DeleteConfigurationGroup, http.StatusBadRequest, "DELETE", true, requestData{"name": "prod"}, requestData{}, ""},
+		{"ExplainClusterConfiguration no cluster", serv.ExplainClusterConfiguration, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
+	}
+
+	for _, tt := range paramErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestReadMergedConfigurationForOperator checks that the operator gets
+// configuration of cluster merged with default and group profiles
+func TestReadMergedConfigurationForOperator(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	ctx := context.Background()
+	cluster := "00000000-0000-0000-0000-000000000000"
+
+	profiles, err := serv.Storage.StoreConfigurationProfile(ctx, "tester", "default", `{"no_op":"default","gather":{"period":"2h"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultProfile := int(profiles[len(profiles)-1].ID)
+	profiles, err = serv.Storage.StoreConfigurationProfile(ctx, "tester", "prod", `{"watch":["x"],"gather":{"logs":true}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prodProfile := int(profiles[len(profiles)-1].ID)
+
+	if err := serv.Storage.SetDefaultConfiguration(ctx, defaultProfile, "tester"); err != nil {
+		t.Fatal(err)
+	}
+	if err := serv.Storage.NewConfigurationGroup(ctx, "prod", "env=prod", 0, prodProfile, "tester"); err != nil {
+		t.Fatal(err)
+	}
+	if err := serv.Storage.SetClusterLabel(ctx, cluster, "env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	request := httptest.NewRequest("GET", "/api/v1/operator/configuration/"+cluster, nil)
+	recorder := httptest.NewRecorder()
+	serv.ReadConfigurationForOperator(recorder, mux.SetURLVars(request, map[string]string{"cluster": cluster}))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Configuration string `json:"configuration"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	// keys of cluster configuration override keys of the group and default profiles
+	assert.JSONEq(t, `{"no_op":"X","watch":["a","b","c"],"gather":{"period":"2h","logs":true}}`, response.Configuration)
+
+	request = httptest.NewRequest("GET", "/api/v1/client/cluster/"+cluster+"/configuration/explain", nil)
+	recorder = httptest.NewRecorder()
+	serv.ExplainClusterConfiguration(recorder, mux.SetURLVars(request, map[string]string{"cluster": cluster}))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var explanation struct {
+		Origins map[string]string `json:"origins"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &explanation); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{
+		"/no_op":         "cluster",
+		"/watch":         "cluster",
+		"/gather/period": "default",
+		"/gather/logs":   "group/prod",
+	}, explanation.Origins)
+}
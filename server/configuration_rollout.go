@@ -0,0 +1,175 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/configuration_rollout.html
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+)
+
+// GetConfigurationRollouts method returns all configuration rollouts
+func (s *Server) GetConfigurationRollouts(writer http.ResponseWriter, request *http.Request) {
+	// try to read all rollouts from storage
+	rollouts, err := s.Storage.ListConfigurationRollouts(request.Context())
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("rollouts", rollouts))
+	}
+}
+
+// GetConfigurationRollout method returns configuration rollout specified by
+// its ID together with progress of its waves and with all clusters touched
+// by it
+func (s *Server) GetConfigurationRollout(writer http.ResponseWriter, request *http.Request) {
+	id, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading rollout ID from request\n")
+		return
+	}
+
+	// try to read the rollout and its clusters from storage
+	status, err := storage.GetConfigurationRolloutStatus(request.Context(), s.Storage, int(id))
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("rollout", status))
+	}
+}
+
+// NewConfigurationRollout method creates new rollout of configuration
+// profile to clusters selected by label selector. The first wave of the
+// rollout is applied immediately.
+func (s *Server) NewConfigurationRollout(writer http.ResponseWriter, request *http.Request) {
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// reason needs to be specified in request
+	reason, foundReason := request.URL.Query()["reason"]
+	if !foundReason {
+		TryToSendBadRequestServerResponse(writer, "Reason needs to be specified\n")
+		return
+	}
+
+	// waves need to be specified in request
+	wavesParam, foundWaves := request.URL.Query()["waves"]
+	if !foundWaves {
+		TryToSendBadRequestServerResponse(writer, "Rollout waves need to be specified\n")
+		return
+	}
+	waves, err := storage.ParseRolloutWaves(wavesParam[0])
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// configuration profile needs to be specified in request
+	profile, err := retrieveProfileQueryParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// label selector is optional, all clusters are selected without it
+	selector := request.URL.Query().Get("selector")
+
+	// try to record the action NewConfigurationRollout into Splunk
+	err = s.Splunk.LogAction("NewConfigurationRollout", username[0], strconv.Itoa(profile)+" "+selector)
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to create the rollout in storage
+	rollout, err := s.Storage.NewConfigurationRollout(request.Context(), profile, selector, waves, username[0], reason[0])
+	if err != nil {
+		TryToSendStorageError(writer, err)
+		return
+	}
+
+	// and apply its first wave
+	rollout, err = storage.AdvanceConfigurationRollout(request.Context(), s.Storage, rollout.ID)
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendCreatedServerResponse(writer, responses.BuildOkResponseWithData("rollout", rollout))
+	}
+}
+
+// changeConfigurationRolloutState changes state of configuration rollout
+// specified by its ID
+func (s *Server) changeConfigurationRolloutState(writer http.ResponseWriter, request *http.Request, action string, state storage.RolloutState) {
+	id, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading rollout ID from request\n")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// try to record the action into Splunk
+	err = s.Splunk.LogAction(action, username[0], strconv.Itoa(int(id)))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to change the state of rollout in storage
+	rollout, err := storage.ChangeConfigurationRolloutState(request.Context(), s.Storage, int(id), state, username[0])
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("rollout", rollout))
+	}
+}
+
+// PauseConfigurationRollout method pauses running configuration rollout,
+// no further waves are applied until the rollout is resumed
+func (s *Server) PauseConfigurationRollout(writer http.ResponseWriter, request *http.Request) {
+	s.changeConfigurationRolloutState(writer, request, "PauseConfigurationRollout", storage.RolloutPaused)
+}
+
+// ResumeConfigurationRollout method resumes paused configuration rollout
+func (s *Server) ResumeConfigurationRollout(writer http.ResponseWriter, request *http.Request) {
+	s.changeConfigurationRolloutState(writer, request, "ResumeConfigurationRollout", storage.RolloutRunning)
+}
+
+// AbortConfigurationRollout method aborts configuration rollout, clusters
+// already touched by the rollout keep their configuration
+func (s *Server) AbortConfigurationRollout(writer http.ResponseWriter, request *http.Request) {
+	s.changeConfigurationRolloutState(writer, request, "AbortConfigurationRollout", storage.RolloutAborted)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/configuration_rollout_test.html
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// TestNonErrorsConfigurationRolloutsWithData tests OK behaviour with mock data
+func TestNonErrorsConfigurationRolloutsWithData(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	nonErrorTT := []testCase{
+		{"GetConfigurationRollouts no rollout OK", serv.GetConfigurationRollouts, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"NewConfigurationRollout OK", serv.NewConfigurationRollout, http.StatusCreated, "POST", true, requestData{}, requestData{"username": "tester", "reason": "new gathering", "profile": "1", "waves": "50%,100%"}, ""},
+		{"NewConfigurationRollout with selector OK", serv.NewConfigurationRollout, http.StatusCreated, "POST", true, requestData{}, requestData{"username": "tester", "reason": "new gathering", "profile": "2", "selector": "env=prod", "waves": "100"}, ""},
+		{"NewConfigurationRollout unknown profile", serv.NewConfigurationRollout, http.StatusNotFound, "POST", true, requestData{}, requestData{"username": "tester", "reason": "reason", "profile": "42", "waves": "100"}, ""},
+		{"NewConfigurationRollout wrong selector", serv.NewConfigurationRollout, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "reason": "reason", "profile": "1", "selector": "env=not valid", "waves": "100"}, ""},
+		{"GetConfigurationRollouts OK", serv.GetConfigurationRollouts, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"GetConfigurationRollout OK", serv.GetConfigurationRollout, http.StatusOK, "GET", true, requestData{"id": "1"}, requestData{}, ""},
+		{"GetConfigurationRollout unknown rollout", serv.GetConfigurationRollout, http.StatusNotFound, "GET", true, requestData{"id": "42"}, requestData{}, ""},
+		{"PauseConfigurationRollout OK", serv.PauseConfigurationRollout, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester"}, ""},
+		{"PauseConfigurationRollout already paused", serv.PauseConfigurationRollout, http.StatusConflict, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester"}, ""},
+		{"ResumeConfigurationRollout OK", serv.ResumeConfigurationRollout, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester"}, ""},
+		{"ResumeConfigurationRollout unknown rollout", serv.ResumeConfigurationRollout, http.StatusNotFound, "PUT", true, requestData{"id": "42"}, requestData{"username": "tester"}, ""},
+		{"AbortConfigurationRollout OK", serv.AbortConfigurationRollout, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester"}, ""},
+		{"AbortConfigurationRollout already aborted", serv.AbortConfigurationRollout, http.StatusConflict, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester"}, ""},
+		{"ResumeConfigurationRollout aborted rollout", serv.ResumeConfigurationRollout, http.StatusConflict, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester"}, ""},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestDatabaseErrorConfigurationRollouts tests unexpected behaviour by closing DB connection (consistency check)
+func TestDatabaseErrorConfigurationRollouts(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+
+	dbErrorTT := []testCase{
+		{"GetConfigurationRollouts DB error", serv.GetConfigurationRollouts, http.StatusInternalServerError, "GET", true, requestData{}, requestData{}, ""},
+		{"NewConfigurationRollout DB error", serv.NewConfigurationRollout, http.StatusInternalServerError, "POST", true, requestData{}, requestData{"username": "tester", "reason": "reason", "profile": "1", "waves": "100"}, ""},
+		{"GetConfigurationRollout DB error", serv.GetConfigurationRollout, http.StatusInternalServerError, "GET", true, requestData{"id": "1"}, requestData{}, ""},
+		{"PauseConfigurationRollout DB error", serv.PauseConfigurationRollout, http.StatusInternalServerError, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester"}, ""},
+	}
+	serv.Storage.Close()
+
+	for _, tt := range dbErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestParameterErrorsConfigurationRollouts tests wrong request parameters
+func TestParameterErrorsConfigurationRollouts(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	paramErrorTT := []testCase{
+		{"NewConfigurationRollout no username", serv.NewConfigurationRollout, http.StatusBadRequest, "POST", true, requestData{}, requestData{"reason": "reason", "profile": "1", "waves": "100"}, ""},
+		{"NewConfigurationRollout no reason", serv.NewConfigurationRollout, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "profile": "1", "waves": "100"}, ""},
+		{"NewConfigurationRollout no waves", serv.NewConfigurationRollout, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "reason": "reason", "profile": "1"}, ""},
+		{"NewConfigurationRollout wrong waves", serv.NewConfigurationRollout, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "reason": "reason", "profile": "1", "waves": "10,50"}, ""},
+		{"NewConfigurationRollout no profile", serv.NewConfigurationRollout, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "reason": "reason", "waves": "100"}, ""},
+		{"GetConfigurationRollout wrong id", serv.GetConfigurationRollout, http.StatusBadRequest, "GET", true, requestData{"id": "x"}, requestData{}, ""},
+		{"PauseConfigurationRollout wrong id", serv.PauseConfigurationRollout, http.StatusBadRequest, "PUT", true, requestData{"id": "x"}, requestData{"username": "tester"}, ""},
+		{"ResumeConfigurationRollout no username", serv.ResumeConfigurationRollout, http.StatusBadRequest, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"AbortConfigurationRollout no username", serv.AbortConfigurationRollout, http.StatusBadRequest, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+	}
+
+	for _, tt := range paramErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestConfigurationRolloutAcknowledgedByOperator checks that configuration
+// read by the operator is acknowledged and that the rollout is completed then
+func TestConfigurationRolloutAcknowledgedByOperator(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	ctx := context.Background()
+	cluster := "00000000-0000-0000-0000-000000000001"
+
+	if err := serv.Storage.SetClusterLabel(ctx, cluster, "env", "canary"); err != nil {
+		t.Fatal(err)
+	}
+	rollout, err := serv.Storage.NewConfigurationRollout(ctx, 2, "env=canary", []int{100}, "tester", "reason")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.AdvanceConfigurationRollout(ctx, serv.Storage, rollout.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	request := httptest.NewRequest("GET", "/api/v1/operator/configuration/"+cluster, nil)
+	recorder := httptest.NewRecorder()
+	serv.ReadConfigurationForOperator(recorder, mux.SetURLVars(request, map[string]string{"cluster": cluster}))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	status, err := storage.GetConfigurationRolloutStatus(ctx, serv.Storage, rollout.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, status.Clusters, 1)
+	assert.Equal(t, storage.RolloutClusterAcknowledged, status.Clusters[0].Status)
+
+	rollout, err = storage.AdvanceConfigurationRollout(ctx, serv.Storage, rollout.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, storage.RolloutCompleted, rollout.State)
+}
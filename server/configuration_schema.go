@@ -0,0 +1,109 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/configuration_schema.html
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+)
+
+// validateConfiguration checks that configuration conforms to the schema of
+// operator configuration
+func (s *Server) validateConfiguration(ctx context.Context, configuration string) error {
+	schema, err := s.Storage.GetConfigurationSchema(ctx)
+	if err != nil {
+		return err
+	}
+	return storage.ValidateConfiguration(schema.Schema, configuration)
+}
+
+// GetConfigurationSchema method returns JSON schema that configuration
+// profiles and cluster configurations are validated against
+func (s *Server) GetConfigurationSchema(writer http.ResponseWriter, request *http.Request) {
+	// try to read the schema from storage
+	schema, err := s.Storage.GetConfigurationSchema(request.Context())
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("schema", schema))
+	}
+}
+
+// SetConfigurationSchema method sets JSON schema that configuration profiles
+// and cluster configurations are validated against. The schema needs to be
+// provided in the request body.
+func (s *Server) SetConfigurationSchema(writer http.ResponseWriter, request *http.Request) {
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// read schema from request body
+	schema, err := io.ReadAll(request.Body)
+	if err != nil || len(schema) == 0 {
+		TryToSendBadRequestServerResponse(writer, "Schema needs to be provided in the request body")
+		return
+	}
+
+	// try to record the action SetConfigurationSchema into Splunk
+	err = s.Splunk.LogAction("SetConfigurationSchema", username[0], string(schema))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to store the schema into storage
+	err = s.Storage.SetConfigurationSchema(request.Context(), string(schema), username[0])
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// ValidateConfiguration method checks that configuration provided in the
+// request body conforms to the schema of operator configuration. The
+// configuration is not stored.
+func (s *Server) ValidateConfiguration(writer http.ResponseWriter, request *http.Request) {
+	// read configuration from request body
+	configuration, err := io.ReadAll(request.Body)
+	if err != nil || len(configuration) == 0 {
+		TryToSendBadRequestServerResponse(writer, "Configuration needs to be provided in the request body")
+		return
+	}
+
+	err = s.validateConfiguration(request.Context(), string(configuration))
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
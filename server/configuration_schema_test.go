@@ -0,0 +1,82 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/configuration_schema_test.html
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestNonErrorsConfigurationSchemaWithData tests OK behaviour with mock data
+func TestNonErrorsConfigurationSchemaWithData(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	cluster := "00000000-0000-0000-0000-000000000000"
+	nonErrorTT := []testCase{
+		{"GetConfigurationSchema built-in OK", serv.GetConfigurationSchema, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"ValidateConfiguration OK", serv.ValidateConfiguration, http.StatusOK, "POST", true, requestData{}, requestData{}, `{"no_op":"X", "watch":["a"]}`},
+		{"ValidateConfiguration malformed JSON", serv.ValidateConfiguration, http.StatusBadRequest, "POST", true, requestData{}, requestData{}, `{"no_op":"X",}`},
+		{"ValidateConfiguration not conforming to schema", serv.ValidateConfiguration, http.StatusBadRequest, "POST", true, requestData{}, requestData{}, `{"watch":"a"}`},
+		{"SetConfigurationSchema OK", serv.SetConfigurationSchema, http.StatusOK, "PUT", true, requestData{}, requestData{"username": "tester"}, `{"type": "object", "required": ["no_op"]}`},
+		{"SetConfigurationSchema invalid schema", serv.SetConfigurationSchema, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester"}, `{"type": "unknown"}`},
+		{"GetConfigurationSchema OK", serv.GetConfigurationSchema, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"ValidateConfiguration not conforming to new schema", serv.ValidateConfiguration, http.StatusBadRequest, "POST", true, requestData{}, requestData{}, `{"watch":["a"]}`},
+		{"NewClusterConfiguration not conforming to new schema", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": cluster}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, `{"watch":["a"]}`},
+		{"NewClusterConfiguration conforming to new schema", serv.NewClusterConfiguration, http.StatusOK, "POST", false, requestData{"cluster": cluster}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, `{"no_op":"X"}`},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestDatabaseErrorConfigurationSchema tests unexpected behaviour by closing DB connection (consistency check)
+func TestDatabaseErrorConfigurationSchema(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+
+	dbErrorTT := []testCase{
+		{"GetConfigurationSchema DB error", serv.GetConfigurationSchema, http.StatusInternalServerError, "GET", true, requestData{}, requestData{}, ""},
+		{"SetConfigurationSchema DB error", serv.SetConfigurationSchema, http.StatusInternalServerError, "PUT", true, requestData{}, requestData{"username": "tester"}, `{"type": "object"}`},
+		{"ValidateConfiguration DB error", serv.ValidateConfiguration, http.StatusInternalServerError, "POST", true, requestData{}, requestData{}, `{"no_op":"X"}`},
+	}
+
+	serv.Storage.Close()
+
+	for _, tt := range dbErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestParameterErrorsConfigurationSchema tests wrong request parameters
+func TestParameterErrorsConfigurationSchema(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	paramErrorTT := []testCase{
+		{"SetConfigurationSchema no username", serv.SetConfigurationSchema, http.StatusBadRequest, "PUT", true, requestData{}, requestData{}, `{"type": "object"}`},
+		{"SetConfigurationSchema no schema", serv.SetConfigurationSchema, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester"}, ""},
+		{"ValidateConfiguration no configuration", serv.ValidateConfiguration, http.StatusBadRequest, "POST", true, requestData{}, requestData{}, ""},
+	}
+
+	for _, tt := range paramErrorTT {
+		testRequest(t, &tt)
+	}
+}
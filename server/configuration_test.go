@@ -31,11 +31,11 @@ func TestNonErrorsConfigurationWithoutData(t *testing.T) {
 
 	nonErrorTT := []testCase{
 		{"GetConfiguration Not Found", serv.GetConfiguration, http.StatusNotFound, "GET", true, requestData{"id": "1"}, requestData{}, ""},
-		{"DeleteConfiguration Not Found", serv.DeleteConfiguration, http.StatusNotFound, "DELETE", false, requestData{"id": "1"}, requestData{}, ""},
+		{"DeleteConfiguration Not Found", withHeader(serv.DeleteConfiguration, "If-Match", "*"), http.StatusNotFound, "DELETE", false, requestData{"id": "1"}, requestData{}, ""},
 		{"GetAllConfigurations OK", serv.GetAllConfigurations, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
 		{"GetClusterConfiguration Not Found", serv.GetClusterConfiguration, http.StatusNotFound, "GET", true, requestData{"cluster": "1"}, requestData{}, ""},
-		{"EnableConfiguration Not Found", serv.EnableConfiguration, http.StatusNotFound, "PUT", false, requestData{"id": "1"}, requestData{}, ""},
-		{"DisableConfiguration Not Found", serv.DisableConfiguration, http.StatusNotFound, "PUT", false, requestData{"id": "1"}, requestData{}, ""},
+		{"EnableConfiguration Not Found", withHeader(serv.EnableConfiguration, "If-Match", "*"), http.StatusNotFound, "PUT", false, requestData{"id": "1"}, requestData{}, ""},
+		{"DisableConfiguration Not Found", withHeader(serv.DisableConfiguration, "If-Match", "*"), http.StatusNotFound, "PUT", false, requestData{"id": "1"}, requestData{}, ""},
 	}
 
 	for _, tt := range nonErrorTT {
@@ -51,13 +51,39 @@ func TestNonErrorsConfigurationWithData(t *testing.T) {
 	nonErrorTT := []testCase{
 		{"GetConfiguration OK", serv.GetConfiguration, http.StatusOK, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"GetAllConfigurations OK", serv.GetAllConfigurations, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"SearchConfigurations OK", serv.SearchConfigurations, http.StatusOK, "GET", true, requestData{}, requestData{"cluster": "00000000-0000-0000-0000-000000000000,00000000-0000-0000-0000-000000000001", "active": "1", "order_by": "changed_at"}, ""},
 		{"GetClusterConfiguration OK", serv.GetClusterConfiguration, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000001"}, requestData{}, ""},
-		{"EnableConfiguration OK", serv.EnableConfiguration, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
-		{"DisableConfiguration OK", serv.DisableConfiguration, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
-		{"DeleteConfiguration OK", serv.DeleteConfiguration, http.StatusOK, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
-		{"EnableClusterConfiguration OK", serv.EnableClusterConfiguration, http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
-		{"DisableClusterConfiguration OK", serv.DisableClusterConfiguration, http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
-		{"NewClusterConfiguration OK", serv.NewClusterConfiguration, http.StatusOK, "POST", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, "Test config"},
+		{"EnableConfiguration other configuration active", withHeader(serv.EnableConfiguration, "If-Match", `"1"`), http.StatusConflict, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"DisableConfiguration other configuration OK", withHeader(serv.DisableConfiguration, "If-Match", "*"), http.StatusOK, "PUT", true, requestData{"id": "2"}, requestData{}, ""},
+		{"EnableConfiguration OK", withHeader(serv.EnableConfiguration, "If-Match", `"1"`), http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"DisableConfiguration changed in the meantime", withHeader(serv.DisableConfiguration, "If-Match", `"1"`), http.StatusPreconditionFailed, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"DisableConfiguration no If-Match", serv.DisableConfiguration, http.StatusPreconditionRequired, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"DisableConfiguration OK", withHeader(serv.DisableConfiguration, "If-Match", `"2"`), http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"DeleteConfiguration changed in the meantime", withHeader(serv.DeleteConfiguration, "If-Match", `"2"`), http.StatusPreconditionFailed, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
+		{"DeleteConfiguration no If-Match", serv.DeleteConfiguration, http.StatusPreconditionRequired, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
+		{"DeleteConfiguration OK", withHeader(serv.DeleteConfiguration, "If-Match", "*"), http.StatusOK, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
+		{"GetConfiguration deleted", serv.GetConfiguration, http.StatusNotFound, "GET", true, requestData{"id": "1"}, requestData{}, ""},
+		{"GetAllConfigurations including deleted OK", serv.GetAllConfigurations, http.StatusOK, "GET", true, requestData{}, requestData{"include_deleted": "true"}, ""},
+		{"GetClusterConfiguration including deleted OK", serv.GetClusterConfiguration, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000001"}, requestData{"include_deleted": "1"}, ""},
+		{"RestoreConfiguration OK", serv.RestoreConfiguration, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"RestoreConfiguration not deleted", serv.RestoreConfiguration, http.StatusNotFound, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"EnableClusterConfiguration OK", withHeader(serv.EnableClusterConfiguration, "If-Match", "*"), http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
+		{"DisableClusterConfiguration no If-Match", serv.DisableClusterConfiguration, http.StatusPreconditionRequired, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
+		{"DisableClusterConfiguration changed in the meantime", withHeader(serv.DisableClusterConfiguration, "If-Match", `"100"`), http.StatusPreconditionFailed, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
+		{"DisableClusterConfiguration OK", withHeader(serv.DisableClusterConfiguration, "If-Match", "*"), http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
+		{"NewClusterConfiguration OK", serv.NewClusterConfiguration, http.StatusOK, "POST", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, `{"no_op":"Test config"}`},
+		{"EnableClusterConfiguration newest configuration active", withHeader(serv.EnableClusterConfiguration, "If-Match", "*"), http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
+		{"DisableClusterConfiguration newest configuration OK", withHeader(serv.DisableClusterConfiguration, "If-Match", "*"), http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
+		{"DisableClusterConfiguration no active configuration", withHeader(serv.DisableClusterConfiguration, "If-Match", "*"), http.StatusNotFound, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
+		{"NewClusterConfiguration not JSON", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, "Test config"},
+		{"NewClusterConfiguration not conforming to schema", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, `{"no_op":false}`},
+		{"AssignClusterConfiguration OK", serv.AssignClusterConfiguration, http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000003"}, requestData{"username": "test", "reason": "shared", "profile": "3"}, ""},
+		{"AssignClusterConfiguration pinned OK", serv.AssignClusterConfiguration, http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000004"}, requestData{"username": "test", "reason": "shared", "profile": "3", "revision": "1"}, ""},
+		{"AssignClusterConfiguration unknown revision", serv.AssignClusterConfiguration, http.StatusNotFound, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000004"}, requestData{"username": "test", "reason": "shared", "profile": "3", "revision": "100"}, ""},
+		{"AssignClusterConfiguration unknown profile", serv.AssignClusterConfiguration, http.StatusNotFound, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000004"}, requestData{"username": "test", "reason": "shared", "profile": "100"}, ""},
+		{"RollbackClusterConfiguration OK", serv.RollbackClusterConfiguration, http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000004"}, requestData{"username": "test", "reason": "rollback"}, ""},
+		{"RollbackClusterConfiguration no previous configuration", serv.RollbackClusterConfiguration, http.StatusNotFound, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000004"}, requestData{"username": "test", "reason": "rollback", "to": "previous"}, ""},
+		{"RollbackClusterConfiguration to configuration of other cluster", serv.RollbackClusterConfiguration, http.StatusNotFound, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000004"}, requestData{"username": "test", "reason": "rollback", "to": "1"}, ""},
 	}
 
 	for _, tt := range nonErrorTT {
@@ -65,6 +91,16 @@ func TestNonErrorsConfigurationWithData(t *testing.T) {
 	}
 }
 
+// TestSearchConfigurationsWithMemoryStorage tests that configuration search
+// is refused by storage that is not based on SQL
+func TestSearchConfigurationsWithMemoryStorage(t *testing.T) {
+	serv := MockedIOCServerWithMemoryStorage(t)
+	defer serv.Storage.Close()
+
+	tt := testCase{"SearchConfigurations Not implemented", serv.SearchConfigurations, http.StatusNotImplemented, "GET", true, requestData{}, requestData{}, ""}
+	testRequest(t, &tt)
+}
+
 // TestDatabaseErrorConfiguration tests unexpected behaviour by closing DB connection (consistency check)
 func TestDatabaseErrorConfiguration(t *testing.T) {
 	serv := MockedIOCServer(t, true)
@@ -72,13 +108,17 @@ func TestDatabaseErrorConfiguration(t *testing.T) {
 	dbErrorTT := []testCase{
 		{"GetConfiguration DB error", serv.GetConfiguration, http.StatusInternalServerError, "GET", false, requestData{"id": "1"}, requestData{}, ""},
 		{"GetAllConfigurations DB error", serv.GetAllConfigurations, http.StatusInternalServerError, "GET", false, requestData{}, requestData{}, ""},
+		{"SearchConfigurations DB error", serv.SearchConfigurations, http.StatusInternalServerError, "GET", false, requestData{}, requestData{}, ""},
 		{"GetClusterConfiguration DB error", serv.GetClusterConfiguration, http.StatusInternalServerError, "GET", false, requestData{"cluster": "1"}, requestData{}, ""},
-		{"EnableConfiguration DB error", serv.EnableConfiguration, http.StatusInternalServerError, "PUT", false, requestData{"id": "1"}, requestData{}, ""},
-		{"DisableConfiguration DB error", serv.DisableConfiguration, http.StatusInternalServerError, "PUT", false, requestData{"id": "1"}, requestData{}, ""},
-		{"DeleteConfiguration DB error", serv.DeleteConfiguration, http.StatusInternalServerError, "DELETE", false, requestData{"id": "1"}, requestData{}, ""},
-		{"EnableClusterConfiguration DB error", serv.EnableClusterConfiguration, http.StatusInternalServerError, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
-		{"DisableClusterConfiguration DB error", serv.DisableClusterConfiguration, http.StatusInternalServerError, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
-		{"NewClusterConfiguration DB error", serv.NewClusterConfiguration, http.StatusInternalServerError, "POST", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, "Test config"},
+		{"EnableConfiguration DB error", withHeader(serv.EnableConfiguration, "If-Match", "*"), http.StatusInternalServerError, "PUT", false, requestData{"id": "1"}, requestData{}, ""},
+		{"DisableConfiguration DB error", withHeader(serv.DisableConfiguration, "If-Match", "*"), http.StatusInternalServerError, "PUT", false, requestData{"id": "1"}, requestData{}, ""},
+		{"DeleteConfiguration DB error", withHeader(serv.DeleteConfiguration, "If-Match", "*"), http.StatusInternalServerError, "DELETE", false, requestData{"id": "1"}, requestData{}, ""},
+		{"RestoreConfiguration DB error", serv.RestoreConfiguration, http.StatusInternalServerError, "PUT", false, requestData{"id": "1"}, requestData{}, ""},
+		{"EnableClusterConfiguration DB error", withHeader(serv.EnableClusterConfiguration, "If-Match", "*"), http.StatusInternalServerError, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
+		{"DisableClusterConfiguration DB error", withHeader(serv.DisableClusterConfiguration, "If-Match", "*"), http.StatusInternalServerError, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester", "reason": "test"}, ""},
+		{"NewClusterConfiguration DB error", serv.NewClusterConfiguration, http.StatusInternalServerError, "POST", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, `{"no_op":"Test config"}`},
+		{"AssignClusterConfiguration DB error", serv.AssignClusterConfiguration, http.StatusInternalServerError, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "shared", "profile": "1"}, ""},
+		{"RollbackClusterConfiguration DB error", serv.RollbackClusterConfiguration, http.StatusInternalServerError, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "rollback"}, ""},
 	}
 
 	// close storage
@@ -95,11 +135,16 @@ func TestParameterErrorsConfiguration(t *testing.T) {
 	defer serv.Storage.Close()
 
 	paramErrorTT := []testCase{
+		{"SearchConfigurations non-int configuration", serv.SearchConfigurations, http.StatusBadRequest, "GET", true, requestData{}, requestData{"configuration": "non-int"}, ""},
+		{"SearchConfigurations unknown order column", serv.SearchConfigurations, http.StatusBadRequest, "GET", true, requestData{}, requestData{"order_by": "reason"}, ""},
 		{"GetConfiguration no id", serv.GetConfiguration, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
 		{"GetConfiguration non-int id", serv.GetConfiguration, http.StatusBadRequest, "GET", true, requestData{"id": "non-int"}, requestData{}, ""},
 		{"DeleteConfiguration no id", serv.DeleteConfiguration, http.StatusBadRequest, "DELETE", true, requestData{}, requestData{}, ""},
 		{"DeleteConfiguration non-int id", serv.DeleteConfiguration, http.StatusBadRequest, "DELETE", true, requestData{"id": "non-int"}, requestData{}, ""},
 		{"GetClusterConfiguration no id", serv.GetClusterConfiguration, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
+		{"GetClusterConfiguration non-bool include_deleted", serv.GetClusterConfiguration, http.StatusBadRequest, "GET", true, requestData{"cluster": "1"}, requestData{"include_deleted": "maybe"}, ""},
+		{"GetAllConfigurations non-bool include_deleted", serv.GetAllConfigurations, http.StatusBadRequest, "GET", true, requestData{}, requestData{"include_deleted": "maybe"}, ""},
+		{"RestoreConfiguration non-int id", serv.RestoreConfiguration, http.StatusBadRequest, "PUT", true, requestData{"id": "non-int"}, requestData{}, ""},
 		{"EnableConfiguration no id", serv.EnableConfiguration, http.StatusBadRequest, "PUT", true, requestData{}, requestData{}, ""},
 		{"DisableConfiguration no id", serv.DisableConfiguration, http.StatusBadRequest, "PUT", true, requestData{}, requestData{}, ""},
 		{"EnableConfiguration non-int id", serv.EnableConfiguration, http.StatusBadRequest, "PUT", true, requestData{"id": "non-int"}, requestData{}, ""},
@@ -110,11 +155,22 @@ func TestParameterErrorsConfiguration(t *testing.T) {
 		{"DisableClusterConfiguration no cluster", serv.DisableClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{}, requestData{"username": "tester", "reason": "test"}, ""},
 		{"DisableClusterConfiguration no reason", serv.DisableClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "tester"}, ""},
 		{"DisableClusterConfiguration no username", serv.DisableClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"reason": "test"}, ""},
-		{"NewClusterConfiguration no cluster", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, "Test config"},
-		{"NewClusterConfiguration no username", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": "1"}, requestData{"reason": "unknown", "description": "testing"}, "Test config"},
-		{"NewClusterConfiguration no reason", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": "1"}, requestData{"username": "test", "description": "testing"}, "Test config"},
-		{"NewClusterConfiguration no description", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": "1"}, requestData{"username": "test", "reason": "unknown"}, "Test config"},
+		{"NewClusterConfiguration no cluster", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, `{"no_op":"Test config"}`},
+		{"NewClusterConfiguration no username", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": "1"}, requestData{"reason": "unknown", "description": "testing"}, `{"no_op":"Test config"}`},
+		{"NewClusterConfiguration no reason", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": "1"}, requestData{"username": "test", "description": "testing"}, `{"no_op":"Test config"}`},
+		{"NewClusterConfiguration no description", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": "1"}, requestData{"username": "test", "reason": "unknown"}, `{"no_op":"Test config"}`},
 		{"NewClusterConfiguration no config in body", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "unknown", "description": "testing"}, ""},
+		{"AssignClusterConfiguration no cluster", serv.AssignClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{}, requestData{"username": "test", "reason": "shared", "profile": "1"}, ""},
+		{"AssignClusterConfiguration no username", serv.AssignClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "1"}, requestData{"reason": "shared", "profile": "1"}, ""},
+		{"AssignClusterConfiguration no reason", serv.AssignClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "1"}, requestData{"username": "test", "profile": "1"}, ""},
+		{"AssignClusterConfiguration no profile", serv.AssignClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "1"}, requestData{"username": "test", "reason": "shared"}, ""},
+		{"AssignClusterConfiguration non-int profile", serv.AssignClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "1"}, requestData{"username": "test", "reason": "shared", "profile": "non-int"}, ""},
+		{"RollbackClusterConfiguration no cluster", serv.RollbackClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{}, requestData{"username": "test", "reason": "rollback"}, ""},
+		{"RollbackClusterConfiguration no username", serv.RollbackClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "1"}, requestData{"reason": "rollback"}, ""},
+		{"RollbackClusterConfiguration no reason", serv.RollbackClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "1"}, requestData{"username": "test"}, ""},
+		{"RollbackClusterConfiguration non-int configuration", serv.RollbackClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "1"}, requestData{"username": "test", "reason": "rollback", "to": "non-int"}, ""},
+		{"RollbackClusterConfiguration non-positive configuration", serv.RollbackClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "1"}, requestData{"username": "test", "reason": "rollback", "to": "-1"}, ""},
+		{"AssignClusterConfiguration non-positive revision", serv.AssignClusterConfiguration, http.StatusBadRequest, "PUT", false, requestData{"cluster": "1"}, requestData{"username": "test", "reason": "shared", "profile": "1", "revision": "0"}, ""},
 	}
 
 	for _, tt := range paramErrorTT {
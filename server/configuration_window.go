@@ -0,0 +1,127 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/configuration_window.html
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+)
+
+// retrieveTimestampQueryParameter reads optional timestamp from query
+// parameter with the given name, zero time is returned when it is not
+// specified
+func retrieveTimestampQueryParameter(request *http.Request, name string) (time.Time, error) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s needs to be timestamp in RFC 3339 format", name)
+	}
+	return timestamp, nil
+}
+
+// retrieveConfigurationWindow reads optional time window of cluster
+// configuration from the effective_from and effective_until query parameters
+func retrieveConfigurationWindow(request *http.Request) (storage.ConfigurationWindow, error) {
+	effectiveFrom, err := retrieveTimestampQueryParameter(request, "effective_from")
+	if err != nil {
+		return storage.ConfigurationWindow{}, err
+	}
+
+	effectiveUntil, err := retrieveTimestampQueryParameter(request, "effective_until")
+	if err != nil {
+		return storage.ConfigurationWindow{}, err
+	}
+
+	return storage.ConfigurationWindow{
+		EffectiveFrom:  effectiveFrom,
+		EffectiveUntil: effectiveUntil,
+	}, nil
+}
+
+// SetConfigurationWindow method changes time window of single cluster
+// configuration. Window is cleared when neither effective_from nor
+// effective_until is specified.
+func (s *Server) SetConfigurationWindow(writer http.ResponseWriter, request *http.Request) {
+	id, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading configuration ID from request\n")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	window, err := retrieveConfigurationWindow(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to record the action SetConfigurationWindow into Splunk
+	err = s.Splunk.LogAction("SetConfigurationWindow", username[0], strconv.Itoa(int(id)))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to change the window in storage
+	err = s.Storage.SetClusterConfigurationWindow(request.Context(), id, window)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// GetConfigurationTransitions method returns activation and expiry events
+// recorded for single cluster configuration
+func (s *Server) GetConfigurationTransitions(writer http.ResponseWriter, request *http.Request) {
+	id, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading configuration ID from request\n")
+		return
+	}
+
+	// try to read all transitions of the configuration from storage
+	transitions, err := s.Storage.ListConfigurationTransitions(request.Context(), id)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("transitions", transitions))
+	}
+}
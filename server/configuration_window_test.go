@@ -0,0 +1,79 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/configuration_window_test.html
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestNonErrorsConfigurationWindowsWithData tests OK behaviour with mock data
+func TestNonErrorsConfigurationWindowsWithData(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	nonErrorTT := []testCase{
+		{"NewClusterConfiguration scheduled OK", serv.NewClusterConfiguration, http.StatusOK, "POST", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "scheduled", "description": "testing", "effective_from": "2030-01-01T00:00:00Z", "effective_until": "2030-02-01T00:00:00Z"}, `{"no_op":"Test config"}`},
+		{"NewClusterConfiguration wrong window", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", false, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "scheduled", "description": "testing", "effective_from": "2030-02-01T00:00:00Z", "effective_until": "2030-01-01T00:00:00Z"}, `{"no_op":"Test config"}`},
+		{"SetConfigurationWindow OK", serv.SetConfigurationWindow, http.StatusOK, "PUT", true, requestData{"id": "2"}, requestData{"username": "tester", "effective_from": "2020-01-01T00:00:00Z"}, ""},
+		{"SetConfigurationWindow clear OK", serv.SetConfigurationWindow, http.StatusOK, "PUT", true, requestData{"id": "2"}, requestData{"username": "tester"}, ""},
+		{"SetConfigurationWindow wrong window", serv.SetConfigurationWindow, http.StatusBadRequest, "PUT", true, requestData{"id": "2"}, requestData{"username": "tester", "effective_from": "2020-01-01T00:00:00Z", "effective_until": "2019-01-01T00:00:00Z"}, ""},
+		{"SetConfigurationWindow unknown configuration", serv.SetConfigurationWindow, http.StatusNotFound, "PUT", true, requestData{"id": "42"}, requestData{"username": "tester"}, ""},
+		{"GetConfigurationTransitions OK", serv.GetConfigurationTransitions, http.StatusOK, "GET", true, requestData{"id": "2"}, requestData{}, ""},
+		{"GetConfigurationTransitions unknown configuration", serv.GetConfigurationTransitions, http.StatusNotFound, "GET", true, requestData{"id": "42"}, requestData{}, ""},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestDatabaseErrorConfigurationWindows tests unexpected behaviour by closing DB connection (consistency check)
+func TestDatabaseErrorConfigurationWindows(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+
+	dbErrorTT := []testCase{
+		{"SetConfigurationWindow DB error", serv.SetConfigurationWindow, http.StatusInternalServerError, "PUT", true, requestData{"id": "2"}, requestData{"username": "tester"}, ""},
+		{"GetConfigurationTransitions DB error", serv.GetConfigurationTransitions, http.StatusInternalServerError, "GET", true, requestData{"id": "2"}, requestData{}, ""},
+	}
+	serv.Storage.Close()
+
+	for _, tt := range dbErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestParameterErrorsConfigurationWindows tests wrong request parameters
+func TestParameterErrorsConfigurationWindows(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	paramErrorTT := []testCase{
+		{"NewClusterConfiguration wrong effective_from", serv.NewClusterConfiguration, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"username": "test", "reason": "scheduled", "description": "testing", "effective_from": "tomorrow"}, `{"no_op":"Test config"}`},
+		{"SetConfigurationWindow wrong id", serv.SetConfigurationWindow, http.StatusBadRequest, "PUT", true, requestData{"id": "x"}, requestData{"username": "tester"}, ""},
+		{"SetConfigurationWindow no username", serv.SetConfigurationWindow, http.StatusBadRequest, "PUT", true, requestData{"id": "2"}, requestData{}, ""},
+		{"SetConfigurationWindow wrong effective_until", serv.SetConfigurationWindow, http.StatusBadRequest, "PUT", true, requestData{"id": "2"}, requestData{"username": "tester", "effective_until": "2020-13-01"}, ""},
+		{"GetConfigurationTransitions wrong id", serv.GetConfigurationTransitions, http.StatusBadRequest, "GET", true, requestData{"id": "x"}, requestData{}, ""},
+	}
+
+	for _, tt := range paramErrorTT {
+		testRequest(t, &tt)
+	}
+}
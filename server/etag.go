@@ -0,0 +1,88 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/etag.html
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// Versions of configuration profiles and cluster configurations are sent to
+// clients in ETag header. Clients need to send the ETag back in If-Match
+// header when they change or delete the item, so concurrent modifications
+// are detected and refused. If-Match with value "*" can be used to change or
+// delete the item regardless of its version.
+
+const (
+	etagHeader    = "ETag"
+	ifMatchHeader = "If-Match"
+)
+
+// errMissingIfMatch is reported when If-Match header has not been sent
+var errMissingIfMatch = errors.New("If-Match header with ETag of the item needs to be specified")
+
+// errInvalidIfMatch is reported when If-Match header does not contain one
+// strong ETag or "*" (weak ETags never match)
+var errInvalidIfMatch = errors.New("If-Match header needs to contain one strong ETag or '*'")
+
+// setETagHeader sets ETag header to the version of item
+func setETagHeader(writer http.ResponseWriter, version int) {
+	writer.Header().Set(etagHeader, `"`+strconv.Itoa(version)+`"`)
+}
+
+// retrieveIfMatchVersion reads the expected version of item from If-Match
+// header. storage.AnyVersion is returned for If-Match: *
+func retrieveIfMatchVersion(request *http.Request) (int, error) {
+	value := strings.TrimSpace(request.Header.Get(ifMatchHeader))
+	if value == "" {
+		return 0, errMissingIfMatch
+	}
+
+	if value == "*" {
+		return storage.AnyVersion, nil
+	}
+
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return 0, errInvalidIfMatch
+	}
+
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || version <= 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// sendIfMatchError sends response for request with missing or unusable
+// If-Match header
+func sendIfMatchError(writer http.ResponseWriter, err error) {
+	if err == errMissingIfMatch {
+		TryToSendResponse(http.StatusPreconditionRequired, writer, err.Error())
+		return
+	}
+	TryToSendResponse(http.StatusPreconditionFailed, writer, err.Error())
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/heartbeat.html
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// operatorUserAgentPrefix is prefix of User-Agent header sent by insights
+// operator, it is followed by version of the operator
+const operatorUserAgentPrefix = "insights-operator/"
+
+// Prometheus metric with number of clusters that have not been seen within
+// the stale cluster window
+var staleClusters = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "stale_clusters",
+	Help: "The number of clusters that have not checked in within the stale cluster window",
+})
+
+// operatorVersion reads version of the operator from User-Agent header, for
+// example "insights-operator/v4.12.0 cluster/..." contains version v4.12.0.
+// Empty string is returned for requests sent by other clients.
+func operatorVersion(request *http.Request) string {
+	userAgent := request.Header.Get("User-Agent")
+	if !strings.HasPrefix(userAgent, operatorUserAgentPrefix) {
+		return ""
+	}
+	return strings.Fields(strings.TrimPrefix(userAgent, operatorUserAgentPrefix) + " ")[0]
+}
+
+// requestAddress returns source address of the request. The first address
+// from X-Forwarded-For header is used for requests sent via proxy.
+func requestAddress(request *http.Request) string {
+	if forwarded := request.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
+
+// RecordOperatorHeartbeat method represents middleware that records last-seen
+// time, source address, and operator version of cluster specified in request
+// handled by the next handler. Heartbeat is recorded after the request is
+// handled, so newly registered clusters are recorded too.
+func (s *Server) RecordOperatorHeartbeat(nextHandler http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			nextHandler.ServeHTTP(writer, request)
+
+			cluster, found := mux.Vars(request)["cluster"]
+			if !found {
+				return
+			}
+			err := s.Storage.RecordClusterHeartbeat(request.Context(), cluster,
+				requestAddress(request), operatorVersion(request), time.Now())
+			if err != nil {
+				log.Println("Unable to record heartbeat of cluster", cluster, err)
+			}
+		})
+}
+
+// GetStaleClusters method returns all clusters that have not checked in
+// within the stale cluster window. The window can be overridden by window
+// query parameter, for example window=1h.
+func (s *Server) GetStaleClusters(writer http.ResponseWriter, request *http.Request) {
+	window := s.StaleClusterWindow
+	if value := request.URL.Query().Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			TryToSendBadRequestServerResponse(writer, "Window needs to be positive duration, for example 24h")
+			return
+		}
+		window = parsed
+	}
+	if window <= 0 {
+		TryToSendBadRequestServerResponse(writer, "Stale cluster window is not configured")
+		return
+	}
+
+	// try to read stale clusters from storage
+	clusters, err := s.Storage.ListStaleClusters(request.Context(), time.Now().Add(-window))
+
+	// check if the storage operation has been successful
+	if err != nil {
+		log.Println("Unable to get list of stale clusters", err)
+		TryToSendStorageError(writer, err)
+		return
+	}
+
+	resp := responses.BuildOkResponseWithData("clusters", clusters)
+	resp["window"] = window.String()
+	TryToSendOKServerResponse(writer, resp)
+}
+
+// UpdateStaleClustersMetric method updates Prometheus metric with number of
+// clusters that have not checked in within the stale cluster window
+func (s *Server) UpdateStaleClustersMetric(ctx context.Context) error {
+	clusters, err := s.Storage.ListStaleClusters(ctx, time.Now().Add(-s.StaleClusterWindow))
+	if err != nil {
+		return err
+	}
+	staleClusters.Set(float64(len(clusters)))
+	return nil
+}
+
+// RunStaleClustersMonitor method periodically updates Prometheus metric with
+// number of stale clusters until the context is cancelled
+func (s *Server) RunStaleClustersMonitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.UpdateStaleClustersMetric(ctx); err != nil {
+				log.Println("Unable to count stale clusters", err)
+			}
+		}
+	}
+}
+
+// clusterHeartbeat reads the last heartbeat of cluster specified by its ID.
+// Nil is returned for clusters that have never been seen.
+func (s *Server) clusterHeartbeat(ctx context.Context, clusterID int) (*storage.ClusterHeartbeat, error) {
+	heartbeat, err := s.Storage.GetClusterHeartbeat(ctx, clusterID)
+	if _, ok := err.(*storage.ItemNotFoundError); ok {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &heartbeat, nil
+}
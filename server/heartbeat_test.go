@@ -0,0 +1,133 @@
+/*
+Copyright © 2019, 2020, 2021, 2022, 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/heartbeat_test.html
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// TestNonErrorsHeartbeatWithData tests OK behaviour with mock data
+func TestNonErrorsHeartbeatWithData(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	nonErrorTT := []testCase{
+		{"GetStaleClusters OK", serv.GetStaleClusters, http.StatusOK, "GET", true, requestData{}, requestData{"window": "1h"}, ""},
+	}
+
+	serv.StaleClusterWindow = 24 * time.Hour
+	nonErrorTT = append(nonErrorTT,
+		testCase{"GetStaleClusters default window OK", serv.GetStaleClusters, http.StatusOK, "GET", true, requestData{}, requestData{}, ""})
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestDatabaseErrorHeartbeat tests unexpected behaviour by closing DB connection (consistency check)
+func TestDatabaseErrorHeartbeat(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+
+	dbErrorTT := []testCase{
+		{"GetStaleClusters DB error", serv.GetStaleClusters, http.StatusInternalServerError, "GET", true, requestData{}, requestData{"window": "1h"}, ""},
+	}
+
+	serv.Storage.Close()
+
+	for _, tt := range dbErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestParameterErrorsHeartbeat tests wrong request parameters
+func TestParameterErrorsHeartbeat(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	paramErrorTT := []testCase{
+		{"GetStaleClusters no window", serv.GetStaleClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
+		{"GetStaleClusters wrong window", serv.GetStaleClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"window": "yesterday"}, ""},
+		{"GetStaleClusters negative window", serv.GetStaleClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"window": "-1h"}, ""},
+	}
+
+	for _, tt := range paramErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestRecordOperatorHeartbeat checks that calls of the operator are recorded
+// and that the last heartbeat is returned together with cluster
+func TestRecordOperatorHeartbeat(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	clusterName := "00000000-0000-0000-0000-000000000001"
+	cluster, err := serv.Storage.GetClusterByName(context.Background(), clusterName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := httptest.NewRequest("GET", "/api/v1/operator/triggers/"+clusterName, nil)
+	request.Header.Set("User-Agent", "insights-operator/v4.12.0 cluster/"+clusterName)
+	request.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+	recorder := httptest.NewRecorder()
+	handler := serv.RecordOperatorHeartbeat(http.HandlerFunc(serv.GetActiveTriggersForCluster))
+	handler.ServeHTTP(recorder, mux.SetURLVars(request, map[string]string{"cluster": clusterName}))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	request = httptest.NewRequest("GET", fmt.Sprintf("/api/v1/client/cluster/%d", cluster.ID), nil)
+	recorder = httptest.NewRecorder()
+	serv.GetClusterByID(recorder, mux.SetURLVars(request, map[string]string{"id": fmt.Sprint(cluster.ID)}))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Heartbeat *storage.ClusterHeartbeat `json:"heartbeat"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, response.Heartbeat) {
+		assert.Equal(t, "10.0.0.1", response.Heartbeat.Address)
+		assert.Equal(t, "v4.12.0", response.Heartbeat.OperatorVersion)
+		assert.NotEmpty(t, response.Heartbeat.LastSeenAt)
+	}
+
+	// the cluster is not stale anymore
+	serv.StaleClusterWindow = time.Hour
+	stale, err := serv.Storage.ListStaleClusters(context.Background(), time.Now().Add(-serv.StaleClusterWindow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, heartbeat := range stale {
+		assert.NotEqual(t, cluster.ID, heartbeat.ClusterID)
+	}
+	assert.NoError(t, serv.UpdateStaleClustersMetric(context.Background()))
+}
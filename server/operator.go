@@ -23,9 +23,12 @@ package server
 // https://redhatinsights.github.io/insights-operator-controller/packages/server/operator.html
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/RedHatInsights/insights-operator-controller/storage"
 	"github.com/RedHatInsights/insights-operator-utils/responses"
@@ -42,26 +45,27 @@ func (s *Server) ReadConfigurationForOperator(writer http.ResponseWriter, reques
 		return
 	}
 
-	// try to read cluster active configuration from storage
-	configuration, err := s.Storage.GetClusterActiveConfiguration(cluster)
+	// try to read cluster configuration merged with default and group profiles
+	configuration, err := s.clusterConfiguration(request.Context(), cluster)
 
 	// check if the storage operation has been successful
-	if itemNotFoundError, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(
-			http.StatusNotFound,
-			writer,
-			fmt.Sprintf("unable to read any active configuration for the cluster %v",
-				itemNotFoundError.ItemID),
-		)
-	} else if err != nil {
+	if err != nil {
 		log.Println("Cannot read cluster configuration", err)
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 	} else {
+		// configuration read by the operator is acknowledged, failure is
+		// not reported to the operator as the configuration is served anyway
+		err = s.Storage.AckClusterConfiguration(request.Context(), cluster, time.Now())
+		if err != nil {
+			log.Println("Cannot acknowledge cluster configuration", err)
+		}
 		sendConfiguration(writer, configuration)
 	}
 }
 
-// RegisterCluster method registers new cluster.
+// RegisterCluster method registers new cluster. The operator can send
+// metadata of the cluster as JSON object in request body, the metadata are
+// stored with the cluster.
 func (s *Server) RegisterCluster(writer http.ResponseWriter, request *http.Request) {
 	// cluster name needs to be specified in request
 	clusterName, foundName := mux.Vars(request)["cluster"]
@@ -73,23 +77,92 @@ func (s *Server) RegisterCluster(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
+	// metadata are optional, empty request body is sent without them
+	metadata, err := retrieveClusterMetadata(request, clusterName)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
 	// try to record the action RegisterCluster into Splunk
-	err := s.Splunk.LogAction("RegisterCluster", "tester", clusterName)
+	err = s.Splunk.LogAction("RegisterCluster", "tester", clusterName)
 	if err != nil {
 		log.Println("(not critical) Log into splunk failed", err)
 	}
 
 	// register new cluster in the storage
-	err = s.Storage.RegisterNewCluster(clusterName)
+	err = s.Storage.RegisterNewCluster(request.Context(), clusterName)
 
 	// check if the storage operation has been successful
 	if err != nil {
 		log.Println("Cannot create new cluster", err)
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
+		return
+	}
+
+	if metadata != nil {
+		err = s.Storage.SetClusterMetadata(request.Context(), clusterName, *metadata)
+		if err != nil {
+			log.Println("Cannot store metadata of cluster", err)
+			TryToSendStorageError(writer, err)
+			return
+		}
 	}
 	TryToSendCreatedServerResponse(writer, responses.BuildOkResponse())
 }
 
+// retrieveClusterMetadata reads optional metadata of cluster from request
+// body. Nil is returned when request body is empty. Version of the operator
+// is read from User-Agent header when it is not part of the metadata.
+func retrieveClusterMetadata(request *http.Request, clusterName string) (*storage.ClusterMetadata, error) {
+	if request.Body == nil {
+		return nil, nil
+	}
+
+	var metadata storage.ClusterMetadata
+	err := json.NewDecoder(request.Body).Decode(&metadata)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.New("Cluster metadata needs to be JSON object")
+	}
+	if err := storage.CheckClusterMetadata(clusterName, metadata); err != nil {
+		return nil, err
+	}
+
+	if metadata.OperatorVersion == "" {
+		metadata.OperatorVersion = operatorVersion(request)
+	}
+	return &metadata, nil
+}
+
+// operatorTrigger is trigger sent to the operator, its parameters are sent as
+// JSON object instead of string
+type operatorTrigger struct {
+	storage.Trigger
+	Parameters json.RawMessage `json:"parameters"`
+}
+
+// operatorTriggers converts triggers read from storage into triggers sent to
+// the operator. Triggers created without parameters have empty parameters
+// object.
+func operatorTriggers(triggers []storage.Trigger) []operatorTrigger {
+	result := make([]operatorTrigger, 0, len(triggers))
+	for _, trigger := range triggers {
+		parameters := json.RawMessage("{}")
+		if trigger.Parameters != "" {
+			if json.Valid([]byte(trigger.Parameters)) {
+				parameters = json.RawMessage(trigger.Parameters)
+			} else {
+				log.Printf("Parameters of trigger %d are not valid JSON", trigger.ID)
+			}
+		}
+		result = append(result, operatorTrigger{Trigger: trigger, Parameters: parameters})
+	}
+	return result
+}
+
 // GetActiveTriggersForCluster method returns list of triggers for single cluster
 func (s *Server) GetActiveTriggersForCluster(writer http.ResponseWriter, request *http.Request) {
 	// cluster name needs to be specified in request
@@ -100,19 +173,106 @@ func (s *Server) GetActiveTriggersForCluster(writer http.ResponseWriter, request
 	}
 
 	// try to read list of active cluster triggers
-	triggers, err := s.Storage.ListActiveClusterTriggers(cluster)
+	triggers, err := s.Storage.ListActiveClusterTriggers(request.Context(), cluster)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("triggers", operatorTriggers(triggers)))
+	}
+}
+
+// operatorTriggerStates contains states of triggers that can be reported by
+// the operator
+var operatorTriggerStates = []storage.TriggerState{
+	storage.TriggerDelivered, storage.TriggerRunning,
+	storage.TriggerSucceeded, storage.TriggerFailed,
+}
+
+// ReportTriggerStateForCluster method changes state of single cluster's
+// trigger. It is used by the operator to report progress of the trigger.
+func (s *Server) ReportTriggerStateForCluster(writer http.ResponseWriter, request *http.Request) {
+	// cluster name needs to be specified in request
+	cluster, found := mux.Vars(request)["cluster"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Cluster name needs to be specified")
+		return
+	}
+
+	// trigger ID needs to be specified in request
+	triggerID, err := retrievePositiveIntRequestParameter(request, "trigger")
+	if err != nil {
+		TryToSendResponse(http.StatusBadRequest, writer, err.Error())
+		return
+	}
+
+	// new state needs to be specified in request parameter
+	state, err := storage.ParseTriggerState(request.URL.Query().Get("state"))
+	if err != nil || !containsTriggerState(operatorTriggerStates, state) {
+		TryToSendBadRequestServerResponse(writer, "State needs to be one of delivered, running, succeeded, or failed")
+		return
+	}
+
+	// try to change state of the trigger in storage
+	err = s.Storage.ChangeClusterTriggerState(request.Context(), cluster, triggerID, state)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// ReportTriggerResultForCluster method stores result of single cluster's
+// trigger. The result is JSON object sent in request body and its status
+// (succeeded or failed) becomes state of the trigger.
+func (s *Server) ReportTriggerResultForCluster(writer http.ResponseWriter, request *http.Request) {
+	// cluster name needs to be specified in request
+	cluster, found := mux.Vars(request)["cluster"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Cluster name needs to be specified")
+		return
+	}
+
+	// trigger ID needs to be specified in request
+	triggerID, err := retrievePositiveIntRequestParameter(request, "trigger")
+	if err != nil {
+		TryToSendResponse(http.StatusBadRequest, writer, err.Error())
+		return
+	}
+
+	// result needs to be sent in request body
+	var result storage.TriggerResult
+	if err := json.NewDecoder(request.Body).Decode(&result); err != nil {
+		TryToSendBadRequestServerResponse(writer, "Trigger result needs to be JSON object")
+		return
+	}
+
+	// try to store the result in storage
+	err = s.Storage.SetTriggerResult(request.Context(), cluster, triggerID, result)
 
 	// check if the storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
-		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("triggers", triggers))
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
 	}
 }
 
-// AckTriggerForCluster method perform ack for single cluster's trigger
+// containsTriggerState checks whether the state is in list of states
+func containsTriggerState(states []storage.TriggerState, state storage.TriggerState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// AckTriggerForCluster method perform ack for single cluster's trigger, i.e.
+// it marks the trigger as delivered
 func (s *Server) AckTriggerForCluster(writer http.ResponseWriter, request *http.Request) {
 	// cluster name needs to be specified in request
 	cluster, found := mux.Vars(request)["cluster"]
@@ -129,13 +289,11 @@ func (s *Server) AckTriggerForCluster(writer http.ResponseWriter, request *http.
 	}
 
 	// try to ack cluster in storage
-	err = s.Storage.AckTrigger(cluster, triggerID)
+	err = s.Storage.AckTrigger(request.Context(), cluster, triggerID)
 
 	// check if the storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
 	}
@@ -20,8 +20,18 @@ package server_test
 // https://redhatinsights.github.io/insights-operator-controller/packages/server/operator_test.html
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
 )
 
 // TestNonErrorsConfigurationWithoutData tests OK behaviour with empty DB (schema only)
@@ -33,6 +43,8 @@ func TestNonErrorsOperatorWithoutData(t *testing.T) {
 		{"ReadConfigurationForOperator Not Found", serv.ReadConfigurationForOperator, http.StatusNotFound, "GET", true, requestData{"cluster": "1"}, requestData{}, ""},
 		{"GetActiveTriggersForCluster Not Found", serv.GetActiveTriggersForCluster, http.StatusNotFound, "GET", true, requestData{"cluster": "1"}, requestData{}, ""},
 		{"AckTriggerForCluster Not Found", serv.AckTriggerForCluster, http.StatusNotFound, "GET", true, requestData{"cluster": "1", "trigger": "1"}, requestData{}, ""},
+		{"ReportTriggerStateForCluster Not Found", serv.ReportTriggerStateForCluster, http.StatusNotFound, "PUT", true, requestData{"cluster": "1", "trigger": "1"}, requestData{"state": "running"}, ""},
+		{"ReportTriggerResultForCluster Not Found", serv.ReportTriggerResultForCluster, http.StatusNotFound, "POST", true, requestData{"cluster": "1", "trigger": "1"}, requestData{}, `{"status": "succeeded", "duration": 10, "location": "must-gather/1.tar.gz", "size": 100}`},
 		{"RegisterCluster OK", serv.RegisterCluster, http.StatusCreated, "PUT", true, requestData{"cluster": "1"}, requestData{}, ""},
 	}
 
@@ -50,8 +62,16 @@ func TestNonErrorsOperatorWithData(t *testing.T) {
 		{"ReadConfigurationForOperator OK", serv.ReadConfigurationForOperator, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{}, ""},
 		{"GetActiveTriggersForCluster OK", serv.GetActiveTriggersForCluster, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{}, ""},
 		{"GetActiveTriggersForCluster No triggers OK", serv.GetActiveTriggersForCluster, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000004"}, requestData{}, ""},
-		{"AckTriggerForCluster OK", serv.AckTriggerForCluster, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "1"}, requestData{}, ""},
+		{"AckTriggerForCluster OK", serv.AckTriggerForCluster, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{}, ""},
+		{"AckTriggerForCluster cancelled trigger", serv.AckTriggerForCluster, http.StatusConflict, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "1"}, requestData{}, ""},
+		{"AckTriggerForCluster trigger of other cluster", serv.AckTriggerForCluster, http.StatusNotFound, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "4"}, requestData{}, ""},
+		{"ReportTriggerStateForCluster running OK", serv.ReportTriggerStateForCluster, http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{"state": "running"}, ""},
+		{"ReportTriggerStateForCluster succeeded OK", serv.ReportTriggerStateForCluster, http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{"state": "succeeded"}, ""},
+		{"ReportTriggerStateForCluster finished trigger", serv.ReportTriggerStateForCluster, http.StatusConflict, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{"state": "running"}, ""},
+		{"ReportTriggerResultForCluster OK", serv.ReportTriggerResultForCluster, http.StatusOK, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{}, `{"status": "succeeded", "duration": 10, "location": "must-gather/1.tar.gz", "size": 100}`},
+		{"ReportTriggerResultForCluster other status", serv.ReportTriggerResultForCluster, http.StatusConflict, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{}, `{"status": "failed", "error": "timeout"}`},
 		{"RegisterCluster OK", serv.RegisterCluster, http.StatusCreated, "PUT", true, requestData{"cluster": "1"}, requestData{}, ""},
+		{"RegisterCluster with metadata OK", serv.RegisterCluster, http.StatusCreated, "PUT", true, requestData{"cluster": "2"}, requestData{}, `{"ocp_version": "4.12.0", "platform": "AWS", "operator_version": "v4.12.0", "region": "us-east-1", "node_count": 3}`},
 	}
 
 	for _, tt := range nonErrorTT {
@@ -59,6 +79,52 @@ func TestNonErrorsOperatorWithData(t *testing.T) {
 	}
 }
 
+// TestActiveTriggerParametersForOperator tests that trigger parameters are
+// sent to the operator as JSON object
+func TestActiveTriggerParametersForOperator(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	vars := map[string]string{"cluster": "cluster", "trigger": "must-gather"}
+
+	request := httptest.NewRequest("PUT", "/api/v1/operator/register/cluster", nil)
+	recorder := httptest.NewRecorder()
+	serv.RegisterCluster(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	err := serv.Storage.NewSupportCase(context.Background(), "01234567", "1234", "", "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request = httptest.NewRequest("POST", "/api/v1/client/cluster/cluster/trigger/must-gather?username=tester&reason=test&link=link&case=01234567",
+		bytes.NewBufferString(`{"image": "quay.io/must-gather", "namespaces": ["default"]}`))
+	recorder = httptest.NewRecorder()
+	serv.RegisterClusterTrigger(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	request = httptest.NewRequest("GET", "/api/v1/operator/cluster/cluster/triggers", nil)
+	recorder = httptest.NewRecorder()
+	serv.GetActiveTriggersForCluster(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Triggers []struct {
+			Type       string                 `json:"type"`
+			Parameters map[string]interface{} `json:"parameters"`
+		} `json:"triggers"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, response.Triggers, 1)
+	assert.Equal(t, "must-gather", response.Triggers[0].Type)
+	assert.Equal(t, map[string]interface{}{
+		"image":      "quay.io/must-gather",
+		"namespaces": []interface{}{"default"},
+	}, response.Triggers[0].Parameters)
+}
+
 // TestDatabaseErrorOperator tests unexpected behaviour by closing DB connection (consistency check)
 func TestDatabaseErrorOperator(t *testing.T) {
 	serv := MockedIOCServer(t, true)
@@ -67,6 +133,8 @@ func TestDatabaseErrorOperator(t *testing.T) {
 		{"ReadConfigurationForOperator DB error", serv.ReadConfigurationForOperator, http.StatusInternalServerError, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{}, ""},
 		{"GetActiveTriggersForCluster DB error", serv.GetActiveTriggersForCluster, http.StatusInternalServerError, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{}, ""},
 		{"AckTriggerForCluster DB error", serv.AckTriggerForCluster, http.StatusInternalServerError, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "1"}, requestData{}, ""},
+		{"ReportTriggerStateForCluster DB error", serv.ReportTriggerStateForCluster, http.StatusInternalServerError, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{"state": "running"}, ""},
+		{"ReportTriggerResultForCluster DB error", serv.ReportTriggerResultForCluster, http.StatusInternalServerError, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{}, `{"status": "succeeded", "duration": 10, "location": "must-gather/1.tar.gz", "size": 100}`},
 		{"RegisterCluster DB error", serv.RegisterCluster, http.StatusInternalServerError, "PUT", true, requestData{"cluster": "1"}, requestData{}, ""},
 	}
 
@@ -87,10 +155,125 @@ func TestParameterErrorsOperator(t *testing.T) {
 		{"GetActiveTriggersForCluster no cluster", serv.GetActiveTriggersForCluster, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
 		{"AckTriggerForCluster no trigger", serv.AckTriggerForCluster, http.StatusBadRequest, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{}, ""},
 		{"AckTriggerForCluster no cluster", serv.AckTriggerForCluster, http.StatusBadRequest, "GET", true, requestData{"trigger": "1"}, requestData{}, ""},
+		{"ReportTriggerStateForCluster no state", serv.ReportTriggerStateForCluster, http.StatusBadRequest, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{}, ""},
+		{"ReportTriggerStateForCluster unknown state", serv.ReportTriggerStateForCluster, http.StatusBadRequest, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{"state": "unknown"}, ""},
+		{"ReportTriggerStateForCluster state reserved for client", serv.ReportTriggerStateForCluster, http.StatusBadRequest, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{"state": "cancelled"}, ""},
+		{"ReportTriggerStateForCluster no trigger", serv.ReportTriggerStateForCluster, http.StatusBadRequest, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"state": "running"}, ""},
+		{"ReportTriggerResultForCluster no trigger", serv.ReportTriggerResultForCluster, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{}, `{"status": "succeeded", "duration": 10, "location": "must-gather/1.tar.gz", "size": 100}`},
+		{"ReportTriggerResultForCluster no result", serv.ReportTriggerResultForCluster, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{}, ""},
+		{"ReportTriggerResultForCluster malformed result", serv.ReportTriggerResultForCluster, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{}, `{"status": `},
+		{"ReportTriggerResultForCluster wrong status", serv.ReportTriggerResultForCluster, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{}, `{"status": "running"}`},
+		{"ReportTriggerResultForCluster negative size", serv.ReportTriggerResultForCluster, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2"}, requestData{}, `{"status": "failed", "size": -1}`},
 		{"RegisterCluster no cluster", serv.RegisterCluster, http.StatusBadRequest, "PUT", true, requestData{}, requestData{}, ""},
+		{"RegisterCluster malformed metadata", serv.RegisterCluster, http.StatusBadRequest, "PUT", true, requestData{"cluster": "1"}, requestData{}, `{"ocp_version": `},
+		{"RegisterCluster negative node count", serv.RegisterCluster, http.StatusBadRequest, "PUT", true, requestData{"cluster": "1"}, requestData{}, `{"node_count": -1}`},
 	}
 
 	for _, tt := range paramErrorTT {
 		testRequest(t, &tt)
 	}
 }
+
+// TestTriggerResultForClient tests that result reported by the operator is
+// returned together with the trigger
+func TestTriggerResultForClient(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	vars := map[string]string{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "2", "id": "2"}
+
+	request := httptest.NewRequest("PUT", "/api/v1/operator/trigger/00000000-0000-0000-0000-000000000000/ack/2", nil)
+	recorder := httptest.NewRecorder()
+	serv.AckTriggerForCluster(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	request = httptest.NewRequest("POST", "/api/v1/operator/trigger/00000000-0000-0000-0000-000000000000/result/2",
+		bytes.NewBufferString(`{"status": "failed", "error": "upload failed", "duration": 12.5, "location": "must-gather/2.tar.gz", "size": 2048}`))
+	recorder = httptest.NewRecorder()
+	serv.ReportTriggerResultForCluster(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	request = httptest.NewRequest("GET", "/api/v1/client/trigger/2", nil)
+	recorder = httptest.NewRecorder()
+	serv.GetTrigger(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Trigger storage.Trigger `json:"trigger"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, storage.TriggerFailed, response.Trigger.State)
+	if assert.NotNil(t, response.Trigger.Result) {
+		assert.Equal(t, "upload failed", response.Trigger.Result.Error)
+		assert.Equal(t, "must-gather/2.tar.gz", response.Trigger.Result.Location)
+		assert.Equal(t, int64(2048), response.Trigger.Result.Size)
+		assert.Equal(t, 12.5, response.Trigger.Result.Duration)
+	}
+}
+
+// TestClusterMetadataForClient tests that metadata sent by the operator
+// during registration are returned together with the cluster and that
+// clusters can be searched by them
+func TestClusterMetadataForClient(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	vars := map[string]string{"cluster": "cluster"}
+
+	request := httptest.NewRequest("PUT", "/api/v1/operator/register/cluster",
+		bytes.NewBufferString(`{"ocp_version": "4.12.0", "platform": "GCP", "region": "europe-west1", "node_count": 6}`))
+	request.Header.Set("User-Agent", "insights-operator/v4.12.0 cluster/cluster")
+	recorder := httptest.NewRecorder()
+	serv.RegisterCluster(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	// repeated registration updates metadata of the same cluster
+	request = httptest.NewRequest("PUT", "/api/v1/operator/register/cluster",
+		bytes.NewBufferString(`{"ocp_version": "4.12.0", "platform": "GCP", "region": "europe-west1", "node_count": 7}`))
+	request.Header.Set("User-Agent", "insights-operator/v4.12.0 cluster/cluster")
+	recorder = httptest.NewRecorder()
+	serv.RegisterCluster(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	cluster, err := serv.Storage.GetClusterByName(context.Background(), "cluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request = httptest.NewRequest("GET", fmt.Sprintf("/api/v1/client/cluster/%d", cluster.ID), nil)
+	recorder = httptest.NewRecorder()
+	serv.GetClusterByID(recorder, mux.SetURLVars(request, map[string]string{"id": fmt.Sprint(cluster.ID)}))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Metadata *storage.ClusterMetadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, response.Metadata) {
+		assert.Equal(t, "4.12.0", response.Metadata.OCPVersion)
+		assert.Equal(t, "GCP", response.Metadata.Platform)
+		// version of the operator is read from User-Agent header
+		assert.Equal(t, "v4.12.0", response.Metadata.OperatorVersion)
+		assert.Equal(t, "europe-west1", response.Metadata.Region)
+		assert.Equal(t, 7, response.Metadata.NodeCount)
+	}
+
+	request = httptest.NewRequest("GET", "/api/v1/client/cluster/search?platform=GCP", nil)
+	recorder = httptest.NewRecorder()
+	serv.SearchCluster(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var clusters struct {
+		Clusters []storage.Cluster `json:"clusters"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &clusters); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, clusters.Clusters, 1) {
+		assert.Equal(t, cluster.ID, clusters.Clusters[0].ID)
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/pagination.html
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+)
+
+// defaultSortColumn is used when no sort parameter is specified, all listed
+// items can be sorted by their ID
+const defaultSortColumn = "id"
+
+// defaultPageLimit is number of items on one page when no limit parameter is
+// specified, so clients that do not read lists page by page do not read
+// whole tables
+const defaultPageLimit = 100
+
+// maxPageLimit is the highest number of items on one page that can be
+// requested
+const maxPageLimit = 1000
+
+// pageCursor points to the last item of the previous page. It contains the
+// sort specification too, so it can not be used with other ordering.
+type pageCursor struct {
+	Sort  string            `json:"s"`
+	Order storage.SortOrder `json:"o"`
+	Value storage.SortValue `json:"v"`
+	ID    int64             `json:"id"`
+}
+
+// encode converts the cursor into opaque string that can be sent to client
+func (c pageCursor) encode() string {
+	// marshalling of this structure can not fail
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePageCursor converts opaque string sent by client back into cursor
+func decodePageCursor(value string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("Cursor is not valid")
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.New("Cursor is not valid")
+	}
+	return &cursor, nil
+}
+
+// pageList gives access to items of any page returned by storage, so all
+// pages can be sent to client the same way
+type pageList interface {
+	Len() int
+	Item(i int) storage.PageItem
+}
+
+// retrievePageParameters reads optional query parameters "limit", "sort",
+// "order", and "cursor" that select one page of listed items. Items can be
+// sorted only by columns listed in sortColumns. Default limit is used when
+// it is not specified and limits over maxPageLimit are refused.
+func retrievePageParameters(request *http.Request, sortColumns []string) (storage.Page, error) {
+	query := request.URL.Query()
+	page := storage.Page{
+		Limit: defaultPageLimit,
+		Sort:  query.Get("sort"),
+		Order: storage.SortOrder(query.Get("order")),
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, fmt.Errorf("Limit has to be a number between 1 and %d", maxPageLimit)
+		}
+		page.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := decodePageCursor(value)
+		if err != nil {
+			return page, err
+		}
+		// sort specification is taken from the cursor when it is not set explicitly
+		if page.Sort == "" {
+			page.Sort = cursor.Sort
+		}
+		if page.Order == "" {
+			page.Order = cursor.Order
+		}
+		if page.Sort != cursor.Sort || page.Order != cursor.Order {
+			return page, errors.New("Cursor does not match sort and order parameters")
+		}
+		page.After = &storage.PageCursor{Value: cursor.Value, ID: cursor.ID}
+	}
+
+	if page.Sort == "" {
+		page.Sort = defaultSortColumn
+	}
+	if page.Order == "" {
+		page.Order = storage.Ascending
+	}
+	if page.Order != storage.Ascending && page.Order != storage.Descending {
+		return page, errors.New("Order needs to be asc or desc")
+	}
+	for _, column := range sortColumns {
+		if column == page.Sort {
+			return page, nil
+		}
+	}
+	return page, errors.New("Items can not be sorted by the specified column")
+}
+
+// buildPageResponse creates response with one page of listed items, total
+// number of items, and link to the next page if there is any. Storage
+// returns one item more than the limit when there is next page.
+func buildPageResponse(request *http.Request, name string, list pageList, total int, page storage.Page) map[string]interface{} {
+	count := list.Len()
+	more := page.Limit > 0 && count > page.Limit
+	if more {
+		count = page.Limit
+	}
+
+	items := make([]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		items = append(items, list.Item(i))
+	}
+
+	response := responses.BuildOkResponseWithData(name, items)
+	response["total"] = total
+	if more {
+		last := list.Item(count - 1)
+		next := pageCursor{
+			Sort:  page.Sort,
+			Order: page.Order,
+			Value: last.SortValue(page.Sort),
+			ID:    last.PageID(),
+		}
+		nextURL := *request.URL
+		query := nextURL.Query()
+		query.Set("cursor", next.encode())
+		nextURL.RawQuery = query.Encode()
+		response["next"] = nextURL.String()
+	}
+	return response
+}
+
+// clusterList gives access to page of clusters
+type clusterList []storage.Cluster
+
+func (l clusterList) Len() int                    { return len(l) }
+func (l clusterList) Item(i int) storage.PageItem { return l[i] }
+
+// profileList gives access to page of configuration profiles
+type profileList []storage.ConfigurationProfile
+
+func (l profileList) Len() int                    { return len(l) }
+func (l profileList) Item(i int) storage.PageItem { return l[i] }
+
+// revisionList gives access to page of configuration profile revisions
+type revisionList []storage.ConfigurationProfileRevision
+
+func (l revisionList) Len() int                    { return len(l) }
+func (l revisionList) Item(i int) storage.PageItem { return l[i] }
+
+// configurationList gives access to page of cluster configurations
+type configurationList []storage.ClusterConfiguration
+
+func (l configurationList) Len() int                    { return len(l) }
+func (l configurationList) Item(i int) storage.PageItem { return l[i] }
+
+// triggerList gives access to page of triggers
+type triggerList []storage.Trigger
+
+func (l triggerList) Len() int                    { return len(l) }
+func (l triggerList) Item(i int) storage.PageItem { return l[i] }
@@ -0,0 +1,181 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/pagination_test.html
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// clustersPage is one page of clusters returned by GetClusters handler
+type clustersPage struct {
+	Clusters []storage.Cluster `json:"clusters"`
+	Total    int               `json:"total"`
+	Next     string            `json:"next"`
+}
+
+// readClustersPage calls GetClusters handler with the specified URL and
+// decodes the returned page
+func readClustersPage(t *testing.T, handler func(http.ResponseWriter, *http.Request), url string) clustersPage {
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("GET", url, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var page clustersPage
+	if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+// TestClustersPagination checks that all clusters are returned page by page
+// in the requested order
+func TestClustersPagination(t *testing.T) {
+	serv := MockedIOCServerWithMemoryStorage(t)
+	defer serv.Storage.Close()
+
+	for _, name := range []string{"c", "a", "e", "b", "d"} {
+		if err := serv.Storage.RegisterNewCluster(context.Background(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := []storage.ClusterName{}
+	url := "/api/v1/client/cluster?limit=2&sort=name&order=desc"
+	pages := 0
+	for url != "" {
+		page := readClustersPage(t, serv.GetClusters, url)
+		assert.Equal(t, 5, page.Total)
+		assert.True(t, len(page.Clusters) <= 2)
+		for _, cluster := range page.Clusters {
+			names = append(names, cluster.Name)
+		}
+		url = page.Next
+		pages++
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []storage.ClusterName{"e", "d", "c", "b", "a"}, names)
+}
+
+// TestPaginationStableOrdering checks that items added before the cursor do
+// not change content of the following pages
+func TestPaginationStableOrdering(t *testing.T) {
+	serv := MockedIOCServerWithMemoryStorage(t)
+	defer serv.Storage.Close()
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := serv.Storage.RegisterNewCluster(context.Background(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page := readClustersPage(t, serv.GetClusters, "/api/v1/client/cluster?limit=2&sort=name")
+	assert.Len(t, page.Clusters, 2)
+
+	// new cluster would be listed on the first page
+	if err := serv.Storage.RegisterNewCluster(context.Background(), "0"); err != nil {
+		t.Fatal(err)
+	}
+
+	page = readClustersPage(t, serv.GetClusters, page.Next)
+	assert.Equal(t, 4, page.Total)
+	assert.Len(t, page.Clusters, 1)
+	assert.Equal(t, storage.ClusterName("c"), page.Clusters[0].Name)
+	assert.Empty(t, page.Next)
+}
+
+// TestPaginationDefaultLimit checks that items are returned page by page
+// even when no limit is specified
+func TestPaginationDefaultLimit(t *testing.T) {
+	const defaultPageLimit = 100
+
+	serv := MockedIOCServerWithMemoryStorage(t)
+	defer serv.Storage.Close()
+
+	for i := 0; i < defaultPageLimit+1; i++ {
+		if err := serv.Storage.RegisterNewCluster(context.Background(), fmt.Sprintf("cluster-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page := readClustersPage(t, serv.GetClusters, "/api/v1/client/cluster")
+	assert.Equal(t, defaultPageLimit+1, page.Total)
+	assert.Len(t, page.Clusters, defaultPageLimit)
+	assert.NotEmpty(t, page.Next)
+
+	page = readClustersPage(t, serv.GetClusters, page.Next)
+	assert.Len(t, page.Clusters, 1)
+	assert.Empty(t, page.Next)
+}
+
+// TestParameterErrorsPagination tests wrong pagination parameters
+func TestParameterErrorsPagination(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	paramErrorTT := []testCase{
+		{"GetClusters non-int limit", serv.GetClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"limit": "all"}, ""},
+		{"GetClusters negative limit", serv.GetClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"limit": "-1"}, ""},
+		{"GetClusters zero limit", serv.GetClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"limit": "0"}, ""},
+		{"GetClusters limit over maximum", serv.GetClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"limit": "1001"}, ""},
+		{"GetClusters unknown sort column", serv.GetClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"sort": "deleted_by"}, ""},
+		{"GetClusters wrong order", serv.GetClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"order": "random"}, ""},
+		{"GetClusters wrong cursor", serv.GetClusters, http.StatusBadRequest, "GET", true, requestData{}, requestData{"cursor": "not a cursor"}, ""},
+		{"ListConfigurationProfiles unknown sort column", serv.ListConfigurationProfiles, http.StatusBadRequest, "GET", true, requestData{}, requestData{"sort": "name"}, ""},
+		{"ListConfigurationProfileRevisions unknown sort column", serv.ListConfigurationProfileRevisions, http.StatusBadRequest, "GET", true, requestData{"id": "1"}, requestData{"sort": "name"}, ""},
+		{"GetAllConfigurations unknown sort column", serv.GetAllConfigurations, http.StatusBadRequest, "GET", true, requestData{}, requestData{"sort": "name"}, ""},
+		{"GetClusterConfiguration unknown sort column", serv.GetClusterConfiguration, http.StatusBadRequest, "GET", true, requestData{"cluster": "1"}, requestData{"sort": "name"}, ""},
+		{"GetAllTriggers unknown sort column", serv.GetAllTriggers, http.StatusBadRequest, "GET", true, requestData{}, requestData{"sort": "name"}, ""},
+		{"GetClusterTriggers unknown sort column", serv.GetClusterTriggers, http.StatusBadRequest, "GET", true, requestData{"cluster": "1"}, requestData{"sort": "name"}, ""},
+	}
+
+	for _, tt := range paramErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestNonErrorsPagination tests pagination of all list endpoints with mock data
+func TestNonErrorsPagination(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	nonErrorTT := []testCase{
+		{"GetClusters page", serv.GetClusters, http.StatusOK, "GET", true, requestData{}, requestData{"limit": "1", "sort": "name"}, ""},
+		{"ListConfigurationProfiles page", serv.ListConfigurationProfiles, http.StatusOK, "GET", true, requestData{}, requestData{"limit": "1", "sort": "changed_at", "order": "desc"}, ""},
+		{"ListConfigurationProfileRevisions page", serv.ListConfigurationProfileRevisions, http.StatusOK, "GET", true, requestData{"id": "1"}, requestData{"limit": "1"}, ""},
+		{"GetAllConfigurations page", serv.GetAllConfigurations, http.StatusOK, "GET", true, requestData{}, requestData{"limit": "1", "sort": "cluster"}, ""},
+		{"GetClusterConfiguration page", serv.GetClusterConfiguration, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000001"}, requestData{"limit": "1", "sort": "active"}, ""},
+		{"GetAllTriggers page", serv.GetAllTriggers, http.StatusOK, "GET", true, requestData{}, requestData{"limit": "1", "sort": "triggered_at"}, ""},
+		{"GetClusterTriggers page", serv.GetClusterTriggers, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"limit": "1", "sort": "type"}, ""},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
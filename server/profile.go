@@ -23,24 +23,40 @@ package server
 // https://redhatinsights.github.io/insights-operator-controller/packages/server/profile.html
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-controller/utils"
 	"github.com/RedHatInsights/insights-operator-utils/responses"
 )
 
 // ListConfigurationProfiles method reads list of configuration profiles.
+// Deleted profiles are returned only when include_deleted=true is specified.
+// The list is paginated and sorted according to the page parameters.
 func (s *Server) ListConfigurationProfiles(writer http.ResponseWriter, request *http.Request) {
-	// try to read list of configuration profiles from storage
-	profiles, err := s.Storage.ListConfigurationProfiles()
+	includeDeleted, err := retrieveIncludeDeletedParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Value of include_deleted parameter needs to be boolean")
+		return
+	}
+
+	page, err := retrievePageParameters(request, storage.ProfileSortColumns)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to read one page of configuration profiles from storage
+	profiles, total, err := s.Storage.ListConfigurationProfilesPage(request.Context(), includeDeleted, page)
 
 	// check if the storage operation was successful
 	if err == nil {
-		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("profiles", profiles))
+		TryToSendOKServerResponse(writer, buildPageResponse(request, "profiles", profileList(profiles), total, page))
 	} else {
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 	}
 }
 
@@ -54,14 +70,13 @@ func (s *Server) GetConfigurationProfile(writer http.ResponseWriter, request *ht
 	}
 
 	// try to read configuration for profile specified by its ID
-	profile, err := s.Storage.GetConfigurationProfile(int(id))
+	profile, err := s.Storage.GetConfigurationProfile(request.Context(), int(id))
 
 	// check if the storage operation was successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
+		setETagHeader(writer, profile.Version)
 		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("profile", profile))
 	}
 }
@@ -92,23 +107,30 @@ func (s *Server) NewConfigurationProfile(writer http.ResponseWriter, request *ht
 		return
 	}
 
+	// configuration needs to conform to the schema of operator configuration
+	if err := s.validateConfiguration(request.Context(), string(configuration)); err != nil {
+		TryToSendStorageError(writer, err)
+		return
+	}
+
 	// try to record the action NewConfigurationProfile into Splunk
 	err = s.Splunk.LogAction("NewConfigurationProfile", username[0], string(configuration))
 	// and check whether the Splunk operation was successful
 	checkSplunkOperation(err)
 
 	// try to store configuration profile into storage
-	profiles, err := s.Storage.StoreConfigurationProfile(username[0], description[0], string(configuration))
+	profiles, err := s.Storage.StoreConfigurationProfile(request.Context(), username[0], description[0], string(configuration))
 
 	// check if the storage operation was successful
 	if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendCreatedServerResponse(writer, responses.BuildOkResponseWithData("profiles", profiles))
 	}
 }
 
-// DeleteConfigurationProfile method deletes configuration profile
+// DeleteConfigurationProfile method marks configuration profile as deleted.
+// ETag of the profile needs to be specified in If-Match header.
 func (s *Server) DeleteConfigurationProfile(writer http.ResponseWriter, request *http.Request) {
 	// profile ID needs to be specified in request
 	id, err := retrieveIDRequestParameter(request)
@@ -117,25 +139,62 @@ func (s *Server) DeleteConfigurationProfile(writer http.ResponseWriter, request
 		return
 	}
 
+	// expected version of profile needs to be specified in request
+	version, err := retrieveIfMatchVersion(request)
+	if err != nil {
+		sendIfMatchError(writer, err)
+		return
+	}
+
 	// try to record the action DeleteConfigurationProfile into Splunk
 	err = s.Splunk.LogAction("DeleteConfigurationProfile", "tester", strconv.Itoa(int(id)))
 	// and check whether the Splunk operation was successful
 	checkSplunkOperation(err)
 
 	// try to delete configuration profile from storage
-	profiles, err := s.Storage.DeleteConfigurationProfile(int(id))
+	profiles, err := s.Storage.DeleteConfigurationProfile(request.Context(), int(id), version, requestUsername(request))
 
 	// check if the storage operation was successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("profiles", profiles))
 	}
 }
 
-// ChangeConfigurationProfile method changes configuration profile
+// RestoreConfigurationProfile method restores configuration profile that has
+// been deleted.
+func (s *Server) RestoreConfigurationProfile(writer http.ResponseWriter, request *http.Request) {
+	// profile ID needs to be specified in request
+	id, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading profile ID from request\n")
+		return
+	}
+
+	// try to record the action RestoreConfigurationProfile into Splunk
+	err = s.Splunk.LogAction("RestoreConfigurationProfile", "tester", strconv.Itoa(int(id)))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to restore configuration profile in storage
+	err = s.Storage.RestoreConfigurationProfile(request.Context(), int(id))
+
+	// check if the storage operation was successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		profiles, err := s.Storage.ListConfigurationProfiles(request.Context(), false)
+		if err != nil {
+			TryToSendStorageError(writer, err)
+		} else {
+			TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("profiles", profiles))
+		}
+	}
+}
+
+// ChangeConfigurationProfile method changes configuration profile. ETag of
+// the profile needs to be specified in If-Match header.
 func (s *Server) ChangeConfigurationProfile(writer http.ResponseWriter, request *http.Request) {
 	// profile ID needs to be specified in request
 	id, err := retrieveIDRequestParameter(request)
@@ -161,26 +220,151 @@ func (s *Server) ChangeConfigurationProfile(writer http.ResponseWriter, request
 		return
 	}
 
+	// expected version of profile needs to be specified in request
+	version, err := retrieveIfMatchVersion(request)
+	if err != nil {
+		sendIfMatchError(writer, err)
+		return
+	}
+
 	configuration, err := io.ReadAll(request.Body)
 	if err != nil || len(configuration) == 0 {
 		TryToSendBadRequestServerResponse(writer, "Configuration needs to be provided in the request body")
 		return
 	}
 
+	// configuration needs to conform to the schema of operator configuration
+	if err := s.validateConfiguration(request.Context(), string(configuration)); err != nil {
+		TryToSendStorageError(writer, err)
+		return
+	}
+
 	// try to record the action ChangeConfigurationProfile into Splunk
 	err = s.Splunk.LogAction("ChangeConfigurationProfile", username[0], string(configuration))
 	// and check whether the Splunk operation was successful
 	checkSplunkOperation(err)
 
 	// try to change configuration profile configuration in storage
-	profiles, err := s.Storage.ChangeConfigurationProfile(int(id), username[0], description[0], string(configuration))
+	profiles, err := s.Storage.ChangeConfigurationProfile(request.Context(), int(id), version, username[0], description[0], string(configuration))
 
 	// check if the storage operation was successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("profiles", profiles))
 	}
 }
+
+// ListConfigurationProfileRevisions method reads all revisions of profile specified by its ID
+func (s *Server) ListConfigurationProfileRevisions(writer http.ResponseWriter, request *http.Request) {
+	// profile ID needs to be specified in request
+	id, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading profile ID from request\n")
+		return
+	}
+
+	page, err := retrievePageParameters(request, storage.RevisionSortColumns)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to read one page of revisions of profile specified by its ID
+	revisions, total, err := s.Storage.ListConfigurationProfileRevisionsPage(request.Context(), int(id), page)
+
+	// check if the storage operation was successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, buildPageResponse(request, "revisions", revisionList(revisions), total, page))
+	}
+}
+
+// GetConfigurationProfileRevision method reads one revision of profile specified by its ID
+func (s *Server) GetConfigurationProfileRevision(writer http.ResponseWriter, request *http.Request) {
+	// profile ID needs to be specified in request
+	id, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading profile ID from request\n")
+		return
+	}
+
+	// revision number needs to be specified in request
+	revision, err := retrievePositiveIntRequestParameter(request, "revision")
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading revision from request\n")
+		return
+	}
+
+	// try to read selected revision of profile
+	profileRevision, err := s.Storage.GetConfigurationProfileRevision(request.Context(), int(id), int(revision))
+
+	// check if the storage operation was successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("revision", profileRevision))
+	}
+}
+
+// retrieveRevisionQueryParameter reads revision number from query parameter
+func retrieveRevisionQueryParameter(request *http.Request, paramName string) (int, error) {
+	value, found := request.URL.Query()[paramName]
+	if !found {
+		return 0, fmt.Errorf("'%v' param not found", paramName)
+	}
+
+	revision, err := strconv.Atoi(value[0])
+	if err != nil {
+		return 0, err
+	}
+	if revision <= 0 {
+		return 0, fmt.Errorf("'%v' param needs to be positive", paramName)
+	}
+	return revision, nil
+}
+
+// DiffConfigurationProfileRevisions method returns structural JSON diff
+// between two revisions of profile specified by its ID. Revisions are
+// specified by query parameters 'from' and 'to'.
+func (s *Server) DiffConfigurationProfileRevisions(writer http.ResponseWriter, request *http.Request) {
+	// profile ID needs to be specified in request
+	id, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading profile ID from request\n")
+		return
+	}
+
+	// both revisions need to be specified in request
+	fromRevision, err := retrieveRevisionQueryParameter(request, "from")
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading 'from' revision from request: "+err.Error())
+		return
+	}
+
+	toRevision, err := retrieveRevisionQueryParameter(request, "to")
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Error reading 'to' revision from request: "+err.Error())
+		return
+	}
+
+	revisions := make([]storage.ConfigurationProfileRevision, 2)
+	for i, revision := range []int{fromRevision, toRevision} {
+		revisions[i], err = s.Storage.GetConfigurationProfileRevision(request.Context(), int(id), revision)
+
+		// check if the storage operation was successful
+		if err != nil {
+			TryToSendStorageError(writer, err)
+			return
+		}
+	}
+
+	changes, err := utils.DiffJSON(revisions[0].Configuration, revisions[1].Configuration)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Revisions can not be compared, configuration is not valid JSON: "+err.Error())
+		return
+	}
+
+	TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("changes", changes))
+}
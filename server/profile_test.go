@@ -20,8 +20,13 @@ package server_test
 // https://redhatinsights.github.io/insights-operator-controller/packages/server/profile_test.html
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
 )
 
 // TestNonErrorsConfigurationWithoutData tests OK behaviour with empty DB (schema only)
@@ -32,9 +37,12 @@ func TestNonErrorsProfileWithoutData(t *testing.T) {
 	nonErrorTT := []testCase{
 		{"GetConfigurationProfile Not Found", serv.GetConfigurationProfile, http.StatusNotFound, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"ListConfigurationProfiles OK", serv.ListConfigurationProfiles, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
-		{"DeleteConfigurationProfile Not Found", serv.DeleteConfigurationProfile, http.StatusNotFound, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
-		{"ChangeConfigurationProfile Not Found", serv.ChangeConfigurationProfile, http.StatusNotFound, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester", "description": "test"}, "Test config"},
-		{"NewConfigurationProfile OK", serv.NewConfigurationProfile, http.StatusCreated, "POST", true, requestData{}, requestData{"username": "tester", "description": "test"}, "Test config"},
+		{"DeleteConfigurationProfile Not Found", withHeader(serv.DeleteConfigurationProfile, "If-Match", "*"), http.StatusNotFound, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
+		{"ChangeConfigurationProfile Not Found", withHeader(serv.ChangeConfigurationProfile, "If-Match", "*"), http.StatusNotFound, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"NewConfigurationProfile OK", serv.NewConfigurationProfile, http.StatusCreated, "POST", true, requestData{}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"ListConfigurationProfileRevisions Not Found", serv.ListConfigurationProfileRevisions, http.StatusNotFound, "GET", true, requestData{"id": "42"}, requestData{}, ""},
+		{"GetConfigurationProfileRevision Not Found", serv.GetConfigurationProfileRevision, http.StatusNotFound, "GET", true, requestData{"id": "42", "revision": "1"}, requestData{}, ""},
+		{"DiffConfigurationProfileRevisions Not Found", serv.DiffConfigurationProfileRevisions, http.StatusNotFound, "GET", true, requestData{"id": "42"}, requestData{"from": "1", "to": "2"}, ""},
 	}
 
 	for _, tt := range nonErrorTT {
@@ -47,12 +55,41 @@ func TestNonErrorsProfileWithData(t *testing.T) {
 	serv := MockedIOCServer(t, true)
 	defer serv.Storage.Close()
 
+	// profile with configuration that is not JSON, it could have been stored
+	// before configurations have been validated
+	_, err := serv.Storage.StoreConfigurationProfile(context.Background(), "tester", "test", "Test config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	nonErrorTT := []testCase{
 		{"GetConfigurationProfile OK", serv.GetConfigurationProfile, http.StatusOK, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"ListConfigurationProfiles OK", serv.ListConfigurationProfiles, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
-		{"DeleteConfigurationProfile OK", serv.DeleteConfigurationProfile, http.StatusOK, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
-		{"NewConfigurationProfile OK", serv.NewConfigurationProfile, http.StatusCreated, "POST", true, requestData{}, requestData{"username": "tester", "description": "test"}, "Test config"},
-		{"ChangeConfigurationProfile OK", serv.ChangeConfigurationProfile, http.StatusOK, "PUT", true, requestData{"id": "4"}, requestData{"username": "tester", "description": "test"}, "Test config"},
+		{"DeleteConfigurationProfile OK", withHeader(serv.DeleteConfigurationProfile, "If-Match", "*"), http.StatusOK, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
+		{"NewConfigurationProfile OK", serv.NewConfigurationProfile, http.StatusCreated, "POST", true, requestData{}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"NewConfigurationProfile not JSON", serv.NewConfigurationProfile, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "description": "test"}, `{"no_op":}`},
+		{"NewConfigurationProfile not conforming to schema", serv.NewConfigurationProfile, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "description": "test"}, `["no_op"]`},
+		{"ChangeConfigurationProfile OK", withHeader(serv.ChangeConfigurationProfile, "If-Match", `"1"`), http.StatusOK, "PUT", true, requestData{"id": "4"}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"ChangeConfigurationProfile with JSON OK", withHeader(serv.ChangeConfigurationProfile, "If-Match", "*"), http.StatusOK, "PUT", true, requestData{"id": "3"}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Z", "watch":["d"]}`},
+		{"ChangeConfigurationProfile not JSON", withHeader(serv.ChangeConfigurationProfile, "If-Match", "*"), http.StatusBadRequest, "PUT", true, requestData{"id": "3"}, requestData{"username": "tester", "description": "test"}, "Test config"},
+		{"ChangeConfigurationProfile not conforming to schema", withHeader(serv.ChangeConfigurationProfile, "If-Match", "*"), http.StatusBadRequest, "PUT", true, requestData{"id": "3"}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Z", "watch":[1]}`},
+		{"ChangeConfigurationProfile changed in the meantime", withHeader(serv.ChangeConfigurationProfile, "If-Match", `"1"`), http.StatusPreconditionFailed, "PUT", true, requestData{"id": "3"}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"ChangeConfigurationProfile weak ETag", withHeader(serv.ChangeConfigurationProfile, "If-Match", `W/"2"`), http.StatusPreconditionFailed, "PUT", true, requestData{"id": "3"}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"ChangeConfigurationProfile no If-Match", serv.ChangeConfigurationProfile, http.StatusPreconditionRequired, "PUT", true, requestData{"id": "3"}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"DeleteConfigurationProfile changed in the meantime", withHeader(serv.DeleteConfigurationProfile, "If-Match", `"1"`), http.StatusPreconditionFailed, "DELETE", true, requestData{"id": "3"}, requestData{}, ""},
+		{"DeleteConfigurationProfile no If-Match", serv.DeleteConfigurationProfile, http.StatusPreconditionRequired, "DELETE", true, requestData{"id": "3"}, requestData{}, ""},
+		{"DeleteConfigurationProfile current version OK", withHeader(serv.DeleteConfigurationProfile, "If-Match", `"1"`), http.StatusOK, "DELETE", true, requestData{"id": "2"}, requestData{}, ""},
+		{"ListConfigurationProfiles including deleted OK", serv.ListConfigurationProfiles, http.StatusOK, "GET", true, requestData{}, requestData{"include_deleted": "true"}, ""},
+		{"GetConfigurationProfile deleted", serv.GetConfigurationProfile, http.StatusNotFound, "GET", true, requestData{"id": "2"}, requestData{}, ""},
+		{"RestoreConfigurationProfile OK", serv.RestoreConfigurationProfile, http.StatusOK, "PUT", true, requestData{"id": "2"}, requestData{}, ""},
+		{"RestoreConfigurationProfile not deleted", serv.RestoreConfigurationProfile, http.StatusNotFound, "PUT", true, requestData{"id": "2"}, requestData{}, ""},
+		{"ListConfigurationProfileRevisions OK", serv.ListConfigurationProfileRevisions, http.StatusOK, "GET", true, requestData{"id": "4"}, requestData{}, ""},
+		{"ListConfigurationProfileRevisions migrated profile OK", serv.ListConfigurationProfileRevisions, http.StatusOK, "GET", true, requestData{"id": "0"}, requestData{}, ""},
+		{"GetConfigurationProfileRevision OK", serv.GetConfigurationProfileRevision, http.StatusOK, "GET", true, requestData{"id": "4", "revision": "2"}, requestData{}, ""},
+		{"GetConfigurationProfileRevision Not Found", serv.GetConfigurationProfileRevision, http.StatusNotFound, "GET", true, requestData{"id": "4", "revision": "3"}, requestData{}, ""},
+		{"DiffConfigurationProfileRevisions OK", serv.DiffConfigurationProfileRevisions, http.StatusOK, "GET", true, requestData{"id": "3"}, requestData{"from": "1", "to": "2"}, ""},
+		{"DiffConfigurationProfileRevisions Not Found", serv.DiffConfigurationProfileRevisions, http.StatusNotFound, "GET", true, requestData{"id": "3"}, requestData{"from": "1", "to": "3"}, ""},
+		{"DiffConfigurationProfileRevisions not JSON", serv.DiffConfigurationProfileRevisions, http.StatusBadRequest, "GET", true, requestData{"id": "4"}, requestData{"from": "1", "to": "2"}, ""},
 	}
 
 	for _, tt := range nonErrorTT {
@@ -67,9 +104,12 @@ func TestDatabaseErrorProfile(t *testing.T) {
 	dbErrorTT := []testCase{
 		{"GetConfigurationProfile Not Found", serv.GetConfigurationProfile, http.StatusInternalServerError, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"ListConfigurationProfiles OK", serv.ListConfigurationProfiles, http.StatusInternalServerError, "GET", true, requestData{}, requestData{}, ""},
-		{"DeleteConfigurationProfile Not Found", serv.DeleteConfigurationProfile, http.StatusInternalServerError, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
-		{"ChangeConfigurationProfile Not Found", serv.ChangeConfigurationProfile, http.StatusInternalServerError, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester", "description": "test"}, "Test config"},
-		{"NewConfigurationProfile OK", serv.NewConfigurationProfile, http.StatusInternalServerError, "POST", true, requestData{}, requestData{"username": "tester", "description": "test"}, "Test config"},
+		{"DeleteConfigurationProfile Not Found", withHeader(serv.DeleteConfigurationProfile, "If-Match", "*"), http.StatusInternalServerError, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
+		{"RestoreConfigurationProfile", serv.RestoreConfigurationProfile, http.StatusInternalServerError, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"ChangeConfigurationProfile Not Found", withHeader(serv.ChangeConfigurationProfile, "If-Match", "*"), http.StatusInternalServerError, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"NewConfigurationProfile OK", serv.NewConfigurationProfile, http.StatusInternalServerError, "POST", true, requestData{}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"ListConfigurationProfileRevisions", serv.ListConfigurationProfileRevisions, http.StatusInternalServerError, "GET", true, requestData{"id": "1"}, requestData{}, ""},
+		{"GetConfigurationProfileRevision", serv.GetConfigurationProfileRevision, http.StatusInternalServerError, "GET", true, requestData{"id": "1", "revision": "1"}, requestData{}, ""},
 	}
 
 	serv.Storage.Close()
@@ -89,17 +129,52 @@ func TestParameterErrorsProfile(t *testing.T) {
 		{"GetConfigurationProfile non-int id", serv.GetConfigurationProfile, http.StatusBadRequest, "GET", true, requestData{"id": "non-int"}, requestData{}, ""},
 		{"DeleteConfigurationProfile no id", serv.DeleteConfigurationProfile, http.StatusBadRequest, "DELETE", true, requestData{}, requestData{}, ""},
 		{"DeleteConfigurationProfile non-int id", serv.DeleteConfigurationProfile, http.StatusBadRequest, "DELETE", true, requestData{"id": "non-int"}, requestData{}, ""},
-		{"ChangeConfigurationProfile no id", serv.ChangeConfigurationProfile, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester", "description": "test"}, "Test config"},
-		{"ChangeConfigurationProfile non-int id", serv.ChangeConfigurationProfile, http.StatusBadRequest, "PUT", true, requestData{"id": "non-int"}, requestData{"username": "tester", "description": "test"}, "Test config"},
-		{"ChangeConfigurationProfile no description", serv.ChangeConfigurationProfile, http.StatusBadRequest, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester"}, "Test config"},
-		{"ChangeConfigurationProfile no username", serv.ChangeConfigurationProfile, http.StatusBadRequest, "PUT", true, requestData{"id": "1"}, requestData{"description": "test"}, "Test config"},
-		{"ChangeConfigurationProfile no config in body", serv.ChangeConfigurationProfile, http.StatusBadRequest, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester", "description": "test"}, ""},
-		{"NewConfigurationProfile no description", serv.NewConfigurationProfile, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester"}, "Test config"},
-		{"NewConfigurationProfile no username", serv.NewConfigurationProfile, http.StatusBadRequest, "POST", true, requestData{}, requestData{"description": "test"}, "Test config"},
+		{"RestoreConfigurationProfile non-int id", serv.RestoreConfigurationProfile, http.StatusBadRequest, "PUT", true, requestData{"id": "non-int"}, requestData{}, ""},
+		{"ListConfigurationProfiles non-bool include_deleted", serv.ListConfigurationProfiles, http.StatusBadRequest, "GET", true, requestData{}, requestData{"include_deleted": "maybe"}, ""},
+		{"ChangeConfigurationProfile no id", serv.ChangeConfigurationProfile, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"ChangeConfigurationProfile non-int id", serv.ChangeConfigurationProfile, http.StatusBadRequest, "PUT", true, requestData{"id": "non-int"}, requestData{"username": "tester", "description": "test"}, `{"no_op":"Test config"}`},
+		{"ChangeConfigurationProfile no description", serv.ChangeConfigurationProfile, http.StatusBadRequest, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester"}, `{"no_op":"Test config"}`},
+		{"ChangeConfigurationProfile no username", serv.ChangeConfigurationProfile, http.StatusBadRequest, "PUT", true, requestData{"id": "1"}, requestData{"description": "test"}, `{"no_op":"Test config"}`},
+		{"ChangeConfigurationProfile no config in body", withHeader(serv.ChangeConfigurationProfile, "If-Match", "*"), http.StatusBadRequest, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester", "description": "test"}, ""},
+		{"NewConfigurationProfile no description", serv.NewConfigurationProfile, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester"}, `{"no_op":"Test config"}`},
+		{"NewConfigurationProfile no username", serv.NewConfigurationProfile, http.StatusBadRequest, "POST", true, requestData{}, requestData{"description": "test"}, `{"no_op":"Test config"}`},
 		{"NewConfigurationProfile no config in body", serv.NewConfigurationProfile, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "description": "test"}, ""},
+		{"ListConfigurationProfileRevisions non-int id", serv.ListConfigurationProfileRevisions, http.StatusBadRequest, "GET", true, requestData{"id": "non-int"}, requestData{}, ""},
+		{"GetConfigurationProfileRevision non-int revision", serv.GetConfigurationProfileRevision, http.StatusBadRequest, "GET", true, requestData{"id": "1", "revision": "non-int"}, requestData{}, ""},
+		{"DiffConfigurationProfileRevisions no from", serv.DiffConfigurationProfileRevisions, http.StatusBadRequest, "GET", true, requestData{"id": "1"}, requestData{"to": "1"}, ""},
+		{"DiffConfigurationProfileRevisions zero to", serv.DiffConfigurationProfileRevisions, http.StatusBadRequest, "GET", true, requestData{"id": "1"}, requestData{"from": "1", "to": "0"}, ""},
 	}
 
 	for _, tt := range paramErrorTT {
 		testRequest(t, &tt)
 	}
 }
+
+// TestConfigurationProfileETag checks that ETag returned with profile can be used to change it
+func TestConfigurationProfileETag(t *testing.T) {
+	serv := MockedIOCServerWithMemoryStorage(t)
+	defer serv.Storage.Close()
+
+	_, err := serv.Storage.StoreConfigurationProfile(context.Background(), "tester", "description", "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := mux.SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"id": "1"})
+	recorder := httptest.NewRecorder()
+	serv.GetConfigurationProfile(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	etag := recorder.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	change := &testCase{"ChangeConfigurationProfile with ETag", withHeader(serv.ChangeConfigurationProfile, "If-Match", etag), http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester", "description": "test"}, "{}"}
+	testRequest(t, change)
+
+	// the same ETag can not be used again
+	change.expectedHeader = http.StatusPreconditionFailed
+	testRequest(t, change)
+
+	recorder = httptest.NewRecorder()
+	serv.GetConfigurationProfile(recorder, request)
+	assert.Equal(t, `"2"`, recorder.Header().Get("ETag"))
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/purge.html
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+)
+
+// PurgeDeletedItems method permanently removes clusters, configuration
+// profiles, and cluster configurations that have been deleted before the
+// configured retention period.
+func (s *Server) PurgeDeletedItems(writer http.ResponseWriter, request *http.Request) {
+	if s.DeletedRetention <= 0 {
+		TryToSendBadRequestServerResponse(writer, "Retention period for deleted items is not configured")
+		return
+	}
+
+	// try to record the action PurgeDeletedItems into Splunk
+	err := s.Splunk.LogAction("PurgeDeletedItems", "tester", s.DeletedRetention.String())
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	purged, err := s.Storage.PurgeDeleted(request.Context(), time.Now().Add(-s.DeletedRetention))
+	if err != nil {
+		log.Println("Unable to purge deleted items", err)
+		TryToSendStorageError(writer, err)
+		return
+	}
+
+	resp := responses.BuildOkResponse()
+	resp["purged"] = purged
+	TryToSendOKServerResponse(writer, resp)
+}
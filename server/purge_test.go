@@ -0,0 +1,69 @@
+/*
+Copyright © 2019, 2020, 2021, 2022, 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/purge_test.html
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestPurgeDeletedItems tests purge of deleted items
+func TestPurgeDeletedItems(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	disabledTT := []testCase{
+		{"PurgeDeletedItems retention not configured", serv.PurgeDeletedItems, http.StatusBadRequest, "POST", true, requestData{}, requestData{}, ""},
+	}
+
+	for _, tt := range disabledTT {
+		testRequest(t, &tt)
+	}
+
+	serv.DeletedRetention = time.Hour
+
+	nonErrorTT := []testCase{
+		{"DeleteCluster OK", serv.DeleteCluster, http.StatusOK, "DELETE", false, requestData{"id": "1"}, requestData{}, ""},
+		{"PurgeDeletedItems OK", serv.PurgeDeletedItems, http.StatusOK, "POST", true, requestData{}, requestData{}, ""},
+		// cluster has been deleted recently, so it is kept
+		{"RestoreCluster OK", serv.RestoreCluster, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestDatabaseErrorPurge tests unexpected behaviour by closing DB connection (consistency check)
+func TestDatabaseErrorPurge(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	serv.DeletedRetention = time.Hour
+
+	dbErrorTT := []testCase{
+		{"PurgeDeletedItems DB error", serv.PurgeDeletedItems, http.StatusInternalServerError, "POST", true, requestData{}, requestData{}, ""},
+	}
+
+	serv.Storage.Close()
+
+	for _, tt := range dbErrorTT {
+		testRequest(t, &tt)
+	}
+}
@@ -27,6 +27,7 @@ package server
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"github.com/RedHatInsights/insights-operator-controller/logging"
 	"github.com/RedHatInsights/insights-operator-controller/storage"
@@ -53,7 +54,17 @@ type Server struct {
 	TLSCert  string
 	TLSKey   string
 
-	ClusterQuery *storage.ClusterQuery
+	// DeletedRetention is the period for which deleted items are kept
+	// before they can be purged, zero means that purge is disabled
+	DeletedRetention time.Duration
+
+	// StaleClusterWindow is the period after which clusters that have not
+	// checked in are considered stale
+	StaleClusterWindow time.Duration
+
+	ClusterQuery              *storage.ClusterQuery
+	TriggerQuery              *storage.TriggerQuery
+	ClusterConfigurationQuery *storage.ClusterConfigurationQuery
 }
 
 // APIPrefix is appended before all REST API endpoint addresses
@@ -155,6 +166,16 @@ func retrieveIDRequestParameter(request *http.Request) (int64, error) {
 	return retrievePositiveIntRequestParameter(request, "id")
 }
 
+// retrieveIncludeDeletedParameter reads optional query parameter
+// "include_deleted" that selects whether deleted items are to be listed
+func retrieveIncludeDeletedParameter(request *http.Request) (bool, error) {
+	value := request.URL.Query().Get("include_deleted")
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
 // MainEndpoint method is handler for the main endpoint of REST API server
 func (s *Server) MainEndpoint(writer http.ResponseWriter, request *http.Request) {
 	start := time.Now()
@@ -189,7 +210,8 @@ func (s *Server) AddDefaultHeaders(nextHandler http.Handler) http.Handler {
 					w.Header().Set("Access-Control-Allow-Origin", origin)
 				}
 				w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, If-Match")
+				w.Header().Set("Access-Control-Expose-Headers", "ETag")
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 			}
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -202,7 +224,13 @@ func (s *Server) Initialize() {
 	log.Println("Environment: ", Environment)
 	log.Println("API Prefix: ", APIPrefix)
 	log.Println("Initializing HTTP server at", s.Address)
-	s.ClusterQuery = storage.NewClusterQuery(s.Storage)
+	// cluster, trigger, and configuration search is based on SQL query
+	// builder, so it is available for SQL-based storages only
+	if storager, ok := s.Storage.(storage.Storager); ok {
+		s.ClusterQuery = storage.NewClusterQuery(storager)
+		s.TriggerQuery = storage.NewTriggerQuery(storager)
+		s.ClusterConfigurationQuery = storage.NewClusterConfigurationQuery(storager)
+	}
 	router := mux.NewRouter().StrictSlash(true)
 	router.Use(s.LogRequest)
 	if Environment == "production" {
@@ -227,8 +255,20 @@ func (s *Server) Initialize() {
 	clientRouter.HandleFunc("/cluster/{name}", s.NewCluster).Methods("POST")
 	clientRouter.HandleFunc("/cluster/{id:[0-9]+}", s.GetClusterByID).Methods("GET")
 	clientRouter.HandleFunc("/cluster/{id:[0-9]+}", s.DeleteCluster).Methods("DELETE")
+	clientRouter.HandleFunc("/cluster/{id:[0-9]+}/restore", s.RestoreCluster).Methods("PUT")
 	clientRouter.HandleFunc("/cluster/search", s.SearchCluster).Methods("GET")
 
+	// clusters that have not checked in
+	// (handler is implemented in the file heartbeat.go)
+	clientRouter.HandleFunc("/cluster/stale", s.GetStaleClusters).Methods("GET")
+
+	// cluster labels and label selectors
+	// (handlers are implemented in the file cluster_label.go)
+	clientRouter.HandleFunc("/cluster/select", s.SelectClusters).Methods("GET")
+	clientRouter.HandleFunc("/cluster/{cluster}/label", s.GetClusterLabels).Methods("GET")
+	clientRouter.HandleFunc("/cluster/{cluster}/label/{key:.+}", s.SetClusterLabel).Methods("PUT")
+	clientRouter.HandleFunc("/cluster/{cluster}/label/{key:.+}", s.DeleteClusterLabel).Methods("DELETE")
+
 	// configuration profiles
 	// (handlers are implemented in the file profile.go)
 	clientRouter.HandleFunc("/profile", s.ListConfigurationProfiles).Methods("GET")
@@ -236,38 +276,107 @@ func (s *Server) Initialize() {
 	clientRouter.HandleFunc("/profile/{id}", s.ChangeConfigurationProfile).Methods("PUT")
 	clientRouter.HandleFunc("/profile", s.NewConfigurationProfile).Methods("POST")
 	clientRouter.HandleFunc("/profile/{id}", s.DeleteConfigurationProfile).Methods("DELETE")
+	clientRouter.HandleFunc("/profile/{id}/restore", s.RestoreConfigurationProfile).Methods("PUT")
+	clientRouter.HandleFunc("/profile/{id}/revision", s.ListConfigurationProfileRevisions).Methods("GET")
+	clientRouter.HandleFunc("/profile/{id}/revision/{revision}", s.GetConfigurationProfileRevision).Methods("GET")
+	clientRouter.HandleFunc("/profile/{id}/diff", s.DiffConfigurationProfileRevisions).Methods("GET")
 
 	// configurations
 	// (handlers are implemented in the file configuration.go)
 	clientRouter.HandleFunc("/configuration", s.GetAllConfigurations).Methods("GET")
+	clientRouter.HandleFunc("/configuration/search", s.SearchConfigurations).Methods("GET")
 	clientRouter.HandleFunc("/configuration/{id}", s.GetConfiguration).Methods("GET")
 	clientRouter.HandleFunc("/configuration/{id}", s.DeleteConfiguration).Methods("DELETE")
+	clientRouter.HandleFunc("/configuration/{id}/restore", s.RestoreConfiguration).Methods("PUT")
 	clientRouter.HandleFunc("/configuration/{id}/enable", s.EnableConfiguration).Methods("PUT")
 	clientRouter.HandleFunc("/configuration/{id}/disable", s.DisableConfiguration).Methods("PUT")
 
+	// time windows of cluster configurations
+	// (handlers are implemented in the file configuration_window.go)
+	clientRouter.HandleFunc("/configuration/{id}/window", s.SetConfigurationWindow).Methods("PUT")
+	clientRouter.HandleFunc("/configuration/{id}/transitions", s.GetConfigurationTransitions).Methods("GET")
+
+	// schema of configuration
+	// (handlers are implemented in the file configuration_schema.go)
+	clientRouter.HandleFunc("/configuration/validate", s.ValidateConfiguration).Methods("POST")
+	clientRouter.HandleFunc("/configuration-schema", s.GetConfigurationSchema).Methods("GET")
+	clientRouter.HandleFunc("/configuration-schema", s.SetConfigurationSchema).Methods("PUT")
+
 	// clusters and its configurations
 	// (handlers are implemented in the file configuration.go)
 	clientRouter.HandleFunc("/cluster/{cluster}/configuration", s.GetClusterConfiguration).Methods("GET")
 	clientRouter.HandleFunc("/cluster/{cluster}/configuration/create", s.NewClusterConfiguration).Methods("POST")
+	clientRouter.HandleFunc("/cluster/{cluster}/configuration/assign", s.AssignClusterConfiguration).Methods("PUT")
+	clientRouter.HandleFunc("/cluster/{cluster}/configuration/rollback", s.RollbackClusterConfiguration).Methods("PUT")
 	clientRouter.HandleFunc("/cluster/{cluster}/configuration/enable", s.EnableClusterConfiguration).Methods("PUT")
 	clientRouter.HandleFunc("/cluster/{cluster}/configuration/disable", s.DisableClusterConfiguration).Methods("PUT")
 
+	// layers of configuration merged with configuration of each cluster
+	// (handlers are implemented in the file configuration_layer.go)
+	clientRouter.HandleFunc("/configuration-default", s.GetDefaultConfiguration).Methods("GET")
+	clientRouter.HandleFunc("/configuration-default", s.SetDefaultConfiguration).Methods("PUT")
+	clientRouter.HandleFunc("/configuration-default", s.DeleteDefaultConfiguration).Methods("DELETE")
+	clientRouter.HandleFunc("/configuration-group", s.GetConfigurationGroups).Methods("GET")
+	clientRouter.HandleFunc("/configuration-group/{name}", s.NewConfigurationGroup).Methods("POST")
+	clientRouter.HandleFunc("/configuration-group/{name}", s.DeleteConfigurationGroup).Methods("DELETE")
+	clientRouter.HandleFunc("/cluster/{cluster}/configuration/explain", s.ExplainClusterConfiguration).Methods("GET")
+
+	// staged rollouts of configuration profiles
+	// (handlers are implemented in the file configuration_rollout.go)
+	clientRouter.HandleFunc("/rollout", s.GetConfigurationRollouts).Methods("GET")
+	clientRouter.HandleFunc("/rollout", s.NewConfigurationRollout).Methods("POST")
+	clientRouter.HandleFunc("/rollout/{id}", s.GetConfigurationRollout).Methods("GET")
+	clientRouter.HandleFunc("/rollout/{id}/pause", s.PauseConfigurationRollout).Methods("PUT")
+	clientRouter.HandleFunc("/rollout/{id}/resume", s.ResumeConfigurationRollout).Methods("PUT")
+	clientRouter.HandleFunc("/rollout/{id}/abort", s.AbortConfigurationRollout).Methods("PUT")
+
 	// triggers
 	clientRouter.HandleFunc("/trigger", s.GetAllTriggers).Methods("GET")
+	clientRouter.HandleFunc("/trigger/search", s.SearchTriggers).Methods("GET")
 	clientRouter.HandleFunc("/trigger/{id}", s.GetTrigger).Methods("GET")
 	clientRouter.HandleFunc("/trigger/{id}", s.DeleteTrigger).Methods("DELETE")
 	clientRouter.HandleFunc("/trigger/{id}/activate", s.ActivateTrigger).Methods("PUT", "POST")
 	clientRouter.HandleFunc("/trigger/{id}/deactivate", s.DeactivateTrigger).Methods("PUT", "POST")
+	clientRouter.HandleFunc("/trigger/{id}/transitions", s.GetTriggerTransitions).Methods("GET")
 	clientRouter.HandleFunc("/cluster/{cluster}/trigger", s.GetClusterTriggers).Methods("GET")
 	clientRouter.HandleFunc("/cluster/{cluster}/trigger/{trigger}", s.RegisterClusterTrigger).Methods("POST")
 
+	// triggers registered for batch of clusters
+	// (handler is implemented in the file trigger_batch.go)
+	clientRouter.HandleFunc("/trigger-batch/{trigger}", s.RegisterTriggerBatch).Methods("POST")
+
+	// trigger types
+	// (handlers are implemented in the file trigger_type.go)
+	clientRouter.HandleFunc("/trigger-type", s.GetTriggerTypes).Methods("GET")
+	clientRouter.HandleFunc("/trigger-type/{type}", s.GetTriggerType).Methods("GET")
+	clientRouter.HandleFunc("/trigger-type/{type}", s.NewTriggerType).Methods("POST")
+	clientRouter.HandleFunc("/trigger-type/{type}", s.ChangeTriggerTypeDescription).Methods("PUT")
+	clientRouter.HandleFunc("/trigger-type/{type}/ttl", s.SetTriggerTypeDefaultTTL).Methods("PUT")
+	clientRouter.HandleFunc("/trigger-type/{type}/deprecate", s.DeprecateTriggerType).Methods("PUT")
+	clientRouter.HandleFunc("/trigger-type/{type}/requires-case", s.SetTriggerTypeRequiresCase).Methods("PUT")
+
+	// support cases that triggers are linked to
+	// (handlers are implemented in the file support_case.go)
+	clientRouter.HandleFunc("/case", s.GetSupportCases).Methods("GET")
+	clientRouter.HandleFunc("/case/{case}", s.GetSupportCase).Methods("GET")
+	clientRouter.HandleFunc("/case/{case}", s.NewSupportCase).Methods("POST")
+	clientRouter.HandleFunc("/case/{case}/status", s.ChangeSupportCaseStatus).Methods("PUT")
+	clientRouter.HandleFunc("/case/{case}/trigger", s.GetSupportCaseTriggers).Methods("GET")
+
+	// permanent removal of deleted items
+	// (handler is implemented in the file purge.go)
+	clientRouter.HandleFunc("/purge", s.PurgeDeletedItems).Methods("POST")
+
 	// REST API endpoints used by insights operator
 	// (handlers are implemented in the file operator.go)
 	operatorRouter := router.PathPrefix(APIPrefix + "operator").Subrouter()
+	operatorRouter.Use(s.RecordOperatorHeartbeat)
 	operatorRouter.HandleFunc("/register/{cluster}", s.RegisterCluster).Methods("GET", "PUT")
 	operatorRouter.HandleFunc("/configuration/{cluster}", s.ReadConfigurationForOperator).Methods("GET")
 	operatorRouter.HandleFunc("/triggers/{cluster}", s.GetActiveTriggersForCluster).Methods("GET")
 	operatorRouter.HandleFunc("/trigger/{cluster}/ack/{trigger}", s.AckTriggerForCluster).Methods("GET", "PUT")
+	operatorRouter.HandleFunc("/trigger/{cluster}/state/{trigger}", s.ReportTriggerStateForCluster).Methods("PUT")
+	operatorRouter.HandleFunc("/trigger/{cluster}/result/{trigger}", s.ReportTriggerResultForCluster).Methods("POST", "PUT")
 
 	// Prometheus metrics
 	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
@@ -336,6 +445,44 @@ func TryToSendInternalServerError(writer http.ResponseWriter, message string) {
 	}
 }
 
+// TryToSendStorageError function tries to send server response with info
+// about failed storage operation. Items that were not found are reported
+// with 404 Not Found status, cancelled or timed out operations with 504
+// Gateway Timeout status, changes of items modified in the
+// meantime with 412 Precondition Failed status, invalid input with 400 Bad
+// Request status and conflicts with existing items with 409 Conflict status.
+// All other errors are reported as internal server errors. Wrapped storage
+// errors are recognized too.
+func TryToSendStorageError(writer http.ResponseWriter, err error) {
+	switch {
+	case errors.As(err, new(*storage.ItemNotFoundError)),
+		errors.Is(err, storage.ErrNoSuchObj):
+		TryToSendResponse(http.StatusNotFound, writer, err.Error())
+	case errors.As(err, new(*storage.QueryCancelledError)):
+		TryToSendResponse(http.StatusGatewayTimeout, writer, err.Error())
+	case errors.As(err, new(*storage.VersionMismatchError)):
+		TryToSendResponse(http.StatusPreconditionFailed, writer, err.Error())
+	case errors.As(err, new(*storage.InvalidParametersError)),
+		errors.As(err, new(*storage.DeprecatedTriggerTypeError)),
+		errors.As(err, new(*storage.InvalidTriggerResultError)),
+		errors.As(err, new(*storage.InvalidSupportCaseError)),
+		errors.As(err, new(*storage.InvalidLabelError)),
+		errors.As(err, new(*storage.InvalidClusterMetadataError)),
+		errors.As(err, new(*storage.ConfigurationValidationError)),
+		errors.As(err, new(*storage.InvalidRolloutError)),
+		errors.As(err, new(*storage.InvalidConfigurationWindowError)),
+		errors.As(err, new(*storage.InvalidPageError)):
+		TryToSendBadRequestServerResponse(writer, err.Error())
+	case errors.As(err, new(*storage.ItemAlreadyExistsError)),
+		errors.As(err, new(*storage.InvalidTriggerTransitionError)),
+		errors.As(err, new(*storage.ActiveConfigurationConflictError)),
+		errors.As(err, new(*storage.InvalidRolloutTransitionError)):
+		TryToSendResponse(http.StatusConflict, writer, err.Error())
+	default:
+		TryToSendInternalServerError(writer, err.Error())
+	}
+}
+
 // TryToSendBadRequestServerResponse function tries to send server response with
 // bad request info.
 func TryToSendBadRequestServerResponse(writer http.ResponseWriter, message string) {
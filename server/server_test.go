@@ -20,6 +20,8 @@ package server_test
 // https://redhatinsights.github.io/insights-operator-controller/packages/server/server_test.html
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -36,7 +38,8 @@ import (
 func TestAddDefaultHeaders(t *testing.T) {
 	expectedHeaders := map[string]string{
 		"Access-Control-Allow-Methods":     "POST, GET, OPTIONS, PUT, DELETE",
-		"Access-Control-Allow-Headers":     "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token",
+		"Access-Control-Allow-Headers":     "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, If-Match",
+		"Access-Control-Expose-Headers":    "ETag",
 		"Access-Control-Allow-Credentials": "true",
 		"Access-Control-Allow-Origin":      "local",
 	}
@@ -157,3 +160,46 @@ func TestServerInitializeHTTPSServer(t *testing.T) {
 		serv.Initialize()
 	}, 5*time.Second, false)
 }
+
+// TestCancelledRequest checks that storage operation cancelled together with
+// the request is reported by distinct HTTP status
+func TestCancelledRequest(t *testing.T) {
+	for _, serv := range []*server.Server{MockedIOCServer(t, true), MockedIOCServerWithMemoryStorage(t)} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, "GET", "/client/cluster", http.NoBody)
+		rr := httptest.NewRecorder()
+
+		serv.GetClusters(rr, req)
+		CheckResponse(t, rr, http.StatusGatewayTimeout, true)
+
+		serv.Storage.Close()
+	}
+}
+
+// TestTryToSendStorageError checks HTTP status codes of storage errors,
+// including errors wrapped by other errors
+func TestTryToSendStorageError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{&storage.ItemNotFoundError{ItemID: 1}, http.StatusNotFound},
+		{fmt.Errorf("wrapped: %w", &storage.ItemNotFoundError{ItemID: 1}), http.StatusNotFound},
+		{storage.ErrNoSuchObj, http.StatusNotFound},
+		{&storage.QueryCancelledError{Reason: context.DeadlineExceeded}, http.StatusGatewayTimeout},
+		{&storage.VersionMismatchError{}, http.StatusPreconditionFailed},
+		{&storage.InvalidLabelError{}, http.StatusBadRequest},
+		{&storage.ActiveConfigurationConflictError{}, http.StatusConflict},
+		{fmt.Errorf("wrapped: %w", &storage.InvalidRolloutTransitionError{}), http.StatusConflict},
+		{fmt.Errorf("wrapped: %w", &storage.QueryCancelledError{Reason: context.Canceled}), http.StatusGatewayTimeout},
+		{fmt.Errorf("unknown error"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		server.TryToSendStorageError(rr, tt.err)
+		CheckResponse(t, rr, tt.status, true)
+	}
+}
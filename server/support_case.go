@@ -0,0 +1,171 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/support_case.html
+
+import (
+	"net/http"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/gorilla/mux"
+)
+
+// GetSupportCases method returns list of all support cases
+func (s *Server) GetSupportCases(writer http.ResponseWriter, request *http.Request) {
+	// try to read list of all support cases from storage
+	supportCases, err := s.Storage.ListSupportCases(request.Context())
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("cases", supportCases))
+	}
+}
+
+// GetSupportCase method returns single support case specified by its number
+func (s *Server) GetSupportCase(writer http.ResponseWriter, request *http.Request) {
+	// case number needs to be specified in request parameter
+	caseNumber, found := mux.Vars(request)["case"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Case number needs to be specified")
+		return
+	}
+
+	// try to read support case from storage
+	supportCase, err := s.Storage.GetSupportCase(request.Context(), caseNumber)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("case", supportCase))
+	}
+}
+
+// NewSupportCase method registers new open support case
+func (s *Server) NewSupportCase(writer http.ResponseWriter, request *http.Request) {
+	// case number needs to be specified in request parameter
+	caseNumber, found := mux.Vars(request)["case"]
+	if !found || caseNumber == "" {
+		TryToSendBadRequestServerResponse(writer, "Case number needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// customer account needs to be specified in request
+	account := request.URL.Query().Get("account")
+	if account == "" {
+		TryToSendBadRequestServerResponse(writer, "Account needs to be specified\n")
+		return
+	}
+
+	// link to customer consent is optional
+	consentLink := request.URL.Query().Get("consent_link")
+
+	// try to record the action NewSupportCase into Splunk
+	err := s.Splunk.LogAction("NewSupportCase", username[0], caseNumber)
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to register new support case in storage
+	err = s.Storage.NewSupportCase(request.Context(), caseNumber, account, consentLink, username[0])
+	if err != nil {
+		TryToSendStorageError(writer, err)
+		return
+	}
+
+	supportCase, err := s.Storage.GetSupportCase(request.Context(), caseNumber)
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendCreatedServerResponse(writer, responses.BuildOkResponseWithData("case", supportCase))
+	}
+}
+
+// ChangeSupportCaseStatus method opens or closes support case, new triggers
+// can not be linked to closed cases
+func (s *Server) ChangeSupportCaseStatus(writer http.ResponseWriter, request *http.Request) {
+	// case number needs to be specified in request parameter
+	caseNumber, found := mux.Vars(request)["case"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Case number needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// status needs to be specified in request
+	status, err := storage.ParseSupportCaseStatus(request.URL.Query().Get("status"))
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Status needs to be specified as open or closed")
+		return
+	}
+
+	// try to record the action ChangeSupportCaseStatus into Splunk
+	err = s.Splunk.LogAction("ChangeSupportCaseStatus", username[0], caseNumber+" "+string(status))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to change the support case in storage
+	err = s.Storage.ChangeSupportCaseStatus(request.Context(), caseNumber, status)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// GetSupportCaseTriggers method returns all triggers linked to support case
+// together with results reported by insights operator
+func (s *Server) GetSupportCaseTriggers(writer http.ResponseWriter, request *http.Request) {
+	// case number needs to be specified in request parameter
+	caseNumber, found := mux.Vars(request)["case"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Case number needs to be specified")
+		return
+	}
+
+	// try to read list of triggers linked to the case from storage
+	triggers, err := s.Storage.ListSupportCaseTriggers(request.Context(), caseNumber)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("triggers", triggers))
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/support_case_test.html
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// TestNonErrorsSupportCaseWithoutData tests OK behaviour with empty DB (schema only)
+func TestNonErrorsSupportCaseWithoutData(t *testing.T) {
+	serv := MockedIOCServer(t, false)
+	defer serv.Storage.Close()
+
+	nonErrorTT := []testCase{
+		{"GetSupportCases OK", serv.GetSupportCases, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"GetSupportCase Not Found", serv.GetSupportCase, http.StatusNotFound, "GET", true, requestData{"case": "01234567"}, requestData{}, ""},
+		{"ChangeSupportCaseStatus Not Found", serv.ChangeSupportCaseStatus, http.StatusNotFound, "PUT", true, requestData{"case": "01234567"}, requestData{"username": "tester", "status": "closed"}, ""},
+		{"GetSupportCaseTriggers Not Found", serv.GetSupportCaseTriggers, http.StatusNotFound, "GET", true, requestData{"case": "01234567"}, requestData{}, ""},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestNonErrorsSupportCaseWithData tests OK behaviour with mock data
+func TestNonErrorsSupportCaseWithData(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	nonErrorTT := []testCase{
+		{"NewSupportCase OK", serv.NewSupportCase, http.StatusCreated, "POST", true, requestData{"case": "01234567"}, requestData{"username": "tester", "account": "1234", "consent_link": "link"}, ""},
+		{"NewSupportCase already exists", serv.NewSupportCase, http.StatusConflict, "POST", true, requestData{"case": "01234567"}, requestData{"username": "tester", "account": "1234"}, ""},
+		{"GetSupportCases OK", serv.GetSupportCases, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"GetSupportCase OK", serv.GetSupportCase, http.StatusOK, "GET", true, requestData{"case": "01234567"}, requestData{}, ""},
+		{"RegisterClusterTrigger without case", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, ""},
+		{"RegisterClusterTrigger unknown case", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "case": "unknown"}, ""},
+		{"RegisterClusterTrigger with case OK", serv.RegisterClusterTrigger, http.StatusOK, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "case": "01234567"}, ""},
+		{"GetSupportCaseTriggers OK", serv.GetSupportCaseTriggers, http.StatusOK, "GET", true, requestData{"case": "01234567"}, requestData{}, ""},
+		{"ChangeSupportCaseStatus OK", serv.ChangeSupportCaseStatus, http.StatusOK, "PUT", true, requestData{"case": "01234567"}, requestData{"username": "tester", "status": "closed"}, ""},
+		{"RegisterClusterTrigger closed case", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "case": "01234567"}, ""},
+		{"SetTriggerTypeRequiresCase OK", serv.SetTriggerTypeRequiresCase, http.StatusOK, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester", "required": "false"}, ""},
+		{"SetTriggerTypeRequiresCase Not Found", serv.SetTriggerTypeRequiresCase, http.StatusNotFound, "PUT", true, requestData{"type": "unknown"}, requestData{"username": "tester", "required": "false"}, ""},
+		{"RegisterClusterTrigger case not required OK", serv.RegisterClusterTrigger, http.StatusOK, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, ""},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestDatabaseErrorSupportCase tests unexpected behaviour by closing DB connection (consistency check)
+func TestDatabaseErrorSupportCase(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+
+	dbErrorTT := []testCase{
+		{"GetSupportCases DB error", serv.GetSupportCases, http.StatusInternalServerError, "GET", true, requestData{}, requestData{}, ""},
+		{"GetSupportCase DB error", serv.GetSupportCase, http.StatusInternalServerError, "GET", true, requestData{"case": "01234567"}, requestData{}, ""},
+		{"NewSupportCase DB error", serv.NewSupportCase, http.StatusInternalServerError, "POST", true, requestData{"case": "01234567"}, requestData{"username": "tester", "account": "1234"}, ""},
+		{"ChangeSupportCaseStatus DB error", serv.ChangeSupportCaseStatus, http.StatusInternalServerError, "PUT", true, requestData{"case": "01234567"}, requestData{"username": "tester", "status": "closed"}, ""},
+		{"GetSupportCaseTriggers DB error", serv.GetSupportCaseTriggers, http.StatusInternalServerError, "GET", true, requestData{"case": "01234567"}, requestData{}, ""},
+		{"SetTriggerTypeRequiresCase DB error", serv.SetTriggerTypeRequiresCase, http.StatusInternalServerError, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester", "required": "true"}, ""},
+	}
+
+	serv.Storage.Close()
+
+	for _, tt := range dbErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestParameterErrorsSupportCase tests wrong request parameters
+func TestParameterErrorsSupportCase(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	paramErrorTT := []testCase{
+		{"GetSupportCase no case", serv.GetSupportCase, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
+		{"NewSupportCase no case", serv.NewSupportCase, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "account": "1234"}, ""},
+		{"NewSupportCase no username", serv.NewSupportCase, http.StatusBadRequest, "POST", true, requestData{"case": "01234567"}, requestData{"account": "1234"}, ""},
+		{"NewSupportCase no account", serv.NewSupportCase, http.StatusBadRequest, "POST", true, requestData{"case": "01234567"}, requestData{"username": "tester"}, ""},
+		{"ChangeSupportCaseStatus no case", serv.ChangeSupportCaseStatus, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester", "status": "closed"}, ""},
+		{"ChangeSupportCaseStatus no username", serv.ChangeSupportCaseStatus, http.StatusBadRequest, "PUT", true, requestData{"case": "01234567"}, requestData{"status": "closed"}, ""},
+		{"ChangeSupportCaseStatus wrong status", serv.ChangeSupportCaseStatus, http.StatusBadRequest, "PUT", true, requestData{"case": "01234567"}, requestData{"username": "tester", "status": "pending"}, ""},
+		{"GetSupportCaseTriggers no case", serv.GetSupportCaseTriggers, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
+		{"SetTriggerTypeRequiresCase no type", serv.SetTriggerTypeRequiresCase, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester", "required": "true"}, ""},
+		{"SetTriggerTypeRequiresCase no username", serv.SetTriggerTypeRequiresCase, http.StatusBadRequest, "PUT", true, requestData{"type": "must-gather"}, requestData{"required": "true"}, ""},
+		{"SetTriggerTypeRequiresCase no flag", serv.SetTriggerTypeRequiresCase, http.StatusBadRequest, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester"}, ""},
+	}
+
+	for _, tt := range paramErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestSupportCaseTriggerResults checks that results of triggers linked to
+// support case are returned together with the triggers
+func TestSupportCaseTriggerResults(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	vars := map[string]string{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather", "case": "01234567"}
+
+	request := httptest.NewRequest("POST", "/api/v1/client/case/01234567?username=tester&account=1234", nil)
+	recorder := httptest.NewRecorder()
+	serv.NewSupportCase(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+
+	request = httptest.NewRequest("POST", "/api/v1/client/cluster/00000000-0000-0000-0000-000000000000/trigger/must-gather?username=tester&reason=test&link=link&case=01234567", nil)
+	recorder = httptest.NewRecorder()
+	serv.RegisterClusterTrigger(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// the new trigger is the only one linked to the case
+	request = httptest.NewRequest("GET", "/api/v1/client/case/01234567/trigger", nil)
+	recorder = httptest.NewRecorder()
+	serv.GetSupportCaseTriggers(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		Triggers []storage.Trigger `json:"triggers"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, response.Triggers, 1)
+	assert.Equal(t, "01234567", response.Triggers[0].CaseNumber)
+	assert.Nil(t, response.Triggers[0].Result)
+
+	// results are reported by the operator for delivered triggers
+	triggerID := int64(response.Triggers[0].ID)
+	err := serv.Storage.AckTrigger(request.Context(), vars["cluster"], triggerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = serv.Storage.SetTriggerResult(request.Context(), vars["cluster"], triggerID, storage.TriggerResult{
+		Status:   storage.TriggerSucceeded,
+		Location: "must-gather/1.tar.gz",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder = httptest.NewRecorder()
+	serv.GetSupportCaseTriggers(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, response.Triggers[0].Result) {
+		assert.Equal(t, "must-gather/1.tar.gz", response.Triggers[0].Result.Location)
+	}
+}
@@ -23,6 +23,7 @@ package server_test
 
 import (
 	"bytes"
+	"context"
 	"github.com/RedHatInsights/insights-operator-controller/logging"
 	"github.com/RedHatInsights/insights-operator-controller/server"
 	"github.com/RedHatInsights/insights-operator-controller/storage"
@@ -86,6 +87,15 @@ func testRequest(t *testing.T, test *testCase) {
 	})
 }
 
+// withHeader wraps the handler function so the request is sent with the
+// specified header
+func withHeader(handler handlerFunction, name, value string) handlerFunction {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		request.Header.Set(name, value)
+		handler(writer, request)
+	}
+}
+
 // runSQLiteScript runs the script in `path` against the above defined DB
 func runSQLiteScript(t *testing.T, path string) {
 	script, err := os.Open(path)
@@ -132,13 +142,32 @@ func MockedIOCServer(t *testing.T, mockData bool) *server.Server {
 		TLSKey:   emptyStr,
 	}
 
-	s.ClusterQuery = storage.NewClusterQuery(s.Storage)
+	s.ClusterQuery = storage.NewClusterQuery(db)
+	s.TriggerQuery = storage.NewTriggerQuery(db)
+	s.ClusterConfigurationQuery = storage.NewClusterConfigurationQuery(db)
+
+	return &s
+}
+
+// MockedIOCServerWithMemoryStorage returns an insights-operator-controller
+// Server with disabled Splunk and in-memory storage for testing purposes
+func MockedIOCServerWithMemoryStorage(t *testing.T) *server.Server {
+	splunk := logging.NewClient(false, emptyStr, emptyStr, emptyStr, emptyStr, emptyStr)
+
+	s := server.Server{
+		Address:  emptyStr, // not necessary since handlers are called directly
+		UseHTTPS: false,
+		Storage:  storage.NewMemoryStorage(),
+		Splunk:   splunk,
+		TLSCert:  emptyStr,
+		TLSKey:   emptyStr,
+	}
 
 	return &s
 }
 
 // MockedSQLite deletes the test db, (re)creates it and returns a Storage linked to it
-func MockedSQLite(t *testing.T, mockData bool) storage.Storage {
+func MockedSQLite(t *testing.T, mockData bool) storage.DBStorage {
 	dbDriver := dbDriver
 	storageSpecification := sqliteDB
 
@@ -160,6 +189,12 @@ func MockedSQLite(t *testing.T, mockData bool) storage.Storage {
 		runSQLiteScript(t, "../local_storage/test_data_sqlite.sql")
 	}
 
+	// test data are migrated the same way as data in existing databases
+	err = db.MigrateToLatest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	return db
 }
 
@@ -23,27 +23,107 @@ package server
 // https://redhatinsights.github.io/insights-operator-controller/packages/server/trigger.html
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-controller/utils"
 	"github.com/RedHatInsights/insights-operator-utils/responses"
 	"github.com/gorilla/mux"
 )
 
-// GetAllTriggers method returns list of all triggers
+// retrieveTriggerStates returns trigger states specified by the optional
+// state query parameter, which contains comma separated list of states
+func retrieveTriggerStates(request *http.Request) ([]storage.TriggerState, error) {
+	return storage.ParseTriggerStates(request.URL.Query().Get("state"))
+}
+
+// GetAllTriggers method returns list of all triggers. The list can be
+// filtered by trigger states and it is paginated and sorted according to the
+// page parameters.
 func (s *Server) GetAllTriggers(writer http.ResponseWriter, request *http.Request) {
-	// try to read list of all triggers from storage
-	triggers, err := s.Storage.ListAllTriggers()
+	page, err := retrievePageParameters(request, storage.TriggerSortColumns)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	states, err := retrieveTriggerStates(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to read one page of triggers from storage
+	triggers, total, err := s.Storage.ListAllTriggersPage(request.Context(), states, page)
 
 	// check if the storage operation has been successful
 	if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+		TryToSendStorageError(writer, err)
 		return
 	}
+	TryToSendOKServerResponse(writer, buildPageResponse(request, "triggers", triggerList(triggers), total, page))
+}
+
+// SearchTriggers method returns list of triggers filtered by cluster, type,
+// state, reason, user, or time when the trigger has been created.
+func (s *Server) SearchTriggers(writer http.ResponseWriter, request *http.Request) {
+	var req storage.SearchTriggerRequest
+
+	// search is not supported by all storage implementations
+	if s.TriggerQuery == nil {
+		TryToSendResponse(http.StatusNotImplemented, writer, "Trigger search is not supported by the configured storage")
+		return
+	}
+
+	err := utils.DecodeValidRequest(&req, SearchTriggerTemplate, request.URL.Query())
+	if err != nil {
+		log.Println(err)
+		TryToSendResponse(http.StatusBadRequest, writer, err.Error())
+		return
+	}
+
+	// states are checked separately, because more states can be specified
+	if _, err := storage.ParseTriggerStates(req.State); err != nil {
+		TryToSendResponse(http.StatusBadRequest, writer, err.Error())
+		return
+	}
+
+	triggers, err := s.TriggerQuery.QueryMany(request.Context(), req)
+	if err != nil {
+		log.Println("Unable to read triggers from database", err)
+		TryToSendStorageError(writer, err)
+		return
+	}
+
 	TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("triggers", triggers))
 }
 
+// SearchTriggerTemplate defines validation rules and messages for SearchTriggers
+var SearchTriggerTemplate = utils.MergeMaps(map[string]interface{}{
+	// all acceptable fields are listed
+	// case sensitive
+	"id":             "int~Error reading and decoding trigger ID from query",
+	"cluster":        "",
+	"type":           "",
+	"active":         "in(0|1)~Active needs to be 0 or 1",
+	"expired":        "in(0|1)~Expired needs to be 0 or 1",
+	"state":          "",
+	"case":           "",
+	"reason":         "",
+	"triggered_by":   "",
+	"triggered_from": "",
+	"triggered_to":   "",
+	"order_by":       "in(id|type|cluster|triggered_at|triggered_by|active|expires_at|state)~Triggers can not be ordered by the specified column",
+	"order":          "in(asc|desc)~Order needs to be asc or desc",
+	// all filters are optional, so the whole request is not validated
+	"": "",
+}, utils.PaginationTemplate)
+
 // GetTrigger method returns single trigger by id
 func (s *Server) GetTrigger(writer http.ResponseWriter, request *http.Request) {
 	// trigger ID needs to be specified in request parameter
@@ -54,18 +134,36 @@ func (s *Server) GetTrigger(writer http.ResponseWriter, request *http.Request) {
 	}
 
 	// try to read trigger identified by its ID from storage
-	trigger, err := s.Storage.GetTriggerByID(id)
+	trigger, err := s.Storage.GetTriggerByID(request.Context(), id)
 
 	// check if the storage operation has been successful
-	if err == storage.ErrNoSuchObj {
-		TryToSendResponse(http.StatusNotFound, writer, fmt.Sprintf("No such trigger for ID %v", id))
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("trigger", trigger))
 	}
 }
 
+// GetTriggerTransitions method returns all changes of state of single trigger
+func (s *Server) GetTriggerTransitions(writer http.ResponseWriter, request *http.Request) {
+	// trigger ID needs to be specified in request parameter
+	id, err := retrieveIDRequestParameter(request)
+	if err != nil {
+		TryToSendResponse(http.StatusBadRequest, writer, err.Error())
+		return
+	}
+
+	// try to read transitions of trigger identified by its ID from storage
+	transitions, err := s.Storage.ListTriggerTransitions(request.Context(), id)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("transitions", transitions))
+	}
+}
+
 // DeleteTrigger method deletes single trigger
 func (s *Server) DeleteTrigger(writer http.ResponseWriter, request *http.Request) {
 	// trigger ID needs to be specified in request parameter
@@ -81,23 +179,18 @@ func (s *Server) DeleteTrigger(writer http.ResponseWriter, request *http.Request
 	checkSplunkOperation(err)
 
 	// try to delete trigger identified by its ID from storage
-	err = s.Storage.DeleteTriggerByID(id)
+	err = s.Storage.DeleteTriggerByID(request.Context(), id)
 
 	// check if the storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(
-			http.StatusNotFound,
-			writer,
-			responses.BuildOkResponse(),
-		)
-	} else if err != nil {
-		TryToSendResponse(http.StatusInternalServerError, writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
 	}
 }
 
-// ActivateTrigger method actives single trigger
+// ActivateTrigger method actives single trigger, i.e. it moves cancelled or
+// failed trigger into pending state
 func (s *Server) ActivateTrigger(writer http.ResponseWriter, request *http.Request) {
 	// trigger ID needs to be specified in request parameter
 	id, err := retrieveIDRequestParameter(request)
@@ -111,20 +204,21 @@ func (s *Server) ActivateTrigger(writer http.ResponseWriter, request *http.Reque
 	// and check whether the Splunk operation was successful
 	checkSplunkOperation(err)
 
-	// try to activate trigger identified by its ID from storage
-	err = s.Storage.ChangeStateOfTriggerByID(id, 1)
+	// try to activate trigger identified by its ID from storage, user name
+	// is optional
+	username := request.URL.Query().Get("username")
+	err = s.Storage.ChangeTriggerState(request.Context(), id, storage.TriggerPending, username)
 
 	// check if the storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
 	}
 }
 
-// DeactivateTrigger method deactivates single trigger
+// DeactivateTrigger method deactivates single trigger, i.e. it cancels the
+// trigger
 func (s *Server) DeactivateTrigger(writer http.ResponseWriter, request *http.Request) {
 	// trigger ID needs to be specified in request parameter
 	id, err := retrieveIDRequestParameter(request)
@@ -138,20 +232,21 @@ func (s *Server) DeactivateTrigger(writer http.ResponseWriter, request *http.Req
 	// and check whether the Splunk operation was successful
 	checkSplunkOperation(err)
 
-	// try to deactivate trigger identified by its ID from storage
-	err = s.Storage.ChangeStateOfTriggerByID(id, 0)
+	// try to cancel trigger identified by its ID from storage, user name is
+	// optional
+	username := request.URL.Query().Get("username")
+	err = s.Storage.ChangeTriggerState(request.Context(), id, storage.TriggerCancelled, username)
 
 	// check if the storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
 	}
 }
 
-// GetClusterTriggers method returns list of triggers for single cluster
+// GetClusterTriggers method returns list of triggers for single cluster. The
+// list can be filtered by trigger states.
 func (s *Server) GetClusterTriggers(writer http.ResponseWriter, request *http.Request) {
 	// cluster name needs to be specified in request parameter
 	cluster, found := mux.Vars(request)["cluster"]
@@ -160,19 +255,47 @@ func (s *Server) GetClusterTriggers(writer http.ResponseWriter, request *http.Re
 		return
 	}
 
-	// try to read list of all triggers for specified cluster from storage
-	triggers, err := s.Storage.ListClusterTriggers(cluster)
+	page, err := retrievePageParameters(request, storage.TriggerSortColumns)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	states, err := retrieveTriggerStates(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// try to read one page of triggers for specified cluster from storage
+	triggers, total, err := s.Storage.ListClusterTriggersPage(request.Context(), cluster, states, page)
 
 	// check if the storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
-		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("triggers", triggers))
+		TryToSendOKServerResponse(writer, buildPageResponse(request, "triggers", triggerList(triggers), total, page))
 	}
 }
 
+// retrieveTriggerExpiration reads optional expiration of new trigger from
+// request, zero time is returned when it is not specified
+func retrieveTriggerExpiration(request *http.Request) (time.Time, error) {
+	value := request.URL.Query().Get("expires_at")
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("Expiration needs to be timestamp in RFC 3339 format")
+	}
+	if !expiresAt.After(time.Now()) {
+		return time.Time{}, errors.New("Expiration needs to be in the future")
+	}
+	return expiresAt, nil
+}
+
 // RegisterClusterTrigger method registers new trigger for cluster
 func (s *Server) RegisterClusterTrigger(writer http.ResponseWriter, request *http.Request) {
 	// cluster name needs to be specified in request parameter
@@ -210,19 +333,35 @@ func (s *Server) RegisterClusterTrigger(writer http.ResponseWriter, request *htt
 		return
 	}
 
+	// expiration of the trigger is optional, default time to live of the
+	// trigger type is used when it is not specified
+	expiresAt, err := retrieveTriggerExpiration(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// support case is optional, but some trigger types require it
+	caseNumber := request.URL.Query().Get("case")
+
+	// trigger parameters are optional JSON object sent in request body
+	parameters, err := io.ReadAll(request.Body)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Trigger parameters can not be read from the request body")
+		return
+	}
+
 	// try to record the action RegisterTrigger into Splunk
-	err := s.Splunk.LogTriggerAction("RegisterTrigger", username[0], cluster, triggerType)
+	err = s.Splunk.LogTriggerAction("RegisterTrigger", username[0], cluster, triggerType)
 	// and check whether the Splunk operation was successful
 	checkSplunkOperation(err)
 
 	// try to create new trigger in storage
-	err = s.Storage.NewTrigger(cluster, triggerType, username[0], reason[0], link[0])
+	err = s.Storage.NewTrigger(request.Context(), cluster, triggerType, username[0], reason[0], link[0], string(parameters), expiresAt, caseNumber)
 
 	// check if the storage operation has been successful
-	if _, ok := err.(*storage.ItemNotFoundError); ok {
-		TryToSendResponse(http.StatusNotFound, writer, err.Error())
-	} else if err != nil {
-		TryToSendInternalServerError(writer, err.Error())
+	if err != nil {
+		TryToSendStorageError(writer, err)
 	} else {
 		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
 	}
@@ -0,0 +1,195 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/trigger_batch.html
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/gorilla/mux"
+)
+
+// TriggerBatchRequest represents request body of RegisterTriggerBatch
+//     Clusters: names of clusters that the trigger is registered for
+//     Selector: optional label selector of additional clusters
+//     Parameters: optional parameters of all triggers in the batch
+type TriggerBatchRequest struct {
+	Clusters   []string        `json:"clusters"`
+	Selector   string          `json:"selector,omitempty"`
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+// TriggerBatchOutcome represents outcome of trigger registration for one
+// cluster in the batch
+//     Cluster: cluster name
+//     Created: flag indicating whether the trigger has been registered
+//     Error: reason why the trigger has not been registered
+type TriggerBatchOutcome struct {
+	Cluster string `json:"cluster"`
+	Created bool   `json:"created"`
+	Error   string `json:"error,omitempty"`
+}
+
+// newBatchID generates random ID of trigger batch used in audit log
+func newBatchID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// uniqueClusterNames returns cluster names without duplicates and empty
+// names, the order of names is kept
+func uniqueClusterNames(clusters []string) []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, cluster := range clusters {
+		if cluster != "" && !seen[cluster] {
+			seen[cluster] = true
+			result = append(result, cluster)
+		}
+	}
+	return result
+}
+
+// RegisterTriggerBatch method registers new trigger of the same type for all
+// clusters listed in request body and all clusters selected by label
+// selector from request body. Triggers are registered independently,
+// so outcome of the registration is returned for each cluster.
+func (s *Server) RegisterTriggerBatch(writer http.ResponseWriter, request *http.Request) {
+	// trigger type needs to be specified in request parameter
+	triggerType, found := mux.Vars(request)["trigger"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Trigger type needs to be specified")
+		return
+	}
+
+	// user name needs to be specified in request parameter
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// reason needs to be specified in request parameter
+	reason, foundReason := request.URL.Query()["reason"]
+	if !foundReason {
+		TryToSendBadRequestServerResponse(writer, "Reason needs to be specified\n")
+		return
+	}
+
+	// link needs to be specified in request parameter
+	link, foundLink := request.URL.Query()["link"]
+	if !foundLink {
+		TryToSendBadRequestServerResponse(writer, "Link needs to be specified\n")
+		return
+	}
+
+	// expiration of the triggers is optional
+	expiresAt, err := retrieveTriggerExpiration(request)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+
+	// support case is optional, but some trigger types require it
+	caseNumber := request.URL.Query().Get("case")
+
+	// list of clusters needs to be sent in request body
+	var batch TriggerBatchRequest
+	if err := json.NewDecoder(request.Body).Decode(&batch); err != nil {
+		TryToSendBadRequestServerResponse(writer, "Trigger batch needs to be JSON object with list of clusters")
+		return
+	}
+
+	// clusters selected by labels are added to the listed ones
+	selector, err := storage.ParseLabelSelector(batch.Selector)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, err.Error())
+		return
+	}
+	if len(selector) > 0 {
+		selected, err := s.Storage.ListClustersBySelector(request.Context(), selector)
+		if err != nil {
+			TryToSendStorageError(writer, err)
+			return
+		}
+		for _, cluster := range selected {
+			batch.Clusters = append(batch.Clusters, string(cluster.Name))
+		}
+	}
+
+	clusters := uniqueClusterNames(batch.Clusters)
+	if len(clusters) == 0 {
+		TryToSendBadRequestServerResponse(writer, "At least one cluster needs to be specified")
+		return
+	}
+
+	// all triggers in the batch are of the same type, so it is checked once
+	if _, err := s.Storage.GetTriggerType(request.Context(), triggerType); err != nil {
+		TryToSendStorageError(writer, err)
+		return
+	}
+
+	batchID, err := newBatchID()
+	if err != nil {
+		TryToSendInternalServerError(writer, err.Error())
+		return
+	}
+
+	// try to record the whole batch into Splunk as one action
+	err = s.Splunk.LogTriggerBatchAction("RegisterTriggerBatch", username[0], batchID, strings.Join(clusters, ","), triggerType)
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	parameters := ""
+	if len(batch.Parameters) > 0 {
+		parameters = string(batch.Parameters)
+	}
+
+	outcomes := []TriggerBatchOutcome{}
+	created := 0
+	for _, cluster := range clusters {
+		outcome := TriggerBatchOutcome{Cluster: cluster, Created: true}
+		err := s.Storage.NewTrigger(request.Context(), cluster, triggerType, username[0], reason[0], link[0], parameters, expiresAt, caseNumber)
+		if err != nil {
+			outcome.Created = false
+			outcome.Error = err.Error()
+		} else {
+			created++
+		}
+		outcomes = append(outcomes, outcome)
+	}
+
+	resp := responses.BuildOkResponse()
+	resp["batch_id"] = batchID
+	resp["created"] = created
+	resp["triggers"] = outcomes
+	TryToSendOKServerResponse(writer, resp)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/trigger_batch_test.html
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/server"
+)
+
+// TestNonErrorsTriggerBatchWithData tests OK behaviour with mock data
+func TestNonErrorsTriggerBatchWithData(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	nonErrorTT := []testCase{
+		{"NewSupportCase OK", serv.NewSupportCase, http.StatusCreated, "POST", true, requestData{"case": "01234567"}, requestData{"username": "tester", "account": "1234"}, ""},
+		{"RegisterTriggerBatch OK", serv.RegisterTriggerBatch, http.StatusOK, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "case": "01234567"}, `{"clusters": ["00000000-0000-0000-0000-000000000000", "00000000-0000-0000-0000-000000000001"]}`},
+		{"RegisterTriggerBatch with parameters OK", serv.RegisterTriggerBatch, http.StatusOK, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "case": "01234567", "expires_at": "2100-01-01T00:00:00Z"}, `{"clusters": ["00000000-0000-0000-0000-000000000000"], "parameters": {"image": "quay.io/must-gather"}}`},
+		{"SetClusterLabel OK", serv.SetClusterLabel, http.StatusOK, "PUT", true, requestData{"cluster": "00000000-0000-0000-0000-000000000002", "key": "env"}, requestData{"username": "tester", "value": "prod"}, ""},
+		{"RegisterTriggerBatch selector OK", serv.RegisterTriggerBatch, http.StatusOK, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "case": "01234567"}, `{"selector": "env=prod"}`},
+		{"RegisterTriggerBatch unknown cluster OK", serv.RegisterTriggerBatch, http.StatusOK, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "case": "01234567"}, `{"clusters": ["unknown"]}`},
+		{"RegisterTriggerBatch unknown type", serv.RegisterTriggerBatch, http.StatusNotFound, "POST", true, requestData{"trigger": "unknown"}, requestData{"username": "tester", "reason": "test", "link": "link"}, `{"clusters": ["00000000-0000-0000-0000-000000000000"]}`},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestDatabaseErrorTriggerBatch tests unexpected behaviour by closing DB connection (consistency check)
+func TestDatabaseErrorTriggerBatch(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+
+	dbErrorTT := []testCase{
+		{"RegisterTriggerBatch DB error", serv.RegisterTriggerBatch, http.StatusInternalServerError, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, `{"clusters": ["00000000-0000-0000-0000-000000000000"]}`},
+	}
+
+	serv.Storage.Close()
+
+	for _, tt := range dbErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestParameterErrorsTriggerBatch tests wrong request parameters
+func TestParameterErrorsTriggerBatch(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	clusters := `{"clusters": ["00000000-0000-0000-0000-000000000000"]}`
+	paramErrorTT := []testCase{
+		{"RegisterTriggerBatch no trigger", serv.RegisterTriggerBatch, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "reason": "test", "link": "link"}, clusters},
+		{"RegisterTriggerBatch no username", serv.RegisterTriggerBatch, http.StatusBadRequest, "POST", true, requestData{"trigger": "must-gather"}, requestData{"reason": "test", "link": "link"}, clusters},
+		{"RegisterTriggerBatch no reason", serv.RegisterTriggerBatch, http.StatusBadRequest, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "link": "link"}, clusters},
+		{"RegisterTriggerBatch no link", serv.RegisterTriggerBatch, http.StatusBadRequest, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test"}, clusters},
+		{"RegisterTriggerBatch past expiration", serv.RegisterTriggerBatch, http.StatusBadRequest, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "expires_at": "2000-01-01T00:00:00Z"}, clusters},
+		{"RegisterTriggerBatch no body", serv.RegisterTriggerBatch, http.StatusBadRequest, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, ""},
+		{"RegisterTriggerBatch no clusters", serv.RegisterTriggerBatch, http.StatusBadRequest, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, `{"clusters": []}`},
+		{"RegisterTriggerBatch no selected clusters", serv.RegisterTriggerBatch, http.StatusBadRequest, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, `{"selector": "env=prod"}`},
+		{"RegisterTriggerBatch wrong selector", serv.RegisterTriggerBatch, http.StatusBadRequest, "POST", true, requestData{"trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, `{"selector": "env=not valid"}`},
+	}
+
+	for _, tt := range paramErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestTriggerBatchOutcomes checks that outcome of trigger registration is
+// returned for each cluster in the batch
+func TestTriggerBatchOutcomes(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	vars := map[string]string{"trigger": "must-gather"}
+	err := serv.Storage.SetTriggerTypeRequiresCase(context.Background(), "must-gather", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := httptest.NewRequest("POST", "/api/v1/client/trigger-batch/must-gather?username=tester&reason=test&link=link",
+		bytes.NewBufferString(`{"clusters": ["00000000-0000-0000-0000-000000000001", "unknown", "00000000-0000-0000-0000-000000000001", "00000000-0000-0000-0000-000000000002"]}`))
+	recorder := httptest.NewRecorder()
+	serv.RegisterTriggerBatch(recorder, mux.SetURLVars(request, vars))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		BatchID  string                       `json:"batch_id"`
+		Created  int                          `json:"created"`
+		Triggers []server.TriggerBatchOutcome `json:"triggers"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, response.BatchID)
+	assert.Equal(t, 2, response.Created)
+
+	// duplicate cluster names are ignored
+	if assert.Len(t, response.Triggers, 3) {
+		assert.True(t, response.Triggers[0].Created)
+		assert.False(t, response.Triggers[1].Created)
+		assert.NotEmpty(t, response.Triggers[1].Error)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000002", response.Triggers[2].Cluster)
+		assert.True(t, response.Triggers[2].Created)
+	}
+
+	triggers, err := serv.Storage.ListClusterTriggers(context.Background(), "00000000-0000-0000-0000-000000000002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, triggers, 1)
+}
@@ -20,8 +20,15 @@ package server_test
 // https://redhatinsights.github.io/insights-operator-controller/packages/server/trigger_test.html
 
 import (
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
 )
 
 // TestNonErrorsConfigurationWithoutData tests OK behaviour with empty DB (schema only)
@@ -31,10 +38,12 @@ func TestNonErrorsTriggerWithoutData(t *testing.T) {
 
 	nonErrorTT := []testCase{
 		{"GetAllTriggers OK", serv.GetAllTriggers, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"SearchTriggers OK", serv.SearchTriggers, http.StatusOK, "GET", true, requestData{}, requestData{"cluster": "test"}, ""},
 		{"GetTrigger Not Found", serv.GetTrigger, http.StatusNotFound, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"DeleteTrigger Not Found", serv.DeleteTrigger, http.StatusNotFound, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
 		{"ActivateTrigger Not Found", serv.ActivateTrigger, http.StatusNotFound, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
 		{"DeactivateTrigger Not Found", serv.DeactivateTrigger, http.StatusNotFound, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"GetTriggerTransitions Not Found", serv.GetTriggerTransitions, http.StatusNotFound, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"GetClusterTriggers Not Found", serv.GetClusterTriggers, http.StatusNotFound, "GET", true, requestData{"cluster": "1"}, requestData{}, ""},
 		{"RegisterClusterTrigger Not Found", serv.RegisterClusterTrigger, http.StatusNotFound, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, ""},
 	}
@@ -51,11 +60,22 @@ func TestNonErrorsTriggerWithData(t *testing.T) {
 
 	nonErrorTT := []testCase{
 		{"GetAllTriggers OK", serv.GetAllTriggers, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"SearchTriggers OK", serv.SearchTriggers, http.StatusOK, "GET", true, requestData{}, requestData{"type": "must-gather", "order_by": "triggered_at", "order": "desc", "limit": "10"}, ""},
 		{"GetTrigger OK", serv.GetTrigger, http.StatusOK, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"ActivateTrigger OK", serv.ActivateTrigger, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
-		{"DeactivateTrigger OK", serv.DeactivateTrigger, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"DeactivateTrigger OK", serv.DeactivateTrigger, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{"username": "tester"}, ""},
+		{"DeactivateTrigger cancelled trigger OK", serv.DeactivateTrigger, http.StatusOK, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"GetTriggerTransitions OK", serv.GetTriggerTransitions, http.StatusOK, "GET", true, requestData{"id": "1"}, requestData{}, ""},
+		{"GetAllTriggers by state OK", serv.GetAllTriggers, http.StatusOK, "GET", true, requestData{}, requestData{"state": "pending,cancelled", "sort": "state"}, ""},
 		{"GetClusterTriggers OK", serv.GetClusterTriggers, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{}, ""},
-		{"RegisterClusterTrigger OK", serv.RegisterClusterTrigger, http.StatusOK, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, ""},
+		{"GetClusterTriggers by state OK", serv.GetClusterTriggers, http.StatusOK, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"state": "running"}, ""},
+		{"SearchTriggers by state OK", serv.SearchTriggers, http.StatusOK, "GET", true, requestData{}, requestData{"state": "pending,delivered", "order_by": "state"}, ""},
+		{"NewSupportCase OK", serv.NewSupportCase, http.StatusCreated, "POST", true, requestData{"case": "01234567"}, requestData{"username": "tester", "account": "1234"}, ""},
+		{"RegisterClusterTrigger OK", serv.RegisterClusterTrigger, http.StatusOK, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "case": "01234567"}, ""},
+		{"RegisterClusterTrigger with parameters OK", serv.RegisterClusterTrigger, http.StatusOK, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "case": "01234567"}, `{"image": "quay.io/must-gather", "timeout": 60}`},
+		{"RegisterClusterTrigger with expiration OK", serv.RegisterClusterTrigger, http.StatusOK, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "case": "01234567", "expires_at": "2100-01-01T00:00:00Z"}, ""},
+		{"SearchTriggers by case OK", serv.SearchTriggers, http.StatusOK, "GET", true, requestData{}, requestData{"case": "01234567"}, ""},
+		{"SearchTriggers expired OK", serv.SearchTriggers, http.StatusOK, "GET", true, requestData{}, requestData{"expired": "1", "order_by": "expires_at"}, ""},
 		{"DeleteTrigger OK", serv.DeleteTrigger, http.StatusOK, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
 	}
 
@@ -64,16 +84,28 @@ func TestNonErrorsTriggerWithData(t *testing.T) {
 	}
 }
 
+// TestSearchTriggersWithMemoryStorage tests that trigger search is refused
+// by storage that is not based on SQL
+func TestSearchTriggersWithMemoryStorage(t *testing.T) {
+	serv := MockedIOCServerWithMemoryStorage(t)
+	defer serv.Storage.Close()
+
+	tt := testCase{"SearchTriggers Not implemented", serv.SearchTriggers, http.StatusNotImplemented, "GET", true, requestData{}, requestData{}, ""}
+	testRequest(t, &tt)
+}
+
 // TestDatabaseErrorTrigger tests unexpected behaviour by closing DB connection (consistency check)
 func TestDatabaseErrorTrigger(t *testing.T) {
 	serv := MockedIOCServer(t, true)
 
 	dbErrorTT := []testCase{
 		{"GetAllTriggers DB error", serv.GetAllTriggers, http.StatusInternalServerError, "GET", true, requestData{}, requestData{}, ""},
+		{"SearchTriggers DB error", serv.SearchTriggers, http.StatusInternalServerError, "GET", true, requestData{}, requestData{}, ""},
 		{"GetTrigger DB error", serv.GetTrigger, http.StatusInternalServerError, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"DeleteTrigger DB error", serv.DeleteTrigger, http.StatusInternalServerError, "DELETE", true, requestData{"id": "1"}, requestData{}, ""},
 		{"ActivateTrigger DB error", serv.ActivateTrigger, http.StatusInternalServerError, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
 		{"DeactivateTrigger DB error", serv.DeactivateTrigger, http.StatusInternalServerError, "PUT", true, requestData{"id": "1"}, requestData{}, ""},
+		{"GetTriggerTransitions DB error", serv.GetTriggerTransitions, http.StatusInternalServerError, "GET", true, requestData{"id": "1"}, requestData{}, ""},
 		{"GetClusterTriggers DB error", serv.GetClusterTriggers, http.StatusInternalServerError, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{}, ""},
 		{"RegisterClusterTrigger DB error", serv.RegisterClusterTrigger, http.StatusInternalServerError, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, ""},
 	}
@@ -91,6 +123,10 @@ func TestParameterErrorsTrigger(t *testing.T) {
 	defer serv.Storage.Close()
 
 	paramErrorTT := []testCase{
+		{"SearchTriggers non-int id", serv.SearchTriggers, http.StatusBadRequest, "GET", true, requestData{}, requestData{"id": "non-int"}, ""},
+		{"SearchTriggers wrong active", serv.SearchTriggers, http.StatusBadRequest, "GET", true, requestData{}, requestData{"active": "yes"}, ""},
+		{"SearchTriggers unknown order column", serv.SearchTriggers, http.StatusBadRequest, "GET", true, requestData{}, requestData{"order_by": "link"}, ""},
+		{"SearchTriggers wrong order", serv.SearchTriggers, http.StatusBadRequest, "GET", true, requestData{}, requestData{"order": "random"}, ""},
 		{"GetTrigger no id", serv.GetTrigger, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
 		{"GetTrigger non-int id", serv.GetTrigger, http.StatusBadRequest, "GET", true, requestData{"id": "non-int"}, requestData{}, ""},
 		{"DeleteTrigger no id", serv.DeleteTrigger, http.StatusBadRequest, "DELETE", true, requestData{}, requestData{}, ""},
@@ -105,9 +141,66 @@ func TestParameterErrorsTrigger(t *testing.T) {
 		{"RegisterClusterTrigger no link", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test"}, ""},
 		{"RegisterClusterTrigger no reason", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "link": "link"}, ""},
 		{"RegisterClusterTrigger no username", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"reason": "test", "link": "link"}, ""},
+		{"RegisterClusterTrigger wrong expiration", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "expires_at": "tomorrow"}, ""},
+		{"RegisterClusterTrigger past expiration", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link", "expires_at": "2000-01-01T00:00:00Z"}, ""},
+		{"SearchTriggers wrong expired", serv.SearchTriggers, http.StatusBadRequest, "GET", true, requestData{}, requestData{"expired": "yes"}, ""},
+		{"SearchTriggers unknown state", serv.SearchTriggers, http.StatusBadRequest, "GET", true, requestData{}, requestData{"state": "pending,unknown"}, ""},
+		{"GetAllTriggers unknown state", serv.GetAllTriggers, http.StatusBadRequest, "GET", true, requestData{}, requestData{"state": "unknown"}, ""},
+		{"GetClusterTriggers unknown state", serv.GetClusterTriggers, http.StatusBadRequest, "GET", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000"}, requestData{"state": "unknown"}, ""},
+		{"GetTriggerTransitions no id", serv.GetTriggerTransitions, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
+		{"GetTriggerTransitions non-int id", serv.GetTriggerTransitions, http.StatusBadRequest, "GET", true, requestData{"id": "non-int"}, requestData{}, ""},
+		{"RegisterClusterTrigger invalid parameters", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, `{"timeout": 0}`},
+		{"RegisterClusterTrigger non-object parameters", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, `["image"]`},
 	}
 
 	for _, tt := range paramErrorTT {
 		testRequest(t, &tt)
 	}
 }
+
+// TestTriggerStatesFilter tests that triggers can be filtered by their states
+// and that states of triggers created before the lifecycle states have been
+// introduced are derived from the active flag
+func TestTriggerStatesFilter(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	readTriggers := func(url string) []storage.Trigger {
+		recorder := httptest.NewRecorder()
+		serv.GetAllTriggers(recorder, httptest.NewRequest("GET", url, nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var response struct {
+			Triggers []storage.Trigger `json:"triggers"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response.Triggers
+	}
+
+	triggers := readTriggers("/api/v1/client/trigger?state=pending")
+	assert.Len(t, triggers, 2)
+	for _, trigger := range triggers {
+		assert.Equal(t, storage.TriggerPending, trigger.State)
+		assert.Equal(t, 1, trigger.Active)
+	}
+	assert.Len(t, readTriggers("/api/v1/client/trigger?state=cancelled"), 2)
+	assert.Len(t, readTriggers("/api/v1/client/trigger?state=running,succeeded"), 0)
+
+	// cancelled trigger is activated again and the change is recorded
+	request := httptest.NewRequest("PUT", "/api/v1/client/trigger/1/activate?username=admin", nil)
+	recorder := httptest.NewRecorder()
+	serv.ActivateTrigger(recorder, mux.SetURLVars(request, map[string]string{"id": "1"}))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Len(t, readTriggers("/api/v1/client/trigger?state=pending"), 3)
+
+	transitions, err := serv.Storage.ListTriggerTransitions(request.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, transitions, 2)
+	assert.Equal(t, storage.TriggerCancelled, transitions[0].State)
+	assert.Equal(t, storage.TriggerPending, transitions[1].State)
+	assert.Equal(t, "admin", transitions[1].ChangedBy)
+}
@@ -0,0 +1,270 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/server
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/trigger_type.html
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/gorilla/mux"
+)
+
+// GetTriggerTypes method returns list of all trigger types including
+// deprecated ones
+func (s *Server) GetTriggerTypes(writer http.ResponseWriter, request *http.Request) {
+	// try to read list of all trigger types from storage
+	triggerTypes, err := s.Storage.ListTriggerTypes(request.Context())
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("trigger_types", triggerTypes))
+	}
+}
+
+// GetTriggerType method returns single trigger type specified by its name
+func (s *Server) GetTriggerType(writer http.ResponseWriter, request *http.Request) {
+	// trigger type needs to be specified in request parameter
+	triggerType, found := mux.Vars(request)["type"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Trigger type needs to be specified")
+		return
+	}
+
+	// try to read trigger type from storage
+	triggerTypeInfo, err := s.Storage.GetTriggerType(request.Context(), triggerType)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponseWithData("trigger_type", triggerTypeInfo))
+	}
+}
+
+// NewTriggerType method creates new trigger type. JSON schema of trigger
+// parameters can be sent in request body.
+func (s *Server) NewTriggerType(writer http.ResponseWriter, request *http.Request) {
+	// trigger type needs to be specified in request parameter
+	triggerType, found := mux.Vars(request)["type"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Trigger type needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// description needs to be specified in request
+	description, foundDescription := request.URL.Query()["description"]
+	if !foundDescription {
+		TryToSendBadRequestServerResponse(writer, "Description needs to be specified\n")
+		return
+	}
+
+	// parameters schema is optional
+	parametersSchema, err := io.ReadAll(request.Body)
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Parameters schema can not be read from the request body")
+		return
+	}
+
+	// try to record the action NewTriggerType into Splunk
+	err = s.Splunk.LogAction("NewTriggerType", username[0], triggerType)
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to create new trigger type in storage
+	err = s.Storage.NewTriggerType(request.Context(), triggerType, description[0], string(parametersSchema))
+	if err != nil {
+		TryToSendStorageError(writer, err)
+		return
+	}
+
+	triggerTypeInfo, err := s.Storage.GetTriggerType(request.Context(), triggerType)
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendCreatedServerResponse(writer, responses.BuildOkResponseWithData("trigger_type", triggerTypeInfo))
+	}
+}
+
+// ChangeTriggerTypeDescription method changes description of trigger type
+func (s *Server) ChangeTriggerTypeDescription(writer http.ResponseWriter, request *http.Request) {
+	// trigger type needs to be specified in request parameter
+	triggerType, found := mux.Vars(request)["type"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Trigger type needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// description needs to be specified in request
+	description, foundDescription := request.URL.Query()["description"]
+	if !foundDescription {
+		TryToSendBadRequestServerResponse(writer, "Description needs to be specified\n")
+		return
+	}
+
+	// try to record the action ChangeTriggerTypeDescription into Splunk
+	err := s.Splunk.LogAction("ChangeTriggerTypeDescription", username[0], triggerType)
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to change the trigger type in storage
+	err = s.Storage.ChangeTriggerTypeDescription(request.Context(), triggerType, description[0])
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// SetTriggerTypeDefaultTTL method sets default time to live of new triggers
+// of trigger type, zero TTL means that new triggers do not expire
+func (s *Server) SetTriggerTypeDefaultTTL(writer http.ResponseWriter, request *http.Request) {
+	// trigger type needs to be specified in request parameter
+	triggerType, found := mux.Vars(request)["type"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Trigger type needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// TTL needs to be specified in request as duration, for example 168h
+	ttl, err := time.ParseDuration(request.URL.Query().Get("ttl"))
+	if err != nil || ttl < 0 {
+		TryToSendBadRequestServerResponse(writer, "TTL needs to be specified as non-negative duration, for example 168h")
+		return
+	}
+
+	// try to record the action SetTriggerTypeDefaultTTL into Splunk
+	err = s.Splunk.LogAction("SetTriggerTypeDefaultTTL", username[0], triggerType+" "+ttl.String())
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to change the trigger type in storage
+	err = s.Storage.SetTriggerTypeDefaultTTL(request.Context(), triggerType, ttl)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// SetTriggerTypeRequiresCase method sets whether new triggers of trigger
+// type need to be linked to open support case
+func (s *Server) SetTriggerTypeRequiresCase(writer http.ResponseWriter, request *http.Request) {
+	// trigger type needs to be specified in request parameter
+	triggerType, found := mux.Vars(request)["type"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Trigger type needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// flag needs to be specified in request as true or false
+	required, err := strconv.ParseBool(request.URL.Query().Get("required"))
+	if err != nil {
+		TryToSendBadRequestServerResponse(writer, "Required flag needs to be specified as true or false")
+		return
+	}
+
+	// try to record the action SetTriggerTypeRequiresCase into Splunk
+	err = s.Splunk.LogAction("SetTriggerTypeRequiresCase", username[0], triggerType+" "+strconv.FormatBool(required))
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to change the trigger type in storage
+	err = s.Storage.SetTriggerTypeRequiresCase(request.Context(), triggerType, required)
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
+
+// DeprecateTriggerType method marks trigger type as deprecated, so new
+// triggers of this type can not be registered
+func (s *Server) DeprecateTriggerType(writer http.ResponseWriter, request *http.Request) {
+	// trigger type needs to be specified in request parameter
+	triggerType, found := mux.Vars(request)["type"]
+	if !found {
+		TryToSendBadRequestServerResponse(writer, "Trigger type needs to be specified")
+		return
+	}
+
+	// username needs to be specified in request
+	username, foundUsername := request.URL.Query()["username"]
+	if !foundUsername {
+		TryToSendBadRequestServerResponse(writer, "User name needs to be specified\n")
+		return
+	}
+
+	// try to record the action DeprecateTriggerType into Splunk
+	err := s.Splunk.LogAction("DeprecateTriggerType", username[0], triggerType)
+	// and check whether the Splunk operation was successful
+	checkSplunkOperation(err)
+
+	// try to deprecate the trigger type in storage
+	err = s.Storage.DeprecateTriggerType(request.Context(), triggerType, username[0])
+
+	// check if the storage operation has been successful
+	if err != nil {
+		TryToSendStorageError(writer, err)
+	} else {
+		TryToSendOKServerResponse(writer, responses.BuildOkResponse())
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/server/trigger_type_test.html
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestNonErrorsTriggerTypeWithoutData tests OK behaviour with empty DB (schema only)
+func TestNonErrorsTriggerTypeWithoutData(t *testing.T) {
+	serv := MockedIOCServer(t, false)
+	defer serv.Storage.Close()
+
+	nonErrorTT := []testCase{
+		{"GetTriggerTypes OK", serv.GetTriggerTypes, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"GetTriggerType Not Found", serv.GetTriggerType, http.StatusNotFound, "GET", true, requestData{"type": "must-gather"}, requestData{}, ""},
+		{"ChangeTriggerTypeDescription Not Found", serv.ChangeTriggerTypeDescription, http.StatusNotFound, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester", "description": "description"}, ""},
+		{"DeprecateTriggerType Not Found", serv.DeprecateTriggerType, http.StatusNotFound, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester"}, ""},
+		{"NewTriggerType OK", serv.NewTriggerType, http.StatusCreated, "POST", true, requestData{"type": "must-gather"}, requestData{"username": "tester", "description": "description"}, `{"type": "object"}`},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestNonErrorsTriggerTypeWithData tests OK behaviour with mock data
+func TestNonErrorsTriggerTypeWithData(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	nonErrorTT := []testCase{
+		{"GetTriggerTypes OK", serv.GetTriggerTypes, http.StatusOK, "GET", true, requestData{}, requestData{}, ""},
+		{"GetTriggerType OK", serv.GetTriggerType, http.StatusOK, "GET", true, requestData{"type": "must-gather"}, requestData{}, ""},
+		{"NewTriggerType without schema OK", serv.NewTriggerType, http.StatusCreated, "POST", true, requestData{"type": "other"}, requestData{"username": "tester", "description": "description"}, ""},
+		{"NewTriggerType already exists", serv.NewTriggerType, http.StatusConflict, "POST", true, requestData{"type": "must-gather"}, requestData{"username": "tester", "description": "description"}, ""},
+		{"ChangeTriggerTypeDescription OK", serv.ChangeTriggerTypeDescription, http.StatusOK, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester", "description": "new description"}, ""},
+		{"SetTriggerTypeDefaultTTL OK", serv.SetTriggerTypeDefaultTTL, http.StatusOK, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester", "ttl": "72h"}, ""},
+		{"SetTriggerTypeDefaultTTL Not Found", serv.SetTriggerTypeDefaultTTL, http.StatusNotFound, "PUT", true, requestData{"type": "unknown"}, requestData{"username": "tester", "ttl": "72h"}, ""},
+		{"DeprecateTriggerType OK", serv.DeprecateTriggerType, http.StatusOK, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester"}, ""},
+		{"DeprecateTriggerType twice", serv.DeprecateTriggerType, http.StatusNotFound, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester"}, ""},
+		{"RegisterClusterTrigger deprecated type", serv.RegisterClusterTrigger, http.StatusBadRequest, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "must-gather"}, requestData{"username": "tester", "reason": "test", "link": "link"}, ""},
+		{"RegisterClusterTrigger unknown type", serv.RegisterClusterTrigger, http.StatusNotFound, "POST", true, requestData{"cluster": "00000000-0000-0000-0000-000000000000", "trigger": "unknown"}, requestData{"username": "tester", "reason": "test", "link": "link"}, ""},
+	}
+
+	for _, tt := range nonErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestDatabaseErrorTriggerType tests unexpected behaviour by closing DB connection (consistency check)
+func TestDatabaseErrorTriggerType(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+
+	dbErrorTT := []testCase{
+		{"GetTriggerTypes DB error", serv.GetTriggerTypes, http.StatusInternalServerError, "GET", true, requestData{}, requestData{}, ""},
+		{"GetTriggerType DB error", serv.GetTriggerType, http.StatusInternalServerError, "GET", true, requestData{"type": "must-gather"}, requestData{}, ""},
+		{"NewTriggerType DB error", serv.NewTriggerType, http.StatusInternalServerError, "POST", true, requestData{"type": "other"}, requestData{"username": "tester", "description": "description"}, ""},
+		{"ChangeTriggerTypeDescription DB error", serv.ChangeTriggerTypeDescription, http.StatusInternalServerError, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester", "description": "description"}, ""},
+		{"SetTriggerTypeDefaultTTL DB error", serv.SetTriggerTypeDefaultTTL, http.StatusInternalServerError, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester", "ttl": "72h"}, ""},
+		{"DeprecateTriggerType DB error", serv.DeprecateTriggerType, http.StatusInternalServerError, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester"}, ""},
+	}
+
+	serv.Storage.Close()
+
+	for _, tt := range dbErrorTT {
+		testRequest(t, &tt)
+	}
+}
+
+// TestParameterErrorsTriggerType tests wrong request parameters
+func TestParameterErrorsTriggerType(t *testing.T) {
+	serv := MockedIOCServer(t, true)
+	defer serv.Storage.Close()
+
+	paramErrorTT := []testCase{
+		{"GetTriggerType no type", serv.GetTriggerType, http.StatusBadRequest, "GET", true, requestData{}, requestData{}, ""},
+		{"NewTriggerType no type", serv.NewTriggerType, http.StatusBadRequest, "POST", true, requestData{}, requestData{"username": "tester", "description": "description"}, ""},
+		{"NewTriggerType no username", serv.NewTriggerType, http.StatusBadRequest, "POST", true, requestData{"type": "other"}, requestData{"description": "description"}, ""},
+		{"NewTriggerType no description", serv.NewTriggerType, http.StatusBadRequest, "POST", true, requestData{"type": "other"}, requestData{"username": "tester"}, ""},
+		{"NewTriggerType invalid schema", serv.NewTriggerType, http.StatusBadRequest, "POST", true, requestData{"type": "other"}, requestData{"username": "tester", "description": "description"}, `{"type": "unknown"}`},
+		{"ChangeTriggerTypeDescription no type", serv.ChangeTriggerTypeDescription, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester", "description": "description"}, ""},
+		{"ChangeTriggerTypeDescription no username", serv.ChangeTriggerTypeDescription, http.StatusBadRequest, "PUT", true, requestData{"type": "must-gather"}, requestData{"description": "description"}, ""},
+		{"ChangeTriggerTypeDescription no description", serv.ChangeTriggerTypeDescription, http.StatusBadRequest, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester"}, ""},
+		{"SetTriggerTypeDefaultTTL no type", serv.SetTriggerTypeDefaultTTL, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester", "ttl": "72h"}, ""},
+		{"SetTriggerTypeDefaultTTL no username", serv.SetTriggerTypeDefaultTTL, http.StatusBadRequest, "PUT", true, requestData{"type": "must-gather"}, requestData{"ttl": "72h"}, ""},
+		{"SetTriggerTypeDefaultTTL no TTL", serv.SetTriggerTypeDefaultTTL, http.StatusBadRequest, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester"}, ""},
+		{"SetTriggerTypeDefaultTTL negative TTL", serv.SetTriggerTypeDefaultTTL, http.StatusBadRequest, "PUT", true, requestData{"type": "must-gather"}, requestData{"username": "tester", "ttl": "-1h"}, ""},
+		{"DeprecateTriggerType no type", serv.DeprecateTriggerType, http.StatusBadRequest, "PUT", true, requestData{}, requestData{"username": "tester"}, ""},
+		{"DeprecateTriggerType no username", serv.DeprecateTriggerType, http.StatusBadRequest, "PUT", true, requestData{"type": "must-gather"}, requestData{}, ""},
+	}
+
+	for _, tt := range paramErrorTT {
+		testRequest(t, &tt)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/activation_test.html
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestActivationError checks that concurrent activation refused by the unique
+// index on active configurations is reported as conflict
+func TestActivationError(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// in-memory database is not shared between connections
+	db.SetMaxOpenConns(1)
+
+	s := NewFromConnection(db, "sqlite3")
+	defer s.Close()
+	if err := s.MigrateToLatest(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RegisterNewCluster(ctx, "cluster"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "reason", "description", "{}"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// activation that is not preceded by the check violates the index
+	_, err = db.Exec("UPDATE operator_configuration SET active = 1 WHERE id = 1")
+	assert.True(t, isUniqueViolation(err))
+
+	err = activationError(ctx, db, 1, err)
+	if assert.IsType(t, &ActiveConfigurationConflictError{}, err) {
+		assert.Equal(t, ClusterConfigurationID(2), err.(*ActiveConfigurationConflictError).ActiveID)
+	}
+
+	// other errors are kept as they are
+	other := errors.New("other error")
+	assert.Equal(t, other, activationError(ctx, db, 1, other))
+}
+
+// TestIsUniqueViolation checks that unique violations are recognized for
+// PostgreSQL too
+func TestIsUniqueViolation(t *testing.T) {
+	assert.True(t, isUniqueViolation(&pq.Error{Code: "23505"}))
+	assert.False(t, isUniqueViolation(&pq.Error{Code: "23503"}))
+	assert.False(t, isUniqueViolation(errors.New("other error")))
+	assert.False(t, isUniqueViolation(nil))
+}
@@ -0,0 +1,190 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/active_configuration_test.html
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkSingleActiveConfiguration checks that only one configuration of each
+// cluster can be activated
+func checkSingleActiveConfiguration(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+
+	_, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "first", "description", `{"a":1}`)
+	FailOnError(t, err)
+	configurations, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "second", "description", `{"a":2}`)
+	FailOnError(t, err)
+	first := int64(configurations[0].ID)
+	second := int64(configurations[1].ID)
+
+	err = s.EnableOrDisableClusterConfigurationByID(ctx, first, storage.AnyVersion, "1")
+	assert.IsType(t, &storage.ActiveConfigurationConflictError{}, err)
+	assert.Contains(t, err.Error(), "already active")
+
+	// already active configuration can be enabled again
+	FailOnError(t, s.EnableOrDisableClusterConfigurationByID(ctx, second, storage.AnyVersion, "1"))
+	_, err = s.EnableClusterConfiguration(ctx, "cluster", storage.AnyVersion, "user", "reason")
+	FailOnError(t, err)
+
+	configuration, err := s.GetClusterActiveConfiguration(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":2}`, configuration)
+
+	// deleted active configuration can be restored only when no other
+	// configuration of the cluster is active
+	FailOnError(t, s.DeleteClusterConfigurationByID(ctx, second, storage.AnyVersion, "user"))
+	FailOnError(t, s.EnableOrDisableClusterConfigurationByID(ctx, first, storage.AnyVersion, "1"))
+	assert.IsType(t, &storage.ActiveConfigurationConflictError{}, s.RestoreClusterConfiguration(ctx, second))
+
+	FailOnError(t, s.EnableOrDisableClusterConfigurationByID(ctx, first, storage.AnyVersion, "0"))
+	FailOnError(t, s.RestoreClusterConfiguration(ctx, second))
+
+	// nothing to repair
+	repaired, err := s.RepairActiveConfigurations(ctx)
+	FailOnError(t, err)
+	assert.Equal(t, int64(0), repaired)
+}
+
+// activeConfigurationIDs returns IDs of active configurations
+func activeConfigurationIDs(configurations []storage.ClusterConfiguration) []storage.ClusterConfigurationID {
+	active := []storage.ClusterConfigurationID{}
+	for _, configuration := range configurations {
+		if configuration.Active == "1" {
+			active = append(active, configuration.ID)
+		}
+	}
+	return active
+}
+
+// checkClusterConfigurationState checks that the newest configuration of
+// the cluster is enabled and the active one is disabled when the cluster has
+// more configurations
+func checkClusterConfigurationState(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+
+	_, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "first", "description", `{"a":1}`)
+	FailOnError(t, err)
+	configurations, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "second", "description", `{"a":2}`)
+	FailOnError(t, err)
+	first := configurations[0].ID
+	second := configurations[1].ID
+
+	// the newest configuration is active already
+	configurations, err = s.EnableClusterConfiguration(ctx, "cluster", storage.AnyVersion, "user", "enable")
+	FailOnError(t, err)
+	assert.Equal(t, []storage.ClusterConfigurationID{second}, activeConfigurationIDs(configurations))
+
+	configurations, err = s.DisableClusterConfiguration(ctx, "cluster", storage.AnyVersion, "user", "disable")
+	FailOnError(t, err)
+	assert.Empty(t, activeConfigurationIDs(configurations))
+
+	// there is no active configuration to disable
+	_, err = s.DisableClusterConfiguration(ctx, "cluster", storage.AnyVersion, "user", "disable")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// older configuration is disabled when it is the active one
+	_, err = s.RollbackClusterConfiguration(ctx, "cluster", "user", "rollback", int64(first))
+	FailOnError(t, err)
+	configurations, err = s.DisableClusterConfiguration(ctx, "cluster", storage.AnyVersion, "user", "disable")
+	FailOnError(t, err)
+	assert.Empty(t, activeConfigurationIDs(configurations))
+
+	configurations, err = s.EnableClusterConfiguration(ctx, "cluster", storage.AnyVersion, "user", "enable")
+	FailOnError(t, err)
+	assert.Equal(t, []storage.ClusterConfigurationID{second}, activeConfigurationIDs(configurations))
+}
+
+// TestClusterConfigurationState checks enabling and disabling configuration
+// of cluster stored in SQL database
+func TestClusterConfigurationState(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkClusterConfigurationState(t, mockStorage)
+}
+
+// TestMemoryStorageClusterConfigurationState checks enabling and disabling
+// configuration of cluster stored in memory
+func TestMemoryStorageClusterConfigurationState(t *testing.T) {
+	checkClusterConfigurationState(t, storage.NewMemoryStorage())
+}
+
+// TestSingleActiveConfiguration checks that only one configuration of each
+// cluster stored in SQL database can be active
+func TestSingleActiveConfiguration(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkSingleActiveConfiguration(t, mockStorage)
+}
+
+// TestMemoryStorageSingleActiveConfiguration checks that only one
+// configuration of each cluster stored in memory can be active
+func TestMemoryStorageSingleActiveConfiguration(t *testing.T) {
+	checkSingleActiveConfiguration(t, storage.NewMemoryStorage())
+}
+
+// TestMigratedActiveConfigurations checks that all but the newest active
+// configuration of each cluster are deactivated by the migration
+func TestMigratedActiveConfigurations(t *testing.T) {
+	s, db := mustGetSqliteStorage(t, dataSource, false)
+	defer MustCloseStorage(t, s)
+
+	initializeDatabase(t, db)
+
+	statements := []string{
+		`INSERT INTO cluster (id, name) VALUES (1, 'cluster1'), (2, 'cluster2')`,
+		`INSERT INTO configuration_profile (id, configuration, changed_at, changed_by, description)
+VALUES (1, '{"a":1}', '2019-01-01', 'tester', 'cfg'), (2, '{"a":2}', '2019-01-01', 'tester', 'cfg')`,
+		`INSERT INTO operator_configuration (id, cluster, configuration, changed_at, changed_by, active, reason)
+VALUES (1, 1, 1, '2019-01-01', 'tester', 1, 'first'),
+       (2, 1, 2, '2019-01-01', 'tester', 1, 'second'),
+       (3, 1, 1, '2019-01-01', 'tester', 0, 'disabled'),
+       (4, 2, 1, '2019-01-01', 'tester', 1, 'other')`,
+	}
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		FailOnError(t, err)
+	}
+
+	FailOnError(t, s.MigrateToLatest(context.Background()))
+
+	configurations, err := s.ListAllClusterConfigurations(context.Background(), false)
+	FailOnError(t, err)
+	assert.Equal(t, []string{"second", "other"}, activeConfigurations(configurations))
+
+	configuration, err := s.GetClusterActiveConfiguration(context.Background(), "cluster1")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":2}`, configuration)
+
+	// the invariant is enforced by the schema itself
+	_, err = db.Exec(`UPDATE operator_configuration SET active = 1 WHERE id = 1`)
+	assert.Error(t, err)
+}
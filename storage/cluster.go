@@ -36,8 +36,13 @@ import (
 // SearchClusterRequest defines type safe SearchCluster request, it is reused and defines request validation tags
 type SearchClusterRequest struct {
 	utils.Pagination
-	ID   int    `schema:"id"`
-	Name string `schema:"name"`
+	ID              int    `schema:"id"`
+	Name            string `schema:"name"`
+	Selector        string `schema:"selector"`
+	OCPVersion      string `schema:"ocp_version"`
+	Platform        string `schema:"platform"`
+	OperatorVersion string `schema:"operator_version"`
+	Region          string `schema:"region"`
 }
 
 // ClusterQuery is Sql query model for Cluster
@@ -60,6 +65,11 @@ type Cols struct {
 // ClusterCol is type of cluster column
 type ClusterCol Column
 
+// ColumnName returns name of the column used in SQL queries
+func (c ClusterCol) ColumnName() Column {
+	return Column(c)
+}
+
 // cols is filling Cols structure with actual column names
 var clusterColsDef Cols = Cols{
 	ID:   ClusterCol("ID"),
@@ -108,33 +118,57 @@ type Storager interface {
 	Connections() *sql.DB
 	Placeholder() sq.PlaceholderFormat
 	QueryOne(context.Context, []Column, sq.SelectBuilder, func(Column, interface{}) (interface{}, error), interface{}) error
+	QueryMany(context.Context, []Column, sq.SelectBuilder, func(Column, interface{}) (interface{}, error), func() interface{}) error
 }
 
 // ClusterQueryBuilder is just a typed wrapped to build queries more conviniently using only exposed methods
 type ClusterQueryBuilder struct {
-	sb sq.SelectBuilder
+	qb QueryBuilder
 }
 
 // Query exposes typed Cluster queryBuilder
 func (c *ClusterQuery) Query() ClusterQueryBuilder {
-	qb := ClusterQueryBuilder{}
-	builder := sq.StatementBuilder.PlaceholderFormat(c.storage.Placeholder())
-	qb.sb = builder.Select(ColNames(c.selectColumns...)...).From(c.TableName).RunWith(c.storage.Connections())
-	return qb
+	return ClusterQueryBuilder{qb: NewQueryBuilder(c.storage, c.TableName, clusterQueryCols(c.selectColumns)...)}
 }
 
 // Equals add a SQL Where Predicate using AND (if any exists) using Equals (=) operand.
 // Ignores Zero values in values
 // For example: WHERE col = 3
 func (b ClusterQueryBuilder) Equals(col ClusterCol, v interface{}) ClusterQueryBuilder {
-	// We will ignore Zero values and skip condition
-	if utils.ZeroValue(v) {
-		return b
-	}
 	// squirell QueryBuilder is immutable, so after adding new condition it needs to be assigned back for further changes
 	// also only result q := Equals (q from that case) contains change
 	// AND this condition
-	b.sb = b.sb.Where(sq.Eq{string(col): v})
+	b.qb = b.qb.Equals(col, v)
+	return b
+}
+
+// NotDeleted adds a SQL Where Predicate using AND (if any exists) that skips
+// deleted clusters.
+// For example: WHERE deleted_at IS NULL
+func (b ClusterQueryBuilder) NotDeleted() ClusterQueryBuilder {
+	b.qb = b.qb.IsNull(ClusterCol("deleted_at"))
+	return b
+}
+
+// Matching adds SQL Where Predicates using AND (if any exists) that select
+// only clusters with labels that meet all requirements of the label selector.
+// For example: WHERE EXISTS (SELECT 1 FROM cluster_label WHERE ...)
+func (b ClusterQueryBuilder) Matching(selector LabelSelector) ClusterQueryBuilder {
+	for _, requirement := range selector {
+		b.qb.sb = b.qb.sb.Where(requirement.predicate())
+	}
+	return b
+}
+
+// MetadataEquals adds a SQL Where Predicate using AND (if any exists) that
+// selects only clusters with metadata column equal to the value.
+// Ignores empty values
+// For example: WHERE EXISTS (SELECT 1 FROM cluster_metadata WHERE ... AND platform = ?)
+func (b ClusterQueryBuilder) MetadataEquals(col ClusterMetadataCol, value string) ClusterQueryBuilder {
+	if value == "" {
+		return b
+	}
+	b.qb.sb = b.qb.sb.Where(sq.Expr("EXISTS (SELECT 1 FROM cluster_metadata WHERE cluster_metadata.cluster = cluster.ID AND cluster_metadata."+string(col)+" = ?)", value))
 	return b
 }
 
@@ -142,12 +176,7 @@ func (b ClusterQueryBuilder) Equals(col ClusterCol, v interface{}) ClusterQueryB
 // This can be used for paging.
 // It skips Zero values
 func (b ClusterQueryBuilder) WithPaging(limit, offset int) ClusterQueryBuilder {
-	if limit != 0 {
-		b.sb = b.sb.Limit(uint64(limit))
-	}
-	if offset != 0 {
-		b.sb = b.sb.Offset(uint64(offset))
-	}
+	b.qb = b.qb.WithPaging(limit, offset)
 	return b
 }
 
@@ -176,16 +205,65 @@ func (c *ClusterQuery) QueryOne(ctx context.Context, req SearchClusterRequest) (
 	qb := c.Query().
 		Equals(c.Cols.ID, req.ID).
 		Equals(c.Cols.Name, req.Name).
+		NotDeleted().
 		WithPaging(req.Limit, req.Offset)
 
 	cluster := &Cluster{}
-	err := c.storage.QueryOne(ctx, storageCols(c.selectColumns), qb.sb, c.mapCol, cluster)
+	err := c.storage.QueryOne(ctx, storageCols(c.selectColumns), qb.qb.sb, c.mapCol, cluster)
 	if err != nil {
 		return nil, err
 	}
 	return cluster, nil
 }
 
+// QueryMany will query DB with generated command and return all clusters
+// that match the request, including label selector
+func (c *ClusterQuery) QueryMany(ctx context.Context, req SearchClusterRequest) ([]Cluster, error) {
+	if c == nil {
+		panic("ClusterQuery must not be nil. Make sure the Server has a pointer reference to ClusterQuery by calling NewClusterQuery().")
+	}
+	selector, err := ParseLabelSelector(req.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	qb := c.Query().
+		Equals(c.Cols.ID, req.ID).
+		Equals(c.Cols.Name, req.Name).
+		Matching(selector).
+		MetadataEquals(MetadataOCPVersion, req.OCPVersion).
+		MetadataEquals(MetadataPlatform, req.Platform).
+		MetadataEquals(MetadataOperatorVersion, req.OperatorVersion).
+		MetadataEquals(MetadataRegion, req.Region).
+		NotDeleted().
+		WithPaging(req.Limit, req.Offset)
+	return c.queryMany(ctx, qb)
+}
+
+// queryMany reads all clusters selected by the query builder ordered by
+// their IDs
+func (c *ClusterQuery) queryMany(ctx context.Context, qb ClusterQueryBuilder) ([]Cluster, error) {
+	qb.qb = qb.qb.OrderBy(c.Cols.ID, Ascending)
+
+	clusters := []*Cluster{}
+	next := func() interface{} {
+		cluster := &Cluster{}
+		clusters = append(clusters, cluster)
+		return cluster
+	}
+
+	err := c.storage.QueryMany(ctx, storageCols(c.selectColumns), qb.qb.sb, c.mapCol, next)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Cluster{}
+	for _, cluster := range clusters {
+		result = append(result, *cluster)
+	}
+	return result, nil
+}
+
 func storageCols(cols []ClusterCol) []Column {
 	c := []Column{}
 	for _, s := range cols {
@@ -194,6 +272,15 @@ func storageCols(cols []ClusterCol) []Column {
 	return c
 }
 
+// clusterQueryCols converts list of typed ClusterCols to columns accepted by QueryBuilder
+func clusterQueryCols(cols []ClusterCol) []QueryColumn {
+	c := []QueryColumn{}
+	for _, s := range cols {
+		c = append(c, s)
+	}
+	return c
+}
+
 // ColNames Creates a list fo string column names from list of typed ClusterCols
 func ColNames(cols ...ClusterCol) []string {
 	cns := []string{}
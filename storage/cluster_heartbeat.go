@@ -0,0 +1,57 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/cluster_heartbeat.html
+
+import (
+	"database/sql"
+)
+
+// ClusterHeartbeat represents the last call of the operator running on cluster
+//     ClusterID: cluster ID
+//     ClusterName: cluster name
+//     LastSeenAt: timestamp of the last call, it is empty for clusters that have never called the controller
+//     Address: source address of the last call
+//     OperatorVersion: version of the operator reported in the last call
+type ClusterHeartbeat struct {
+	ClusterID       ClusterID   `json:"cluster_id"`
+	ClusterName     ClusterName `json:"cluster_name"`
+	LastSeenAt      string      `json:"last_seen_at"`
+	Address         string      `json:"address"`
+	OperatorVersion string      `json:"operator_version"`
+}
+
+// clusterHeartbeatColumns are columns read by scanClusterHeartbeat, cluster
+// table needs to be joined with cluster_heartbeat table
+const clusterHeartbeatColumns = `cluster.id, cluster.name, cluster_heartbeat.last_seen_at,
+       cluster_heartbeat.address, cluster_heartbeat.operator_version`
+
+// scanClusterHeartbeat reads one heartbeat selected by
+// clusterHeartbeatColumns using the provided scan function of selected row
+func scanClusterHeartbeat(scan func(dest ...interface{}) error) (ClusterHeartbeat, error) {
+	var result ClusterHeartbeat
+	var lastSeenAt, address, operatorVersion sql.NullString
+
+	err := scan(&result.ClusterID, &result.ClusterName, &lastSeenAt, &address, &operatorVersion)
+	result.LastSeenAt = lastSeenAt.String
+	result.Address = address.String
+	result.OperatorVersion = operatorVersion.String
+	return result, err
+}
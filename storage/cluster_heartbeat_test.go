@@ -0,0 +1,92 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/cluster_heartbeat_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkClusterHeartbeats checks that heartbeats of clusters are recorded and
+// that clusters that have not been seen for long time are listed as stale
+func checkClusterHeartbeats(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster1"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster2"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster3"))
+
+	clusters, err := s.ListOfClusters(ctx, false)
+	FailOnError(t, err)
+
+	now := time.Now()
+	FailOnError(t, s.RecordClusterHeartbeat(ctx, "cluster1", "10.0.0.1", "4.12.0", now.Add(-2*time.Hour)))
+	FailOnError(t, s.RecordClusterHeartbeat(ctx, "cluster2", "10.0.0.2", "4.12.0", now.Add(-2*time.Hour)))
+
+	// heartbeat is replaced by the newer one
+	FailOnError(t, s.RecordClusterHeartbeat(ctx, "cluster2", "10.0.0.3", "4.13.0", now))
+
+	heartbeat, err := s.GetClusterHeartbeat(ctx, int(clusters[1].ID))
+	FailOnError(t, err)
+	assert.Equal(t, storage.ClusterName("cluster2"), heartbeat.ClusterName)
+	assert.Equal(t, "10.0.0.3", heartbeat.Address)
+	assert.Equal(t, "4.13.0", heartbeat.OperatorVersion)
+	assert.NotEmpty(t, heartbeat.LastSeenAt)
+
+	// cluster that has never been seen has no heartbeat
+	_, err = s.GetClusterHeartbeat(ctx, int(clusters[2].ID))
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	err = s.RecordClusterHeartbeat(ctx, "unknown", "10.0.0.1", "4.12.0", now)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	stale, err := s.ListStaleClusters(ctx, now.Add(-time.Hour))
+	FailOnError(t, err)
+	assert.Len(t, stale, 2)
+	assert.Equal(t, storage.ClusterName("cluster1"), stale[0].ClusterName)
+	assert.NotEmpty(t, stale[0].LastSeenAt)
+	assert.Equal(t, storage.ClusterName("cluster3"), stale[1].ClusterName)
+	assert.Empty(t, stale[1].LastSeenAt)
+
+	// deleted clusters are not stale
+	FailOnError(t, s.DeleteClusterByName(ctx, "cluster3", "user"))
+	stale, err = s.ListStaleClusters(ctx, now.Add(-time.Hour))
+	FailOnError(t, err)
+	assert.Len(t, stale, 1)
+}
+
+// TestClusterHeartbeats checks heartbeats of clusters stored in SQL database
+func TestClusterHeartbeats(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkClusterHeartbeats(t, mockStorage)
+}
+
+// TestMemoryStorageClusterHeartbeats checks heartbeats of clusters stored in
+// memory
+func TestMemoryStorageClusterHeartbeats(t *testing.T) {
+	checkClusterHeartbeats(t, storage.NewMemoryStorage())
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/cluster_label.html
+
+import (
+	"regexp"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// labelKeyPattern and labelValuePattern define allowed keys and values of
+// cluster labels, they are the same as in Kubernetes
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,251}[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?)?$`)
+)
+
+// LabelOperator represents operator of label selector requirement
+type LabelOperator string
+
+// Operators supported by label selectors
+const (
+	LabelEquals       LabelOperator = "="
+	LabelNotEquals    LabelOperator = "!="
+	LabelExists       LabelOperator = "exists"
+	LabelDoesNotExist LabelOperator = "!"
+)
+
+// LabelRequirement represents one requirement of label selector
+//     Key: label key
+//     Operator: operator used to compare label value
+//     Value: compared value, it is empty for existence checks
+type LabelRequirement struct {
+	Key      string
+	Operator LabelOperator
+	Value    string
+}
+
+// LabelSelector selects clusters by their labels, cluster is selected when
+// all requirements of the selector are met
+type LabelSelector []LabelRequirement
+
+// CheckClusterLabel checks that key and value of cluster label are valid
+func CheckClusterLabel(key, value string) error {
+	if !labelKeyPattern.MatchString(key) {
+		return &InvalidLabelError{Label: key, Reason: "label key needs to consist of alphanumeric characters, '-', '_', '.', or '/'"}
+	}
+	if !labelValuePattern.MatchString(value) {
+		return &InvalidLabelError{Label: key, Reason: "label value needs to consist of alphanumeric characters, '-', '_', or '.'"}
+	}
+	return nil
+}
+
+// ParseLabelSelector converts comma separated list of requirements, for
+// example "env=prod,tier!=free", into LabelSelector. Requirements can use
+// =, ==, and != operators, "key" selects clusters with the label and "!key"
+// selects clusters without the label.
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	result := LabelSelector{}
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var requirement LabelRequirement
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			requirement = LabelRequirement{Key: kv[0], Operator: LabelNotEquals, Value: kv[1]}
+		case strings.Contains(part, "=="):
+			kv := strings.SplitN(part, "==", 2)
+			requirement = LabelRequirement{Key: kv[0], Operator: LabelEquals, Value: kv[1]}
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(part, "=", 2)
+			requirement = LabelRequirement{Key: kv[0], Operator: LabelEquals, Value: kv[1]}
+		case strings.HasPrefix(part, "!"):
+			requirement = LabelRequirement{Key: part[1:], Operator: LabelDoesNotExist}
+		default:
+			requirement = LabelRequirement{Key: part, Operator: LabelExists}
+		}
+		requirement.Key = strings.TrimSpace(requirement.Key)
+		requirement.Value = strings.TrimSpace(requirement.Value)
+
+		if err := CheckClusterLabel(requirement.Key, requirement.Value); err != nil {
+			return nil, err
+		}
+		result = append(result, requirement)
+	}
+	return result, nil
+}
+
+// Matches checks whether labels meet all requirements of the selector. Empty
+// selector matches all labels.
+func (selector LabelSelector) Matches(labels map[string]string) bool {
+	for _, requirement := range selector {
+		value, found := labels[requirement.Key]
+		switch requirement.Operator {
+		case LabelEquals:
+			if !found || value != requirement.Value {
+				return false
+			}
+		case LabelNotEquals:
+			if found && value == requirement.Value {
+				return false
+			}
+		case LabelExists:
+			if !found {
+				return false
+			}
+		case LabelDoesNotExist:
+			if found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String converts the selector back into comma separated list of
+// requirements
+func (selector LabelSelector) String() string {
+	parts := []string{}
+	for _, requirement := range selector {
+		switch requirement.Operator {
+		case LabelExists:
+			parts = append(parts, requirement.Key)
+		case LabelDoesNotExist:
+			parts = append(parts, "!"+requirement.Key)
+		default:
+			parts = append(parts, requirement.Key+string(requirement.Operator)+requirement.Value)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// predicate converts the requirement into SQL predicate that checks labels
+// of clusters selected from cluster table
+func (requirement LabelRequirement) predicate() sq.Sqlizer {
+	const subquery = "EXISTS (SELECT 1 FROM cluster_label WHERE cluster_label.cluster = cluster.ID AND cluster_label.name = ?"
+	switch requirement.Operator {
+	case LabelEquals:
+		return sq.Expr(subquery+" AND cluster_label.value = ?)", requirement.Key, requirement.Value)
+	case LabelNotEquals:
+		return sq.Expr("NOT "+subquery+" AND cluster_label.value = ?)", requirement.Key, requirement.Value)
+	case LabelDoesNotExist:
+		return sq.Expr("NOT "+subquery+")", requirement.Key)
+	default:
+		return sq.Expr(subquery+")", requirement.Key)
+	}
+}
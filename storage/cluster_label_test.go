@@ -0,0 +1,147 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/cluster_label_test.html
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkClusterLabels checks that labels can be set on clusters and that
+// clusters can be selected by label selectors
+func checkClusterLabels(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster1"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster2"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster3"))
+
+	FailOnError(t, s.SetClusterLabel(ctx, "cluster1", "env", "prod"))
+	FailOnError(t, s.SetClusterLabel(ctx, "cluster1", "tier", "free"))
+	FailOnError(t, s.SetClusterLabel(ctx, "cluster2", "env", "stage"))
+	FailOnError(t, s.SetClusterLabel(ctx, "cluster3", "env", "prod"))
+
+	// label value is replaced
+	FailOnError(t, s.SetClusterLabel(ctx, "cluster2", "env", "prod"))
+
+	labels, err := s.ListClusterLabels(ctx, "cluster1")
+	FailOnError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod", "tier": "free"}, labels)
+
+	labels, err = s.ListClusterLabels(ctx, "cluster2")
+	FailOnError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod"}, labels)
+
+	selector, err := storage.ParseLabelSelector("env=prod,tier!=free")
+	FailOnError(t, err)
+	clusters, err := s.ListClustersBySelector(ctx, selector)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 2)
+	assert.Equal(t, storage.ClusterName("cluster2"), clusters[0].Name)
+	assert.Equal(t, storage.ClusterName("cluster3"), clusters[1].Name)
+
+	selector, err = storage.ParseLabelSelector("tier")
+	FailOnError(t, err)
+	clusters, err = s.ListClustersBySelector(ctx, selector)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 1)
+	assert.Equal(t, storage.ClusterName("cluster1"), clusters[0].Name)
+
+	// deleted clusters are not selected
+	FailOnError(t, s.DeleteClusterByName(ctx, "cluster3", "user"))
+	selector, err = storage.ParseLabelSelector("env=prod")
+	FailOnError(t, err)
+	clusters, err = s.ListClustersBySelector(ctx, selector)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 2)
+
+	FailOnError(t, s.DeleteClusterLabel(ctx, "cluster1", "tier"))
+	err = s.DeleteClusterLabel(ctx, "cluster1", "tier")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	err = s.SetClusterLabel(ctx, "cluster1", "env", "not valid")
+	assert.IsType(t, &storage.InvalidLabelError{}, err)
+
+	err = s.SetClusterLabel(ctx, "unknown", "env", "prod")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.ListClusterLabels(ctx, "unknown")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+}
+
+// TestClusterLabels checks cluster labels stored in SQL database
+func TestClusterLabels(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkClusterLabels(t, mockStorage)
+}
+
+// TestMemoryStorageClusterLabels checks cluster labels stored in memory
+func TestMemoryStorageClusterLabels(t *testing.T) {
+	checkClusterLabels(t, storage.NewMemoryStorage())
+}
+
+// TestParseLabelSelector checks parsing of label selectors
+func TestParseLabelSelector(t *testing.T) {
+	selector, err := storage.ParseLabelSelector("env=prod, tier!=free,region==eu,gpu,!legacy")
+	FailOnError(t, err)
+	assert.Equal(t, storage.LabelSelector{
+		{Key: "env", Operator: storage.LabelEquals, Value: "prod"},
+		{Key: "tier", Operator: storage.LabelNotEquals, Value: "free"},
+		{Key: "region", Operator: storage.LabelEquals, Value: "eu"},
+		{Key: "gpu", Operator: storage.LabelExists},
+		{Key: "legacy", Operator: storage.LabelDoesNotExist},
+	}, selector)
+	assert.Equal(t, "env=prod,tier!=free,region=eu,gpu,!legacy", selector.String())
+
+	selector, err = storage.ParseLabelSelector("")
+	FailOnError(t, err)
+	assert.Empty(t, selector)
+
+	_, err = storage.ParseLabelSelector("=prod")
+	assert.IsType(t, &storage.InvalidLabelError{}, err)
+
+	_, err = storage.ParseLabelSelector("env=not valid")
+	assert.IsType(t, &storage.InvalidLabelError{}, err)
+}
+
+// TestLabelSelectorMatches checks that label selectors match cluster labels
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "tier": "free"}
+
+	matching := []string{"", "env=prod", "env", "!region", "region!=eu", "env=prod,tier=free"}
+	for _, s := range matching {
+		selector, err := storage.ParseLabelSelector(s)
+		FailOnError(t, err)
+		assert.True(t, selector.Matches(labels), s)
+	}
+
+	notMatching := []string{"env=stage", "region", "!env", "tier!=free", "env=prod,region=eu"}
+	for _, s := range notMatching {
+		selector, err := storage.ParseLabelSelector(s)
+		FailOnError(t, err)
+		assert.False(t, selector.Matches(labels), s)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/cluster_metadata.html
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ClusterMetadata represents metadata of cluster reported by the operator
+// during registration
+//     OCPVersion: version of OpenShift Container Platform
+//     Platform: infrastructure platform, for example AWS
+//     OperatorVersion: version of insights operator
+//     Region: region the cluster is running in
+//     NodeCount: number of cluster nodes
+//     UpdatedAt: timestamp when the metadata has been reported
+type ClusterMetadata struct {
+	OCPVersion      string `json:"ocp_version"`
+	Platform        string `json:"platform"`
+	OperatorVersion string `json:"operator_version"`
+	Region          string `json:"region"`
+	NodeCount       int    `json:"node_count"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+// ClusterMetadataCol is column of cluster metadata that can be used to
+// search clusters
+type ClusterMetadataCol string
+
+// Columns of cluster metadata that can be used to search clusters
+const (
+	MetadataOCPVersion      ClusterMetadataCol = "ocp_version"
+	MetadataPlatform        ClusterMetadataCol = "platform"
+	MetadataOperatorVersion ClusterMetadataCol = "operator_version"
+	MetadataRegion          ClusterMetadataCol = "region"
+)
+
+// clusterMetadataColumns are columns read by scanClusterMetadata
+const clusterMetadataColumns = "ocp_version, platform, operator_version, region, node_count, updated_at"
+
+// CheckClusterMetadata checks that the metadata can be stored with cluster
+func CheckClusterMetadata(clusterName string, metadata ClusterMetadata) error {
+	if metadata.NodeCount < 0 {
+		return &InvalidClusterMetadataError{Cluster: clusterName, Reason: errors.New("node count can not be negative")}
+	}
+	return nil
+}
+
+// scanClusterMetadata reads metadata selected by clusterMetadataColumns
+// using the provided scan function of selected row
+func scanClusterMetadata(scan func(dest ...interface{}) error) (ClusterMetadata, error) {
+	var result ClusterMetadata
+	var ocpVersion, platform, operatorVersion, region, updatedAt sql.NullString
+
+	err := scan(&ocpVersion, &platform, &operatorVersion, &region, &result.NodeCount, &updatedAt)
+	result.OCPVersion = ocpVersion.String
+	result.Platform = platform.String
+	result.OperatorVersion = operatorVersion.String
+	result.Region = region.String
+	result.UpdatedAt = updatedAt.String
+	return result, err
+}
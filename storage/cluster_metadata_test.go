@@ -0,0 +1,160 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/cluster_metadata_test.html
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkClusterMetadata checks that metadata reported by the operator are
+// stored with clusters
+func checkClusterMetadata(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster1"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster2"))
+
+	clusters, err := s.ListOfClusters(ctx, false)
+	FailOnError(t, err)
+
+	FailOnError(t, s.SetClusterMetadata(ctx, "cluster1", storage.ClusterMetadata{
+		OCPVersion: "4.12.0", Platform: "AWS", OperatorVersion: "v4.12.0", Region: "us-east-1", NodeCount: 3,
+	}))
+
+	// metadata reported again replace the previous ones
+	FailOnError(t, s.SetClusterMetadata(ctx, "cluster1", storage.ClusterMetadata{
+		OCPVersion: "4.13.0", Platform: "AWS", OperatorVersion: "v4.13.0", Region: "us-east-1", NodeCount: 5,
+	}))
+
+	metadata, err := s.GetClusterMetadata(ctx, int(clusters[0].ID))
+	FailOnError(t, err)
+	assert.Equal(t, "4.13.0", metadata.OCPVersion)
+	assert.Equal(t, "AWS", metadata.Platform)
+	assert.Equal(t, "v4.13.0", metadata.OperatorVersion)
+	assert.Equal(t, "us-east-1", metadata.Region)
+	assert.Equal(t, 5, metadata.NodeCount)
+	assert.NotEmpty(t, metadata.UpdatedAt)
+
+	// cluster that has not reported metadata
+	_, err = s.GetClusterMetadata(ctx, int(clusters[1].ID))
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	err = s.SetClusterMetadata(ctx, "cluster2", storage.ClusterMetadata{NodeCount: -1})
+	assert.IsType(t, &storage.InvalidClusterMetadataError{}, err)
+
+	err = s.SetClusterMetadata(ctx, "unknown", storage.ClusterMetadata{})
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+}
+
+// TestClusterMetadata checks metadata of clusters stored in SQL database
+func TestClusterMetadata(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkClusterMetadata(t, mockStorage)
+}
+
+// TestMemoryStorageClusterMetadata checks metadata of clusters stored in
+// memory
+func TestMemoryStorageClusterMetadata(t *testing.T) {
+	checkClusterMetadata(t, storage.NewMemoryStorage())
+}
+
+// checkRepeatedRegistration checks that cluster registered repeatedly by the
+// operator is stored only once and that its metadata are kept
+func checkRepeatedRegistration(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "dup"))
+	FailOnError(t, s.SetClusterMetadata(ctx, "dup", storage.ClusterMetadata{Platform: "AWS"}))
+	FailOnError(t, s.RegisterNewCluster(ctx, "dup"))
+
+	clusters, err := s.ListOfClusters(ctx, false)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 1)
+
+	metadata, err := s.GetClusterMetadata(ctx, int(clusters[0].ID))
+	FailOnError(t, err)
+	assert.Equal(t, "AWS", metadata.Platform)
+
+	// names of clusters that are not deleted are unique
+	err = s.CreateNewCluster(ctx, 100, "dup")
+	assert.IsType(t, &storage.ItemAlreadyExistsError{}, err)
+
+	// deleted cluster can be registered again
+	FailOnError(t, s.DeleteClusterByName(ctx, "dup", "admin"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "dup"))
+	clusters, err = s.ListOfClusters(ctx, true)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 2)
+}
+
+// TestRepeatedRegistration checks repeated registration of cluster stored in
+// SQL database
+func TestRepeatedRegistration(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkRepeatedRegistration(t, mockStorage)
+}
+
+// TestMemoryStorageRepeatedRegistration checks repeated registration of
+// cluster stored in memory
+func TestMemoryStorageRepeatedRegistration(t *testing.T) {
+	checkRepeatedRegistration(t, storage.NewMemoryStorage())
+}
+
+// TestSearchClustersByMetadata checks that clusters stored in SQL database
+// can be searched by their metadata
+func TestSearchClustersByMetadata(t *testing.T) {
+	ctx := context.Background()
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	FailOnError(t, mockStorage.RegisterNewCluster(ctx, "cluster1"))
+	FailOnError(t, mockStorage.RegisterNewCluster(ctx, "cluster2"))
+	FailOnError(t, mockStorage.RegisterNewCluster(ctx, "cluster3"))
+	FailOnError(t, mockStorage.SetClusterMetadata(ctx, "cluster1", storage.ClusterMetadata{OCPVersion: "4.12.0", Platform: "AWS"}))
+	FailOnError(t, mockStorage.SetClusterMetadata(ctx, "cluster2", storage.ClusterMetadata{OCPVersion: "4.13.0", Platform: "AWS"}))
+	FailOnError(t, mockStorage.SetClusterLabel(ctx, "cluster2", "env", "prod"))
+
+	query := storage.NewClusterQuery(mockStorage)
+
+	clusters, err := query.QueryMany(ctx, storage.SearchClusterRequest{Platform: "AWS"})
+	FailOnError(t, err)
+	assert.Len(t, clusters, 2)
+
+	clusters, err = query.QueryMany(ctx, storage.SearchClusterRequest{Platform: "AWS", OCPVersion: "4.12.0"})
+	FailOnError(t, err)
+	if assert.Len(t, clusters, 1) {
+		assert.Equal(t, storage.ClusterName("cluster1"), clusters[0].Name)
+	}
+
+	clusters, err = query.QueryMany(ctx, storage.SearchClusterRequest{Platform: "AWS", Selector: "env=prod"})
+	FailOnError(t, err)
+	if assert.Len(t, clusters, 1) {
+		assert.Equal(t, storage.ClusterName("cluster2"), clusters[0].Name)
+	}
+}
@@ -33,7 +33,7 @@ import (
 
 func TestMap(t *testing.T) {
 	c := &Cluster{}
-	cqb := NewClusterQuery(Storage{})
+	cqb := NewClusterQuery(DBStorage{})
 
 	mappedCluster, err := cqb.Map([]ClusterCol{clusterColsDef.ID, clusterColsDef.Name}, c)
 	if err != nil {
@@ -66,19 +66,19 @@ func TestQueryBy(t *testing.T) {
 		{
 			name:  "ID",
 			req:   SearchClusterRequest{ID: 1},
-			query: "SELECT ID, Name FROM cluster WHERE ID = ?",
+			query: "SELECT ID, Name FROM cluster WHERE ID = ? AND deleted_at IS NULL",
 			args:  []interface{}{1},
 		},
 		{
 			name:  "Name",
 			req:   SearchClusterRequest{Name: "name"},
-			query: "SELECT ID, Name FROM cluster WHERE Name = ?",
+			query: "SELECT ID, Name FROM cluster WHERE Name = ? AND deleted_at IS NULL",
 			args:  []interface{}{"name"},
 		},
 		{
 			name:  "NameWithLimitAndOffset",
 			req:   SearchClusterRequest{Name: "my cluster", Pagination: utils.Pagination{Offset: 1, Limit: 100}},
-			query: "SELECT ID, Name FROM cluster WHERE Name = ? LIMIT 100 OFFSET 1",
+			query: "SELECT ID, Name FROM cluster WHERE Name = ? AND deleted_at IS NULL LIMIT 100 OFFSET 1",
 			args:  []interface{}{"my cluster"},
 		},
 	}
@@ -101,6 +101,59 @@ func TestQueryBy(t *testing.T) {
 	}
 }
 
+func TestQueryManyBy(t *testing.T) {
+
+	tcs := []struct {
+		name  string
+		req   SearchClusterRequest
+		query string
+		args  []interface{}
+	}{
+		{
+			name:  "NoSelector",
+			req:   SearchClusterRequest{},
+			query: "SELECT ID, Name FROM cluster WHERE deleted_at IS NULL ORDER BY ID",
+			args:  nil,
+		},
+		{
+			name: "Selector",
+			req:  SearchClusterRequest{Selector: "env=prod,tier!=free,!legacy"},
+			query: "SELECT ID, Name FROM cluster WHERE " +
+				"EXISTS (SELECT 1 FROM cluster_label WHERE cluster_label.cluster = cluster.ID AND cluster_label.name = ? AND cluster_label.value = ?) AND " +
+				"NOT EXISTS (SELECT 1 FROM cluster_label WHERE cluster_label.cluster = cluster.ID AND cluster_label.name = ? AND cluster_label.value = ?) AND " +
+				"NOT EXISTS (SELECT 1 FROM cluster_label WHERE cluster_label.cluster = cluster.ID AND cluster_label.name = ?) AND " +
+				"deleted_at IS NULL ORDER BY ID",
+			args: []interface{}{"env", "prod", "tier", "free", "legacy"},
+		},
+		{
+			name: "Metadata",
+			req:  SearchClusterRequest{Platform: "AWS", Region: "us-east-1"},
+			query: "SELECT ID, Name FROM cluster WHERE " +
+				"EXISTS (SELECT 1 FROM cluster_metadata WHERE cluster_metadata.cluster = cluster.ID AND cluster_metadata.platform = ?) AND " +
+				"EXISTS (SELECT 1 FROM cluster_metadata WHERE cluster_metadata.cluster = cluster.ID AND cluster_metadata.region = ?) AND " +
+				"deleted_at IS NULL ORDER BY ID",
+			args: []interface{}{"AWS", "us-east-1"},
+		},
+	}
+	for _, tt := range tcs {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &TestStorage{}
+			cqb := NewClusterQuery(storage)
+
+			_, err := cqb.QueryMany(context.Background(), tt.req)
+			if err != nil {
+				t.Errorf("querymany failed with error %s", err)
+			}
+			if tt.query != storage.lastQuery {
+				t.Errorf("expected query %s doesn't match actual query %s", tt.query, storage.lastQuery)
+			}
+			if !reflect.DeepEqual(tt.args, storage.lastArgs) {
+				t.Errorf("expected args %s doesn't match actual args %s", tt.args, storage.lastArgs)
+			}
+		})
+	}
+}
+
 type TestStorage struct {
 	lastQuery string
 	lastArgs  []interface{}
@@ -120,4 +173,9 @@ func (s *TestStorage) QueryOne(ctx context.Context, selectCols []Column, selectB
 	return s.lastErr
 }
 
+func (s *TestStorage) QueryMany(ctx context.Context, selectCols []Column, selectBuilder sq.SelectBuilder, mapper func(Column, interface{}) (interface{}, error), next func() interface{}) error {
+	s.lastQuery, s.lastArgs, s.lastErr = selectBuilder.ToSql()
+	return s.lastErr
+}
+
 var _ Storager = (*TestStorage)(nil)
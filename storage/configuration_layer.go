@@ -0,0 +1,272 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/configuration_layer.html
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Names of configuration layers, name of group layer consists of
+// GroupConfigurationLayer prefix and name of the group
+const (
+	DefaultConfigurationLayer = "default"
+	GroupConfigurationLayer   = "group/"
+	ClusterConfigurationLayer = "cluster"
+)
+
+// DefaultConfiguration represents global default configuration profile that
+// is applied to all clusters
+//     Profile: ID of configuration profile
+//     ChangedAt: timestamp of the last change
+//     ChangedBy: username of admin that made the last change
+type DefaultConfiguration struct {
+	Profile   ConfigurationID `json:"profile"`
+	ChangedAt string          `json:"changed_at"`
+	ChangedBy string          `json:"changed_by"`
+}
+
+// ConfigurationGroup represents configuration profile that is applied to all
+// clusters selected by label selector
+//     ID: unique key
+//     Name: unique name of the group
+//     Selector: label selector of clusters in the group
+//     Priority: groups with higher priority override groups with lower one
+//     Profile: ID of configuration profile
+//     ChangedAt: timestamp of the last change
+//     ChangedBy: username of admin that made the last change
+type ConfigurationGroup struct {
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	Selector  string          `json:"selector"`
+	Priority  int             `json:"priority"`
+	Profile   ConfigurationID `json:"profile"`
+	ChangedAt string          `json:"changed_at"`
+	ChangedBy string          `json:"changed_by"`
+}
+
+// ConfigurationLayer represents one layer of configuration of cluster
+//     Name: name of the layer, default, group/<name>, or cluster
+//     Configuration: a JSON structure stored in a string
+type ConfigurationLayer struct {
+	Name          string `json:"name"`
+	Configuration string `json:"configuration"`
+}
+
+// EffectiveConfiguration represents configuration of cluster merged from all
+// its layers
+//     Configuration: the merged JSON structure stored in a string
+//     Layers: merged layers, from the lowest precedence to the highest one
+//     Origins: name of layer that each key comes from, keys are JSON pointers
+type EffectiveConfiguration struct {
+	Configuration string               `json:"configuration"`
+	Layers        []ConfigurationLayer `json:"layers"`
+	Origins       map[string]string    `json:"origins"`
+}
+
+// defaultConfigurationID is ID of the only row of configuration_default table
+const defaultConfigurationID = 1
+
+// configurationGroupColumns are columns of configuration_group table read by
+// scanConfigurationGroup
+const configurationGroupColumns = "id, name, selector, priority, configuration, changed_at, changed_by"
+
+// CheckConfigurationGroupSelector checks label selector of configuration
+// group and returns it in normalized form. Groups need to select clusters
+// by at least one label, global default profile is used for all clusters.
+func CheckConfigurationGroupSelector(selector string) (string, error) {
+	parsed, err := ParseLabelSelector(selector)
+	if err != nil {
+		return "", err
+	}
+	if len(parsed) == 0 {
+		return "", &InvalidLabelError{Label: selector, Reason: "configuration group needs to select clusters by at least one label"}
+	}
+	return parsed.String(), nil
+}
+
+// sortConfigurationGroups sorts groups in the order they are applied, the
+// group with the highest priority is the last one
+func sortConfigurationGroups(groups []ConfigurationGroup) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Priority != groups[j].Priority {
+			return groups[i].Priority < groups[j].Priority
+		}
+		return groups[i].ID < groups[j].ID
+	})
+}
+
+// scanConfigurationGroup reads one configuration group selected by
+// configurationGroupColumns using the provided scan function of selected row
+func scanConfigurationGroup(scan func(dest ...interface{}) error) (ConfigurationGroup, error) {
+	var result ConfigurationGroup
+	var changedAt, changedBy sql.NullString
+
+	err := scan(&result.ID, &result.Name, &result.Selector, &result.Priority, &result.Profile, &changedAt, &changedBy)
+	result.ChangedAt = changedAt.String
+	result.ChangedBy = changedBy.String
+	return result, err
+}
+
+// profileConfigurationLayer reads configuration profile of the layer.
+// Deleted profiles are not used, so false is returned for them.
+func profileConfigurationLayer(ctx context.Context, s Storage, name string, profileID ConfigurationID) (ConfigurationLayer, bool, error) {
+	profile, err := s.GetConfigurationProfile(ctx, int(profileID))
+	if _, notFound := err.(*ItemNotFoundError); notFound {
+		return ConfigurationLayer{}, false, nil
+	}
+	if err != nil {
+		return ConfigurationLayer{}, false, err
+	}
+	return ConfigurationLayer{Name: name, Configuration: profile.Configuration}, true, nil
+}
+
+// ClusterConfigurationLayers returns all configuration layers of cluster
+// specified by its name, from the lowest precedence to the highest one:
+// global default profile, profiles of groups that select the cluster sorted
+// by their priority, and active configuration of the cluster.
+// ItemNotFoundError is returned when no layer is applied to the cluster.
+func ClusterConfigurationLayers(ctx context.Context, s Storage, clusterName string) ([]ConfigurationLayer, error) {
+	// labels are read first as it checks that the cluster exists
+	labels, err := s.ListClusterLabels(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := []ConfigurationLayer{}
+	appendLayer := func(name string, profileID ConfigurationID) error {
+		layer, found, err := profileConfigurationLayer(ctx, s, name, profileID)
+		if found {
+			layers = append(layers, layer)
+		}
+		return err
+	}
+
+	defaultConfiguration, err := s.GetDefaultConfiguration(ctx)
+	if err == nil {
+		err = appendLayer(DefaultConfigurationLayer, defaultConfiguration.Profile)
+	}
+	if _, notFound := err.(*ItemNotFoundError); err != nil && !notFound {
+		return nil, err
+	}
+
+	groups, err := s.ListConfigurationGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		selector, err := ParseLabelSelector(group.Selector)
+		if err != nil || !selector.Matches(labels) {
+			continue
+		}
+		if err := appendLayer(GroupConfigurationLayer+group.Name, group.Profile); err != nil {
+			return nil, err
+		}
+	}
+
+	configuration, err := s.GetClusterActiveConfiguration(ctx, clusterName)
+	if err == nil {
+		layers = append(layers, ConfigurationLayer{Name: ClusterConfigurationLayer, Configuration: configuration})
+	}
+	if _, notFound := err.(*ItemNotFoundError); err != nil && !notFound {
+		return nil, err
+	}
+
+	if len(layers) == 0 {
+		return nil, &ItemNotFoundError{ItemID: clusterName}
+	}
+	return layers, nil
+}
+
+// MergeConfigurationLayers deep-merges JSON objects stored in configuration
+// layers. Layers are merged in the given order, so keys of later layers
+// override keys of the earlier ones. Objects are merged key by key, all other
+// values, including arrays, are replaced as a whole.
+func MergeConfigurationLayers(layers []ConfigurationLayer) (EffectiveConfiguration, error) {
+	var merged interface{}
+	origins := make(map[string]string)
+
+	for _, layer := range layers {
+		var value interface{}
+		if err := json.Unmarshal([]byte(layer.Configuration), &value); err != nil {
+			return EffectiveConfiguration{}, &InvalidConfigurationError{Layer: layer.Name, Reason: err}
+		}
+		merged = mergeConfigurationValue(merged, value, "", layer.Name, origins)
+	}
+
+	configuration, err := json.Marshal(merged)
+	if err != nil {
+		return EffectiveConfiguration{}, err
+	}
+	return EffectiveConfiguration{
+		Configuration: string(configuration),
+		Layers:        layers,
+		Origins:       origins,
+	}, nil
+}
+
+// mergeConfigurationValue merges value from layer into the value merged from
+// previous layers. Origins of all keys that are set by the layer are updated,
+// origins of keys that are replaced are removed.
+func mergeConfigurationValue(merged, value interface{}, pointer, layer string, origins map[string]string) interface{} {
+	mergedObject, mergedIsObject := merged.(map[string]interface{})
+	object, isObject := value.(map[string]interface{})
+
+	if !mergedIsObject || !isObject {
+		removeConfigurationOrigins(origins, pointer)
+		if isObject && len(object) > 0 {
+			for key, item := range object {
+				object[key] = mergeConfigurationValue(nil, item, pointer+"/"+escapeJSONPointer(key), layer, origins)
+			}
+		} else {
+			origins[pointer] = layer
+		}
+		return value
+	}
+
+	// empty object that has been merged before is not a leaf anymore
+	if len(object) > 0 {
+		delete(origins, pointer)
+	}
+	for key, item := range object {
+		mergedObject[key] = mergeConfigurationValue(mergedObject[key], item, pointer+"/"+escapeJSONPointer(key), layer, origins)
+	}
+	return mergedObject
+}
+
+// removeConfigurationOrigins removes origins of key specified by JSON pointer
+// and of all its nested keys
+func removeConfigurationOrigins(origins map[string]string, pointer string) {
+	for key := range origins {
+		if key == pointer || strings.HasPrefix(key, pointer+"/") {
+			delete(origins, key)
+		}
+	}
+}
+
+// escapeJSONPointer escapes object key so it can be used as JSON pointer
+// reference token
+func escapeJSONPointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/configuration_layer_test.html
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// storeProfile stores new configuration profile and returns its ID
+func storeProfile(t *testing.T, s storage.Storage, configuration string) int {
+	profiles, err := s.StoreConfigurationProfile(context.Background(), "user", "description", configuration)
+	FailOnError(t, err)
+	return int(profiles[len(profiles)-1].ID)
+}
+
+// checkConfigurationLayers checks that default and group configuration
+// profiles are merged with configuration of cluster
+func checkConfigurationLayers(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster1"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster2"))
+	FailOnError(t, s.SetClusterLabel(ctx, "cluster1", "env", "prod"))
+
+	// no layer is applied to clusters without configuration
+	_, err := storage.ClusterConfigurationLayers(ctx, s, "cluster1")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+	_, err = s.GetDefaultConfiguration(ctx)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	defaultProfile := storeProfile(t, s, `{"no_op":"X","watch":["a"],"gather":{"period":"2h","logs":true}}`)
+	prodProfile := storeProfile(t, s, `{"gather":{"period":"1h"}}`)
+	fastProfile := storeProfile(t, s, `{"gather":{"period":"10m"}}`)
+
+	// unknown profiles can not be used
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.SetDefaultConfiguration(ctx, 42, "user"))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.NewConfigurationGroup(ctx, "prod", "env=prod", 0, 42, "user"))
+
+	FailOnError(t, s.SetDefaultConfiguration(ctx, defaultProfile, "user"))
+	defaultConfiguration, err := s.GetDefaultConfiguration(ctx)
+	FailOnError(t, err)
+	assert.Equal(t, storage.ConfigurationID(defaultProfile), defaultConfiguration.Profile)
+	assert.Equal(t, "user", defaultConfiguration.ChangedBy)
+
+	// groups need valid non-empty selector and unique name
+	assert.IsType(t, &storage.InvalidLabelError{}, s.NewConfigurationGroup(ctx, "all", "", 0, prodProfile, "user"))
+	assert.IsType(t, &storage.InvalidLabelError{}, s.NewConfigurationGroup(ctx, "invalid", "env=!", 0, prodProfile, "user"))
+	FailOnError(t, s.NewConfigurationGroup(ctx, "fast", "env", 20, fastProfile, "user"))
+	FailOnError(t, s.NewConfigurationGroup(ctx, "prod", "env == prod", 10, prodProfile, "user"))
+	assert.IsType(t, &storage.ItemAlreadyExistsError{}, s.NewConfigurationGroup(ctx, "prod", "env=prod", 0, prodProfile, "user"))
+
+	groups, err := s.ListConfigurationGroups(ctx)
+	FailOnError(t, err)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "prod", groups[0].Name)
+	assert.Equal(t, "env=prod", groups[0].Selector)
+	assert.Equal(t, "fast", groups[1].Name)
+
+	_, err = s.CreateClusterConfiguration(ctx, "cluster1", "user", "reason", "description", `{"no_op":"Y"}`)
+	FailOnError(t, err)
+
+	layers, err := storage.ClusterConfigurationLayers(ctx, s, "cluster1")
+	FailOnError(t, err)
+	assert.Len(t, layers, 4)
+	assert.Equal(t, "default", layers[0].Name)
+	assert.Equal(t, "group/prod", layers[1].Name)
+	assert.Equal(t, "group/fast", layers[2].Name)
+	assert.Equal(t, "cluster", layers[3].Name)
+
+	effective, err := storage.MergeConfigurationLayers(layers)
+	FailOnError(t, err)
+	assert.JSONEq(t, `{"no_op":"Y","watch":["a"],"gather":{"period":"10m","logs":true}}`, effective.Configuration)
+	assert.Equal(t, map[string]string{
+		"/no_op":         "cluster",
+		"/watch":         "default",
+		"/gather/period": "group/fast",
+		"/gather/logs":   "default",
+	}, effective.Origins)
+
+	// cluster without labels and own configuration gets the default profile
+	layers, err = storage.ClusterConfigurationLayers(ctx, s, "cluster2")
+	FailOnError(t, err)
+	assert.Len(t, layers, 1)
+	assert.Equal(t, "default", layers[0].Name)
+
+	// deleted groups and profiles are not applied
+	FailOnError(t, s.DeleteConfigurationGroup(ctx, "fast"))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.DeleteConfigurationGroup(ctx, "fast"))
+	_, err = s.DeleteConfigurationProfile(ctx, prodProfile, storage.AnyVersion, "user")
+	FailOnError(t, err)
+
+	layers, err = storage.ClusterConfigurationLayers(ctx, s, "cluster1")
+	FailOnError(t, err)
+	assert.Len(t, layers, 2)
+
+	FailOnError(t, s.DeleteDefaultConfiguration(ctx))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.DeleteDefaultConfiguration(ctx))
+	_, err = storage.ClusterConfigurationLayers(ctx, s, "cluster2")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// unknown clusters have no configuration
+	_, err = storage.ClusterConfigurationLayers(ctx, s, "unknown")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+}
+
+// TestConfigurationLayers checks configuration layers stored in SQL database
+func TestConfigurationLayers(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkConfigurationLayers(t, mockStorage)
+}
+
+// TestMemoryStorageConfigurationLayers checks configuration layers stored in
+// memory
+func TestMemoryStorageConfigurationLayers(t *testing.T) {
+	checkConfigurationLayers(t, storage.NewMemoryStorage())
+}
+
+// TestMergeConfigurationLayers checks that layers are deep-merged and that
+// origins of replaced keys are updated
+func TestMergeConfigurationLayers(t *testing.T) {
+	effective, err := storage.MergeConfigurationLayers([]storage.ConfigurationLayer{
+		{Name: "default", Configuration: `{"a":{"b":1,"c":2},"d":{},"e/f":3}`},
+		{Name: "group/g", Configuration: `{"a":"replaced","d":{"x":null}}`},
+		{Name: "cluster", Configuration: `{"a":{"z":true},"e/f":[1]}`},
+	})
+	FailOnError(t, err)
+	assert.JSONEq(t, `{"a":{"z":true},"d":{"x":null},"e/f":[1]}`, effective.Configuration)
+	assert.Equal(t, map[string]string{
+		"/a/z":  "cluster",
+		"/d/x":  "group/g",
+		"/e~1f": "cluster",
+	}, effective.Origins)
+	assert.Len(t, effective.Layers, 3)
+
+	_, err = storage.MergeConfigurationLayers([]storage.ConfigurationLayer{
+		{Name: "cluster", Configuration: "not a JSON"},
+	})
+	assert.IsType(t, &storage.InvalidConfigurationError{}, err)
+}
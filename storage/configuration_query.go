@@ -0,0 +1,223 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/configuration_query.html
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RedHatInsights/insights-operator-controller/utils"
+)
+
+// SearchClusterConfigurationRequest defines type safe SearchConfiguration request, it is reused and defines request validation tags
+type SearchClusterConfigurationRequest struct {
+	utils.Pagination
+	ID            int    `schema:"id"`
+	Cluster       string `schema:"cluster"`
+	Configuration int    `schema:"configuration"`
+	Active        string `schema:"active"`
+	Reason        string `schema:"reason"`
+	ChangedBy     string `schema:"changed_by"`
+	ChangedFrom   string `schema:"changed_from"`
+	ChangedTo     string `schema:"changed_to"`
+	OrderBy       string `schema:"order_by"`
+	Order         string `schema:"order"`
+}
+
+// ClusterConfigurationQuery is Sql query model for ClusterConfiguration
+type ClusterConfigurationQuery struct {
+	storage Storager
+
+	Cols          ClusterConfigurationCols
+	selectColumns []ClusterConfigurationCol
+	From          string
+}
+
+// clusterConfigurationFrom contains all tables that need to be joined to read cluster configurations
+var clusterConfigurationFrom = `operator_configuration JOIN cluster ON cluster.id = operator_configuration.cluster`
+
+// ClusterConfigurationCols defines which columns can be used in ClusterConfiguration queries, just for type safe operations with them
+type ClusterConfigurationCols struct {
+	ID               ClusterConfigurationCol
+	Cluster          ClusterConfigurationCol
+	Configuration    ClusterConfigurationCol
+	ChangedAt        ClusterConfigurationCol
+	ChangedBy        ClusterConfigurationCol
+	Active           ClusterConfigurationCol
+	Reason           ClusterConfigurationCol
+	Version          ClusterConfigurationCol
+	Revision         ClusterConfigurationCol
+	EffectiveFrom    ClusterConfigurationCol
+	EffectiveUntil   ClusterConfigurationCol
+	DeletedAt        ClusterConfigurationCol
+	ClusterDeletedAt ClusterConfigurationCol
+}
+
+// ClusterConfigurationCol is type of cluster configuration column
+type ClusterConfigurationCol Column
+
+// ColumnName returns name of the column used in SQL queries
+func (c ClusterConfigurationCol) ColumnName() Column {
+	return Column(c)
+}
+
+// clusterConfigurationColsDef is filling ClusterConfigurationCols structure with actual column names
+var clusterConfigurationColsDef = ClusterConfigurationCols{
+	ID:               ClusterConfigurationCol("operator_configuration.id"),
+	Cluster:          ClusterConfigurationCol("cluster.name"),
+	Configuration:    ClusterConfigurationCol("operator_configuration.configuration"),
+	ChangedAt:        ClusterConfigurationCol("operator_configuration.changed_at"),
+	ChangedBy:        ClusterConfigurationCol("operator_configuration.changed_by"),
+	Active:           ClusterConfigurationCol("operator_configuration.active"),
+	Reason:           ClusterConfigurationCol("operator_configuration.reason"),
+	Version:          ClusterConfigurationCol("operator_configuration.version"),
+	Revision:         ClusterConfigurationCol("operator_configuration.revision"),
+	EffectiveFrom:    ClusterConfigurationCol("operator_configuration.effective_from"),
+	EffectiveUntil:   ClusterConfigurationCol("operator_configuration.effective_until"),
+	DeletedAt:        ClusterConfigurationCol("operator_configuration.deleted_at"),
+	ClusterDeletedAt: ClusterConfigurationCol("cluster.deleted_at"),
+}
+
+var clusterConfigurationCols = []ClusterConfigurationCol{
+	clusterConfigurationColsDef.ID, clusterConfigurationColsDef.Cluster,
+	clusterConfigurationColsDef.Configuration,
+	clusterConfigurationColsDef.ChangedAt, clusterConfigurationColsDef.ChangedBy,
+	clusterConfigurationColsDef.Active, clusterConfigurationColsDef.Reason,
+	clusterConfigurationColsDef.Version, clusterConfigurationColsDef.Revision,
+	clusterConfigurationColsDef.EffectiveFrom, clusterConfigurationColsDef.EffectiveUntil,
+}
+
+// ClusterConfigurationOrderColumns contains columns that cluster
+// configurations can be ordered by, indexed by names used in search requests
+var ClusterConfigurationOrderColumns = map[string]ClusterConfigurationCol{
+	"id":            clusterConfigurationColsDef.ID,
+	"cluster":       clusterConfigurationColsDef.Cluster,
+	"configuration": clusterConfigurationColsDef.Configuration,
+	"changed_at":    clusterConfigurationColsDef.ChangedAt,
+	"changed_by":    clusterConfigurationColsDef.ChangedBy,
+	"active":        clusterConfigurationColsDef.Active,
+}
+
+// NewClusterConfigurationQuery creates new ClusterConfigurationQuery Sql model
+func NewClusterConfigurationQuery(s Storager) *ClusterConfigurationQuery {
+	return &ClusterConfigurationQuery{
+		storage:       s,
+		Cols:          clusterConfigurationColsDef,
+		selectColumns: clusterConfigurationCols,
+		From:          clusterConfigurationFrom,
+	}
+}
+
+// columns converts list of selected ClusterConfigurationCols to columns accepted by QueryBuilder
+func (c *ClusterConfigurationQuery) columns() []QueryColumn {
+	cols := []QueryColumn{}
+	for _, col := range c.selectColumns {
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// Query exposes generic queryBuilder that selects ClusterConfiguration columns
+func (c *ClusterConfigurationQuery) Query() QueryBuilder {
+	return NewQueryBuilder(c.storage, c.From, c.columns()...)
+}
+
+// mapCol defines mapping from type safe column to a struct field in result ClusterConfiguration.
+// Used by db row.Scan
+func (c *ClusterConfigurationQuery) mapCol(storageCol Column, configuration interface{}) (interface{}, error) {
+	col := ClusterConfigurationCol(storageCol)
+	r := configuration.(*ClusterConfiguration)
+	switch col {
+	case clusterConfigurationColsDef.ID:
+		return &r.ID, nil
+	case clusterConfigurationColsDef.Cluster:
+		return &r.Cluster, nil
+	case clusterConfigurationColsDef.Configuration:
+		return &r.Configuration, nil
+	case clusterConfigurationColsDef.ChangedAt:
+		return &r.ChangedAt, nil
+	case clusterConfigurationColsDef.ChangedBy:
+		return &r.ChangedBy, nil
+	case clusterConfigurationColsDef.Active:
+		return &r.Active, nil
+	case clusterConfigurationColsDef.Reason:
+		return &r.Reason, nil
+	case clusterConfigurationColsDef.Version:
+		return &r.Version, nil
+	case clusterConfigurationColsDef.Revision:
+		return &r.Revision, nil
+	case clusterConfigurationColsDef.EffectiveFrom:
+		return nullableString{&r.EffectiveFrom}, nil
+	case clusterConfigurationColsDef.EffectiveUntil:
+		return nullableString{&r.EffectiveUntil}, nil
+	default:
+		return nil, fmt.Errorf("unknown col %s", col)
+	}
+}
+
+// QueryMany will query DB with generated command and return all cluster
+// configurations that match the request. Deleted configurations and
+// configurations of deleted clusters are never returned.
+func (c *ClusterConfigurationQuery) QueryMany(ctx context.Context, req SearchClusterConfigurationRequest) ([]ClusterConfiguration, error) {
+	if c == nil {
+		panic("ClusterConfigurationQuery must not be nil. Make sure the Server has a pointer reference to ClusterConfigurationQuery by calling NewClusterConfigurationQuery().")
+	}
+	orderBy, found := ClusterConfigurationOrderColumns[req.OrderBy]
+	if req.OrderBy == "" {
+		orderBy, found = c.Cols.ID, true
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown col %s", req.OrderBy)
+	}
+
+	qb := c.Query().
+		Equals(c.Cols.ID, req.ID).
+		In(c.Cols.Cluster, SplitValues(req.Cluster)...).
+		Equals(c.Cols.Configuration, req.Configuration).
+		Equals(c.Cols.Active, req.Active).
+		Like(c.Cols.Reason, LikePattern(req.Reason)).
+		Equals(c.Cols.ChangedBy, req.ChangedBy).
+		Range(c.Cols.ChangedAt, req.ChangedFrom, req.ChangedTo).
+		IsNull(c.Cols.DeletedAt).
+		IsNull(c.Cols.ClusterDeletedAt).
+		OrderBy(orderBy, SortOrder(req.Order)).
+		WithPaging(req.Limit, req.Offset)
+
+	configurations := []*ClusterConfiguration{}
+	next := func() interface{} {
+		configuration := &ClusterConfiguration{}
+		configurations = append(configurations, configuration)
+		return configuration
+	}
+
+	err := c.storage.QueryMany(ctx, storageColumns(c.columns()), qb.sb, c.mapCol, next)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []ClusterConfiguration{}
+	for _, configuration := range configurations {
+		result = append(result, *configuration)
+	}
+	return result, nil
+}
@@ -0,0 +1,435 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/configuration_rollout.html
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RolloutState represents state of configuration rollout
+type RolloutState string
+
+// States of configuration rollouts. Running rollouts apply their waves one
+// by one, paused rollouts wait until they are resumed. Aborted and completed
+// rollouts are finished.
+const (
+	RolloutRunning   RolloutState = "running"
+	RolloutPaused    RolloutState = "paused"
+	RolloutAborted   RolloutState = "aborted"
+	RolloutCompleted RolloutState = "completed"
+)
+
+// rolloutTransitions contains states that rollout can move to from its
+// current state
+var rolloutTransitions = map[RolloutState][]RolloutState{
+	RolloutRunning: {RolloutPaused, RolloutAborted, RolloutCompleted},
+	RolloutPaused:  {RolloutRunning, RolloutAborted},
+}
+
+// Statuses of clusters touched by rollout. Configuration of pending cluster
+// has been created, but its operator has not called the controller since
+// then. Operator of seen cluster has called the controller, but it has not
+// read the configuration yet. Acknowledged configuration has been read by
+// the operator.
+const (
+	RolloutClusterPending      = "pending"
+	RolloutClusterSeen         = "seen"
+	RolloutClusterAcknowledged = "acknowledged"
+)
+
+// ConfigurationRollout represents staged rollout of configuration profile to
+// clusters selected by label selector
+//     ID: unique key
+//     Profile: ID of configuration profile
+//     Revision: revision of the profile that is rolled out
+//     Selector: label selector of clusters, empty selector selects all clusters
+//     Waves: percentages of selected clusters that have the profile applied after each wave
+//     CurrentWave: number of the last applied wave, 0 before the first wave is applied
+//     State: state of the rollout
+//     CreatedAt: timestamp of creation
+//     CreatedBy: username of admin that created the rollout
+//     ChangedAt: timestamp of the last change
+//     ChangedBy: username of admin that made the last change
+//     Reason: reason for the rollout
+type ConfigurationRollout struct {
+	ID          int             `json:"id"`
+	Profile     ConfigurationID `json:"profile"`
+	Revision    int             `json:"revision"`
+	Selector    string          `json:"selector"`
+	Waves       []int           `json:"waves"`
+	CurrentWave int             `json:"current_wave"`
+	State       RolloutState    `json:"state"`
+	CreatedAt   string          `json:"created_at"`
+	CreatedBy   string          `json:"created_by"`
+	ChangedAt   string          `json:"changed_at"`
+	ChangedBy   string          `json:"changed_by"`
+	Reason      string          `json:"reason"`
+}
+
+// ConfigurationRolloutCluster represents one cluster touched by rollout
+//     Cluster: cluster name
+//     Wave: number of wave that touched the cluster
+//     Configuration: ID of cluster configuration created by the rollout
+//     AppliedAt: timestamp of creation of the cluster configuration
+//     AckedAt: timestamp of the first read of the configuration by the operator
+//     LastSeenAt: timestamp of the last call of the operator
+//     Status: pending, seen, or acknowledged
+type ConfigurationRolloutCluster struct {
+	Cluster       ClusterName            `json:"cluster"`
+	Wave          int                    `json:"wave"`
+	Configuration ClusterConfigurationID `json:"configuration"`
+	AppliedAt     string                 `json:"applied_at"`
+	AckedAt       string                 `json:"acked_at"`
+	LastSeenAt    string                 `json:"last_seen_at"`
+	Status        string                 `json:"status"`
+}
+
+// ConfigurationRolloutWave represents progress of one wave of rollout
+//     Wave: number of the wave
+//     Percentage: percentage of selected clusters touched after the wave
+//     Clusters: number of clusters touched by the wave
+//     Acknowledged: number of clusters that acknowledged the configuration
+type ConfigurationRolloutWave struct {
+	Wave         int `json:"wave"`
+	Percentage   int `json:"percentage"`
+	Clusters     int `json:"clusters"`
+	Acknowledged int `json:"acknowledged"`
+}
+
+// ConfigurationRolloutStatus represents rollout together with progress of
+// its waves and with all clusters touched by it
+type ConfigurationRolloutStatus struct {
+	Rollout  ConfigurationRollout          `json:"rollout"`
+	Waves    []ConfigurationRolloutWave    `json:"waves"`
+	Clusters []ConfigurationRolloutCluster `json:"clusters"`
+}
+
+// configurationRolloutColumns are columns of configuration_rollout table read
+// by scanConfigurationRollout
+const configurationRolloutColumns = `id, configuration, revision, selector, waves, current_wave, state,
+       created_at, created_by, changed_at, changed_by, reason`
+
+// configurationRolloutClusterColumns are columns read by
+// scanConfigurationRolloutCluster, configuration_rollout_cluster table needs
+// to be joined with cluster, operator_configuration, and cluster_heartbeat
+// tables
+const configurationRolloutClusterColumns = `cluster.name, configuration_rollout_cluster.wave,
+       configuration_rollout_cluster.configuration, configuration_rollout_cluster.applied_at,
+       operator_configuration.acked_at, cluster_heartbeat.last_seen_at,
+       CASE WHEN operator_configuration.acked_at IS NOT NULL THEN '` + RolloutClusterAcknowledged + `'
+            WHEN cluster_heartbeat.last_seen_at >= configuration_rollout_cluster.applied_at THEN '` + RolloutClusterSeen + `'
+            ELSE '` + RolloutClusterPending + `' END`
+
+// ParseRolloutWaves converts comma separated list of percentages, for
+// example "1,10,100", into waves of rollout. Percentages need to be
+// increasing and the last wave needs to touch all selected clusters.
+func ParseRolloutWaves(waves string) ([]int, error) {
+	result := []int{}
+	for _, wave := range strings.Split(waves, ",") {
+		percentage, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(wave), "%"))
+		if err != nil {
+			return nil, &InvalidRolloutError{Waves: waves, Reason: "percentages need to be integers"}
+		}
+		result = append(result, percentage)
+	}
+	if err := CheckRolloutWaves(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CheckRolloutWaves checks that percentages of waves are increasing and that
+// the last wave touches all selected clusters
+func CheckRolloutWaves(waves []int) error {
+	if len(waves) == 0 {
+		return &InvalidRolloutError{Reason: "at least one wave is needed"}
+	}
+	previous := 0
+	for _, percentage := range waves {
+		if percentage <= previous || percentage > 100 {
+			return &InvalidRolloutError{Waves: formatRolloutWaves(waves), Reason: "percentages need to be increasing and between 1 and 100"}
+		}
+		previous = percentage
+	}
+	if previous != 100 {
+		return &InvalidRolloutError{Waves: formatRolloutWaves(waves), Reason: "the last wave needs to touch 100% of clusters"}
+	}
+	return nil
+}
+
+// formatRolloutWaves converts waves of rollout into comma separated list of
+// percentages as stored in configuration_rollout table
+func formatRolloutWaves(waves []int) string {
+	percentages := make([]string, len(waves))
+	for i, percentage := range waves {
+		percentages[i] = strconv.Itoa(percentage)
+	}
+	return strings.Join(percentages, ",")
+}
+
+// CanChangeRolloutState checks whether rollout in state from can move to
+// state to
+func CanChangeRolloutState(from, to RolloutState) bool {
+	for _, state := range rolloutTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// scanConfigurationRollout reads one rollout selected by
+// configurationRolloutColumns using the provided scan function of selected row
+func scanConfigurationRollout(scan func(dest ...interface{}) error) (ConfigurationRollout, error) {
+	var result ConfigurationRollout
+	var waves string
+	var reason sql.NullString
+
+	err := scan(&result.ID, &result.Profile, &result.Revision, &result.Selector, &waves,
+		&result.CurrentWave, &result.State, &result.CreatedAt, &result.CreatedBy,
+		&result.ChangedAt, &result.ChangedBy, &reason)
+	if err != nil {
+		return result, err
+	}
+	result.Reason = reason.String
+	result.Waves, err = ParseRolloutWaves(waves)
+	return result, err
+}
+
+// scanConfigurationRolloutCluster reads one cluster touched by rollout
+// selected by configurationRolloutClusterColumns using the provided scan
+// function of selected row
+func scanConfigurationRolloutCluster(scan func(dest ...interface{}) error) (ConfigurationRolloutCluster, error) {
+	var result ConfigurationRolloutCluster
+	var ackedAt, lastSeenAt sql.NullString
+
+	err := scan(&result.Cluster, &result.Wave, &result.Configuration, &result.AppliedAt,
+		&ackedAt, &lastSeenAt, &result.Status)
+	result.AckedAt = ackedAt.String
+	result.LastSeenAt = lastSeenAt.String
+	return result, err
+}
+
+// activeConfigurationID returns ID of the active configuration from list of
+// cluster configurations
+func activeConfigurationID(configurations []ClusterConfiguration) (ClusterConfigurationID, bool) {
+	for _, configuration := range configurations {
+		if configuration.Active == "1" {
+			return configuration.ID, true
+		}
+	}
+	return 0, false
+}
+
+// applyConfigurationRolloutWave creates configuration for clusters touched
+// by the given wave of rollout. Clusters selected by the rollout are touched
+// in order of their IDs until the percentage of the wave is reached. New
+// configuration is activated and all previous configurations of the cluster
+// are deactivated, as CreateClusterConfiguration does.
+func applyConfigurationRolloutWave(ctx context.Context, s Storage, rollout ConfigurationRollout, wave int, touched []ConfigurationRolloutCluster) error {
+	selector, err := ParseLabelSelector(rollout.Selector)
+	if err != nil {
+		return err
+	}
+	selected, err := s.ListClustersBySelector(ctx, selector)
+	if err != nil {
+		return err
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].ID < selected[j].ID
+	})
+
+	alreadyTouched := make(map[ClusterName]bool)
+	for _, cluster := range touched {
+		alreadyTouched[cluster.Cluster] = true
+	}
+
+	// number of selected clusters that need to be touched after the wave
+	target := (len(selected)*rollout.Waves[wave-1] + 99) / 100
+	count := 0
+	for _, cluster := range selected {
+		if alreadyTouched[cluster.Name] {
+			count++
+		}
+	}
+
+	reason := fmt.Sprintf("rollout %d wave %d: %s", rollout.ID, wave, rollout.Reason)
+	for _, cluster := range selected {
+		if count >= target {
+			break
+		}
+		if alreadyTouched[cluster.Name] {
+			continue
+		}
+
+		configurations, err := s.AssignClusterConfiguration(ctx, string(cluster.Name), rollout.CreatedBy, reason, int(rollout.Profile), rollout.Revision)
+		if err != nil {
+			return err
+		}
+		configurationID, found := activeConfigurationID(configurations)
+		if !found {
+			return &ItemNotFoundError{ItemID: cluster.Name}
+		}
+		err = s.AddConfigurationRolloutCluster(ctx, rollout.ID, string(cluster.Name), wave, configurationID)
+		if err != nil {
+			return err
+		}
+		count++
+	}
+	return nil
+}
+
+// AdvanceConfigurationRollout applies the next wave of running rollout
+// specified by its ID when all clusters touched by the current wave have
+// acknowledged their configuration. Rollout is completed when its last wave
+// is acknowledged. Paused and finished rollouts are not changed.
+func AdvanceConfigurationRollout(ctx context.Context, s Storage, id int) (ConfigurationRollout, error) {
+	rollout, err := s.GetConfigurationRollout(ctx, id)
+	if err != nil || rollout.State != RolloutRunning {
+		return rollout, err
+	}
+
+	touched, err := s.ListConfigurationRolloutClusters(ctx, id)
+	if err != nil {
+		return rollout, err
+	}
+	for _, cluster := range touched {
+		if cluster.Wave == rollout.CurrentWave && cluster.Status != RolloutClusterAcknowledged {
+			return rollout, nil
+		}
+	}
+
+	if rollout.CurrentWave == len(rollout.Waves) {
+		err = s.UpdateConfigurationRollout(ctx, id, RolloutCompleted, rollout.CurrentWave, rollout.CreatedBy)
+	} else {
+		wave := rollout.CurrentWave + 1
+		if err := applyConfigurationRolloutWave(ctx, s, rollout, wave, touched); err != nil {
+			return rollout, err
+		}
+		err = s.UpdateConfigurationRollout(ctx, id, RolloutRunning, wave, rollout.CreatedBy)
+	}
+	if err != nil {
+		return rollout, err
+	}
+	return s.GetConfigurationRollout(ctx, id)
+}
+
+// ChangeConfigurationRolloutState pauses, resumes, or aborts rollout
+// specified by its ID. Resumed rollout is advanced immediately.
+func ChangeConfigurationRolloutState(ctx context.Context, s Storage, id int, state RolloutState, username string) (ConfigurationRollout, error) {
+	rollout, err := s.GetConfigurationRollout(ctx, id)
+	if err != nil {
+		return rollout, err
+	}
+	if !CanChangeRolloutState(rollout.State, state) {
+		return rollout, &InvalidRolloutTransitionError{RolloutID: id, From: rollout.State, To: state}
+	}
+
+	err = s.UpdateConfigurationRollout(ctx, id, state, rollout.CurrentWave, username)
+	if err != nil {
+		return rollout, err
+	}
+	if state == RolloutRunning {
+		return AdvanceConfigurationRollout(ctx, s, id)
+	}
+	return s.GetConfigurationRollout(ctx, id)
+}
+
+// GetConfigurationRolloutStatus returns rollout specified by its ID together
+// with progress of its waves and with all clusters touched by it
+func GetConfigurationRolloutStatus(ctx context.Context, s Storage, id int) (ConfigurationRolloutStatus, error) {
+	rollout, err := s.GetConfigurationRollout(ctx, id)
+	if err != nil {
+		return ConfigurationRolloutStatus{}, err
+	}
+	clusters, err := s.ListConfigurationRolloutClusters(ctx, id)
+	if err != nil {
+		return ConfigurationRolloutStatus{}, err
+	}
+
+	waves := make([]ConfigurationRolloutWave, len(rollout.Waves))
+	for i, percentage := range rollout.Waves {
+		waves[i] = ConfigurationRolloutWave{Wave: i + 1, Percentage: percentage}
+	}
+	for _, cluster := range clusters {
+		if cluster.Wave < 1 || cluster.Wave > len(waves) {
+			continue
+		}
+		waves[cluster.Wave-1].Clusters++
+		if cluster.Status == RolloutClusterAcknowledged {
+			waves[cluster.Wave-1].Acknowledged++
+		}
+	}
+	return ConfigurationRolloutStatus{Rollout: rollout, Waves: waves, Clusters: clusters}, nil
+}
+
+// AdvanceConfigurationRollouts advances all running rollouts, number of
+// rollouts that applied their next wave or that have been completed is
+// returned
+func AdvanceConfigurationRollouts(ctx context.Context, s Storage) (int, error) {
+	rollouts, err := s.ListConfigurationRollouts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	advanced := 0
+	for _, rollout := range rollouts {
+		if rollout.State != RolloutRunning {
+			continue
+		}
+		result, err := AdvanceConfigurationRollout(ctx, s, rollout.ID)
+		if err != nil {
+			return advanced, err
+		}
+		if result.CurrentWave != rollout.CurrentWave || result.State != rollout.State {
+			advanced++
+		}
+	}
+	return advanced, nil
+}
+
+// RunRolloutScheduler periodically advances running rollouts until the
+// context is cancelled
+func RunRolloutScheduler(ctx context.Context, storage Storage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			advanced, err := AdvanceConfigurationRollouts(ctx, storage)
+			if err != nil {
+				log.Println("Unable to advance configuration rollouts", err)
+			} else if advanced > 0 {
+				log.Printf("%d configuration rollouts advanced", advanced)
+			}
+		}
+	}
+}
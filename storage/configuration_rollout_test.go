@@ -0,0 +1,224 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/configuration_rollout_test.html
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// TestParseRolloutWaves checks parsing and validation of rollout waves
+func TestParseRolloutWaves(t *testing.T) {
+	waves, err := storage.ParseRolloutWaves("1%, 10%, 100%")
+	FailOnError(t, err)
+	assert.Equal(t, []int{1, 10, 100}, waves)
+
+	waves, err = storage.ParseRolloutWaves("100")
+	FailOnError(t, err)
+	assert.Equal(t, []int{100}, waves)
+
+	for _, invalid := range []string{"", "x", "10,5,100", "10,10,100", "0,100", "10,50", "50,150"} {
+		_, err := storage.ParseRolloutWaves(invalid)
+		assert.IsType(t, &storage.InvalidRolloutError{}, err, invalid)
+	}
+}
+
+// TestCanChangeRolloutState checks allowed transitions between rollout states
+func TestCanChangeRolloutState(t *testing.T) {
+	assert.True(t, storage.CanChangeRolloutState(storage.RolloutRunning, storage.RolloutPaused))
+	assert.True(t, storage.CanChangeRolloutState(storage.RolloutPaused, storage.RolloutRunning))
+	assert.True(t, storage.CanChangeRolloutState(storage.RolloutPaused, storage.RolloutAborted))
+	assert.False(t, storage.CanChangeRolloutState(storage.RolloutPaused, storage.RolloutPaused))
+	assert.False(t, storage.CanChangeRolloutState(storage.RolloutAborted, storage.RolloutRunning))
+	assert.False(t, storage.CanChangeRolloutState(storage.RolloutCompleted, storage.RolloutAborted))
+}
+
+// ackRolloutWave acknowledges configuration of all clusters touched by the
+// given wave of rollout
+func ackRolloutWave(t *testing.T, s storage.Storage, id, wave int) {
+	ctx := context.Background()
+
+	clusters, err := s.ListConfigurationRolloutClusters(ctx, id)
+	FailOnError(t, err)
+	for _, cluster := range clusters {
+		if cluster.Wave == wave {
+			FailOnError(t, s.AckClusterConfiguration(ctx, string(cluster.Cluster), time.Now()))
+		}
+	}
+}
+
+// checkConfigurationRollout checks staged rollout of configuration profile
+// for any storage implementation
+func checkConfigurationRollout(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	for i := 1; i <= 4; i++ {
+		name := fmt.Sprintf("prod%d", i)
+		FailOnError(t, s.RegisterNewCluster(ctx, name))
+		FailOnError(t, s.SetClusterLabel(ctx, name, "env", "prod"))
+	}
+	FailOnError(t, s.RegisterNewCluster(ctx, "stage"))
+
+	profiles, err := s.StoreConfigurationProfile(ctx, "user", "description", `{"a":1}`)
+	FailOnError(t, err)
+	profileID := int(profiles[len(profiles)-1].ID)
+	_, err = s.ChangeConfigurationProfile(ctx, profileID, storage.AnyVersion, "user", "description", `{"a":2}`)
+	FailOnError(t, err)
+
+	_, err = s.NewConfigurationRollout(ctx, profileID+1, "env=prod", []int{100}, "admin", "reason")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+	_, err = s.NewConfigurationRollout(ctx, profileID, "env=prod", []int{50}, "admin", "reason")
+	assert.IsType(t, &storage.InvalidRolloutError{}, err)
+	_, err = s.NewConfigurationRollout(ctx, profileID, "=prod", []int{100}, "admin", "reason")
+	assert.IsType(t, &storage.InvalidLabelError{}, err)
+
+	rollout, err := s.NewConfigurationRollout(ctx, profileID, "env=prod", []int{25, 100}, "admin", "reason")
+	FailOnError(t, err)
+	assert.Equal(t, storage.RolloutRunning, rollout.State)
+	assert.Equal(t, 2, rollout.Revision)
+	assert.Equal(t, 0, rollout.CurrentWave)
+	assert.Equal(t, []int{25, 100}, rollout.Waves)
+
+	// later revisions of the profile are not rolled out
+	_, err = s.ChangeConfigurationProfile(ctx, profileID, storage.AnyVersion, "user", "description", `{"a":3}`)
+	FailOnError(t, err)
+
+	// the first wave touches one of four selected clusters
+	rollout, err = storage.AdvanceConfigurationRollout(ctx, s, rollout.ID)
+	FailOnError(t, err)
+	assert.Equal(t, 1, rollout.CurrentWave)
+
+	status, err := storage.GetConfigurationRolloutStatus(ctx, s, rollout.ID)
+	FailOnError(t, err)
+	assert.Len(t, status.Clusters, 1)
+	assert.Equal(t, storage.ClusterName("prod1"), status.Clusters[0].Cluster)
+	assert.Equal(t, storage.RolloutClusterPending, status.Clusters[0].Status)
+	assert.Equal(t, []storage.ConfigurationRolloutWave{
+		{Wave: 1, Percentage: 25, Clusters: 1},
+		{Wave: 2, Percentage: 100},
+	}, status.Waves)
+
+	configuration, err := s.GetClusterActiveConfiguration(ctx, "prod1")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":2}`, configuration)
+
+	// the next wave waits until the configuration is acknowledged
+	rollout, err = storage.AdvanceConfigurationRollout(ctx, s, rollout.ID)
+	FailOnError(t, err)
+	assert.Equal(t, 1, rollout.CurrentWave)
+
+	FailOnError(t, s.RecordClusterHeartbeat(ctx, "prod1", "10.0.0.1", "1.0", time.Now().Add(time.Second)))
+	status, err = storage.GetConfigurationRolloutStatus(ctx, s, rollout.ID)
+	FailOnError(t, err)
+	assert.Equal(t, storage.RolloutClusterSeen, status.Clusters[0].Status)
+	assert.NotEmpty(t, status.Clusters[0].LastSeenAt)
+
+	ackRolloutWave(t, s, rollout.ID, 1)
+	status, err = storage.GetConfigurationRolloutStatus(ctx, s, rollout.ID)
+	FailOnError(t, err)
+	assert.Equal(t, storage.RolloutClusterAcknowledged, status.Clusters[0].Status)
+	assert.NotEmpty(t, status.Clusters[0].AckedAt)
+
+	// paused rollout is not advanced
+	rollout, err = storage.ChangeConfigurationRolloutState(ctx, s, rollout.ID, storage.RolloutPaused, "operator")
+	FailOnError(t, err)
+	assert.Equal(t, storage.RolloutPaused, rollout.State)
+	assert.Equal(t, "operator", rollout.ChangedBy)
+
+	advanced, err := storage.AdvanceConfigurationRollouts(ctx, s)
+	FailOnError(t, err)
+	assert.Equal(t, 0, advanced)
+
+	// resumed rollout applies the second wave to remaining clusters
+	rollout, err = storage.ChangeConfigurationRolloutState(ctx, s, rollout.ID, storage.RolloutRunning, "operator")
+	FailOnError(t, err)
+	assert.Equal(t, storage.RolloutRunning, rollout.State)
+	assert.Equal(t, 2, rollout.CurrentWave)
+
+	clusters, err := s.ListConfigurationRolloutClusters(ctx, rollout.ID)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 4)
+	for _, cluster := range clusters[1:] {
+		assert.Equal(t, 2, cluster.Wave)
+		assert.Equal(t, storage.RolloutClusterPending, cluster.Status)
+	}
+
+	configurations, err := s.ListClusterConfiguration(ctx, "prod4", false)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 1)
+	assert.Equal(t, 2, configurations[0].Revision)
+
+	// clusters that are not selected are not touched
+	configurations, err = s.ListClusterConfiguration(ctx, "stage", false)
+	FailOnError(t, err)
+	assert.Empty(t, configurations)
+
+	// rollout is completed when its last wave is acknowledged
+	ackRolloutWave(t, s, rollout.ID, 2)
+	advanced, err = storage.AdvanceConfigurationRollouts(ctx, s)
+	FailOnError(t, err)
+	assert.Equal(t, 1, advanced)
+
+	rollout, err = s.GetConfigurationRollout(ctx, rollout.ID)
+	FailOnError(t, err)
+	assert.Equal(t, storage.RolloutCompleted, rollout.State)
+
+	_, err = storage.ChangeConfigurationRolloutState(ctx, s, rollout.ID, storage.RolloutAborted, "operator")
+	assert.IsType(t, &storage.InvalidRolloutTransitionError{}, err)
+
+	// aborted rollout is never advanced
+	aborted, err := s.NewConfigurationRollout(ctx, profileID, "", []int{100}, "admin", "reason")
+	FailOnError(t, err)
+	aborted, err = storage.ChangeConfigurationRolloutState(ctx, s, aborted.ID, storage.RolloutAborted, "operator")
+	FailOnError(t, err)
+	assert.Equal(t, storage.RolloutAborted, aborted.State)
+
+	aborted, err = storage.AdvanceConfigurationRollout(ctx, s, aborted.ID)
+	FailOnError(t, err)
+	assert.Equal(t, 0, aborted.CurrentWave)
+
+	rollouts, err := s.ListConfigurationRollouts(ctx)
+	FailOnError(t, err)
+	assert.Len(t, rollouts, 2)
+
+	_, err = s.GetConfigurationRollout(ctx, aborted.ID+1)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+	_, err = storage.GetConfigurationRolloutStatus(ctx, s, aborted.ID+1)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+}
+
+// TestConfigurationRollout checks configuration rollouts stored in SQL database
+func TestConfigurationRollout(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkConfigurationRollout(t, mockStorage)
+}
+
+// TestMemoryStorageConfigurationRollout checks configuration rollouts stored in memory
+func TestMemoryStorageConfigurationRollout(t *testing.T) {
+	checkConfigurationRollout(t, storage.NewMemoryStorage())
+}
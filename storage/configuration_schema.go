@@ -0,0 +1,93 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/configuration_schema.html
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/RedHatInsights/insights-operator-controller/utils"
+)
+
+// DefaultConfigurationSchema is JSON schema of operator configuration that
+// is used until another schema is set
+const DefaultConfigurationSchema = `{"type": "object", "properties": {"no_op": {"type": "string"}, "watch": {"type": "array", "items": {"type": "string"}}}}`
+
+// configurationSchemaID is ID of the only row of configuration_schema table
+const configurationSchemaID = 1
+
+// ConfigurationSchema represents JSON schema that configuration profiles and
+// cluster configurations are validated against
+//     Schema: the JSON schema stored in a string
+//     ChangedAt: timestamp of the last change, empty for built-in schema
+//     ChangedBy: username of admin that made the last change
+type ConfigurationSchema struct {
+	Schema    string `json:"schema"`
+	ChangedAt string `json:"changed_at"`
+	ChangedBy string `json:"changed_by"`
+}
+
+// defaultConfigurationSchema returns the built-in schema
+func defaultConfigurationSchema() ConfigurationSchema {
+	return ConfigurationSchema{Schema: DefaultConfigurationSchema}
+}
+
+// CheckConfigurationSchema checks that the schema of operator configuration
+// is valid JSON schema
+func CheckConfigurationSchema(schema string) error {
+	if err := utils.CheckJSONSchema(schema); err != nil {
+		return &ConfigurationValidationError{Violations: []string{err.Error()}}
+	}
+	return nil
+}
+
+// ValidateConfiguration checks that configuration is well-formed JSON
+// document conforming to the schema of operator configuration. Violations
+// are reported with JSON pointer to the invalid value, malformed documents
+// with line and column of the syntax error.
+func ValidateConfiguration(schema, configuration string) error {
+	var value interface{}
+	if err := json.Unmarshal([]byte(configuration), &value); err != nil {
+		return &ConfigurationValidationError{Violations: []string{malformedJSON(configuration, err)}}
+	}
+
+	err := utils.ValidateJSONSchema(schema, configuration)
+	if schemaErr, ok := err.(*utils.JSONSchemaError); ok {
+		return &ConfigurationValidationError{Violations: schemaErr.Violations}
+	}
+	return err
+}
+
+// malformedJSON describes error found by JSON parser, syntax errors are
+// described with line and column of the invalid character
+func malformedJSON(document string, err error) string {
+	syntaxErr, ok := err.(*json.SyntaxError)
+	if !ok {
+		return fmt.Sprintf("malformed JSON: %v", err)
+	}
+
+	// offset points right behind the invalid character
+	parsed := document[:syntaxErr.Offset]
+	line := strings.Count(parsed, "\n") + 1
+	column := len(parsed) - strings.LastIndex(parsed, "\n") - 1
+	return fmt.Sprintf("malformed JSON at line %d, column %d: %v", line, column, err)
+}
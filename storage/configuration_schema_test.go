@@ -0,0 +1,93 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/configuration_schema_test.html
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkConfigurationSchema checks that built-in schema is used until another
+// valid schema is set
+func checkConfigurationSchema(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	schema, err := s.GetConfigurationSchema(ctx)
+	FailOnError(t, err)
+	assert.Equal(t, storage.DefaultConfigurationSchema, schema.Schema)
+	assert.Empty(t, schema.ChangedBy)
+
+	err = s.SetConfigurationSchema(ctx, `{"type": "unknown"}`, "user")
+	assert.IsType(t, &storage.ConfigurationValidationError{}, err)
+
+	FailOnError(t, s.SetConfigurationSchema(ctx, `{"type": "object", "required": ["no_op"]}`, "user"))
+	FailOnError(t, s.SetConfigurationSchema(ctx, `{"type": "object"}`, "admin"))
+
+	schema, err = s.GetConfigurationSchema(ctx)
+	FailOnError(t, err)
+	assert.Equal(t, `{"type": "object"}`, schema.Schema)
+	assert.Equal(t, "admin", schema.ChangedBy)
+	assert.NotEmpty(t, schema.ChangedAt)
+}
+
+// TestConfigurationSchema checks schema of configuration stored in SQL
+// database
+func TestConfigurationSchema(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkConfigurationSchema(t, mockStorage)
+}
+
+// TestMemoryStorageConfigurationSchema checks schema of configuration stored
+// in memory
+func TestMemoryStorageConfigurationSchema(t *testing.T) {
+	checkConfigurationSchema(t, storage.NewMemoryStorage())
+}
+
+// TestValidateConfiguration checks that violations of schema and syntax
+// errors are reported with their position in the document
+func TestValidateConfiguration(t *testing.T) {
+	schema := storage.DefaultConfigurationSchema
+
+	assert.NoError(t, storage.ValidateConfiguration(schema, `{"no_op":"X", "watch":["a","b","c"]}`))
+	assert.NoError(t, storage.ValidateConfiguration(schema, `{"unknown": true}`))
+
+	err := storage.ValidateConfiguration(schema, `{"no_op": 1, "watch": ["a", false]}`)
+	if assert.IsType(t, &storage.ConfigurationValidationError{}, err) {
+		assert.ElementsMatch(t, []string{
+			"/no_op: Invalid type. Expected: string, given: integer",
+			"/watch/1: Invalid type. Expected: string, given: boolean",
+		}, err.(*storage.ConfigurationValidationError).Violations)
+	}
+
+	err = storage.ValidateConfiguration(schema, `["no_op"]`)
+	assert.EqualError(t, err, "Invalid configuration: (root): Invalid type. Expected: object, given: array")
+
+	err = storage.ValidateConfiguration(schema, "{\n  \"no_op\": \"X\",\n  \"watch\": [\"a\",]\n}")
+	assert.EqualError(t, err, "Invalid configuration: malformed JSON at line 3, column 17: invalid character ']' looking for beginning of value")
+
+	err = storage.ValidateConfiguration(schema, "Test config")
+	assert.IsType(t, &storage.ConfigurationValidationError{}, err)
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/configuration_window.html
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Events recorded for cluster configurations with time window. Configuration
+// is activated when its window starts and it expires when its window ends.
+const (
+	ConfigurationActivated = "activated"
+	ConfigurationExpired   = "expired"
+)
+
+// ConfigurationWindow represents time window when cluster configuration is
+// effective. Previous configuration of the cluster is served outside the
+// window.
+//     EffectiveFrom: configuration is not effective before this time, zero time means no restriction
+//     EffectiveUntil: configuration is not effective since this time, zero time means no restriction
+type ConfigurationWindow struct {
+	EffectiveFrom  time.Time
+	EffectiveUntil time.Time
+}
+
+// ConfigurationTransition represents one event recorded for cluster
+// configuration by the scheduler
+type ConfigurationTransition struct {
+	Event     string `json:"event"`
+	ChangedAt string `json:"changed_at"`
+}
+
+// CheckConfigurationWindow checks that the window ends after it starts
+func CheckConfigurationWindow(window ConfigurationWindow) error {
+	if !window.EffectiveFrom.IsZero() && !window.EffectiveUntil.IsZero() &&
+		!window.EffectiveUntil.After(window.EffectiveFrom) {
+		return &InvalidConfigurationWindowError{Reason: "effective_until needs to be after effective_from"}
+	}
+	return nil
+}
+
+// Contains checks whether configuration with the window is effective at the
+// given time
+func (window ConfigurationWindow) Contains(t time.Time) bool {
+	if !window.EffectiveFrom.IsZero() && t.Before(window.EffectiveFrom) {
+		return false
+	}
+	if !window.EffectiveUntil.IsZero() && !t.Before(window.EffectiveUntil) {
+		return false
+	}
+	return true
+}
+
+// nullableTime converts timestamp into value stored in SQL database, NULL is
+// stored for zero time
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return utcTime(t)
+}
+
+// RunConfigurationScheduler periodically records activation and expiry of
+// cluster configurations with time window until the context is cancelled
+func RunConfigurationScheduler(ctx context.Context, storage Storage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			recorded, err := storage.RecordConfigurationTransitions(ctx, now)
+			if err != nil {
+				log.Println("Unable to record configuration transitions", err)
+			} else if recorded > 0 {
+				log.Printf("%d configuration transitions recorded", recorded)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/configuration_window_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// TestConfigurationWindowContains checks whether time is inside of
+// configuration window
+func TestConfigurationWindowContains(t *testing.T) {
+	now := time.Now()
+
+	assert.True(t, storage.ConfigurationWindow{}.Contains(now))
+	assert.True(t, storage.ConfigurationWindow{EffectiveFrom: now}.Contains(now))
+	assert.False(t, storage.ConfigurationWindow{EffectiveFrom: now.Add(time.Second)}.Contains(now))
+	assert.False(t, storage.ConfigurationWindow{EffectiveUntil: now}.Contains(now))
+	assert.True(t, storage.ConfigurationWindow{EffectiveFrom: now.Add(-time.Hour), EffectiveUntil: now.Add(time.Hour)}.Contains(now))
+
+	assert.Nil(t, storage.CheckConfigurationWindow(storage.ConfigurationWindow{EffectiveUntil: now}))
+	err := storage.CheckConfigurationWindow(storage.ConfigurationWindow{EffectiveFrom: now, EffectiveUntil: now})
+	assert.IsType(t, &storage.InvalidConfigurationWindowError{}, err)
+}
+
+// checkConfigurationWindow checks that configuration is served only within
+// its time window and that activation and expiry of the configuration are
+// recorded
+func checkConfigurationWindow(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "scheduled"))
+
+	_, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "first", "description", `{"a":1}`)
+	FailOnError(t, err)
+
+	// configuration scheduled to the future is active, but the previous one is served
+	window := storage.ConfigurationWindow{EffectiveFrom: now.Add(time.Hour)}
+	configurations, err := s.CreateScheduledClusterConfiguration(ctx, "cluster", "user", "second", "description", `{"a":2}`, window)
+	FailOnError(t, err)
+	assert.Equal(t, []string{"second"}, activeConfigurations(configurations))
+	assert.NotEmpty(t, configurations[1].EffectiveFrom)
+	assert.Empty(t, configurations[1].EffectiveUntil)
+	id := int64(configurations[1].ID)
+
+	configuration, err := s.GetClusterActiveConfiguration(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":1}`, configuration)
+
+	recorded, err := s.RecordConfigurationTransitions(ctx, now)
+	FailOnError(t, err)
+	assert.Equal(t, int64(0), recorded)
+
+	// activation is recorded only once
+	recorded, err = s.RecordConfigurationTransitions(ctx, now.Add(2*time.Hour))
+	FailOnError(t, err)
+	assert.Equal(t, int64(1), recorded)
+	recorded, err = s.RecordConfigurationTransitions(ctx, now.Add(3*time.Hour))
+	FailOnError(t, err)
+	assert.Equal(t, int64(0), recorded)
+
+	transitions, err := s.ListConfigurationTransitions(ctx, id)
+	FailOnError(t, err)
+	assert.Len(t, transitions, 1)
+	assert.Equal(t, storage.ConfigurationActivated, transitions[0].Event)
+
+	// configuration is served within its window
+	window = storage.ConfigurationWindow{EffectiveFrom: now.Add(-time.Hour), EffectiveUntil: now.Add(time.Hour)}
+	FailOnError(t, s.SetClusterConfigurationWindow(ctx, id, window))
+	configuration, err = s.GetClusterActiveConfiguration(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":2}`, configuration)
+
+	// and the previous configuration is served again after the window ends
+	window = storage.ConfigurationWindow{EffectiveFrom: now.Add(-2 * time.Hour), EffectiveUntil: now.Add(-time.Hour)}
+	FailOnError(t, s.SetClusterConfigurationWindow(ctx, id, window))
+	configuration, err = s.GetClusterActiveConfiguration(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":1}`, configuration)
+
+	recorded, err = s.RecordConfigurationTransitions(ctx, now)
+	FailOnError(t, err)
+	assert.Equal(t, int64(1), recorded)
+
+	transitions, err = s.ListConfigurationTransitions(ctx, id)
+	FailOnError(t, err)
+	assert.Len(t, transitions, 2)
+	assert.Equal(t, storage.ConfigurationExpired, transitions[1].Event)
+
+	// configuration without window is always served
+	FailOnError(t, s.SetClusterConfigurationWindow(ctx, id, storage.ConfigurationWindow{}))
+	configuration, err = s.GetClusterActiveConfiguration(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":2}`, configuration)
+
+	// there is nothing to fall back to
+	window = storage.ConfigurationWindow{EffectiveFrom: now.Add(time.Hour)}
+	_, err = s.CreateScheduledClusterConfiguration(ctx, "scheduled", "user", "reason", "description", `{"a":3}`, window)
+	FailOnError(t, err)
+	_, err = s.GetClusterActiveConfiguration(ctx, "scheduled")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// configuration rolled back from is never served outside the window
+	FailOnError(t, s.RegisterNewCluster(ctx, "rollback"))
+	configurations, err = s.CreateClusterConfiguration(ctx, "rollback", "user", "good", "description", `{"a":"good"}`)
+	FailOnError(t, err)
+	good := int64(configurations[0].ID)
+	_, err = s.CreateClusterConfiguration(ctx, "rollback", "user", "bad", "description", `{"a":"bad"}`)
+	FailOnError(t, err)
+	configurations, err = s.RollbackClusterConfiguration(ctx, "rollback", "user", "rollback", good)
+	FailOnError(t, err)
+	assert.Equal(t, []string{"rollback"}, activeConfigurations(configurations))
+
+	window = storage.ConfigurationWindow{EffectiveFrom: now.Add(time.Hour)}
+	_, err = s.CreateScheduledClusterConfiguration(ctx, "rollback", "user", "scheduled", "description", `{"a":"scheduled"}`, window)
+	FailOnError(t, err)
+	configuration, err = s.GetClusterActiveConfiguration(ctx, "rollback")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":"good"}`, configuration)
+
+	// invalid windows are refused
+	window = storage.ConfigurationWindow{EffectiveFrom: now, EffectiveUntil: now.Add(-time.Hour)}
+	_, err = s.CreateScheduledClusterConfiguration(ctx, "cluster", "user", "reason", "description", `{"a":4}`, window)
+	assert.IsType(t, &storage.InvalidConfigurationWindowError{}, err)
+	err = s.SetClusterConfigurationWindow(ctx, id, window)
+	assert.IsType(t, &storage.InvalidConfigurationWindowError{}, err)
+
+	err = s.SetClusterConfigurationWindow(ctx, id+42, storage.ConfigurationWindow{})
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+	_, err = s.ListConfigurationTransitions(ctx, id+42)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+}
+
+// TestConfigurationWindow checks configuration windows stored in SQL database
+func TestConfigurationWindow(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkConfigurationWindow(t, mockStorage)
+}
+
+// TestMemoryStorageConfigurationWindow checks configuration windows stored in memory
+func TestMemoryStorageConfigurationWindow(t *testing.T) {
+	checkConfigurationWindow(t, storage.NewMemoryStorage())
+}
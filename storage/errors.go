@@ -21,7 +21,9 @@ package storage
 // https://redhatinsights.github.io/insights-operator-controller/packages/storage/errors.html
 
 import (
+	"context"
 	"fmt"
+	"strings"
 )
 
 // ItemNotFoundError shows that item with provided ItemID wasn't found in storage
@@ -32,3 +34,219 @@ type ItemNotFoundError struct {
 func (e *ItemNotFoundError) Error() string {
 	return fmt.Sprintf("Item with ID %s was not found in the storage", e.ItemID)
 }
+
+// VersionMismatchError shows that item with provided ItemID has not been
+// changed or deleted, because its current version differs from the expected
+// one (i.e. it has been changed by someone else in the meantime)
+type VersionMismatchError struct {
+	ItemID  interface{}
+	Version int
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("Item with ID %v is not at version %d, it has been changed in the meantime", e.ItemID, e.Version)
+}
+
+// ItemAlreadyExistsError shows that item with provided ItemID can not be
+// created, because it is already stored in storage
+type ItemAlreadyExistsError struct {
+	ItemID interface{}
+}
+
+func (e *ItemAlreadyExistsError) Error() string {
+	return fmt.Sprintf("Item with ID %v already exists in the storage", e.ItemID)
+}
+
+// ActiveConfigurationConflictError shows that cluster configuration can not
+// be activated, because other configuration of the same cluster is active
+type ActiveConfigurationConflictError struct {
+	ID       ClusterConfigurationID
+	ActiveID ClusterConfigurationID
+}
+
+func (e *ActiveConfigurationConflictError) Error() string {
+	return fmt.Sprintf("Configuration %d can not be activated, configuration %d of the same cluster is already active", e.ID, e.ActiveID)
+}
+
+// DeprecatedTriggerTypeError shows that new trigger can not be created,
+// because its trigger type has been deprecated
+type DeprecatedTriggerTypeError struct {
+	TriggerType string
+}
+
+func (e *DeprecatedTriggerTypeError) Error() string {
+	return fmt.Sprintf("Trigger type %s is deprecated, new triggers of this type can not be created", e.TriggerType)
+}
+
+// InvalidTriggerTransitionError shows that trigger can not be moved into the
+// requested state from its current state
+type InvalidTriggerTransitionError struct {
+	TriggerID interface{}
+	From      TriggerState
+	To        TriggerState
+}
+
+func (e *InvalidTriggerTransitionError) Error() string {
+	return fmt.Sprintf("Trigger with ID %v can not change its state from %s to %s", e.TriggerID, e.From, e.To)
+}
+
+// InvalidTriggerResultError shows that result reported by the operator can
+// not be stored with trigger
+type InvalidTriggerResultError struct {
+	Reason error
+}
+
+func (e *InvalidTriggerResultError) Error() string {
+	return fmt.Sprintf("Invalid trigger result: %v", e.Reason)
+}
+
+// InvalidSupportCaseError shows that trigger can not be linked to support
+// case, because the case is not registered or it is not open, or because the
+// trigger type requires the case and none has been provided
+type InvalidSupportCaseError struct {
+	CaseNumber string
+	Reason     string
+}
+
+func (e *InvalidSupportCaseError) Error() string {
+	if e.CaseNumber == "" {
+		return fmt.Sprintf("Invalid support case: %s", e.Reason)
+	}
+	return fmt.Sprintf("Invalid support case %s: %s", e.CaseNumber, e.Reason)
+}
+
+// InvalidLabelError shows that cluster label can not be set, because its
+// key or value is not valid
+type InvalidLabelError struct {
+	Label  string
+	Reason string
+}
+
+func (e *InvalidLabelError) Error() string {
+	return fmt.Sprintf("Invalid label %s: %s", e.Label, e.Reason)
+}
+
+// InvalidClusterMetadataError shows that metadata reported by the operator
+// can not be stored with cluster
+type InvalidClusterMetadataError struct {
+	Cluster string
+	Reason  error
+}
+
+func (e *InvalidClusterMetadataError) Error() string {
+	return fmt.Sprintf("Invalid metadata of cluster %s: %v", e.Cluster, e.Reason)
+}
+
+// InvalidRolloutError shows that configuration rollout can not be created,
+// because its waves are not valid
+type InvalidRolloutError struct {
+	Waves  string
+	Reason string
+}
+
+func (e *InvalidRolloutError) Error() string {
+	return fmt.Sprintf("Invalid rollout waves %s: %s", e.Waves, e.Reason)
+}
+
+// InvalidRolloutTransitionError shows that configuration rollout can not be
+// moved into the requested state from its current state
+type InvalidRolloutTransitionError struct {
+	RolloutID int
+	From      RolloutState
+	To        RolloutState
+}
+
+func (e *InvalidRolloutTransitionError) Error() string {
+	return fmt.Sprintf("Rollout with ID %d can not change its state from %s to %s", e.RolloutID, e.From, e.To)
+}
+
+// InvalidConfigurationError shows that configuration layer is not a valid
+// JSON structure, so it can not be merged with other layers
+type InvalidConfigurationError struct {
+	Layer  string
+	Reason error
+}
+
+func (e *InvalidConfigurationError) Error() string {
+	return fmt.Sprintf("Invalid configuration in layer %s: %v", e.Layer, e.Reason)
+}
+
+// InvalidConfigurationWindowError shows that time window of cluster
+// configuration is not valid
+type InvalidConfigurationWindowError struct {
+	Reason string
+}
+
+func (e *InvalidConfigurationWindowError) Error() string {
+	return fmt.Sprintf("Invalid configuration window: %s", e.Reason)
+}
+
+// InvalidPageError shows that requested page of listed items is not valid,
+// for example because items can not be sorted by the requested column
+type InvalidPageError struct {
+	Reason string
+}
+
+func (e *InvalidPageError) Error() string {
+	return fmt.Sprintf("Invalid page: %s", e.Reason)
+}
+
+// ConfigurationValidationError shows that configuration is not well-formed
+// JSON document conforming to the schema of operator configuration, or that
+// the schema itself is not valid
+type ConfigurationValidationError struct {
+	Violations []string
+}
+
+func (e *ConfigurationValidationError) Error() string {
+	return fmt.Sprintf("Invalid configuration: %s", strings.Join(e.Violations, "; "))
+}
+
+// InvalidParametersError shows that trigger parameters are not a JSON object
+// or that they do not conform to JSON schema registered for the trigger type
+type InvalidParametersError struct {
+	TriggerType string
+	Reason      error
+}
+
+func (e *InvalidParametersError) Error() string {
+	return fmt.Sprintf("Invalid parameters of trigger type %s: %v", e.TriggerType, e.Reason)
+}
+
+// SchemaVersionError shows that the database schema version differs from the
+// latest version known to the service
+type SchemaVersionError struct {
+	Current SchemaVersion
+	Latest  SchemaVersion
+}
+
+func (e *SchemaVersionError) Error() string {
+	if e.Current > e.Latest {
+		return fmt.Sprintf(
+			"Database schema version %d is newer than the latest known version %d",
+			e.Current, e.Latest)
+	}
+	return fmt.Sprintf(
+		"Database schema version %d is older than the latest version %d, migration is needed",
+		e.Current, e.Latest)
+}
+
+// QueryCancelledError shows that storage operation has not been finished,
+// because it has been cancelled by caller or because its timeout has been
+// exceeded
+type QueryCancelledError struct {
+	Reason error
+}
+
+func (e *QueryCancelledError) Error() string {
+	if e.Reason == context.DeadlineExceeded {
+		return "Storage operation timed out"
+	}
+	return "Storage operation has been cancelled"
+}
+
+// Unwrap returns the reason of cancellation (context.Canceled or
+// context.DeadlineExceeded)
+func (e *QueryCancelledError) Unwrap() error {
+	return e.Reason
+}
@@ -0,0 +1,2816 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/memory_storage.html
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryClusterConfiguration represents one operator_configuration record
+// stored in memory. Cluster and profile are referenced by their IDs the same
+// way as in the SQL schema.
+type memoryClusterConfiguration struct {
+	ID        ClusterConfigurationID
+	Cluster   ClusterID
+	Profile   ConfigurationID
+	ChangedAt time.Time
+	ChangedBy string
+	Active    string
+	Reason    string
+	Version   int
+	DeletedAt time.Time
+	DeletedBy string
+	Revision  int
+	AckedAt   time.Time
+	Window    ConfigurationWindow
+	Previous  ClusterConfigurationID
+
+	Transitions []ConfigurationTransition
+}
+
+// memoryRolloutCluster represents one cluster touched by configuration
+// rollout stored in memory.
+type memoryRolloutCluster struct {
+	Cluster       ClusterID
+	Wave          int
+	Configuration ClusterConfigurationID
+	AppliedAt     time.Time
+}
+
+// memoryTrigger represents one trigger record stored in memory.
+type memoryTrigger struct {
+	ID          TriggerID
+	Type        int
+	Cluster     ClusterID
+	Reason      string
+	Link        string
+	TriggeredAt time.Time
+	TriggeredBy string
+	Parameters  string
+	ExpiresAt   time.Time
+	State       TriggerState
+	Transitions []TriggerTransition
+	Result      *TriggerResult
+	SupportCase int
+}
+
+// memoryTriggerType represents one trigger_type record stored in memory.
+type memoryTriggerType struct {
+	ID               int
+	Type             string
+	Description      string
+	ParametersSchema string
+	DeprecatedAt     string
+	DeprecatedBy     string
+	DefaultTTL       int
+	RequiresCase     bool
+}
+
+// toTriggerType converts trigger type stored in memory into TriggerType
+func (t memoryTriggerType) toTriggerType() TriggerType {
+	return TriggerType{
+		ID:               t.ID,
+		Type:             t.Type,
+		Description:      t.Description,
+		ParametersSchema: t.ParametersSchema,
+		DeprecatedAt:     t.DeprecatedAt,
+		DeprecatedBy:     t.DeprecatedBy,
+		DefaultTTL:       t.DefaultTTL,
+		RequiresCase:     t.RequiresCase,
+	}
+}
+
+// MemoryStorage is an implementation of Storage interface that keeps all
+// data in memory. It is thread-safe and it is useful for demos and tests
+// where no real database is available.
+type MemoryStorage struct {
+	mutex sync.RWMutex
+
+	clusters       map[ClusterID]Cluster
+	profiles       map[ConfigurationID]ConfigurationProfile
+	revisions      map[ConfigurationID][]ConfigurationProfileRevision
+	configurations map[ClusterConfigurationID]memoryClusterConfiguration
+	triggers       map[TriggerID]memoryTrigger
+	triggerTypes   map[int]memoryTriggerType
+	supportCases   map[int]SupportCase
+	clusterLabels  map[ClusterID]map[string]string
+	heartbeats     map[ClusterID]memoryHeartbeat
+	metadata       map[ClusterID]ClusterMetadata
+
+	configurationSchema  *ConfigurationSchema
+	defaultConfiguration *DefaultConfiguration
+	configurationGroups  map[int]ConfigurationGroup
+	rollouts             map[int]ConfigurationRollout
+	rolloutClusters      map[int][]memoryRolloutCluster
+
+	lastClusterID            ClusterID
+	lastProfileID            ConfigurationID
+	lastConfigurationID      ClusterConfigurationID
+	lastTriggerID            TriggerID
+	lastTriggerTypeID        int
+	lastSupportCaseID        int
+	lastConfigurationGroupID int
+	lastRolloutID            int
+}
+
+// make sure MemoryStorage implements the Storage interface
+var _ Storage = (*MemoryStorage)(nil)
+
+// memoryTimeFormat is format used to convert timestamps into strings
+const memoryTimeFormat = time.RFC3339
+
+// isDeletedBefore checks whether the item with the specified deletion
+// timestamp has been deleted before the given time. Empty timestamp is used
+// for items that are not deleted.
+func isDeletedBefore(deletedAt string, t time.Time) bool {
+	if deletedAt == "" {
+		return false
+	}
+	timestamp, err := time.Parse(memoryTimeFormat, deletedAt)
+	return err == nil && timestamp.Before(t)
+}
+
+// NewMemoryStorage function creates and initializes a new instance of
+// MemoryStorage structure with no data.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		clusters:       make(map[ClusterID]Cluster),
+		profiles:       make(map[ConfigurationID]ConfigurationProfile),
+		revisions:      make(map[ConfigurationID][]ConfigurationProfileRevision),
+		configurations: make(map[ClusterConfigurationID]memoryClusterConfiguration),
+		triggers:       make(map[TriggerID]memoryTrigger),
+		triggerTypes:   make(map[int]memoryTriggerType),
+		supportCases:   make(map[int]SupportCase),
+		clusterLabels:  make(map[ClusterID]map[string]string),
+		heartbeats:     make(map[ClusterID]memoryHeartbeat),
+		metadata:       make(map[ClusterID]ClusterMetadata),
+
+		configurationGroups: make(map[int]ConfigurationGroup),
+		rollouts:            make(map[int]ConfigurationRollout),
+		rolloutClusters:     make(map[int][]memoryRolloutCluster),
+	}
+}
+
+// contextError reports cancelled context the same way as DBStorage does.
+// Operations with in-memory storage are fast, so context is checked only
+// before the operation is started.
+func contextError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return &QueryCancelledError{Reason: err}
+	}
+	return nil
+}
+
+// Close method does nothing for in-memory storage, it is provided to satisfy
+// the Storage interface.
+func (storage *MemoryStorage) Close() {
+}
+
+// Ping method always succeeds for in-memory storage unless the context has
+// been cancelled.
+func (storage *MemoryStorage) Ping(ctx context.Context) error {
+	return contextError(ctx)
+}
+
+// ListOfClusters method returns all clusters sorted by their IDs. Deleted
+// clusters are returned only when includeDeleted is set.
+func (storage *MemoryStorage) ListOfClusters(ctx context.Context, includeDeleted bool) ([]Cluster, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	return storage.listOfClusters(includeDeleted), nil
+}
+
+// ListOfClustersPage method returns one page of clusters together with total
+// number of clusters. Deleted clusters are returned only when includeDeleted
+// is set.
+func (storage *MemoryStorage) ListOfClustersPage(ctx context.Context, includeDeleted bool, page Page) ([]Cluster, int, error) {
+	clusters, err := storage.ListOfClusters(ctx, includeDeleted)
+	if err != nil {
+		return []Cluster{}, 0, err
+	}
+
+	items := make([]PageItem, len(clusters))
+	for i := range clusters {
+		items[i] = clusters[i]
+	}
+	items, err = paginateItems(items, clusterPageColumns, page)
+	if err != nil {
+		return []Cluster{}, 0, err
+	}
+
+	result := []Cluster{}
+	for _, item := range items {
+		result = append(result, item.(Cluster))
+	}
+	return result, len(clusters), nil
+}
+
+// listOfClusters returns all clusters sorted by their IDs. Caller needs to
+// hold the lock.
+func (storage *MemoryStorage) listOfClusters(includeDeleted bool) []Cluster {
+	clusters := []Cluster{}
+	for _, cluster := range storage.clusters {
+		if includeDeleted || cluster.DeletedAt == "" {
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].ID < clusters[j].ID
+	})
+	return clusters
+}
+
+// GetCluster method returns the specified cluster. Also see GetClusterByName.
+func (storage *MemoryStorage) GetCluster(ctx context.Context, id int) (Cluster, error) {
+	if err := contextError(ctx); err != nil {
+		return Cluster{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	cluster, found := storage.clusters[ClusterID(id)]
+	if !found || cluster.DeletedAt != "" {
+		return Cluster{}, &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	return cluster, nil
+}
+
+// RegisterNewCluster stores information about new cluster. ID is assigned
+// automatically. Nothing is stored when the cluster is registered already.
+func (storage *MemoryStorage) RegisterNewCluster(ctx context.Context, name string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	// cluster that is registered already is kept
+	if _, err := storage.getClusterByName(name); err == nil {
+		return nil
+	}
+
+	storage.lastClusterID++
+	storage.clusters[storage.lastClusterID] = Cluster{
+		ID:   storage.lastClusterID,
+		Name: ClusterName(name),
+	}
+	return nil
+}
+
+// CreateNewCluster creates a new cluster with specified ID and name.
+func (storage *MemoryStorage) CreateNewCluster(ctx context.Context, id int64, name string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	clusterID := ClusterID(id)
+	if _, found := storage.clusters[clusterID]; found {
+		return fmt.Errorf("cluster with ID %d already exists", id)
+	}
+	if _, err := storage.getClusterByName(name); err == nil {
+		return &ItemAlreadyExistsError{ItemID: name}
+	}
+	storage.clusters[clusterID] = Cluster{
+		ID:   clusterID,
+		Name: ClusterName(name),
+	}
+	if clusterID > storage.lastClusterID {
+		storage.lastClusterID = clusterID
+	}
+	return nil
+}
+
+// DeleteCluster marks cluster with specified ID as deleted.
+func (storage *MemoryStorage) DeleteCluster(ctx context.Context, id int64, username string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	clusterID := ClusterID(id)
+	cluster, found := storage.clusters[clusterID]
+	if !found || cluster.DeletedAt != "" {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	cluster.DeletedAt = utcTime(time.Now()).Format(memoryTimeFormat)
+	cluster.DeletedBy = username
+	storage.clusters[clusterID] = cluster
+	return nil
+}
+
+// DeleteClusterByName marks cluster with specified name as deleted.
+func (storage *MemoryStorage) DeleteClusterByName(ctx context.Context, name, username string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	deleted := false
+	for id, cluster := range storage.clusters {
+		if cluster.Name == ClusterName(name) && cluster.DeletedAt == "" {
+			cluster.DeletedAt = utcTime(time.Now()).Format(memoryTimeFormat)
+			cluster.DeletedBy = username
+			storage.clusters[id] = cluster
+			deleted = true
+		}
+	}
+	if !deleted {
+		return &ItemNotFoundError{
+			ItemID: name,
+		}
+	}
+	return nil
+}
+
+// RestoreCluster restores cluster with specified ID that has been deleted.
+// Cluster can not be restored when other cluster with the same name has been
+// registered in the meantime.
+func (storage *MemoryStorage) RestoreCluster(ctx context.Context, id int64) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	clusterID := ClusterID(id)
+	cluster, found := storage.clusters[clusterID]
+	if !found || cluster.DeletedAt == "" {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	for _, other := range storage.clusters {
+		if other.Name == cluster.Name && other.DeletedAt == "" {
+			return &ItemAlreadyExistsError{
+				ItemID: cluster.Name,
+			}
+		}
+	}
+	cluster.DeletedAt = ""
+	cluster.DeletedBy = ""
+	storage.clusters[clusterID] = cluster
+	return nil
+}
+
+// purgeCluster removes the cluster and all records that refer to it (the
+// same as "on delete cascade" in SQL schema). Caller needs to hold the lock.
+func (storage *MemoryStorage) purgeCluster(id ClusterID) {
+	delete(storage.clusters, id)
+	delete(storage.clusterLabels, id)
+	delete(storage.heartbeats, id)
+	delete(storage.metadata, id)
+	for configurationID, configuration := range storage.configurations {
+		if configuration.Cluster == id {
+			delete(storage.configurations, configurationID)
+		}
+	}
+	for triggerID, trigger := range storage.triggers {
+		if trigger.Cluster == id {
+			delete(storage.triggers, triggerID)
+		}
+	}
+}
+
+// GetClusterByName returns a cluster specified by its name. Also see GetCluster.
+func (storage *MemoryStorage) GetClusterByName(ctx context.Context, name string) (Cluster, error) {
+	if err := contextError(ctx); err != nil {
+		return Cluster{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	return storage.getClusterByName(name)
+}
+
+// getClusterByName returns a cluster with the lowest ID that has the
+// specified name. Deleted clusters are skipped. Caller needs to hold the lock.
+func (storage *MemoryStorage) getClusterByName(name string) (Cluster, error) {
+	for _, cluster := range storage.listOfClusters(false) {
+		if cluster.Name == ClusterName(name) {
+			return cluster, nil
+		}
+	}
+	return Cluster{}, &ItemNotFoundError{
+		ItemID: name,
+	}
+}
+
+// ListClusterLabels returns all labels of the cluster specified by its name.
+func (storage *MemoryStorage) ListClusterLabels(ctx context.Context, clusterName string) (map[string]string, error) {
+	if err := contextError(ctx); err != nil {
+		return map[string]string{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	cluster, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	// labels are copied, so they can not be changed by caller
+	labels := make(map[string]string)
+	for key, value := range storage.clusterLabels[cluster.ID] {
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// SetClusterLabel sets value of the label of cluster specified by its name.
+func (storage *MemoryStorage) SetClusterLabel(ctx context.Context, clusterName, key, value string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	if err := CheckClusterLabel(key, value); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	cluster, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return err
+	}
+
+	labels, found := storage.clusterLabels[cluster.ID]
+	if !found {
+		labels = make(map[string]string)
+		storage.clusterLabels[cluster.ID] = labels
+	}
+	labels[key] = value
+	return nil
+}
+
+// DeleteClusterLabel removes the label from cluster specified by its name.
+func (storage *MemoryStorage) DeleteClusterLabel(ctx context.Context, clusterName, key string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	cluster, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return err
+	}
+
+	if _, found := storage.clusterLabels[cluster.ID][key]; !found {
+		return &ItemNotFoundError{
+			ItemID: key,
+		}
+	}
+	delete(storage.clusterLabels[cluster.ID], key)
+	return nil
+}
+
+// ListClustersBySelector returns all clusters (except deleted ones) with
+// labels that meet all requirements of the label selector.
+func (storage *MemoryStorage) ListClustersBySelector(ctx context.Context, selector LabelSelector) ([]Cluster, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	clusters := []Cluster{}
+	for _, cluster := range storage.listOfClusters(false) {
+		if selector.Matches(storage.clusterLabels[cluster.ID]) {
+			clusters = append(clusters, cluster)
+		}
+	}
+	return clusters, nil
+}
+
+// memoryHeartbeat is the last call of the operator running on cluster
+type memoryHeartbeat struct {
+	LastSeenAt      time.Time
+	Address         string
+	OperatorVersion string
+}
+
+// toClusterHeartbeat converts the internal record into ClusterHeartbeat
+// structure. Zero heartbeat is used for clusters that have never been seen.
+func toClusterHeartbeat(cluster Cluster, heartbeat memoryHeartbeat) ClusterHeartbeat {
+	result := ClusterHeartbeat{
+		ClusterID:       cluster.ID,
+		ClusterName:     cluster.Name,
+		Address:         heartbeat.Address,
+		OperatorVersion: heartbeat.OperatorVersion,
+	}
+	if !heartbeat.LastSeenAt.IsZero() {
+		result.LastSeenAt = utcTime(heartbeat.LastSeenAt).Format(memoryTimeFormat)
+	}
+	return result
+}
+
+// RecordClusterHeartbeat records the call of operator running on cluster
+// specified by its name.
+func (storage *MemoryStorage) RecordClusterHeartbeat(ctx context.Context, clusterName, address, operatorVersion string, seenAt time.Time) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	cluster, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return err
+	}
+
+	storage.heartbeats[cluster.ID] = memoryHeartbeat{
+		LastSeenAt:      seenAt,
+		Address:         address,
+		OperatorVersion: operatorVersion,
+	}
+	return nil
+}
+
+// GetClusterHeartbeat returns the last heartbeat of cluster specified by its
+// ID.
+func (storage *MemoryStorage) GetClusterHeartbeat(ctx context.Context, clusterID int) (ClusterHeartbeat, error) {
+	if err := contextError(ctx); err != nil {
+		return ClusterHeartbeat{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	cluster, found := storage.clusters[ClusterID(clusterID)]
+	heartbeat, seen := storage.heartbeats[ClusterID(clusterID)]
+	if !found || cluster.DeletedAt != "" || !seen {
+		return ClusterHeartbeat{}, &ItemNotFoundError{ItemID: clusterID}
+	}
+	return toClusterHeartbeat(cluster, heartbeat), nil
+}
+
+// ListStaleClusters returns all clusters (except deleted ones) that have not
+// been seen since the specified time, including clusters that have never
+// been seen.
+func (storage *MemoryStorage) ListStaleClusters(ctx context.Context, seenBefore time.Time) ([]ClusterHeartbeat, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	heartbeats := []ClusterHeartbeat{}
+	for _, cluster := range storage.listOfClusters(false) {
+		heartbeat := storage.heartbeats[cluster.ID]
+		if heartbeat.LastSeenAt.Before(seenBefore) {
+			heartbeats = append(heartbeats, toClusterHeartbeat(cluster, heartbeat))
+		}
+	}
+	return heartbeats, nil
+}
+
+// SetClusterMetadata stores metadata reported by the operator running on
+// cluster specified by its name.
+func (storage *MemoryStorage) SetClusterMetadata(ctx context.Context, clusterName string, metadata ClusterMetadata) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	if err := CheckClusterMetadata(clusterName, metadata); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	cluster, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return err
+	}
+
+	metadata.UpdatedAt = utcTime(time.Now()).Format(memoryTimeFormat)
+	storage.metadata[cluster.ID] = metadata
+	return nil
+}
+
+// GetClusterMetadata returns metadata of cluster specified by its ID.
+func (storage *MemoryStorage) GetClusterMetadata(ctx context.Context, clusterID int) (ClusterMetadata, error) {
+	if err := contextError(ctx); err != nil {
+		return ClusterMetadata{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	metadata, found := storage.metadata[ClusterID(clusterID)]
+	if !found {
+		return ClusterMetadata{}, &ItemNotFoundError{ItemID: clusterID}
+	}
+	return metadata, nil
+}
+
+// ListConfigurationProfiles returns list of all configuration profiles.
+// Deleted profiles are returned only when includeDeleted is set.
+func (storage *MemoryStorage) ListConfigurationProfiles(ctx context.Context, includeDeleted bool) ([]ConfigurationProfile, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	return storage.listConfigurationProfiles(includeDeleted), nil
+}
+
+// ListConfigurationProfilesPage returns one page of configuration profiles
+// together with total number of profiles. Deleted profiles are returned only
+// when includeDeleted is set.
+func (storage *MemoryStorage) ListConfigurationProfilesPage(ctx context.Context, includeDeleted bool, page Page) ([]ConfigurationProfile, int, error) {
+	profiles, err := storage.ListConfigurationProfiles(ctx, includeDeleted)
+	if err != nil {
+		return []ConfigurationProfile{}, 0, err
+	}
+
+	items := make([]PageItem, len(profiles))
+	for i := range profiles {
+		items[i] = profiles[i]
+	}
+	items, err = paginateItems(items, profilePageColumns, page)
+	if err != nil {
+		return []ConfigurationProfile{}, 0, err
+	}
+
+	result := []ConfigurationProfile{}
+	for _, item := range items {
+		result = append(result, item.(ConfigurationProfile))
+	}
+	return result, len(profiles), nil
+}
+
+// listConfigurationProfiles returns all configuration profiles sorted by
+// their IDs. Caller needs to hold the lock.
+func (storage *MemoryStorage) listConfigurationProfiles(includeDeleted bool) []ConfigurationProfile {
+	profiles := []ConfigurationProfile{}
+	for _, profile := range storage.profiles {
+		if includeDeleted || profile.DeletedAt == "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].ID < profiles[j].ID
+	})
+	return profiles
+}
+
+// GetConfigurationProfile returns one configuration profile identified by its ID.
+func (storage *MemoryStorage) GetConfigurationProfile(ctx context.Context, id int) (ConfigurationProfile, error) {
+	if err := contextError(ctx); err != nil {
+		return ConfigurationProfile{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	profile, found := storage.profiles[ConfigurationID(id)]
+	if !found || profile.DeletedAt != "" {
+		return ConfigurationProfile{}, &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	return profile, nil
+}
+
+// StoreConfigurationProfile stores a given configuration profile.
+func (storage *MemoryStorage) StoreConfigurationProfile(ctx context.Context, username, description, configuration string) ([]ConfigurationProfile, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	storage.insertConfigurationProfile(username, description, configuration)
+	return storage.listConfigurationProfiles(false), nil
+}
+
+// insertConfigurationProfile inserts new configuration profile and returns
+// its ID. Caller needs to hold the lock.
+func (storage *MemoryStorage) insertConfigurationProfile(username, description, configuration string) ConfigurationID {
+	storage.lastProfileID++
+	storage.profiles[storage.lastProfileID] = ConfigurationProfile{
+		ID:            storage.lastProfileID,
+		Configuration: configuration,
+		ChangedAt:     utcTime(time.Now()).Format(memoryTimeFormat),
+		ChangedBy:     username,
+		Description:   description,
+		Version:       1,
+	}
+	storage.insertConfigurationProfileRevision(storage.lastProfileID)
+	return storage.lastProfileID
+}
+
+// insertConfigurationProfileRevision stores the current content of
+// configuration profile as its next revision. Caller needs to hold the lock.
+func (storage *MemoryStorage) insertConfigurationProfileRevision(profileID ConfigurationID) {
+	profile := storage.profiles[profileID]
+	revisions := storage.revisions[profileID]
+	storage.revisions[profileID] = append(revisions, ConfigurationProfileRevision{
+		Profile:       profileID,
+		Revision:      len(revisions) + 1,
+		Configuration: profile.Configuration,
+		ChangedAt:     profile.ChangedAt,
+		ChangedBy:     profile.ChangedBy,
+		Description:   profile.Description,
+	})
+}
+
+// checkVersion checks that item is at the expected version, AnyVersion
+// matches all versions.
+func checkVersion(id interface{}, current, expected int) error {
+	if expected != AnyVersion && current != expected {
+		return &VersionMismatchError{
+			ItemID:  id,
+			Version: expected,
+		}
+	}
+	return nil
+}
+
+// ChangeConfigurationProfile updates the existing configuration profile specified by its ID.
+// Profile is changed only when it is at the expected version (or AnyVersion is used).
+func (storage *MemoryStorage) ChangeConfigurationProfile(ctx context.Context, id, version int, username, description, configuration string) ([]ConfigurationProfile, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	profileID := ConfigurationID(id)
+	profile, found := storage.profiles[profileID]
+	if !found || profile.DeletedAt != "" {
+		return nil, &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	if err := checkVersion(id, profile.Version, version); err != nil {
+		return nil, err
+	}
+
+	profile.Version++
+	profile.Configuration = configuration
+	profile.ChangedAt = utcTime(time.Now()).Format(memoryTimeFormat)
+	profile.ChangedBy = username
+	profile.Description = description
+	storage.profiles[profileID] = profile
+	storage.insertConfigurationProfileRevision(profileID)
+
+	return storage.listConfigurationProfiles(false), nil
+}
+
+// ListConfigurationProfileRevisions returns all revisions of configuration
+// profile specified by its ID, the oldest revision first.
+func (storage *MemoryStorage) ListConfigurationProfileRevisions(ctx context.Context, id int) ([]ConfigurationProfileRevision, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	profileID := ConfigurationID(id)
+	if profile, found := storage.profiles[profileID]; !found || profile.DeletedAt != "" {
+		return []ConfigurationProfileRevision{}, &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+
+	revisions := make([]ConfigurationProfileRevision, len(storage.revisions[profileID]))
+	copy(revisions, storage.revisions[profileID])
+	return revisions, nil
+}
+
+// ListConfigurationProfileRevisionsPage returns one page of revisions of
+// configuration profile specified by its ID together with total number of
+// its revisions.
+func (storage *MemoryStorage) ListConfigurationProfileRevisionsPage(ctx context.Context, id int, page Page) ([]ConfigurationProfileRevision, int, error) {
+	revisions, err := storage.ListConfigurationProfileRevisions(ctx, id)
+	if err != nil {
+		return []ConfigurationProfileRevision{}, 0, err
+	}
+
+	items := make([]PageItem, len(revisions))
+	for i := range revisions {
+		items[i] = revisions[i]
+	}
+	items, err = paginateItems(items, revisionPageColumns, page)
+	if err != nil {
+		return []ConfigurationProfileRevision{}, 0, err
+	}
+
+	result := []ConfigurationProfileRevision{}
+	for _, item := range items {
+		result = append(result, item.(ConfigurationProfileRevision))
+	}
+	return result, len(revisions), nil
+}
+
+// GetConfigurationProfileRevision returns one revision of configuration profile.
+func (storage *MemoryStorage) GetConfigurationProfileRevision(ctx context.Context, id, revision int) (ConfigurationProfileRevision, error) {
+	if err := contextError(ctx); err != nil {
+		return ConfigurationProfileRevision{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	revisions := storage.revisions[ConfigurationID(id)]
+	if revision < 1 || revision > len(revisions) {
+		return ConfigurationProfileRevision{}, &ItemNotFoundError{
+			ItemID: fmt.Sprintf("%v/%v", id, revision),
+		}
+	}
+	return revisions[revision-1], nil
+}
+
+// DeleteConfigurationProfile marks a configuration profile specified by its
+// ID as deleted. Profile is deleted only when it is at the expected version
+// (or AnyVersion is used).
+func (storage *MemoryStorage) DeleteConfigurationProfile(ctx context.Context, id, version int, username string) ([]ConfigurationProfile, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	profileID := ConfigurationID(id)
+	profile, found := storage.profiles[profileID]
+	if !found || profile.DeletedAt != "" {
+		return nil, &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	if err := checkVersion(id, profile.Version, version); err != nil {
+		return nil, err
+	}
+
+	profile.Version++
+	profile.DeletedAt = utcTime(time.Now()).Format(memoryTimeFormat)
+	profile.DeletedBy = username
+	storage.profiles[profileID] = profile
+
+	return storage.listConfigurationProfiles(false), nil
+}
+
+// RestoreConfigurationProfile restores configuration profile specified by its
+// ID that has been deleted.
+func (storage *MemoryStorage) RestoreConfigurationProfile(ctx context.Context, id int) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	profileID := ConfigurationID(id)
+	profile, found := storage.profiles[profileID]
+	if !found || profile.DeletedAt == "" {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	profile.Version++
+	profile.DeletedAt = ""
+	profile.DeletedBy = ""
+	storage.profiles[profileID] = profile
+	return nil
+}
+
+// purgeConfigurationProfile removes the configuration profile together with
+// its revisions and all cluster configurations that use it (the same as "on
+// delete cascade" in SQL schema). Caller needs to hold the lock.
+func (storage *MemoryStorage) purgeConfigurationProfile(profileID ConfigurationID) {
+	delete(storage.profiles, profileID)
+	delete(storage.revisions, profileID)
+	for configurationID, configuration := range storage.configurations {
+		if configuration.Profile == profileID {
+			delete(storage.configurations, configurationID)
+		}
+	}
+	if storage.defaultConfiguration != nil && storage.defaultConfiguration.Profile == profileID {
+		storage.defaultConfiguration = nil
+	}
+	for groupID, group := range storage.configurationGroups {
+		if group.Profile == profileID {
+			delete(storage.configurationGroups, groupID)
+		}
+	}
+	for rolloutID, rollout := range storage.rollouts {
+		if rollout.Profile == profileID {
+			delete(storage.rollouts, rolloutID)
+			delete(storage.rolloutClusters, rolloutID)
+		}
+	}
+}
+
+// isConfigurationProfileUsed checks whether any cluster configuration refers
+// to the configuration profile. Caller needs to hold the lock.
+func (storage *MemoryStorage) isConfigurationProfileUsed(profileID ConfigurationID) bool {
+	for _, configuration := range storage.configurations {
+		if configuration.Profile == profileID {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterConfigurations returns cluster configurations sorted by their IDs.
+// When filter is not nil, only configurations accepted by the filter are
+// returned. Caller needs to hold the lock.
+func (storage *MemoryStorage) clusterConfigurations(filter func(memoryClusterConfiguration) bool) []memoryClusterConfiguration {
+	configurations := []memoryClusterConfiguration{}
+	for _, configuration := range storage.configurations {
+		if filter == nil || filter(configuration) {
+			configurations = append(configurations, configuration)
+		}
+	}
+	sort.Slice(configurations, func(i, j int) bool {
+		return configurations[i].ID < configurations[j].ID
+	})
+	return configurations
+}
+
+// toClusterConfiguration converts the internal record into ClusterConfiguration
+// structure in the same form as returned by DBStorage. Caller needs to hold
+// the lock.
+func (storage *MemoryStorage) toClusterConfiguration(configuration memoryClusterConfiguration) ClusterConfiguration {
+	var deletedAt, effectiveFrom, effectiveUntil string
+	if !configuration.DeletedAt.IsZero() {
+		deletedAt = configuration.DeletedAt.Format(memoryTimeFormat)
+	}
+	if !configuration.Window.EffectiveFrom.IsZero() {
+		effectiveFrom = utcTime(configuration.Window.EffectiveFrom).Format(memoryTimeFormat)
+	}
+	if !configuration.Window.EffectiveUntil.IsZero() {
+		effectiveUntil = utcTime(configuration.Window.EffectiveUntil).Format(memoryTimeFormat)
+	}
+	return ClusterConfiguration{
+		ID:             configuration.ID,
+		Cluster:        string(storage.clusters[configuration.Cluster].Name),
+		Configuration:  strconv.Itoa(int(configuration.Profile)),
+		ChangedAt:      configuration.ChangedAt.Format(memoryTimeFormat),
+		ChangedBy:      configuration.ChangedBy,
+		Active:         configuration.Active,
+		Reason:         configuration.Reason,
+		Version:        configuration.Version,
+		DeletedAt:      deletedAt,
+		DeletedBy:      configuration.DeletedBy,
+		Revision:       configuration.Revision,
+		EffectiveFrom:  effectiveFrom,
+		EffectiveUntil: effectiveUntil,
+	}
+}
+
+// profileConfiguration returns configuration of profile used by the cluster
+// configuration, taking into account the revision the cluster configuration
+// is pinned to. Caller needs to hold the lock.
+func (storage *MemoryStorage) profileConfiguration(configuration memoryClusterConfiguration, profile ConfigurationProfile) string {
+	revisions := storage.revisions[configuration.Profile]
+	if configuration.Revision > 0 && configuration.Revision <= len(revisions) {
+		return revisions[configuration.Revision-1].Configuration
+	}
+	return profile.Configuration
+}
+
+// ListAllClusterConfigurations returns all cluster configurations. Deleted
+// configurations and configurations of deleted clusters are returned only
+// when includeDeleted is set.
+func (storage *MemoryStorage) ListAllClusterConfigurations(ctx context.Context, includeDeleted bool) ([]ClusterConfiguration, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	configurations := []ClusterConfiguration{}
+	for _, configuration := range storage.clusterConfigurations(func(c memoryClusterConfiguration) bool {
+		return includeDeleted || (c.DeletedAt.IsZero() && storage.clusters[c.Cluster].DeletedAt == "")
+	}) {
+		configurations = append(configurations, storage.toClusterConfiguration(configuration))
+	}
+	return configurations, nil
+}
+
+// ListAllClusterConfigurationsPage returns one page of cluster
+// configurations together with total number of configurations. Deleted
+// configurations and configurations of deleted clusters are returned only
+// when includeDeleted is set.
+func (storage *MemoryStorage) ListAllClusterConfigurationsPage(ctx context.Context, includeDeleted bool, page Page) ([]ClusterConfiguration, int, error) {
+	configurations, err := storage.ListAllClusterConfigurations(ctx, includeDeleted)
+	if err != nil {
+		return []ClusterConfiguration{}, 0, err
+	}
+	return paginateClusterConfigurations(configurations, page)
+}
+
+// ListClusterConfiguration returns cluster configurations for the specified
+// cluster. Deleted configurations are returned only when includeDeleted is set.
+func (storage *MemoryStorage) ListClusterConfiguration(ctx context.Context, cluster string, includeDeleted bool) ([]ClusterConfiguration, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	return storage.listClusterConfiguration(cluster, includeDeleted)
+}
+
+// ListClusterConfigurationPage returns one page of configurations of the
+// specified cluster together with total number of its configurations.
+// Deleted configurations are returned only when includeDeleted is set.
+func (storage *MemoryStorage) ListClusterConfigurationPage(ctx context.Context, cluster string, includeDeleted bool, page Page) ([]ClusterConfiguration, int, error) {
+	configurations, err := storage.ListClusterConfiguration(ctx, cluster, includeDeleted)
+	if err != nil {
+		return []ClusterConfiguration{}, 0, err
+	}
+	return paginateClusterConfigurations(configurations, page)
+}
+
+// paginateClusterConfigurations selects one page of cluster configurations
+func paginateClusterConfigurations(configurations []ClusterConfiguration, page Page) ([]ClusterConfiguration, int, error) {
+	items := make([]PageItem, len(configurations))
+	for i := range configurations {
+		items[i] = configurations[i]
+	}
+	items, err := paginateItems(items, configurationPageColumns, page)
+	if err != nil {
+		return []ClusterConfiguration{}, 0, err
+	}
+
+	result := []ClusterConfiguration{}
+	for _, item := range items {
+		result = append(result, item.(ClusterConfiguration))
+	}
+	return result, len(configurations), nil
+}
+
+// listClusterConfiguration returns cluster configurations for the specified
+// cluster. Caller needs to hold the lock.
+func (storage *MemoryStorage) listClusterConfiguration(cluster string, includeDeleted bool) ([]ClusterConfiguration, error) {
+	clusterInfo, err := storage.getClusterByName(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	configurations := []ClusterConfiguration{}
+	for _, configuration := range storage.clusterConfigurations(func(c memoryClusterConfiguration) bool {
+		return c.Cluster == clusterInfo.ID && (includeDeleted || c.DeletedAt.IsZero())
+	}) {
+		configurations = append(configurations, storage.toClusterConfiguration(configuration))
+	}
+	return configurations, nil
+}
+
+// GetClusterConfigurationByID returns cluster configuration for the specified
+// configuration ID together with version of the cluster configuration.
+func (storage *MemoryStorage) GetClusterConfigurationByID(ctx context.Context, id int64) (string, int, error) {
+	if err := contextError(ctx); err != nil {
+		return "", 0, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	configuration, found := storage.configurations[ClusterConfigurationID(id)]
+	if !found || !configuration.DeletedAt.IsZero() {
+		return "", 0, &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	profile, found := storage.profiles[configuration.Profile]
+	if !found {
+		return "", 0, &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	return storage.profileConfiguration(configuration, profile), configuration.Version, nil
+}
+
+// GetClusterActiveConfiguration returns one active configuration for the selected cluster.
+// When the active configuration is outside of its time window, the
+// configuration that was active just before it is returned instead, provided
+// it is effective now.
+func (storage *MemoryStorage) GetClusterActiveConfiguration(ctx context.Context, cluster string) (string, error) {
+	if err := contextError(ctx); err != nil {
+		return "", err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	clusterInfo, err := storage.getClusterByName(cluster)
+	if err == nil {
+		active := storage.clusterConfigurations(func(c memoryClusterConfiguration) bool {
+			return c.Cluster == clusterInfo.ID && c.DeletedAt.IsZero() && c.Active == "1"
+		})
+
+		now := utcTime(time.Now())
+		for _, configuration := range active {
+			candidates := []memoryClusterConfiguration{configuration}
+			if previous, found := storage.configurations[configuration.Previous]; found && previous.DeletedAt.IsZero() {
+				candidates = append(candidates, previous)
+			}
+			for _, candidate := range candidates {
+				if !candidate.Window.Contains(now) {
+					continue
+				}
+				if profile, found := storage.profiles[candidate.Profile]; found && profile.DeletedAt == "" {
+					return storage.profileConfiguration(candidate, profile), nil
+				}
+			}
+		}
+	}
+	return "", &ItemNotFoundError{
+		ItemID: cluster,
+	}
+}
+
+// GetConfigurationIDForCluster returns ID of the newest configuration for the specified cluster name.
+func (storage *MemoryStorage) GetConfigurationIDForCluster(ctx context.Context, cluster string) (int, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	return storage.getConfigurationIDForCluster(cluster, false)
+}
+
+// getConfigurationIDForCluster returns ID of the newest configuration for the
+// specified cluster name, only active configuration is returned when
+// requested. Caller needs to hold the lock.
+func (storage *MemoryStorage) getConfigurationIDForCluster(cluster string, activeOnly bool) (int, error) {
+	clusterInfo, err := storage.getClusterByName(cluster)
+	if err == nil {
+		configurations := storage.clusterConfigurations(func(c memoryClusterConfiguration) bool {
+			return c.Cluster == clusterInfo.ID && c.DeletedAt.IsZero() && (!activeOnly || c.Active == "1")
+		})
+		if len(configurations) > 0 {
+			return int(configurations[len(configurations)-1].ID), nil
+		}
+	}
+	if activeOnly {
+		return 0, &ItemNotFoundError{
+			ItemID: cluster,
+		}
+	}
+	return 0, errors.New("Unknown operator name provided")
+}
+
+// CreateClusterConfiguration creates new configuration for specified cluster.
+// All previous configurations of the cluster are deactivated.
+func (storage *MemoryStorage) CreateClusterConfiguration(ctx context.Context, cluster, username, reason, description, configuration string) ([]ClusterConfiguration, error) {
+	return storage.CreateScheduledClusterConfiguration(ctx, cluster, username, reason, description, configuration, ConfigurationWindow{})
+}
+
+// CreateScheduledClusterConfiguration creates new configuration for
+// specified cluster that is effective only within the time window.
+func (storage *MemoryStorage) CreateScheduledClusterConfiguration(ctx context.Context, cluster, username, reason, description, configuration string, window ConfigurationWindow) ([]ClusterConfiguration, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := CheckConfigurationWindow(window); err != nil {
+		return []ClusterConfiguration{}, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	clusterInfo, err := storage.getClusterByName(cluster)
+	if err != nil {
+		return []ClusterConfiguration{}, err
+	}
+
+	profileID := storage.insertConfigurationProfile(username, description, configuration)
+	storage.activateClusterConfiguration(clusterInfo.ID, profileID, LatestRevision, window, username, reason)
+
+	return storage.listClusterConfiguration(cluster, false)
+}
+
+// AssignClusterConfiguration assigns existing configuration profile to
+// specified cluster. The cluster either follows the latest revision of the
+// profile or it is pinned to the given revision. All previous configurations
+// of the cluster are deactivated.
+func (storage *MemoryStorage) AssignClusterConfiguration(ctx context.Context, cluster, username, reason string, profileID, revision int) ([]ClusterConfiguration, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	clusterInfo, err := storage.getClusterByName(cluster)
+	if err != nil {
+		return []ClusterConfiguration{}, err
+	}
+
+	// deleted profiles can not be assigned
+	profile, found := storage.profiles[ConfigurationID(profileID)]
+	if !found || profile.DeletedAt != "" {
+		return []ClusterConfiguration{}, &ItemNotFoundError{
+			ItemID: profileID,
+		}
+	}
+	if revision < LatestRevision || revision > len(storage.revisions[profile.ID]) {
+		return []ClusterConfiguration{}, &ItemNotFoundError{
+			ItemID: fmt.Sprintf("%v/%v", profileID, revision),
+		}
+	}
+
+	storage.activateClusterConfiguration(clusterInfo.ID, profile.ID, revision, ConfigurationWindow{}, username, reason)
+
+	return storage.listClusterConfiguration(cluster, false)
+}
+
+// RollbackClusterConfiguration re-activates historical configuration of the
+// cluster specified by its ID, or the configuration that precedes the active
+// one when PreviousConfiguration is used. All other configurations of the
+// cluster are deactivated.
+func (storage *MemoryStorage) RollbackClusterConfiguration(ctx context.Context, cluster, username, reason string, id int64) ([]ClusterConfiguration, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	clusterInfo, err := storage.getClusterByName(cluster)
+	if err != nil {
+		return []ClusterConfiguration{}, err
+	}
+
+	// configurations of the cluster sorted by their IDs
+	configurations := storage.clusterConfigurations(func(c memoryClusterConfiguration) bool {
+		return c.Cluster == clusterInfo.ID && c.DeletedAt.IsZero()
+	})
+
+	var configurationID ClusterConfigurationID
+	if id == PreviousConfiguration {
+		// the configuration preceding the newest active one
+		var active ClusterConfigurationID
+		for _, c := range configurations {
+			if c.Active == "1" {
+				active = c.ID
+			}
+		}
+		for _, c := range configurations {
+			if active != 0 && c.ID < active {
+				configurationID = c.ID
+			}
+		}
+	} else {
+		for _, c := range configurations {
+			if c.ID == ClusterConfigurationID(id) {
+				configurationID = c.ID
+			}
+		}
+	}
+	if configurationID == 0 {
+		return []ClusterConfiguration{}, &ItemNotFoundError{
+			ItemID: rollbackConfigurationItemID(id),
+		}
+	}
+
+	// deactivate all previous configurations
+	previous := storage.deactivateClusterConfigurations(clusterInfo.ID)
+
+	// and activate the selected one, re-activated configuration keeps its
+	// previous configuration
+	configuration := storage.configurations[configurationID]
+	if previous != 0 && previous != configurationID {
+		configuration.Previous = previous
+	}
+	configuration.Active = "1"
+	configuration.ChangedAt = utcTime(time.Now())
+	configuration.ChangedBy = username
+	configuration.Reason = reason
+	storage.configurations[configurationID] = configuration
+
+	return storage.listClusterConfiguration(cluster, false)
+}
+
+// deactivateClusterConfigurations deactivates all configurations of the
+// cluster and returns ID of the configuration that has been active (zero when
+// no configuration has been active). Caller needs to hold the lock.
+func (storage *MemoryStorage) deactivateClusterConfigurations(clusterID ClusterID) ClusterConfigurationID {
+	var active ClusterConfigurationID
+	for id, c := range storage.configurations {
+		if c.Cluster == clusterID {
+			if c.Active == "1" && c.DeletedAt.IsZero() && c.ID > active {
+				active = c.ID
+			}
+			c.Active = "0"
+			c.Version++
+			storage.configurations[id] = c
+		}
+	}
+	return active
+}
+
+// activateClusterConfiguration deactivates all previous configurations of
+// the cluster and inserts new active one that uses the specified profile.
+// The configuration that has been active is served outside the time window.
+// Caller needs to hold the lock.
+func (storage *MemoryStorage) activateClusterConfiguration(clusterID ClusterID, profileID ConfigurationID, revision int, window ConfigurationWindow, username, reason string) {
+	// deactivate all previous configurations
+	previous := storage.deactivateClusterConfigurations(clusterID)
+
+	// and insert new one that will be activated
+	storage.lastConfigurationID++
+	storage.configurations[storage.lastConfigurationID] = memoryClusterConfiguration{
+		ID:        storage.lastConfigurationID,
+		Cluster:   clusterID,
+		Profile:   profileID,
+		ChangedAt: utcTime(time.Now()),
+		ChangedBy: username,
+		Active:    "1",
+		Reason:    reason,
+		Version:   1,
+		Revision:  revision,
+		Window:    window,
+		Previous:  previous,
+	}
+}
+
+// EnableClusterConfiguration enables the newest configuration of the specified cluster (set the 'active' flag).
+// Cluster configuration is changed only when it is at the expected version (or AnyVersion is used).
+func (storage *MemoryStorage) EnableClusterConfiguration(ctx context.Context, cluster string, version int, username, reason string) ([]ClusterConfiguration, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	return storage.setClusterConfigurationState(cluster, version, username, reason, "1")
+}
+
+// DisableClusterConfiguration disables the active configuration of the specified cluster (reset the 'active' flag).
+// Cluster configuration is changed only when it is at the expected version (or AnyVersion is used).
+func (storage *MemoryStorage) DisableClusterConfiguration(ctx context.Context, cluster string, version int, username, reason string) ([]ClusterConfiguration, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	return storage.setClusterConfigurationState(cluster, version, username, reason, "0")
+}
+
+// setClusterConfigurationState sets the 'active' flag of the newest cluster
+// configuration or resets the flag of the active one, provided that the
+// configuration is at the expected version.
+func (storage *MemoryStorage) setClusterConfigurationState(cluster string, version int, username, reason, active string) ([]ClusterConfiguration, error) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	id, err := storage.getConfigurationIDForCluster(cluster, active == "0")
+	if err != nil {
+		return []ClusterConfiguration{}, err
+	}
+
+	configurationID := ClusterConfigurationID(id)
+	configuration := storage.configurations[configurationID]
+	if err := checkVersion(int64(id), configuration.Version, version); err != nil {
+		return []ClusterConfiguration{}, err
+	}
+	// only one configuration of the cluster can be active
+	if active == "1" {
+		if err := storage.activeConfigurationConflict(configuration); err != nil {
+			return []ClusterConfiguration{}, err
+		}
+	}
+	configuration.Active = active
+	configuration.ChangedAt = utcTime(time.Now())
+	configuration.ChangedBy = username
+	configuration.Reason = reason
+	configuration.Version++
+	storage.configurations[configurationID] = configuration
+
+	return storage.listClusterConfiguration(cluster, false)
+}
+
+// EnableOrDisableClusterConfigurationByID enables or disables the specified cluster configuration (set or reset the 'active' flag).
+// Cluster configuration is changed only when it is at the expected version (or AnyVersion is used).
+func (storage *MemoryStorage) EnableOrDisableClusterConfigurationByID(ctx context.Context, id int64, version int, active string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	configurationID := ClusterConfigurationID(id)
+	configuration, found := storage.configurations[configurationID]
+	if !found || !configuration.DeletedAt.IsZero() {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	if err := checkVersion(id, configuration.Version, version); err != nil {
+		return err
+	}
+	// only one configuration of the cluster can be active
+	if active == "1" {
+		if err := storage.activeConfigurationConflict(configuration); err != nil {
+			return err
+		}
+	}
+	configuration.Active = active
+	configuration.ChangedAt = utcTime(time.Now())
+	configuration.Version++
+	storage.configurations[configurationID] = configuration
+	return nil
+}
+
+// DeleteClusterConfigurationByID marks cluster configuration specified by its ID as deleted.
+// Cluster configuration is deleted only when it is at the expected version (or AnyVersion is used).
+func (storage *MemoryStorage) DeleteClusterConfigurationByID(ctx context.Context, id int64, version int, username string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	configurationID := ClusterConfigurationID(id)
+	configuration, found := storage.configurations[configurationID]
+	if !found || !configuration.DeletedAt.IsZero() {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	if err := checkVersion(id, configuration.Version, version); err != nil {
+		return err
+	}
+	configuration.Version++
+	configuration.DeletedAt = utcTime(time.Now())
+	configuration.DeletedBy = username
+	storage.configurations[configurationID] = configuration
+	return nil
+}
+
+// RestoreClusterConfiguration restores cluster configuration specified by its
+// ID that has been deleted.
+func (storage *MemoryStorage) RestoreClusterConfiguration(ctx context.Context, id int64) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	configurationID := ClusterConfigurationID(id)
+	configuration, found := storage.configurations[configurationID]
+	if !found || configuration.DeletedAt.IsZero() {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	if configuration.Active == "1" {
+		if err := storage.activeConfigurationConflict(configuration); err != nil {
+			return err
+		}
+	}
+	configuration.Version++
+	configuration.DeletedAt = time.Time{}
+	configuration.DeletedBy = ""
+	storage.configurations[configurationID] = configuration
+	return nil
+}
+
+// RepairActiveConfigurations deactivates all but the newest active
+// configuration of each cluster, so at most one configuration of each
+// cluster stays active. Number of deactivated configurations is returned.
+func (storage *MemoryStorage) RepairActiveConfigurations(ctx context.Context) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	// configurations are sorted by their IDs, so the newest one is the last
+	newest := make(map[ClusterID]ClusterConfigurationID)
+	active := storage.clusterConfigurations(func(c memoryClusterConfiguration) bool {
+		return c.Active == "1" && c.DeletedAt.IsZero()
+	})
+	for _, configuration := range active {
+		newest[configuration.Cluster] = configuration.ID
+	}
+
+	var repaired int64
+	for _, configuration := range active {
+		if configuration.ID != newest[configuration.Cluster] {
+			configuration.Active = "0"
+			configuration.Version++
+			storage.configurations[configuration.ID] = configuration
+			repaired++
+		}
+	}
+	return repaired, nil
+}
+
+// SetClusterConfigurationWindow sets time window when cluster configuration
+// specified by its ID is effective.
+func (storage *MemoryStorage) SetClusterConfigurationWindow(ctx context.Context, id int64, window ConfigurationWindow) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	if err := CheckConfigurationWindow(window); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	configuration, found := storage.configurations[ClusterConfigurationID(id)]
+	if !found || !configuration.DeletedAt.IsZero() {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	configuration.Window = window
+	configuration.Version++
+	storage.configurations[configuration.ID] = configuration
+	return nil
+}
+
+// RecordConfigurationTransitions records activation of active cluster
+// configurations whose time window has started and expiry of active
+// configurations whose time window has ended. Number of recorded events is
+// returned.
+func (storage *MemoryStorage) RecordConfigurationTransitions(ctx context.Context, now time.Time) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	var recorded int64
+	for _, configuration := range storage.clusterConfigurations(func(c memoryClusterConfiguration) bool {
+		return c.Active == "1" && c.DeletedAt.IsZero()
+	}) {
+		window := configuration.Window
+		var events []string
+		if !window.EffectiveFrom.IsZero() && window.Contains(now) {
+			events = append(events, ConfigurationActivated)
+		}
+		if !window.EffectiveUntil.IsZero() && !now.Before(window.EffectiveUntil) {
+			events = append(events, ConfigurationExpired)
+		}
+
+		for _, event := range events {
+			if hasConfigurationTransition(configuration, event) {
+				continue
+			}
+			configuration.Transitions = append(configuration.Transitions, ConfigurationTransition{
+				Event:     event,
+				ChangedAt: now.Format(memoryTimeFormat),
+			})
+			recorded++
+		}
+		storage.configurations[configuration.ID] = configuration
+	}
+	return recorded, nil
+}
+
+// hasConfigurationTransition checks whether the event has already been
+// recorded for the cluster configuration
+func hasConfigurationTransition(configuration memoryClusterConfiguration, event string) bool {
+	for _, transition := range configuration.Transitions {
+		if transition.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ListConfigurationTransitions returns all events recorded for cluster
+// configuration specified by its ID.
+func (storage *MemoryStorage) ListConfigurationTransitions(ctx context.Context, id int64) ([]ConfigurationTransition, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	configuration, found := storage.configurations[ClusterConfigurationID(id)]
+	if !found {
+		return []ConfigurationTransition{}, &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	return append([]ConfigurationTransition{}, configuration.Transitions...), nil
+}
+
+// activeConfigurationConflict checks that no other configuration of the same
+// cluster is active, so the cluster configuration can be activated. Caller
+// needs to hold the lock.
+func (storage *MemoryStorage) activeConfigurationConflict(configuration memoryClusterConfiguration) error {
+	others := storage.clusterConfigurations(func(c memoryClusterConfiguration) bool {
+		return c.Cluster == configuration.Cluster && c.ID != configuration.ID && c.Active == "1" && c.DeletedAt.IsZero()
+	})
+	if len(others) > 0 {
+		return &ActiveConfigurationConflictError{
+			ID:       configuration.ID,
+			ActiveID: others[0].ID,
+		}
+	}
+	return nil
+}
+
+// GetConfigurationSchema returns JSON schema that configurations are
+// validated against. Built-in schema is returned when no schema is set.
+func (storage *MemoryStorage) GetConfigurationSchema(ctx context.Context) (ConfigurationSchema, error) {
+	if err := contextError(ctx); err != nil {
+		return ConfigurationSchema{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	if storage.configurationSchema == nil {
+		return defaultConfigurationSchema(), nil
+	}
+	return *storage.configurationSchema, nil
+}
+
+// SetConfigurationSchema sets JSON schema that configurations are validated
+// against.
+func (storage *MemoryStorage) SetConfigurationSchema(ctx context.Context, schema, username string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	if err := CheckConfigurationSchema(schema); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	storage.configurationSchema = &ConfigurationSchema{
+		Schema:    schema,
+		ChangedAt: utcTime(time.Now()).Format(memoryTimeFormat),
+		ChangedBy: username,
+	}
+	return nil
+}
+
+// GetDefaultConfiguration returns global default configuration profile that
+// is applied to all clusters.
+func (storage *MemoryStorage) GetDefaultConfiguration(ctx context.Context) (DefaultConfiguration, error) {
+	if err := contextError(ctx); err != nil {
+		return DefaultConfiguration{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	if storage.defaultConfiguration == nil {
+		return DefaultConfiguration{}, &ItemNotFoundError{ItemID: DefaultConfigurationLayer}
+	}
+	return *storage.defaultConfiguration, nil
+}
+
+// SetDefaultConfiguration sets global default configuration profile that is
+// applied to all clusters.
+func (storage *MemoryStorage) SetDefaultConfiguration(ctx context.Context, profileID int, username string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	if profile, found := storage.profiles[ConfigurationID(profileID)]; !found || profile.DeletedAt != "" {
+		return &ItemNotFoundError{ItemID: profileID}
+	}
+
+	storage.defaultConfiguration = &DefaultConfiguration{
+		Profile:   ConfigurationID(profileID),
+		ChangedAt: utcTime(time.Now()).Format(memoryTimeFormat),
+		ChangedBy: username,
+	}
+	return nil
+}
+
+// DeleteDefaultConfiguration unsets global default configuration profile.
+func (storage *MemoryStorage) DeleteDefaultConfiguration(ctx context.Context) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	if storage.defaultConfiguration == nil {
+		return &ItemNotFoundError{ItemID: DefaultConfigurationLayer}
+	}
+	storage.defaultConfiguration = nil
+	return nil
+}
+
+// ListConfigurationGroups returns all configuration groups in the order they
+// are applied.
+func (storage *MemoryStorage) ListConfigurationGroups(ctx context.Context) ([]ConfigurationGroup, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	groups := []ConfigurationGroup{}
+	for _, group := range storage.configurationGroups {
+		groups = append(groups, group)
+	}
+	sortConfigurationGroups(groups)
+	return groups, nil
+}
+
+// NewConfigurationGroup creates new configuration group that applies the
+// configuration profile to all clusters selected by label selector.
+func (storage *MemoryStorage) NewConfigurationGroup(ctx context.Context, name, selector string, priority, profileID int, username string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	selector, err := CheckConfigurationGroupSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	if profile, found := storage.profiles[ConfigurationID(profileID)]; !found || profile.DeletedAt != "" {
+		return &ItemNotFoundError{ItemID: profileID}
+	}
+	for _, group := range storage.configurationGroups {
+		if group.Name == name {
+			return &ItemAlreadyExistsError{ItemID: name}
+		}
+	}
+
+	storage.lastConfigurationGroupID++
+	storage.configurationGroups[storage.lastConfigurationGroupID] = ConfigurationGroup{
+		ID:        storage.lastConfigurationGroupID,
+		Name:      name,
+		Selector:  selector,
+		Priority:  priority,
+		Profile:   ConfigurationID(profileID),
+		ChangedAt: utcTime(time.Now()).Format(memoryTimeFormat),
+		ChangedBy: username,
+	}
+	return nil
+}
+
+// DeleteConfigurationGroup deletes configuration group specified by its name.
+func (storage *MemoryStorage) DeleteConfigurationGroup(ctx context.Context, name string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	for id, group := range storage.configurationGroups {
+		if group.Name == name {
+			delete(storage.configurationGroups, id)
+			return nil
+		}
+	}
+	return &ItemNotFoundError{ItemID: name}
+}
+
+// ListConfigurationRollouts returns all configuration rollouts ordered by
+// their IDs.
+func (storage *MemoryStorage) ListConfigurationRollouts(ctx context.Context) ([]ConfigurationRollout, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	rollouts := []ConfigurationRollout{}
+	for _, rollout := range storage.rollouts {
+		rollouts = append(rollouts, rollout)
+	}
+	sort.Slice(rollouts, func(i, j int) bool {
+		return rollouts[i].ID < rollouts[j].ID
+	})
+	return rollouts, nil
+}
+
+// GetConfigurationRollout returns configuration rollout specified by its ID.
+func (storage *MemoryStorage) GetConfigurationRollout(ctx context.Context, id int) (ConfigurationRollout, error) {
+	if err := contextError(ctx); err != nil {
+		return ConfigurationRollout{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	rollout, found := storage.rollouts[id]
+	if !found {
+		return ConfigurationRollout{}, &ItemNotFoundError{ItemID: id}
+	}
+	return rollout, nil
+}
+
+// NewConfigurationRollout creates new running rollout of the latest revision
+// of configuration profile to clusters selected by label selector. No
+// cluster is touched until the first wave is applied.
+func (storage *MemoryStorage) NewConfigurationRollout(ctx context.Context, profileID int, selector string, waves []int, username, reason string) (ConfigurationRollout, error) {
+	if err := contextError(ctx); err != nil {
+		return ConfigurationRollout{}, err
+	}
+
+	parsed, err := ParseLabelSelector(selector)
+	if err != nil {
+		return ConfigurationRollout{}, err
+	}
+	if err := CheckRolloutWaves(waves); err != nil {
+		return ConfigurationRollout{}, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	if profile, found := storage.profiles[ConfigurationID(profileID)]; !found || profile.DeletedAt != "" {
+		return ConfigurationRollout{}, &ItemNotFoundError{ItemID: profileID}
+	}
+
+	// the rollout is pinned to the latest revision of the profile
+	revisions := storage.revisions[ConfigurationID(profileID)]
+	revision := revisions[len(revisions)-1].Revision
+
+	now := utcTime(time.Now()).Format(memoryTimeFormat)
+	storage.lastRolloutID++
+	rollout := ConfigurationRollout{
+		ID:        storage.lastRolloutID,
+		Profile:   ConfigurationID(profileID),
+		Revision:  revision,
+		Selector:  parsed.String(),
+		Waves:     append([]int{}, waves...),
+		State:     RolloutRunning,
+		CreatedAt: now,
+		CreatedBy: username,
+		ChangedAt: now,
+		ChangedBy: username,
+		Reason:    reason,
+	}
+	storage.rollouts[rollout.ID] = rollout
+	return rollout, nil
+}
+
+// UpdateConfigurationRollout changes state and the current wave of
+// configuration rollout specified by its ID.
+func (storage *MemoryStorage) UpdateConfigurationRollout(ctx context.Context, id int, state RolloutState, currentWave int, username string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	rollout, found := storage.rollouts[id]
+	if !found {
+		return &ItemNotFoundError{ItemID: id}
+	}
+	rollout.State = state
+	rollout.CurrentWave = currentWave
+	rollout.ChangedAt = utcTime(time.Now()).Format(memoryTimeFormat)
+	rollout.ChangedBy = username
+	storage.rollouts[id] = rollout
+	return nil
+}
+
+// AddConfigurationRolloutCluster records cluster touched by the given wave
+// of configuration rollout together with configuration created for it.
+func (storage *MemoryStorage) AddConfigurationRolloutCluster(ctx context.Context, id int, clusterName string, wave int, configurationID ClusterConfigurationID) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	cluster, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return err
+	}
+	if _, found := storage.rollouts[id]; !found {
+		return &ItemNotFoundError{ItemID: id}
+	}
+	if _, found := storage.configurations[configurationID]; !found {
+		return &ItemNotFoundError{ItemID: configurationID}
+	}
+	for _, touched := range storage.rolloutClusters[id] {
+		if touched.Cluster == cluster.ID {
+			return &ItemAlreadyExistsError{ItemID: clusterName}
+		}
+	}
+
+	storage.rolloutClusters[id] = append(storage.rolloutClusters[id], memoryRolloutCluster{
+		Cluster:       cluster.ID,
+		Wave:          wave,
+		Configuration: configurationID,
+		AppliedAt:     utcTime(time.Now()),
+	})
+	return nil
+}
+
+// ListConfigurationRolloutClusters returns all clusters (except deleted ones)
+// touched by configuration rollout specified by its ID. Clusters and
+// configurations that have been purged are skipped.
+func (storage *MemoryStorage) ListConfigurationRolloutClusters(ctx context.Context, id int) ([]ConfigurationRolloutCluster, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	if _, found := storage.rollouts[id]; !found {
+		return []ConfigurationRolloutCluster{}, &ItemNotFoundError{ItemID: id}
+	}
+
+	records := []memoryRolloutCluster{}
+	for _, touched := range storage.rolloutClusters[id] {
+		cluster, found := storage.clusters[touched.Cluster]
+		if !found || cluster.DeletedAt != "" {
+			continue
+		}
+		if _, found := storage.configurations[touched.Configuration]; !found {
+			continue
+		}
+		records = append(records, touched)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Wave != records[j].Wave {
+			return records[i].Wave < records[j].Wave
+		}
+		return records[i].Cluster < records[j].Cluster
+	})
+
+	clusters := []ConfigurationRolloutCluster{}
+	for _, record := range records {
+		clusters = append(clusters, storage.toConfigurationRolloutCluster(record))
+	}
+	return clusters, nil
+}
+
+// toConfigurationRolloutCluster converts the internal record into
+// ConfigurationRolloutCluster, status of the cluster is based on its last
+// heartbeat and on acknowledge of its configuration. Caller needs to hold
+// the lock.
+func (storage *MemoryStorage) toConfigurationRolloutCluster(record memoryRolloutCluster) ConfigurationRolloutCluster {
+	configuration := storage.configurations[record.Configuration]
+	heartbeat, seen := storage.heartbeats[record.Cluster]
+
+	result := ConfigurationRolloutCluster{
+		Cluster:       storage.clusters[record.Cluster].Name,
+		Wave:          record.Wave,
+		Configuration: record.Configuration,
+		AppliedAt:     record.AppliedAt.Format(memoryTimeFormat),
+		Status:        RolloutClusterPending,
+	}
+	if seen {
+		result.LastSeenAt = utcTime(heartbeat.LastSeenAt).Format(memoryTimeFormat)
+		if !heartbeat.LastSeenAt.Before(record.AppliedAt) {
+			result.Status = RolloutClusterSeen
+		}
+	}
+	if !configuration.AckedAt.IsZero() {
+		result.AckedAt = utcTime(configuration.AckedAt).Format(memoryTimeFormat)
+		result.Status = RolloutClusterAcknowledged
+	}
+	return result
+}
+
+// AckClusterConfiguration records that the operator running on cluster
+// specified by its name has read its active configuration. Only the first
+// read of each configuration is recorded.
+func (storage *MemoryStorage) AckClusterConfiguration(ctx context.Context, clusterName string, ackedAt time.Time) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	cluster, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return err
+	}
+	for id, configuration := range storage.configurations {
+		if configuration.Cluster == cluster.ID && configuration.Active == "1" &&
+			configuration.DeletedAt.IsZero() && configuration.AckedAt.IsZero() {
+			configuration.AckedAt = ackedAt
+			storage.configurations[id] = configuration
+		}
+	}
+	return nil
+}
+
+// PurgeDeleted permanently removes cluster configurations, clusters, and
+// configuration profiles that have been deleted before the specified time.
+// Records that refer to purged items are removed too. Profiles still referred
+// by configurations of clusters are kept. Number of purged items is returned.
+func (storage *MemoryStorage) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	var purged int64
+
+	for id, configuration := range storage.configurations {
+		if !configuration.DeletedAt.IsZero() && configuration.DeletedAt.Before(deletedBefore) {
+			delete(storage.configurations, id)
+			purged++
+		}
+	}
+	for id, cluster := range storage.clusters {
+		if isDeletedBefore(cluster.DeletedAt, deletedBefore) {
+			storage.purgeCluster(id)
+			purged++
+		}
+	}
+	for id, profile := range storage.profiles {
+		if isDeletedBefore(profile.DeletedAt, deletedBefore) && !storage.isConfigurationProfileUsed(id) {
+			storage.purgeConfigurationProfile(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// listTriggers returns triggers sorted by their IDs. When filter is not nil,
+// only triggers accepted by the filter are returned. Caller needs to hold the
+// lock.
+func (storage *MemoryStorage) listTriggers(filter func(memoryTrigger) bool) []Trigger {
+	records := []memoryTrigger{}
+	for _, trigger := range storage.triggers {
+		if filter == nil || filter(trigger) {
+			records = append(records, trigger)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+
+	triggers := []Trigger{}
+	for _, record := range records {
+		triggers = append(triggers, storage.toTrigger(record))
+	}
+	return triggers
+}
+
+// toTrigger converts the internal record into Trigger structure in the same
+// form as returned by DBStorage. Caller needs to hold the lock.
+func (storage *MemoryStorage) toTrigger(trigger memoryTrigger) Trigger {
+	expiresAt := ""
+	if !trigger.ExpiresAt.IsZero() {
+		expiresAt = trigger.ExpiresAt.Format(memoryTimeFormat)
+	}
+	active, expired := triggerFlags(trigger.State)
+
+	// result is copied, so it can not be changed by caller
+	var result *TriggerResult
+	if trigger.Result != nil {
+		copied := *trigger.Result
+		result = &copied
+	}
+	return Trigger{
+		ID:          trigger.ID,
+		Type:        storage.triggerTypes[trigger.Type].Type,
+		Cluster:     string(storage.clusters[trigger.Cluster].Name),
+		Reason:      trigger.Reason,
+		Link:        trigger.Link,
+		TriggeredAt: trigger.TriggeredAt.Format(memoryTimeFormat),
+		TriggeredBy: trigger.TriggeredBy,
+		Parameters:  trigger.Parameters,
+		Active:      active,
+		ExpiresAt:   expiresAt,
+		Expired:     expired,
+		State:       trigger.State,
+		Result:      result,
+		CaseNumber:  storage.supportCases[trigger.SupportCase].CaseNumber,
+	}
+}
+
+// GetTriggerByID returns all informations about the trigger specified by its ID.
+func (storage *MemoryStorage) GetTriggerByID(ctx context.Context, id int64) (Trigger, error) {
+	if err := contextError(ctx); err != nil {
+		return Trigger{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	trigger, found := storage.triggers[TriggerID(id)]
+	if !found {
+		return Trigger{}, ErrNoSuchObj
+	}
+	return storage.toTrigger(trigger), nil
+}
+
+// DeleteTriggerByID deletes trigger specified by its ID
+// returns ItemNotFoundError if trigger didn't exist
+func (storage *MemoryStorage) DeleteTriggerByID(ctx context.Context, id int64) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	triggerID := TriggerID(id)
+	if _, found := storage.triggers[triggerID]; !found {
+		return &ItemNotFoundError{
+			ItemID: strconv.Itoa(int(id)),
+		}
+	}
+	delete(storage.triggers, triggerID)
+	return nil
+}
+
+// ChangeStateOfTriggerByID change the state ('active', 'inactive') of trigger specified by its ID.
+// Activated triggers become pending, deactivated triggers are cancelled.
+// returns ItemNotFoundError if there weren't rows with such id
+func (storage *MemoryStorage) ChangeStateOfTriggerByID(ctx context.Context, id int64, active int) error {
+	state := TriggerCancelled
+	if active == 1 {
+		state = TriggerPending
+	}
+	return storage.ChangeTriggerState(ctx, id, state, "")
+}
+
+// ChangeTriggerState moves trigger specified by its ID into the given state
+// and records the transition.
+func (storage *MemoryStorage) ChangeTriggerState(ctx context.Context, id int64, state TriggerState, username string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	trigger, found := storage.triggers[TriggerID(id)]
+	if !found {
+		return &ItemNotFoundError{
+			ItemID: strconv.Itoa(int(id)),
+		}
+	}
+	_, err := storage.changeTriggerState(trigger, state, username, utcTime(time.Now()))
+	return err
+}
+
+// ChangeClusterTriggerState moves trigger assigned to the specified cluster
+// into the given state.
+func (storage *MemoryStorage) ChangeClusterTriggerState(ctx context.Context, clusterName string, triggerID int64, state TriggerState) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	return storage.changeClusterTrigger(clusterName, triggerID, state, nil)
+}
+
+// SetTriggerResult stores result reported by the operator with trigger
+// assigned to the specified cluster. The trigger is moved into the state
+// given by status of the result.
+func (storage *MemoryStorage) SetTriggerResult(ctx context.Context, clusterName string, triggerID int64, result TriggerResult) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	if err := checkTriggerResult(result); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	return storage.changeClusterTrigger(clusterName, triggerID, result.Status, &result)
+}
+
+// changeClusterTrigger moves trigger assigned to the specified cluster into
+// the given state and stores the result when it is not nil. Caller needs to
+// hold the lock.
+func (storage *MemoryStorage) changeClusterTrigger(clusterName string, triggerID int64, state TriggerState, result *TriggerResult) error {
+	clusterInfo, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return err
+	}
+
+	trigger, found := storage.triggers[TriggerID(triggerID)]
+	if !found || trigger.Cluster != clusterInfo.ID {
+		return &ItemNotFoundError{
+			ItemID: fmt.Sprintf("%v/%v", clusterName, triggerID),
+		}
+	}
+
+	t := utcTime(time.Now())
+	trigger, err = storage.changeTriggerState(trigger, state, clusterName, t)
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		result.ReportedAt = t.Format(memoryTimeFormat)
+		trigger.Result = result
+	}
+	storage.triggers[trigger.ID] = trigger
+	return nil
+}
+
+// changeTriggerState moves trigger into the given state and records the
+// transition. Changing the trigger into its current state does nothing.
+// Caller needs to hold the lock.
+func (storage *MemoryStorage) changeTriggerState(trigger memoryTrigger, state TriggerState, username string, t time.Time) (memoryTrigger, error) {
+	if trigger.State == state {
+		return trigger, nil
+	}
+	if !CanChangeTriggerState(trigger.State, state) {
+		return trigger, &InvalidTriggerTransitionError{TriggerID: trigger.ID, From: trigger.State, To: state}
+	}
+	trigger.State = state
+	trigger.Transitions = append(trigger.Transitions, TriggerTransition{
+		State:     state,
+		ChangedAt: t.Format(memoryTimeFormat),
+		ChangedBy: username,
+	})
+	storage.triggers[trigger.ID] = trigger
+	return trigger, nil
+}
+
+// ListTriggerTransitions returns all changes of state of trigger specified by
+// its ID, the oldest change is the first one.
+func (storage *MemoryStorage) ListTriggerTransitions(ctx context.Context, id int64) ([]TriggerTransition, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	trigger, found := storage.triggers[TriggerID(id)]
+	if !found {
+		return []TriggerTransition{}, &ItemNotFoundError{
+			ItemID: strconv.Itoa(int(id)),
+		}
+	}
+	return append([]TriggerTransition{}, trigger.Transitions...), nil
+}
+
+// ListAllTriggers returns all triggers.
+func (storage *MemoryStorage) ListAllTriggers(ctx context.Context) ([]Trigger, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	return storage.listTriggers(nil), nil
+}
+
+// ListAllTriggersPage returns one page of triggers that are in one of the
+// specified states together with total number of such triggers. Triggers in
+// all states are returned when no state is specified.
+func (storage *MemoryStorage) ListAllTriggersPage(ctx context.Context, states []TriggerState, page Page) ([]Trigger, int, error) {
+	triggers, err := storage.ListAllTriggers(ctx)
+	if err != nil {
+		return []Trigger{}, 0, err
+	}
+	return paginateTriggers(triggers, states, page)
+}
+
+// ListClusterTriggers returns all triggers assigned to the specified cluster.
+func (storage *MemoryStorage) ListClusterTriggers(ctx context.Context, clusterName string) ([]Trigger, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	clusterInfo, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return []Trigger{}, err
+	}
+
+	return storage.listTriggers(func(t memoryTrigger) bool {
+		return t.Cluster == clusterInfo.ID
+	}), nil
+}
+
+// ListClusterTriggersPage returns one page of triggers of the specified
+// cluster that are in one of the specified states together with total number
+// of such triggers. Triggers in all states are returned when no state is
+// specified.
+func (storage *MemoryStorage) ListClusterTriggersPage(ctx context.Context, clusterName string, states []TriggerState, page Page) ([]Trigger, int, error) {
+	triggers, err := storage.ListClusterTriggers(ctx, clusterName)
+	if err != nil {
+		return []Trigger{}, 0, err
+	}
+	return paginateTriggers(triggers, states, page)
+}
+
+// paginateTriggers selects one page of triggers that are in one of the
+// specified states
+func paginateTriggers(triggers []Trigger, states []TriggerState, page Page) ([]Trigger, int, error) {
+	items := []PageItem{}
+	for _, trigger := range triggers {
+		if hasTriggerState(trigger, states) {
+			items = append(items, trigger)
+		}
+	}
+	total := len(items)
+
+	items, err := paginateItems(items, triggerPageColumns, page)
+	if err != nil {
+		return []Trigger{}, 0, err
+	}
+
+	result := []Trigger{}
+	for _, item := range items {
+		result = append(result, item.(Trigger))
+	}
+	return result, total, nil
+}
+
+// hasTriggerState checks whether the trigger is in one of the specified
+// states, any state is accepted when no state is specified
+func hasTriggerState(trigger Trigger, states []TriggerState) bool {
+	if len(states) == 0 {
+		return true
+	}
+	for _, state := range states {
+		if trigger.State == state {
+			return true
+		}
+	}
+	return false
+}
+
+// ListActiveClusterTriggers returns all active triggers assigned to the
+// specified cluster. Triggers that already expired are not returned.
+func (storage *MemoryStorage) ListActiveClusterTriggers(ctx context.Context, clusterName string) ([]Trigger, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	clusterInfo, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return []Trigger{}, err
+	}
+
+	now := utcTime(time.Now())
+	return storage.listTriggers(func(t memoryTrigger) bool {
+		return t.Cluster == clusterInfo.ID && t.State == TriggerPending &&
+			(t.ExpiresAt.IsZero() || t.ExpiresAt.After(now))
+	}), nil
+}
+
+// AckTrigger marks the selected trigger as delivered to the operator, the
+// transition is recorded with its timestamp. It returns error if trigger
+// wasn't found.
+func (storage *MemoryStorage) AckTrigger(ctx context.Context, clusterName string, triggerID int64) error {
+	return storage.ChangeClusterTriggerState(ctx, clusterName, triggerID, TriggerDelivered)
+}
+
+// NewTrigger constructs new trigger. Default time to live of the trigger type
+// is used when expiresAt is zero. Trigger is linked to the support case when
+// its number is not empty.
+func (storage *MemoryStorage) NewTrigger(ctx context.Context, clusterName, triggerType, userName, reason, link, parameters string, expiresAt time.Time, caseNumber string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	clusterInfo, err := storage.getClusterByName(clusterName)
+	if err != nil {
+		return err
+	}
+
+	triggerTypeInfo, err := storage.getTriggerType(triggerType)
+	if err != nil {
+		return err
+	}
+
+	if triggerTypeInfo.DeprecatedAt != "" {
+		return &DeprecatedTriggerTypeError{TriggerType: triggerType}
+	}
+
+	parameters, err = triggerParameters(triggerTypeInfo, parameters)
+	if err != nil {
+		return err
+	}
+
+	supportCase, err := triggerSupportCase(triggerTypeInfo, caseNumber, storage.getSupportCase)
+	if err != nil {
+		return err
+	}
+
+	triggeredAt := utcTime(time.Now())
+	storage.lastTriggerID++
+	storage.triggers[storage.lastTriggerID] = memoryTrigger{
+		ID:          storage.lastTriggerID,
+		Type:        triggerTypeInfo.ID,
+		Cluster:     clusterInfo.ID,
+		Reason:      reason,
+		Link:        link,
+		TriggeredAt: triggeredAt,
+		TriggeredBy: userName,
+		Parameters:  parameters,
+		ExpiresAt:   utcTime(triggerExpiration(triggerTypeInfo, triggeredAt, expiresAt)),
+		State:       TriggerPending,
+		Transitions: []TriggerTransition{{
+			State:     TriggerPending,
+			ChangedAt: triggeredAt.Format(memoryTimeFormat),
+			ChangedBy: userName,
+		}},
+		SupportCase: supportCase.ID,
+	}
+	return nil
+}
+
+// GetTriggerID returns ID for specified trigger type (name).
+func (storage *MemoryStorage) GetTriggerID(ctx context.Context, triggerType string) (int, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	return storage.getTriggerID(triggerType)
+}
+
+// getTriggerID returns ID for specified trigger type (name). Caller needs to
+// hold the lock.
+func (storage *MemoryStorage) getTriggerID(triggerType string) (int, error) {
+	for id := 1; id <= storage.lastTriggerTypeID; id++ {
+		if t, found := storage.triggerTypes[id]; found && t.Type == triggerType {
+			return id, nil
+		}
+	}
+	return 0, &ItemNotFoundError{ItemID: triggerType}
+}
+
+// ListTriggerTypes returns all trigger types including deprecated ones.
+func (storage *MemoryStorage) ListTriggerTypes(ctx context.Context) ([]TriggerType, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	triggerTypes := []TriggerType{}
+	for id := 1; id <= storage.lastTriggerTypeID; id++ {
+		if t, found := storage.triggerTypes[id]; found {
+			triggerTypes = append(triggerTypes, t.toTriggerType())
+		}
+	}
+	return triggerTypes, nil
+}
+
+// GetTriggerType returns trigger type specified by its name.
+func (storage *MemoryStorage) GetTriggerType(ctx context.Context, triggerType string) (TriggerType, error) {
+	if err := contextError(ctx); err != nil {
+		return TriggerType{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	return storage.getTriggerType(triggerType)
+}
+
+// getTriggerType returns trigger type specified by its name. Caller needs to
+// hold the lock.
+func (storage *MemoryStorage) getTriggerType(triggerType string) (TriggerType, error) {
+	for id := 1; id <= storage.lastTriggerTypeID; id++ {
+		if t, found := storage.triggerTypes[id]; found && t.Type == triggerType {
+			return t.toTriggerType(), nil
+		}
+	}
+	return TriggerType{}, &ItemNotFoundError{ItemID: triggerType}
+}
+
+// NewTriggerType inserts a new trigger type.
+func (storage *MemoryStorage) NewTriggerType(ctx context.Context, ttype, description, parametersSchema string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	if err := checkParametersSchema(ttype, parametersSchema); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	if _, err := storage.getTriggerID(ttype); err == nil {
+		return &ItemAlreadyExistsError{ItemID: ttype}
+	}
+
+	storage.lastTriggerTypeID++
+	storage.triggerTypes[storage.lastTriggerTypeID] = memoryTriggerType{
+		ID:               storage.lastTriggerTypeID,
+		Type:             ttype,
+		Description:      description,
+		ParametersSchema: parametersSchema,
+	}
+	return nil
+}
+
+// ChangeTriggerTypeDescription changes description of trigger type.
+func (storage *MemoryStorage) ChangeTriggerTypeDescription(ctx context.Context, ttype, description string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	id, err := storage.getTriggerID(ttype)
+	if err != nil {
+		return err
+	}
+
+	triggerType := storage.triggerTypes[id]
+	triggerType.Description = description
+	storage.triggerTypes[id] = triggerType
+	return nil
+}
+
+// SetTriggerTypeDefaultTTL sets default time to live of new triggers of the
+// trigger type. Zero TTL means that new triggers do not expire.
+func (storage *MemoryStorage) SetTriggerTypeDefaultTTL(ctx context.Context, ttype string, ttl time.Duration) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	id, err := storage.getTriggerID(ttype)
+	if err != nil {
+		return err
+	}
+
+	triggerType := storage.triggerTypes[id]
+	triggerType.DefaultTTL = int(ttl / time.Second)
+	storage.triggerTypes[id] = triggerType
+	return nil
+}
+
+// SetTriggerTypeRequiresCase sets whether new triggers of the trigger type
+// need to be linked to open support case.
+func (storage *MemoryStorage) SetTriggerTypeRequiresCase(ctx context.Context, ttype string, required bool) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	id, err := storage.getTriggerID(ttype)
+	if err != nil {
+		return err
+	}
+
+	triggerType := storage.triggerTypes[id]
+	triggerType.RequiresCase = required
+	storage.triggerTypes[id] = triggerType
+	return nil
+}
+
+// ListSupportCases returns all support cases sorted by their IDs.
+func (storage *MemoryStorage) ListSupportCases(ctx context.Context) ([]SupportCase, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	supportCases := []SupportCase{}
+	for id := 1; id <= storage.lastSupportCaseID; id++ {
+		if supportCase, found := storage.supportCases[id]; found {
+			supportCases = append(supportCases, supportCase)
+		}
+	}
+	return supportCases, nil
+}
+
+// GetSupportCase returns support case specified by its number.
+func (storage *MemoryStorage) GetSupportCase(ctx context.Context, caseNumber string) (SupportCase, error) {
+	if err := contextError(ctx); err != nil {
+		return SupportCase{}, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	return storage.getSupportCase(caseNumber)
+}
+
+// getSupportCase returns support case specified by its number. Caller needs
+// to hold the lock.
+func (storage *MemoryStorage) getSupportCase(caseNumber string) (SupportCase, error) {
+	for _, supportCase := range storage.supportCases {
+		if supportCase.CaseNumber == caseNumber {
+			return supportCase, nil
+		}
+	}
+	return SupportCase{}, &ItemNotFoundError{ItemID: caseNumber}
+}
+
+// NewSupportCase registers new open support case.
+func (storage *MemoryStorage) NewSupportCase(ctx context.Context, caseNumber, account, consentLink, username string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	if _, err := storage.getSupportCase(caseNumber); err == nil {
+		return &ItemAlreadyExistsError{ItemID: caseNumber}
+	}
+
+	storage.lastSupportCaseID++
+	storage.supportCases[storage.lastSupportCaseID] = SupportCase{
+		ID:          storage.lastSupportCaseID,
+		CaseNumber:  caseNumber,
+		Account:     account,
+		ConsentLink: consentLink,
+		Status:      SupportCaseOpen,
+		CreatedAt:   utcTime(time.Now()).Format(memoryTimeFormat),
+		CreatedBy:   username,
+	}
+	return nil
+}
+
+// ChangeSupportCaseStatus changes status of support case.
+func (storage *MemoryStorage) ChangeSupportCaseStatus(ctx context.Context, caseNumber string, status SupportCaseStatus) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	supportCase, err := storage.getSupportCase(caseNumber)
+	if err != nil {
+		return err
+	}
+
+	supportCase.Status = status
+	storage.supportCases[supportCase.ID] = supportCase
+	return nil
+}
+
+// ListSupportCaseTriggers returns all triggers linked to support case.
+func (storage *MemoryStorage) ListSupportCaseTriggers(ctx context.Context, caseNumber string) ([]Trigger, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	supportCase, err := storage.getSupportCase(caseNumber)
+	if err != nil {
+		return []Trigger{}, err
+	}
+
+	return storage.listTriggers(func(t memoryTrigger) bool {
+		return t.SupportCase == supportCase.ID
+	}), nil
+}
+
+// ExpireTriggers moves all pending triggers that expired before the given
+// time into the expired state.
+func (storage *MemoryStorage) ExpireTriggers(ctx context.Context, now time.Time) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	var expired int64
+	for _, trigger := range storage.triggers {
+		if trigger.State == TriggerPending && !trigger.ExpiresAt.IsZero() && !trigger.ExpiresAt.After(now) {
+			if _, err := storage.changeTriggerState(trigger, TriggerExpired, "", now); err != nil {
+				return expired, err
+			}
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// DeprecateTriggerType marks trigger type as deprecated, so new triggers of
+// this type can not be created.
+func (storage *MemoryStorage) DeprecateTriggerType(ctx context.Context, ttype, username string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	id, err := storage.getTriggerID(ttype)
+	if err != nil {
+		return err
+	}
+
+	triggerType := storage.triggerTypes[id]
+	if triggerType.DeprecatedAt != "" {
+		return &ItemNotFoundError{ItemID: ttype}
+	}
+	triggerType.DeprecatedAt = utcTime(time.Now()).Format(memoryTimeFormat)
+	triggerType.DeprecatedBy = username
+	storage.triggerTypes[id] = triggerType
+	return nil
+}
@@ -0,0 +1,289 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/memory_storage_test.html
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+const (
+	memoryClusterName = "00000000-0000-0000-0000-000000000000"
+	memoryTriggerType = "must-gather"
+)
+
+// mustGetMemoryStorage creates in-memory storage with one cluster and one
+// trigger type
+func mustGetMemoryStorage(t *testing.T) *storage.MemoryStorage {
+	s := storage.NewMemoryStorage()
+	FailOnError(t, s.RegisterNewCluster(context.Background(), memoryClusterName))
+	FailOnError(t, s.NewTriggerType(context.Background(), memoryTriggerType, "description", ""))
+	return s
+}
+
+// TestMemoryStorageClusters checks the cluster-related operations
+func TestMemoryStorageClusters(t *testing.T) {
+	s := mustGetMemoryStorage(t)
+	defer s.Close()
+
+	FailOnError(t, s.Ping(context.Background()))
+	FailOnError(t, s.CreateNewCluster(context.Background(), 10, "cluster10"))
+
+	// ID is already used
+	assert.Error(t, s.CreateNewCluster(context.Background(), 10, "cluster10"))
+
+	clusters, err := s.ListOfClusters(context.Background(), false)
+	FailOnError(t, err)
+	assert.Equal(t, []storage.Cluster{
+		{ID: 1, Name: memoryClusterName},
+		{ID: 10, Name: "cluster10"},
+	}, clusters)
+
+	cluster, err := s.GetCluster(context.Background(), 10)
+	FailOnError(t, err)
+	assert.Equal(t, storage.ClusterName("cluster10"), cluster.Name)
+
+	cluster, err = s.GetClusterByName(context.Background(), memoryClusterName)
+	FailOnError(t, err)
+	assert.Equal(t, storage.ClusterID(1), cluster.ID)
+
+	// next automatically assigned ID should not collide
+	FailOnError(t, s.RegisterNewCluster(context.Background(), "cluster11"))
+	cluster, err = s.GetClusterByName(context.Background(), "cluster11")
+	FailOnError(t, err)
+	assert.Equal(t, storage.ClusterID(11), cluster.ID)
+
+	FailOnError(t, s.DeleteCluster(context.Background(), 10, "user"))
+	FailOnError(t, s.DeleteClusterByName(context.Background(), "cluster11", "user"))
+
+	_, err = s.GetCluster(context.Background(), 10)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.GetClusterByName(context.Background(), "cluster11")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.DeleteCluster(context.Background(), 10, "user"))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.DeleteClusterByName(context.Background(), "cluster11", "user"))
+}
+
+// TestMemoryStorageConfigurationProfiles checks the operations with configuration profiles
+func TestMemoryStorageConfigurationProfiles(t *testing.T) {
+	s := mustGetMemoryStorage(t)
+	defer s.Close()
+
+	profiles, err := s.StoreConfigurationProfile(context.Background(), "user", "description", "configuration")
+	FailOnError(t, err)
+	assert.Len(t, profiles, 1)
+
+	profiles, err = s.ChangeConfigurationProfile(context.Background(), 1, storage.AnyVersion, "user2", "description2", "configuration2")
+	FailOnError(t, err)
+	assert.Len(t, profiles, 1)
+
+	profile, err := s.GetConfigurationProfile(context.Background(), 1)
+	FailOnError(t, err)
+	assert.Equal(t, "configuration2", profile.Configuration)
+	assert.Equal(t, "user2", profile.ChangedBy)
+	assert.Equal(t, "description2", profile.Description)
+
+	_, err = s.ChangeConfigurationProfile(context.Background(), 2, storage.AnyVersion, "user", "description", "configuration")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	profiles, err = s.DeleteConfigurationProfile(context.Background(), 1, storage.AnyVersion, "user")
+	FailOnError(t, err)
+	assert.Len(t, profiles, 0)
+
+	_, err = s.GetConfigurationProfile(context.Background(), 1)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.DeleteConfigurationProfile(context.Background(), 1, storage.AnyVersion, "user")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+}
+
+// TestMemoryStorageClusterConfigurations checks the operations with cluster configurations
+func TestMemoryStorageClusterConfigurations(t *testing.T) {
+	s := mustGetMemoryStorage(t)
+	defer s.Close()
+
+	_, err := s.GetClusterActiveConfiguration(context.Background(), memoryClusterName)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.CreateClusterConfiguration(context.Background(), memoryClusterName, "user", "reason", "description", "first")
+	FailOnError(t, err)
+
+	configurations, err := s.CreateClusterConfiguration(context.Background(), memoryClusterName, "user", "reason", "description", "second")
+	FailOnError(t, err)
+	assert.Len(t, configurations, 2)
+
+	// only the latest configuration should be active
+	assert.Equal(t, "0", configurations[0].Active)
+	assert.Equal(t, "1", configurations[1].Active)
+	assert.Equal(t, memoryClusterName, configurations[1].Cluster)
+
+	configuration, err := s.GetClusterActiveConfiguration(context.Background(), memoryClusterName)
+	FailOnError(t, err)
+	assert.Equal(t, "second", configuration)
+
+	configuration, version, err := s.GetClusterConfigurationByID(context.Background(), 1)
+	FailOnError(t, err)
+	assert.Equal(t, "first", configuration)
+	// first configuration has been changed by deactivation
+	assert.Equal(t, 2, version)
+
+	id, err := s.GetConfigurationIDForCluster(context.Background(), memoryClusterName)
+	FailOnError(t, err)
+	assert.Equal(t, 2, id)
+
+	// only one configuration of the cluster can be active
+	err = s.EnableOrDisableClusterConfigurationByID(context.Background(), 1, storage.AnyVersion, "1")
+	assert.IsType(t, &storage.ActiveConfigurationConflictError{}, err)
+
+	configurations, err = s.DisableClusterConfiguration(context.Background(), memoryClusterName, storage.AnyVersion, "user", "reason")
+	FailOnError(t, err)
+	assert.Equal(t, "0", configurations[1].Active)
+
+	configurations, err = s.EnableClusterConfiguration(context.Background(), memoryClusterName, storage.AnyVersion, "user", "reason")
+	FailOnError(t, err)
+	assert.Equal(t, "0", configurations[0].Active)
+	assert.Equal(t, "1", configurations[1].Active)
+
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.EnableOrDisableClusterConfigurationByID(context.Background(), 42, storage.AnyVersion, "0"))
+
+	all, err := s.ListAllClusterConfigurations(context.Background(), false)
+	FailOnError(t, err)
+	assert.Len(t, all, 2)
+
+	FailOnError(t, s.DeleteClusterConfigurationByID(context.Background(), 1, storage.AnyVersion, "user"))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.DeleteClusterConfigurationByID(context.Background(), 1, storage.AnyVersion, "user"))
+
+	_, err = s.ListClusterConfiguration(context.Background(), "unknown", false)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.CreateClusterConfiguration(context.Background(), "unknown", "user", "reason", "description", "configuration")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// configurations of deleted cluster are not listed
+	FailOnError(t, s.DeleteClusterByName(context.Background(), memoryClusterName, "user"))
+	all, err = s.ListAllClusterConfigurations(context.Background(), false)
+	FailOnError(t, err)
+	assert.Len(t, all, 0)
+}
+
+// TestMemoryStorageTriggers checks the operations with triggers
+func TestMemoryStorageTriggers(t *testing.T) {
+	s := mustGetMemoryStorage(t)
+	defer s.Close()
+
+	FailOnError(t, s.NewTrigger(context.Background(), memoryClusterName, memoryTriggerType, "user", "reason", "link", "", time.Time{}, ""))
+
+	err := s.NewTrigger(context.Background(), memoryClusterName, "unknown", "user", "reason", "link", "", time.Time{}, "")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	err = s.NewTrigger(context.Background(), "unknown", memoryTriggerType, "user", "reason", "link", "", time.Time{}, "")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	trigger, err := s.GetTriggerByID(context.Background(), 1)
+	FailOnError(t, err)
+	assert.Equal(t, memoryTriggerType, trigger.Type)
+	assert.Equal(t, memoryClusterName, trigger.Cluster)
+	assert.Equal(t, 1, trigger.Active)
+
+	_, err = s.GetTriggerByID(context.Background(), 2)
+	assert.Equal(t, storage.ErrNoSuchObj, err)
+
+	triggers, err := s.ListActiveClusterTriggers(context.Background(), memoryClusterName)
+	FailOnError(t, err)
+	assert.Len(t, triggers, 1)
+
+	FailOnError(t, s.AckTrigger(context.Background(), memoryClusterName, 1))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.AckTrigger(context.Background(), memoryClusterName, 2))
+
+	triggers, err = s.ListActiveClusterTriggers(context.Background(), memoryClusterName)
+	FailOnError(t, err)
+	assert.Len(t, triggers, 0)
+
+	// delivered trigger needs to be cancelled before it is activated again
+	assert.IsType(t, &storage.InvalidTriggerTransitionError{}, s.ChangeStateOfTriggerByID(context.Background(), 1, 1))
+	FailOnError(t, s.ChangeStateOfTriggerByID(context.Background(), 1, 0))
+	FailOnError(t, s.ChangeStateOfTriggerByID(context.Background(), 1, 1))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.ChangeStateOfTriggerByID(context.Background(), 2, 1))
+
+	triggers, err = s.ListClusterTriggers(context.Background(), memoryClusterName)
+	FailOnError(t, err)
+	assert.Len(t, triggers, 1)
+
+	FailOnError(t, s.DeleteTriggerByID(context.Background(), 1))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.DeleteTriggerByID(context.Background(), 1))
+
+	triggers, err = s.ListAllTriggers(context.Background())
+	FailOnError(t, err)
+	assert.Len(t, triggers, 0)
+}
+
+// TestMemoryStorageConcurrentAccess checks that storage can be used from more goroutines
+func TestMemoryStorageConcurrentAccess(t *testing.T) {
+	s := mustGetMemoryStorage(t)
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.CreateClusterConfiguration(context.Background(), memoryClusterName, "user", "reason", "description", "configuration")
+			assert.NoError(t, err)
+			_, err = s.GetClusterActiveConfiguration(context.Background(), memoryClusterName)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	configurations, err := s.ListClusterConfiguration(context.Background(), memoryClusterName, false)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 10)
+}
+
+// TestMemoryStorageCancelledContext checks that operations with cancelled
+// context are refused
+func TestMemoryStorageCancelledContext(t *testing.T) {
+	s := mustGetMemoryStorage(t)
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.IsType(t, &storage.QueryCancelledError{}, s.Ping(ctx))
+
+	_, err := s.ListOfClusters(ctx, false)
+	assert.IsType(t, &storage.QueryCancelledError{}, err)
+
+	err = s.RegisterNewCluster(ctx, "cluster")
+	assert.IsType(t, &storage.QueryCancelledError{}, err)
+
+	// nothing should be changed
+	clusters, err := s.ListOfClusters(context.Background(), false)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 1)
+}
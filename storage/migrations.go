@@ -0,0 +1,265 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/migrations.html
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migrations are stored in SQL files in the migrations/<driver> directories.
+// File name has to be in format NNNN_short_description.sql where NNNN is
+// the schema version reached after the migration is applied. Versions have to
+// form a continuous sequence starting from 1. Once released, migration file
+// must never be changed - new migration needs to be added instead.
+//
+//go:embed migrations/sqlite3/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// SchemaVersion represents version of database schema
+type SchemaVersion uint
+
+// Migration represents one step of database schema migration
+type Migration struct {
+	Version     SchemaVersion
+	Description string
+	statements  string
+}
+
+// migrationInfoTable contains the only one record with current schema version
+const migrationInfoTable = "migration_info"
+
+// loadMigrations reads all migrations for given driver, sorted by version
+func loadMigrations(driver string) ([]Migration, error) {
+	switch driver {
+	case "sqlite3", "postgres":
+	default:
+		return nil, errors.New("unknown DB driver:" + driver)
+	}
+
+	directory := path.Join("migrations", driver)
+	entries, err := migrationFiles.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		parts := strings.SplitN(strings.TrimSuffix(name, ".sql"), "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("improper migration file name %s", name)
+		}
+
+		version, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("improper migration file name %s: %v", name, err)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join(directory, name))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{
+			Version:     SchemaVersion(version),
+			Description: strings.ReplaceAll(parts[1], "_", " "),
+			statements:  string(content),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	// versions have to form continuous sequence 1, 2, 3...
+	for i, migration := range migrations {
+		if migration.Version != SchemaVersion(i+1) {
+			return nil, fmt.Errorf("missing migration to schema version %d for driver %s", i+1, driver)
+		}
+	}
+
+	return migrations, nil
+}
+
+// LatestSchemaVersion returns the latest schema version known to this code
+func (storage DBStorage) LatestSchemaVersion() (SchemaVersion, error) {
+	migrations, err := loadMigrations(storage.driver)
+	if err != nil {
+		return 0, err
+	}
+	return SchemaVersion(len(migrations)), nil
+}
+
+// initMigrationInfo creates the table with schema version if it does not
+// exist yet. Database without this table has version 0.
+func (storage DBStorage) initMigrationInfo(ctx context.Context) error {
+	_, err := storage.connections.ExecContext(ctx,
+		"CREATE TABLE IF NOT EXISTS "+migrationInfoTable+" (version INTEGER NOT NULL)")
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = storage.connections.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+migrationInfoTable).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	switch count {
+	case 0:
+		_, err = storage.connections.ExecContext(ctx, "INSERT INTO "+migrationInfoTable+" (version) VALUES (0)")
+		return err
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf("table %s contains %d rows, but exactly one is expected", migrationInfoTable, count)
+	}
+}
+
+// GetSchemaVersion returns the current version of database schema
+func (storage DBStorage) GetSchemaVersion(ctx context.Context) (SchemaVersion, error) {
+	err := storage.initMigrationInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var version SchemaVersion
+	err = storage.connections.QueryRowContext(ctx, "SELECT version FROM "+migrationInfoTable).Scan(&version)
+	return version, err
+}
+
+// PendingMigrations returns list of migrations that has not been applied to
+// the database yet. It can be used for dry-run of migration process.
+func (storage DBStorage) PendingMigrations(ctx context.Context) ([]Migration, error) {
+	migrations, err := loadMigrations(storage.driver)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := storage.GetSchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := SchemaVersion(len(migrations))
+	if current > latest {
+		return nil, &SchemaVersionError{Current: current, Latest: latest}
+	}
+
+	return migrations[current:], nil
+}
+
+// CheckSchemaVersion checks whether the database schema is at the latest
+// version known to this code
+func (storage DBStorage) CheckSchemaVersion(ctx context.Context) error {
+	pending, err := storage.PendingMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		latest := pending[len(pending)-1].Version
+		return &SchemaVersionError{Current: pending[0].Version - 1, Latest: latest}
+	}
+	return nil
+}
+
+// MigrateToLatest applies all pending migrations to the database. Each
+// migration is performed in its own transaction together with the update of
+// schema version. Query timeout is not applied to migrations, because they
+// might take a long time on large databases.
+func (storage DBStorage) MigrateToLatest(ctx context.Context) error {
+	pending, err := storage.PendingMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		log.Println("Database schema is up to date")
+		return nil
+	}
+
+	for _, migration := range pending {
+		log.Printf("Migrating database schema to version %d: %s", migration.Version, migration.Description)
+		err := storage.applyMigration(ctx, migration)
+		if err != nil {
+			log.Printf("Migration to schema version %d failed: %v", migration.Version, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMigration performs one migration step in a transaction
+func (storage DBStorage) applyMigration(ctx context.Context, migration Migration) error {
+	tx, err := storage.connections.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = storage.applyMigrationInTransaction(ctx, tx, migration)
+	if err != nil {
+		// in case of error all we can do is to just log the error
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Println(rollbackErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applyMigrationInTransaction executes migration statements and updates the
+// schema version. To be used in transaction.
+func (storage DBStorage) applyMigrationInTransaction(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	_, err := tx.ExecContext(ctx, migration.statements)
+	if err != nil {
+		return err
+	}
+
+	// version is checked again to detect concurrent migrations
+	result, err := tx.ExecContext(ctx,
+		"UPDATE "+migrationInfoTable+" SET version = $1 WHERE version = $2",
+		migration.Version, migration.Version-1)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return fmt.Errorf("schema version has been changed during migration to version %d", migration.Version)
+	}
+	return nil
+}
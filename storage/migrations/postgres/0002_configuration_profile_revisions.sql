@@ -0,0 +1,35 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Immutable history of configuration profiles. Each change of profile
+-- creates a new revision, the profile itself contains the latest revision.
+
+create table configuration_profile_revision (
+    profile       integer not null,
+    revision      integer not null,
+    configuration varchar not null,
+    changed_at    timestamp,
+    changed_by    varchar,
+    description   varchar,
+    PRIMARY KEY (profile, revision),
+    CONSTRAINT fk_profile
+        foreign key (profile)
+        references configuration_profile(ID)
+        on delete cascade
+);
+
+-- existing profiles become the first revision
+insert into configuration_profile_revision (profile, revision, configuration, changed_at, changed_by, description)
+select ID, 1, configuration, changed_at, changed_by, description from configuration_profile;
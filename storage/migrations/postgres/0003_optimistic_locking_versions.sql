@@ -0,0 +1,20 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Version of configuration profiles and cluster configurations is increased
+-- by each change. It is used by clients to detect concurrent modifications.
+
+alter table configuration_profile add column version integer not null default 1;
+alter table operator_configuration add column version integer not null default 1;
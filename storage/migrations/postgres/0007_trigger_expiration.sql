@@ -0,0 +1,25 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Triggers expire when they are not picked up by the operator in time.
+-- Expired triggers are deactivated and marked as expired by the sweeper.
+-- Default time to live of new triggers (in seconds) is set per trigger type.
+
+alter table trigger add column expires_at timestamp;
+alter table trigger add column expired integer not null default 0;
+alter table trigger_type add column default_ttl integer;
+
+update trigger_type set default_ttl = 604800 where type = 'must-gather';
@@ -0,0 +1,42 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Triggers go through explicit lifecycle states instead of the active flag
+-- and acknowledge timestamp. The flags are kept for compatibility and they
+-- are derived from the state. All changes of state are recorded together
+-- with time of the change.
+
+alter table trigger add column state varchar not null default 'pending';
+
+update trigger set state = 'expired' where expired = 1;
+update trigger set state = 'delivered' where expired = 0 and active = 0 and acked_at > '1970-01-02';
+update trigger set state = 'cancelled' where expired = 0 and active = 0 and state = 'pending';
+
+create table trigger_transition (
+    ID            serial primary key,
+    trigger       integer not null,
+    state         varchar not null,
+    changed_at    timestamp,
+    changed_by    varchar,
+    CONSTRAINT fk_trigger
+        foreign key (trigger)
+        references trigger(ID)
+        on delete cascade
+);
+
+-- current state of existing triggers becomes the first transition
+insert into trigger_transition (trigger, state, changed_at, changed_by)
+select ID, state, triggered_at, triggered_by from trigger order by ID;
@@ -0,0 +1,32 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Metadata of clusters reported by the operator during registration. Each
+-- registration replaces metadata reported before.
+
+create table cluster_metadata (
+    cluster          integer primary key,
+    ocp_version      varchar,
+    platform         varchar,
+    operator_version varchar,
+    region           varchar,
+    node_count       integer not null default 0,
+    updated_at       timestamp not null,
+    CONSTRAINT fk_cluster
+        foreign key (cluster)
+        references cluster(ID)
+        on delete cascade
+);
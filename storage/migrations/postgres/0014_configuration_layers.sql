@@ -0,0 +1,45 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Layers of configuration that are merged with configuration of each cluster.
+-- Global default profile is applied to all clusters, group profiles are
+-- applied to clusters selected by label selector. Configuration of cluster
+-- has the highest precedence.
+
+create table configuration_default (
+    ID               integer primary key,
+    configuration    integer not null,
+    changed_at       timestamp not null,
+    changed_by       varchar not null,
+    CONSTRAINT fk_configuration
+        foreign key (configuration)
+        references configuration_profile(ID)
+        on delete cascade
+);
+
+create table configuration_group (
+    ID               serial primary key,
+    name             varchar not null unique,
+    selector         varchar not null,
+    priority         integer not null default 0,
+    configuration    integer not null,
+    changed_at       timestamp not null,
+    changed_by       varchar not null,
+    CONSTRAINT fk_configuration
+        foreign key (configuration)
+        references configuration_profile(ID)
+        on delete cascade
+);
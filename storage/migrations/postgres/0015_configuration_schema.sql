@@ -0,0 +1,25 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- JSON schema that configuration profiles and cluster configurations are
+-- validated against. Built-in schema is used until the schema is set.
+
+create table configuration_schema (
+    ID               integer primary key,
+    json_schema      varchar not null,
+    changed_at       timestamp not null,
+    changed_by       varchar not null
+);
@@ -0,0 +1,63 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Staged rollouts of configuration profile. Each rollout applies one
+-- revision of profile to clusters selected by label selector in waves, every
+-- cluster touched by the rollout is recorded together with its wave and with
+-- the cluster configuration created for it. Operators acknowledge
+-- configuration by reading it.
+
+alter table operator_configuration add column acked_at timestamp;
+
+create table configuration_rollout (
+    ID               serial primary key,
+    configuration    integer not null,
+    revision         integer not null,
+    selector         varchar not null,
+    waves            varchar not null,
+    current_wave     integer not null default 0,
+    state            varchar not null,
+    created_at       timestamp not null,
+    created_by       varchar not null,
+    changed_at       timestamp not null,
+    changed_by       varchar not null,
+    reason           varchar,
+    CONSTRAINT fk_configuration
+        foreign key (configuration)
+        references configuration_profile(ID)
+        on delete cascade
+);
+
+create table configuration_rollout_cluster (
+    rollout          integer not null,
+    cluster          integer not null,
+    wave             integer not null,
+    configuration    integer not null,
+    applied_at       timestamp not null,
+    PRIMARY KEY (rollout, cluster),
+    CONSTRAINT fk_rollout
+        foreign key (rollout)
+        references configuration_rollout(ID)
+        on delete cascade,
+    CONSTRAINT fk_cluster
+        foreign key (cluster)
+        references cluster(ID)
+        on delete cascade,
+    CONSTRAINT fk_configuration
+        foreign key (configuration)
+        references operator_configuration(ID)
+        on delete cascade
+);
@@ -0,0 +1,34 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Cluster configuration can be effective only within time window, for
+-- example within maintenance window approved by customer. Outside the window
+-- the previous configuration of the cluster is served. Actual activation and
+-- expiry of configurations are recorded by scheduler.
+
+alter table operator_configuration add column effective_from timestamp;
+alter table operator_configuration add column effective_until timestamp;
+
+create table configuration_transition (
+    ID               serial primary key,
+    configuration    integer not null,
+    event            varchar not null,
+    changed_at       timestamp not null,
+    CONSTRAINT fk_configuration
+        foreign key (configuration)
+        references operator_configuration(ID)
+        on delete cascade
+);
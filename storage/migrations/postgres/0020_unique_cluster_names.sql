@@ -0,0 +1,31 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Names of clusters that are not deleted are unique, so repeated registration
+-- of a cluster does not create another cluster with the same name. Duplicate
+-- clusters created by repeated registrations are marked as deleted first,
+-- the oldest cluster with each name is kept. Deletion time is written in UTC
+-- as the timestamps written by the service, so the duplicates are compared
+-- correctly when deleted items are purged.
+
+update cluster
+   set deleted_at = (now() at time zone 'utc'), deleted_by = 'migration'
+ where deleted_at is null
+   and id > (select min(other.id) from cluster as other
+              where other.name = cluster.name and other.deleted_at is null);
+
+create unique index cluster_unique_name
+    on cluster(name) where deleted_at is null;
@@ -0,0 +1,31 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Activated cluster configuration remembers the configuration that was active
+-- just before it. Outside of its time window only that configuration is
+-- served, never a configuration that has been disabled or rolled back in the
+-- meantime. Active configurations created before this migration fall back to
+-- the newest older configuration of the same cluster.
+
+alter table operator_configuration add column previous_configuration integer;
+
+update operator_configuration
+   set previous_configuration =
+       (select max(other.id) from operator_configuration as other
+         where other.cluster = operator_configuration.cluster
+           and other.id < operator_configuration.id
+           and other.deleted_at is null)
+ where active = 1 and deleted_at is null;
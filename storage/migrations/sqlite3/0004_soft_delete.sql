@@ -0,0 +1,25 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+-- Deleted clusters, configuration profiles, and cluster configurations are
+-- only marked as deleted, so their history is kept. They are removed
+-- permanently by purge after the retention period.
+
+alter table cluster add column deleted_at datetime;
+alter table cluster add column deleted_by varchar;
+alter table configuration_profile add column deleted_at datetime;
+alter table configuration_profile add column deleted_by varchar;
+alter table operator_configuration add column deleted_at datetime;
+alter table operator_configuration add column deleted_by varchar;
@@ -0,0 +1,24 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Parameters of triggers are validated against JSON schema registered for
+-- trigger type. Triggers of types without schema accept any JSON object.
+
+alter table trigger_type add column parameters_schema varchar;
+
+update trigger_type
+   set parameters_schema = '{"type": "object", "properties": {"image": {"type": "string", "minLength": 1, "description": "must-gather image"}, "timeout": {"type": "integer", "minimum": 1, "description": "timeout in seconds"}, "namespaces": {"type": "array", "items": {"type": "string"}, "description": "namespaces to gather data from"}}, "additionalProperties": false}'
+ where type = 'must-gather';
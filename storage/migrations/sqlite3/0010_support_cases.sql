@@ -0,0 +1,33 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Customer support cases that must-gather triggers are attached to. Trigger
+-- types can require triggers to be linked to open support case.
+
+create table support_case (
+    ID            integer primary key asc,
+    case_number   varchar not null unique,
+    account       varchar not null,
+    consent_link  varchar,
+    status        varchar not null default 'open',
+    created_at    datetime,
+    created_by    varchar
+);
+
+alter table trigger add column support_case integer references support_case(ID);
+alter table trigger_type add column requires_case integer not null default 0;
+
+update trigger_type set requires_case = 1 where type = 'must-gather';
@@ -0,0 +1,30 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Key/value labels of clusters, for example env=prod, that are used to
+-- select groups of clusters by label selectors. Each cluster can have at
+-- most one value of each label.
+
+create table cluster_label (
+    cluster       integer not null,
+    name          varchar not null,
+    value         varchar not null,
+    primary key (cluster, name),
+    CONSTRAINT fk_cluster
+        foreign key (cluster)
+        references cluster(ID)
+        on delete cascade
+);
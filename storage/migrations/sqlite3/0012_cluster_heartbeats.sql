@@ -0,0 +1,30 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- Last time when the operator running on cluster called the controller,
+-- together with address the call came from and version of the operator.
+-- Clusters that have not checked in for too long are considered stale.
+
+create table cluster_heartbeat (
+    cluster          integer primary key,
+    last_seen_at     datetime not null,
+    address          varchar,
+    operator_version varchar,
+    CONSTRAINT fk_cluster
+        foreign key (cluster)
+        references cluster(ID)
+        on delete cascade
+);
@@ -0,0 +1,30 @@
+-- Copyright 2023 Red Hat, Inc
+--
+-- Licensed under the Apache License, Version 2.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--      http://www.apache.org/licenses/LICENSE-2.0
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+
+
+-- At most one configuration of each cluster can be active. All but the
+-- newest active configuration of each cluster are deactivated first (the
+-- same as RepairActiveConfigurations does), then the invariant is enforced
+-- by partial unique index.
+
+update operator_configuration
+   set active = 0, version = version + 1
+ where active = 1 and deleted_at is null
+   and id < (select max(other.id) from operator_configuration as other
+              where other.cluster = operator_configuration.cluster
+                and other.active = 1 and other.deleted_at is null);
+
+create unique index operator_configuration_single_active
+    on operator_configuration(cluster) where active = 1 and deleted_at is null;
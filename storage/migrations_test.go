@@ -0,0 +1,157 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/migrations_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// deletedAtFormat matches UTC timestamps as written by the SQLite driver
+const deletedAtFormat = `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(\.\d+)?\+00:00$`
+
+// TestMigrateEmptyDatabase checks migration of empty database to the latest version
+func TestMigrateEmptyDatabase(t *testing.T) {
+	s, closer := MustGetMockStorage(t, false)
+	defer closer()
+
+	version, err := s.GetSchemaVersion(context.Background())
+	FailOnError(t, err)
+	assert.Equal(t, storage.SchemaVersion(0), version)
+
+	latest, err := s.LatestSchemaVersion()
+	FailOnError(t, err)
+
+	pending, err := s.PendingMigrations(context.Background())
+	FailOnError(t, err)
+	assert.Len(t, pending, int(latest))
+	assert.Equal(t, storage.SchemaVersion(1), pending[0].Version)
+	assert.Equal(t, "initial schema", pending[0].Description)
+
+	// dry run should not change the schema
+	assert.IsType(t, &storage.SchemaVersionError{}, s.CheckSchemaVersion(context.Background()))
+
+	FailOnError(t, s.MigrateToLatest(context.Background()))
+
+	version, err = s.GetSchemaVersion(context.Background())
+	FailOnError(t, err)
+	assert.Equal(t, latest, version)
+
+	pending, err = s.PendingMigrations(context.Background())
+	FailOnError(t, err)
+	assert.Len(t, pending, 0)
+	FailOnError(t, s.CheckSchemaVersion(context.Background()))
+
+	// repeated migration is no-op
+	FailOnError(t, s.MigrateToLatest(context.Background()))
+
+	// schema has to be usable
+	FailOnError(t, s.RegisterNewCluster(context.Background(), "cluster"))
+}
+
+// TestMigrateDatabaseCreatedByScripts checks migration of database with
+// schema created by scripts, ie. without version table
+func TestMigrateDatabaseCreatedByScripts(t *testing.T) {
+	s, db := mustGetSqliteStorage(t, dataSource, false)
+	defer MustCloseStorage(t, s)
+
+	initializeDatabase(t, db)
+
+	// the same cluster registered twice by older versions of the service
+	for i := 0; i < 2; i++ {
+		_, err := db.Exec("INSERT INTO cluster(name) VALUES ('cluster')")
+		FailOnError(t, err)
+	}
+
+	FailOnError(t, s.MigrateToLatest(context.Background()))
+	FailOnError(t, s.CheckSchemaVersion(context.Background()))
+
+	// existing data has to be preserved
+	cluster, err := s.GetClusterByName(context.Background(), "cluster")
+	FailOnError(t, err)
+	assert.Equal(t, storage.ClusterName("cluster"), cluster.Name)
+	assert.Equal(t, storage.ClusterID(1), cluster.ID)
+
+	// only the oldest of duplicate clusters is kept
+	clusters, err := s.ListOfClusters(context.Background(), false)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 1)
+
+	// deletion time of the duplicate has the same format as deletion time
+	// written by the service, so both are compared correctly when purged
+	FailOnError(t, s.RegisterNewCluster(context.Background(), "other"))
+	FailOnError(t, s.DeleteClusterByName(context.Background(), "other", "tester"))
+
+	var migrated, deleted string
+	FailOnError(t, db.QueryRow("SELECT deleted_at || '' FROM cluster WHERE id = 2").Scan(&migrated))
+	FailOnError(t, db.QueryRow("SELECT deleted_at || '' FROM cluster WHERE name = 'other'").Scan(&deleted))
+	assert.Regexp(t, deletedAtFormat, migrated)
+	assert.Regexp(t, deletedAtFormat, deleted)
+
+	purged, err := s.PurgeDeleted(context.Background(), time.Now())
+	FailOnError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+// TestMigrateNewerSchema checks that database with newer schema is refused
+func TestMigrateNewerSchema(t *testing.T) {
+	s, db := mustGetSqliteStorage(t, dataSource, true)
+	defer MustCloseStorage(t, s)
+
+	_, err := db.Exec("UPDATE migration_info SET version = version + 1")
+	FailOnError(t, err)
+
+	err = s.MigrateToLatest(context.Background())
+	assert.IsType(t, &storage.SchemaVersionError{}, err)
+	assert.Contains(t, err.Error(), "is newer than the latest known version")
+
+	_, err = s.PendingMigrations(context.Background())
+	assert.IsType(t, &storage.SchemaVersionError{}, err)
+
+	assert.IsType(t, &storage.SchemaVersionError{}, s.CheckSchemaVersion(context.Background()))
+}
+
+// TestMigrateUnknownDriver checks that migrations are not available for unknown driver
+func TestMigrateUnknownDriver(t *testing.T) {
+	_, db := mustGetSqliteStorage(t, dataSource, false)
+	s := storage.NewFromConnection(db, "unknown")
+	defer MustCloseStorage(t, s)
+
+	_, err := s.LatestSchemaVersion()
+	assert.EqualError(t, err, "unknown DB driver:unknown")
+
+	assert.EqualError(t, s.MigrateToLatest(context.Background()), "unknown DB driver:unknown")
+}
+
+// TestMigrationsForAllDrivers checks that both dialects know the same schema versions
+func TestMigrationsForAllDrivers(t *testing.T) {
+	sqliteVersion, err := storage.NewFromConnection(nil, "sqlite3").LatestSchemaVersion()
+	FailOnError(t, err)
+
+	postgresVersion, err := storage.NewFromConnection(nil, "postgres").LatestSchemaVersion()
+	FailOnError(t, err)
+
+	assert.Equal(t, sqliteVersion, postgresVersion)
+}
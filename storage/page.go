@@ -0,0 +1,373 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/page.html
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+)
+
+// Page selects one page of listed items. Items are sorted by the Sort column
+// in the given Order and items with the same sort value by their IDs, so the
+// ordering is stable and items are neither skipped nor repeated when other
+// items are added or removed between requests. Only items that follow the
+// item pointed to by After are listed when it is set. Zero Limit means that
+// all remaining items are listed.
+//
+// Storage returns one item more than the Limit when there are more items to
+// be listed, so the caller can tell whether there is a next page.
+type Page struct {
+	Limit int
+	Sort  string
+	Order SortOrder
+	After *PageCursor
+}
+
+// PageCursor points to the last item of the previous page
+type PageCursor struct {
+	Value SortValue
+	ID    int64
+}
+
+// SortValue is value of the column that listed items are sorted by. Only
+// one of its fields is used for given column.
+type SortValue struct {
+	Text   string `json:"t,omitempty"`
+	Number int64  `json:"n,omitempty"`
+}
+
+// PageItem is implemented by all items that can be listed page by page
+type PageItem interface {
+	PageID() int64
+	SortValue(column string) SortValue
+}
+
+// sortKind specifies how the values of sort column are compared
+type sortKind int
+
+const (
+	sortNumber sortKind = iota
+	sortText
+	sortTimestamp
+)
+
+// pageColumn is column of listed items that they can be sorted by. Name is
+// the column of the query that selects the items.
+type pageColumn struct {
+	name string
+	kind sortKind
+}
+
+// ClusterSortColumns contains columns that clusters can be sorted by
+var ClusterSortColumns = []string{"id", "name"}
+
+var clusterPageColumns = map[string]pageColumn{
+	"id":   {"id", sortNumber},
+	"name": {"name", sortText},
+}
+
+// ProfileSortColumns contains columns that configuration profiles can be
+// sorted by
+var ProfileSortColumns = []string{"id", "changed_at", "changed_by", "description"}
+
+var profilePageColumns = map[string]pageColumn{
+	"id":          {"id", sortNumber},
+	"changed_at":  {"changed_at", sortTimestamp},
+	"changed_by":  {"changed_by", sortText},
+	"description": {"description", sortText},
+}
+
+// RevisionSortColumns contains columns that configuration profile revisions
+// can be sorted by, revision number is used as ID of revision
+var RevisionSortColumns = []string{"id", "changed_at", "changed_by"}
+
+var revisionPageColumns = map[string]pageColumn{
+	"id":         {"revision", sortNumber},
+	"changed_at": {"changed_at", sortTimestamp},
+	"changed_by": {"changed_by", sortText},
+}
+
+// ConfigurationSortColumns contains columns that cluster configurations can
+// be sorted by
+var ConfigurationSortColumns = []string{"id", "cluster", "changed_at", "changed_by", "active"}
+
+var configurationPageColumns = map[string]pageColumn{
+	"id":         {"id", sortNumber},
+	"cluster":    {"name", sortText},
+	"changed_at": {"changed_at", sortTimestamp},
+	"changed_by": {"changed_by", sortText},
+	"active":     {"active", sortNumber},
+}
+
+// TriggerSortColumns contains columns that triggers can be sorted by
+var TriggerSortColumns = []string{"id", "type", "cluster", "triggered_at", "triggered_by", "active", "expires_at", "state"}
+
+var triggerPageColumns = map[string]pageColumn{
+	"id":           {"id", sortNumber},
+	"type":         {"type", sortText},
+	"cluster":      {"name", sortText},
+	"triggered_at": {"triggered_at", sortTimestamp},
+	"triggered_by": {"triggered_by", sortText},
+	"active":       {"active", sortNumber},
+	"expires_at":   {"expires_at", sortTimestamp},
+	"state":        {"state", sortText},
+}
+
+// PageID returns ID of the cluster
+func (c Cluster) PageID() int64 {
+	return int64(c.ID)
+}
+
+// SortValue returns value of the cluster column
+func (c Cluster) SortValue(column string) SortValue {
+	if column == "name" {
+		return SortValue{Text: string(c.Name)}
+	}
+	return SortValue{Number: int64(c.ID)}
+}
+
+// PageID returns ID of the configuration profile
+func (p ConfigurationProfile) PageID() int64 {
+	return int64(p.ID)
+}
+
+// SortValue returns value of the configuration profile column
+func (p ConfigurationProfile) SortValue(column string) SortValue {
+	switch column {
+	case "changed_at":
+		return SortValue{Text: p.ChangedAt}
+	case "changed_by":
+		return SortValue{Text: p.ChangedBy}
+	case "description":
+		return SortValue{Text: p.Description}
+	}
+	return SortValue{Number: int64(p.ID)}
+}
+
+// PageID returns revision number, which is used as ID of the revision
+func (r ConfigurationProfileRevision) PageID() int64 {
+	return int64(r.Revision)
+}
+
+// SortValue returns value of the configuration profile revision column
+func (r ConfigurationProfileRevision) SortValue(column string) SortValue {
+	switch column {
+	case "changed_at":
+		return SortValue{Text: r.ChangedAt}
+	case "changed_by":
+		return SortValue{Text: r.ChangedBy}
+	}
+	return SortValue{Number: int64(r.Revision)}
+}
+
+// PageID returns ID of the cluster configuration
+func (c ClusterConfiguration) PageID() int64 {
+	return int64(c.ID)
+}
+
+// SortValue returns value of the cluster configuration column
+func (c ClusterConfiguration) SortValue(column string) SortValue {
+	switch column {
+	case "cluster":
+		return SortValue{Text: c.Cluster}
+	case "changed_at":
+		return SortValue{Text: c.ChangedAt}
+	case "changed_by":
+		return SortValue{Text: c.ChangedBy}
+	case "active":
+		active, _ := strconv.ParseInt(c.Active, 10, 64)
+		return SortValue{Number: active}
+	}
+	return SortValue{Number: int64(c.ID)}
+}
+
+// PageID returns ID of the trigger
+func (t Trigger) PageID() int64 {
+	return int64(t.ID)
+}
+
+// SortValue returns value of the trigger column
+func (t Trigger) SortValue(column string) SortValue {
+	switch column {
+	case "type":
+		return SortValue{Text: t.Type}
+	case "cluster":
+		return SortValue{Text: t.Cluster}
+	case "triggered_at":
+		return SortValue{Text: t.TriggeredAt}
+	case "triggered_by":
+		return SortValue{Text: t.TriggeredBy}
+	case "active":
+		return SortValue{Number: int64(t.Active)}
+	case "expires_at":
+		return SortValue{Text: t.ExpiresAt}
+	case "state":
+		return SortValue{Text: string(t.State)}
+	}
+	return SortValue{Number: int64(t.ID)}
+}
+
+// checkPage checks that items can be sorted by the column specified in page
+func checkPage(columns map[string]pageColumn, page Page) error {
+	if _, found := columns[page.Sort]; !found {
+		return &InvalidPageError{Reason: "items can not be sorted by column " + page.Sort}
+	}
+	if page.Order != Ascending && page.Order != Descending {
+		return &InvalidPageError{Reason: "order needs to be asc or desc"}
+	}
+	return nil
+}
+
+// compareSortValues returns negative number, zero, or positive number when
+// the first value is lower, equal, or greater than the second value
+func compareSortValues(a, b SortValue) int {
+	switch {
+	case a.Number < b.Number:
+		return -1
+	case a.Number > b.Number:
+		return 1
+	case a.Text < b.Text:
+		return -1
+	case a.Text > b.Text:
+		return 1
+	}
+	return 0
+}
+
+// paginateItems sorts items stored in memory and selects the ones that
+// belong to the requested page, including one more item when there are more
+// items to be listed
+func paginateItems(items []PageItem, columns map[string]pageColumn, page Page) ([]PageItem, error) {
+	if err := checkPage(columns, page); err != nil {
+		return nil, err
+	}
+
+	// compare returns negative number when the item precedes the item with
+	// given sort value and ID in the requested ordering
+	compare := func(item PageItem, value SortValue, id int64) int {
+		result := compareSortValues(item.SortValue(page.Sort), value)
+		if result == 0 {
+			switch {
+			case item.PageID() < id:
+				result = -1
+			case item.PageID() > id:
+				result = 1
+			}
+		}
+		if page.Order == Descending {
+			return -result
+		}
+		return result
+	}
+
+	sort.Slice(items, func(a, b int) bool {
+		return compare(items[a], items[b].SortValue(page.Sort), items[b].PageID()) < 0
+	})
+
+	// skip all items up to the cursor
+	start := 0
+	if page.After != nil {
+		start = sort.Search(len(items), func(i int) bool {
+			return compare(items[i], page.After.Value, page.After.ID) > 0
+		})
+	}
+	end := len(items)
+	if page.Limit > 0 && start+page.Limit+1 < end {
+		end = start + page.Limit + 1
+	}
+	return items[start:end], nil
+}
+
+// sortKey returns SQL expression that listed items are sorted by. Timestamps
+// are converted to text in the same format for all drivers, so values read
+// from the database can be compared with values from cursor. NULL texts and
+// timestamps are sorted as empty text, so they can be compared too.
+func (storage DBStorage) sortKey(column pageColumn, expression string) string {
+	switch column.kind {
+	case sortNumber:
+		return expression
+	case sortText:
+		return "COALESCE(" + expression + ", '')"
+	}
+	if storage.driver == "postgres" {
+		return "COALESCE(to_char(" + expression + "::timestamp, 'YYYY-MM-DD HH24:MI:SS.US'), '')"
+	}
+	return "COALESCE(strftime('%Y-%m-%d %H:%M:%f', " + expression + "), '')"
+}
+
+// queryPage selects one page of items listed by the query together with
+// total number of the listed items. Items are selected from the database
+// directly (keyset pagination), rows need to be read and closed by the
+// caller. Query can not be ordered and idColumn is its column with unique
+// IDs of items.
+func (storage DBStorage) queryPage(ctx context.Context, query string, args []interface{}, columns map[string]pageColumn, idColumn string, page Page) (*sql.Rows, int, error) {
+	if err := checkPage(columns, page); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err := storage.connections.QueryRowContext(ctx, "SELECT count(*) FROM ("+query+") paged", args...).Scan(&total)
+	if err != nil {
+		log.Print(err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	column := columns[page.Sort]
+	key := storage.sortKey(column, column.name)
+	direction, comparison := "ASC", ">"
+	if page.Order == Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	pageQuery := "SELECT * FROM (" + query + ") paged"
+	if page.After != nil {
+		var value interface{} = page.After.Value.Number
+		valueParam := fmt.Sprintf("$%d", len(args)+1)
+		switch column.kind {
+		case sortText:
+			value = page.After.Value.Text
+		case sortTimestamp:
+			value = page.After.Value.Text
+			valueParam = storage.sortKey(column, fmt.Sprintf("NULLIF($%d, '')", len(args)+1))
+		}
+		idParam := fmt.Sprintf("$%d", len(args)+2)
+		pageQuery += fmt.Sprintf(" WHERE (%s %s %s OR (%s = %s AND %s %s %s))",
+			key, comparison, valueParam, key, valueParam, idColumn, comparison, idParam)
+		args = append(args, value, page.After.ID)
+	}
+	pageQuery += fmt.Sprintf(" ORDER BY %s %s, %s %s", key, direction, idColumn, direction)
+	if page.Limit > 0 {
+		pageQuery += fmt.Sprintf(" LIMIT %d", page.Limit+1)
+	}
+
+	rows, err := storage.connections.QueryContext(ctx, pageQuery, args...)
+	if err != nil {
+		log.Print(err)
+		return nil, 0, queryError(ctx, err)
+	}
+	return rows, total, nil
+}
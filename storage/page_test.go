@@ -0,0 +1,220 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/page_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// nextPage returns page that follows the given page of items, nil is
+// returned when there are no more items to be listed
+func nextPage(page storage.Page, items []storage.PageItem) *storage.Page {
+	if len(items) <= page.Limit {
+		return nil
+	}
+	last := items[page.Limit-1]
+	page.After = &storage.PageCursor{Value: last.SortValue(page.Sort), ID: last.PageID()}
+	return &page
+}
+
+// checkClustersPagination checks that all clusters are listed page by page
+// in the requested order
+func checkClustersPagination(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	for _, name := range []string{"c", "a", "e", "b", "d"} {
+		FailOnError(t, s.RegisterNewCluster(ctx, name))
+	}
+
+	names := []storage.ClusterName{}
+	page := &storage.Page{Limit: 2, Sort: "name", Order: storage.Descending}
+	for page != nil {
+		clusters, total, err := s.ListOfClustersPage(ctx, false, *page)
+		FailOnError(t, err)
+		assert.Equal(t, 5, total)
+		assert.True(t, len(clusters) <= 3)
+
+		items := []storage.PageItem{}
+		for i, cluster := range clusters {
+			items = append(items, cluster)
+			if i < page.Limit {
+				names = append(names, cluster.Name)
+			}
+		}
+		page = nextPage(*page, items)
+	}
+	assert.Equal(t, []storage.ClusterName{"e", "d", "c", "b", "a"}, names)
+
+	_, _, err := s.ListOfClustersPage(ctx, false, storage.Page{Sort: "deleted_by", Order: storage.Ascending})
+	assert.IsType(t, &storage.InvalidPageError{}, err)
+}
+
+// checkTriggersPagination checks that triggers are sorted by timestamps,
+// triggers without the timestamp first, and that they are filtered by state
+func checkTriggersPagination(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.NewTriggerType(ctx, "must-gather", "must-gather", ""))
+	for _, expiresAt := range []time.Time{now.Add(2 * time.Hour), {}, now.Add(time.Hour), {}, now.Add(3 * time.Hour)} {
+		FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", "", expiresAt, ""))
+	}
+
+	ids := []storage.TriggerID{}
+	page := &storage.Page{Limit: 1, Sort: "expires_at", Order: storage.Ascending}
+	for page != nil {
+		triggers, total, err := s.ListClusterTriggersPage(ctx, "cluster", []storage.TriggerState{storage.TriggerPending}, *page)
+		FailOnError(t, err)
+		assert.Equal(t, 5, total)
+
+		items := []storage.PageItem{}
+		for _, trigger := range triggers {
+			items = append(items, trigger)
+		}
+		ids = append(ids, triggers[0].ID)
+		page = nextPage(*page, items)
+	}
+	assert.Equal(t, []storage.TriggerID{2, 4, 3, 1, 5}, ids)
+
+	triggers, total, err := s.ListAllTriggersPage(ctx, []storage.TriggerState{storage.TriggerDelivered}, storage.Page{Sort: "id", Order: storage.Ascending})
+	FailOnError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, triggers)
+}
+
+// checkProfilesPagination checks that profiles changed at the same time are
+// ordered by their IDs
+func checkProfilesPagination(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		_, err := s.StoreConfigurationProfile(ctx, "user", "description", "{}")
+		FailOnError(t, err)
+	}
+
+	// listProfiles lists IDs of all profiles page by page
+	listProfiles := func(page *storage.Page) []storage.ConfigurationID {
+		ids := []storage.ConfigurationID{}
+		for page != nil {
+			profiles, total, err := s.ListConfigurationProfilesPage(ctx, false, *page)
+			FailOnError(t, err)
+			assert.Equal(t, 4, total)
+
+			items := []storage.PageItem{}
+			for i, profile := range profiles {
+				items = append(items, profile)
+				if i < page.Limit {
+					ids = append(ids, profile.ID)
+				}
+			}
+			page = nextPage(*page, items)
+		}
+		return ids
+	}
+
+	ids := listProfiles(&storage.Page{Limit: 3, Sort: "changed_by", Order: storage.Descending})
+	assert.Equal(t, []storage.ConfigurationID{4, 3, 2, 1}, ids)
+
+	ids = listProfiles(&storage.Page{Limit: 1, Sort: "changed_at", Order: storage.Ascending})
+	assert.Equal(t, []storage.ConfigurationID{1, 2, 3, 4}, ids)
+}
+
+// listProfileIDs lists IDs of all profiles page by page
+func listProfileIDs(t *testing.T, s storage.Storage, page *storage.Page) []storage.ConfigurationID {
+	ids := []storage.ConfigurationID{}
+	for page != nil {
+		profiles, _, err := s.ListConfigurationProfilesPage(context.Background(), false, *page)
+		FailOnError(t, err)
+
+		items := []storage.PageItem{}
+		for i, profile := range profiles {
+			items = append(items, profile)
+			if i < page.Limit {
+				ids = append(ids, profile.ID)
+			}
+		}
+		page = nextPage(*page, items)
+	}
+	return ids
+}
+
+// checkEmptySortValuesPagination checks that items with empty value of the
+// sort column are listed first and the following pages are not lost
+func checkEmptySortValuesPagination(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	for _, description := range []string{"b", "", "a", ""} {
+		_, err := s.StoreConfigurationProfile(ctx, "user", description, "{}")
+		FailOnError(t, err)
+	}
+
+	ids := listProfileIDs(t, s, &storage.Page{Limit: 1, Sort: "description", Order: storage.Ascending})
+	assert.Equal(t, []storage.ConfigurationID{2, 4, 3, 1}, ids)
+
+	ids = listProfileIDs(t, s, &storage.Page{Limit: 1, Sort: "description", Order: storage.Descending})
+	assert.Equal(t, []storage.ConfigurationID{1, 3, 4, 2}, ids)
+}
+
+// TestNullSortValuesPagination checks that items with NULL value of the sort
+// column stored in SQL database are listed the same way as empty values
+func TestNullSortValuesPagination(t *testing.T) {
+	s, db := mustGetSqliteStorage(t, ":memory:", false)
+	defer s.Close()
+
+	// in-memory database is not shared between connections
+	db.SetMaxOpenConns(1)
+	FailOnError(t, s.MigrateToLatest(context.Background()))
+
+	for _, description := range []string{"b", "", "a", ""} {
+		_, err := s.StoreConfigurationProfile(context.Background(), "user", description, "{}")
+		FailOnError(t, err)
+	}
+	_, err := db.Exec("UPDATE configuration_profile SET description = NULL, changed_by = NULL WHERE description = ''")
+	FailOnError(t, err)
+
+	for _, sort := range []string{"description", "changed_by"} {
+		ids := listProfileIDs(t, s, &storage.Page{Limit: 1, Sort: sort, Order: storage.Ascending})
+		assert.Len(t, ids, 4, sort)
+		assert.Equal(t, []storage.ConfigurationID{2, 4}, ids[:2], sort)
+	}
+}
+
+// TestPagination checks pagination of items stored in SQL database
+func TestPagination(t *testing.T) {
+	for _, check := range []func(*testing.T, storage.Storage){checkClustersPagination, checkTriggersPagination, checkProfilesPagination, checkEmptySortValuesPagination} {
+		mockStorage, closer := MustGetMockStorage(t, true)
+		check(t, mockStorage)
+		closer()
+	}
+}
+
+// TestMemoryStoragePagination checks pagination of items stored in memory
+func TestMemoryStoragePagination(t *testing.T) {
+	for _, check := range []func(*testing.T, storage.Storage){checkClustersPagination, checkTriggersPagination, checkProfilesPagination, checkEmptySortValuesPagination} {
+		check(t, storage.NewMemoryStorage())
+	}
+}
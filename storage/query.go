@@ -0,0 +1,187 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/query.html
+
+import (
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/RedHatInsights/insights-operator-controller/utils"
+)
+
+// QueryColumn is implemented by typed columns of all query models
+// (ClusterCol, TriggerCol, ClusterConfigurationCol), so they can be used by
+// the generic QueryBuilder
+type QueryColumn interface {
+	ColumnName() Column
+}
+
+// SortOrder defines the direction of ordering of query results
+type SortOrder string
+
+const (
+	// Ascending sorts query results from the lowest value
+	Ascending SortOrder = "asc"
+	// Descending sorts query results from the highest value
+	Descending SortOrder = "desc"
+)
+
+// QueryBuilder is generic SQL query builder shared by all query models. Its
+// methods add filters, ordering and paging to the query. Zero values passed
+// to filters are ignored, so optional parts of search requests can be used
+// directly.
+type QueryBuilder struct {
+	sb sq.SelectBuilder
+}
+
+// NewQueryBuilder creates a query builder that selects given columns from
+// table (or from tables joined together)
+func NewQueryBuilder(s Storager, from string, cols ...QueryColumn) QueryBuilder {
+	builder := sq.StatementBuilder.PlaceholderFormat(s.Placeholder())
+	sb := builder.Select(columnNames(cols)...).From(from).RunWith(s.Connections())
+	return QueryBuilder{sb: sb}
+}
+
+// SelectBuilder returns the underlying squirrel select builder
+func (b QueryBuilder) SelectBuilder() sq.SelectBuilder {
+	return b.sb
+}
+
+// Equals adds a SQL Where Predicate using AND (if any exists) using Equals (=) operand.
+// For example: WHERE col = 3
+func (b QueryBuilder) Equals(col QueryColumn, v interface{}) QueryBuilder {
+	if utils.ZeroValue(v) {
+		return b
+	}
+	b.sb = b.sb.Where(sq.Eq{string(col.ColumnName()): v})
+	return b
+}
+
+// In adds a SQL Where Predicate using AND (if any exists) that checks
+// whether column value is one of provided values.
+// For example: WHERE col IN (1,2,3)
+func (b QueryBuilder) In(col QueryColumn, values ...interface{}) QueryBuilder {
+	if len(values) == 0 {
+		return b
+	}
+	b.sb = b.sb.Where(sq.Eq{string(col.ColumnName()): values})
+	return b
+}
+
+// Like adds a SQL Where Predicate using AND (if any exists) that matches
+// column value against pattern.
+// For example: WHERE col LIKE '%reason%'
+func (b QueryBuilder) Like(col QueryColumn, pattern string) QueryBuilder {
+	if pattern == "" {
+		return b
+	}
+	b.sb = b.sb.Where(sq.Like{string(col.ColumnName()): pattern})
+	return b
+}
+
+// Range adds SQL Where Predicates using AND (if any exists) that check that
+// column value is in the closed interval <from, to>. Zero from or to value
+// makes the interval open from that side.
+// For example: WHERE col >= 1 AND col <= 10
+func (b QueryBuilder) Range(col QueryColumn, from, to interface{}) QueryBuilder {
+	if !utils.ZeroValue(from) {
+		b.sb = b.sb.Where(sq.GtOrEq{string(col.ColumnName()): from})
+	}
+	if !utils.ZeroValue(to) {
+		b.sb = b.sb.Where(sq.LtOrEq{string(col.ColumnName()): to})
+	}
+	return b
+}
+
+// IsNull adds a SQL Where Predicate using AND (if any exists) that checks
+// that column value is not set.
+// For example: WHERE col IS NULL
+func (b QueryBuilder) IsNull(col QueryColumn) QueryBuilder {
+	b.sb = b.sb.Where(sq.Eq{string(col.ColumnName()): nil})
+	return b
+}
+
+// OrderBy adds ordering by given column to the query. Ascending order is
+// used when no order is specified.
+// For example: ORDER BY col DESC
+func (b QueryBuilder) OrderBy(col QueryColumn, order SortOrder) QueryBuilder {
+	if order == Descending {
+		b.sb = b.sb.OrderBy(string(col.ColumnName()) + " DESC")
+	} else {
+		b.sb = b.sb.OrderBy(string(col.ColumnName()))
+	}
+	return b
+}
+
+// WithPaging is setting how many recors (limit) and from which record (offset)
+// This can be used for paging.
+// It skips Zero values
+func (b QueryBuilder) WithPaging(limit, offset int) QueryBuilder {
+	if limit != 0 {
+		b.sb = b.sb.Limit(uint64(limit))
+	}
+	if offset != 0 {
+		b.sb = b.sb.Offset(uint64(offset))
+	}
+	return b
+}
+
+// SplitValues splits comma separated list of values taken from search
+// request, so it can be used by the In filter
+func SplitValues(values string) []interface{} {
+	result := []interface{}{}
+	for _, value := range strings.Split(values, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// LikePattern creates LIKE pattern that matches all values containing the
+// given substring
+func LikePattern(substring string) string {
+	if substring == "" {
+		return ""
+	}
+	return "%" + substring + "%"
+}
+
+// columnNames creates a list of column names from list of typed columns
+func columnNames(cols []QueryColumn) []string {
+	names := []string{}
+	for _, c := range cols {
+		names = append(names, string(c.ColumnName()))
+	}
+	return names
+}
+
+// storageColumns creates a list of storage columns from list of typed columns
+func storageColumns(cols []QueryColumn) []Column {
+	columns := []Column{}
+	for _, c := range cols {
+		columns = append(columns, c.ColumnName())
+	}
+	return columns
+}
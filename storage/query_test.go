@@ -0,0 +1,140 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/query_test.html
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/RedHatInsights/insights-operator-controller/utils"
+)
+
+const (
+	triggerSelect           = "SELECT trigger.id, trigger_type.type, cluster.name, trigger.reason, trigger.link, trigger.triggered_at, trigger.triggered_by, trigger.parameters, CAST(CASE WHEN trigger.state = 'pending' THEN 1 ELSE 0 END AS INTEGER), trigger.expires_at, CAST(CASE WHEN trigger.state = 'expired' THEN 1 ELSE 0 END AS INTEGER), trigger.state, trigger.result, support_case.case_number FROM trigger JOIN trigger_type ON trigger.type=trigger_type.id JOIN cluster ON trigger.cluster=cluster.id LEFT JOIN support_case ON trigger.support_case=support_case.id"
+	configurationSelect     = "SELECT operator_configuration.id, cluster.name, operator_configuration.configuration, operator_configuration.changed_at, operator_configuration.changed_by, operator_configuration.active, operator_configuration.reason, operator_configuration.version, operator_configuration.revision, operator_configuration.effective_from, operator_configuration.effective_until FROM operator_configuration JOIN cluster ON cluster.id = operator_configuration.cluster"
+	configurationNotDeleted = " operator_configuration.deleted_at IS NULL AND cluster.deleted_at IS NULL"
+)
+
+func TestTriggerQueryBy(t *testing.T) {
+	tcs := []struct {
+		name  string
+		req   SearchTriggerRequest
+		query string
+		args  []interface{}
+	}{
+		{
+			name:  "NoFilter",
+			req:   SearchTriggerRequest{},
+			query: triggerSelect + " ORDER BY trigger.id",
+		},
+		{
+			name:  "ClusterAndTypes",
+			req:   SearchTriggerRequest{Cluster: "cluster", Type: "must-gather, other"},
+			query: triggerSelect + " WHERE cluster.name = ? AND trigger_type.type IN (?,?) ORDER BY trigger.id",
+			args:  []interface{}{"cluster", "must-gather", "other"},
+		},
+		{
+			name:  "ReasonAndRange",
+			req:   SearchTriggerRequest{Reason: "gather", TriggeredFrom: "2023-01-01"},
+			query: triggerSelect + " WHERE trigger.reason LIKE ? AND trigger.triggered_at >= ? ORDER BY trigger.id",
+			args:  []interface{}{"%gather%", "2023-01-01"},
+		},
+		{
+			name:  "OrderWithLimitAndOffset",
+			req:   SearchTriggerRequest{Active: "1", OrderBy: "triggered_at", Order: "desc", Pagination: utils.Pagination{Offset: 1, Limit: 10}},
+			query: triggerSelect + " WHERE CAST(CASE WHEN trigger.state = 'pending' THEN 1 ELSE 0 END AS INTEGER) = ? ORDER BY trigger.triggered_at DESC LIMIT 10 OFFSET 1",
+			args:  []interface{}{"1"},
+		},
+	}
+	for _, tt := range tcs {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &TestStorage{}
+			tq := NewTriggerQuery(storage)
+
+			_, err := tq.QueryMany(context.Background(), tt.req)
+			if err != nil {
+				t.Errorf("querymany failed with error %s", err)
+			}
+			if tt.query != storage.lastQuery {
+				t.Errorf("expected query %s doesn't match actual query %s", tt.query, storage.lastQuery)
+			}
+			if !reflect.DeepEqual(tt.args, storage.lastArgs) {
+				t.Errorf("expected args %s doesn't match actual args %s", tt.args, storage.lastArgs)
+			}
+		})
+	}
+}
+
+func TestClusterConfigurationQueryBy(t *testing.T) {
+	tcs := []struct {
+		name  string
+		req   SearchClusterConfigurationRequest
+		query string
+		args  []interface{}
+	}{
+		{
+			name:  "NoFilter",
+			req:   SearchClusterConfigurationRequest{},
+			query: configurationSelect + " WHERE" + configurationNotDeleted + " ORDER BY operator_configuration.id",
+		},
+		{
+			name:  "ClustersAndProfile",
+			req:   SearchClusterConfigurationRequest{Cluster: "c1,c2", Configuration: 3},
+			query: configurationSelect + " WHERE cluster.name IN (?,?) AND operator_configuration.configuration = ? AND" + configurationNotDeleted + " ORDER BY operator_configuration.id",
+			args:  []interface{}{"c1", "c2", 3},
+		},
+		{
+			name:  "RangeAndOrder",
+			req:   SearchClusterConfigurationRequest{ChangedFrom: "2023-01-01", ChangedTo: "2023-02-01", OrderBy: "changed_at"},
+			query: configurationSelect + " WHERE operator_configuration.changed_at >= ? AND operator_configuration.changed_at <= ? AND" + configurationNotDeleted + " ORDER BY operator_configuration.changed_at",
+			args:  []interface{}{"2023-01-01", "2023-02-01"},
+		},
+	}
+	for _, tt := range tcs {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &TestStorage{}
+			cq := NewClusterConfigurationQuery(storage)
+
+			_, err := cq.QueryMany(context.Background(), tt.req)
+			if err != nil {
+				t.Errorf("querymany failed with error %s", err)
+			}
+			if tt.query != storage.lastQuery {
+				t.Errorf("expected query %s doesn't match actual query %s", tt.query, storage.lastQuery)
+			}
+			if !reflect.DeepEqual(tt.args, storage.lastArgs) {
+				t.Errorf("expected args %s doesn't match actual args %s", tt.args, storage.lastArgs)
+			}
+		})
+	}
+}
+
+func TestQueryUnknownOrderColumn(t *testing.T) {
+	_, err := NewTriggerQuery(&TestStorage{}).QueryMany(context.Background(), SearchTriggerRequest{OrderBy: "link"})
+	if err == nil {
+		t.Error("ordering by unknown column should fail")
+	}
+
+	_, err = NewClusterConfigurationQuery(&TestStorage{}).QueryMany(context.Background(), SearchClusterConfigurationRequest{OrderBy: "reason"})
+	if err == nil {
+		t.Error("ordering by unknown column should fail")
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/revision_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkConfigurationProfileRevisions checks revision history of configuration
+// profiles for any storage implementation
+func checkConfigurationProfileRevisions(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	profiles, err := s.StoreConfigurationProfile(ctx, "user1", "description1", `{"a":1}`)
+	FailOnError(t, err)
+	id := int(profiles[len(profiles)-1].ID)
+
+	_, err = s.ChangeConfigurationProfile(ctx, id, storage.AnyVersion, "user2", "description2", `{"a":2}`)
+	FailOnError(t, err)
+
+	_, err = s.ChangeConfigurationProfile(ctx, id, storage.AnyVersion, "user3", "description3", `{"a":3}`)
+	FailOnError(t, err)
+
+	revisions, err := s.ListConfigurationProfileRevisions(ctx, id)
+	FailOnError(t, err)
+	assert.Len(t, revisions, 3)
+
+	for i, revision := range revisions {
+		assert.Equal(t, storage.ConfigurationID(id), revision.Profile)
+		assert.Equal(t, i+1, revision.Revision)
+	}
+	assert.Equal(t, `{"a":1}`, revisions[0].Configuration)
+	assert.Equal(t, "user1", revisions[0].ChangedBy)
+	assert.Equal(t, "description1", revisions[0].Description)
+
+	revision, err := s.GetConfigurationProfileRevision(ctx, id, 2)
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":2}`, revision.Configuration)
+	assert.Equal(t, "user2", revision.ChangedBy)
+
+	// the profile itself contains the latest revision
+	profile, err := s.GetConfigurationProfile(ctx, id)
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":3}`, profile.Configuration)
+
+	_, err = s.GetConfigurationProfileRevision(ctx, id, 4)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.ListConfigurationProfileRevisions(ctx, id+1)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// failed change does not create any revision
+	_, err = s.ChangeConfigurationProfile(ctx, id+1, storage.AnyVersion, "user", "description", "{}")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// revisions are kept when the profile is deleted
+	_, err = s.DeleteConfigurationProfile(ctx, id, storage.AnyVersion, "user")
+	FailOnError(t, err)
+
+	_, err = s.GetConfigurationProfileRevision(ctx, id, 1)
+	FailOnError(t, err)
+
+	// and they are removed when the profile is purged
+	_, err = s.PurgeDeleted(ctx, time.Now().Add(time.Hour))
+	FailOnError(t, err)
+
+	_, err = s.GetConfigurationProfileRevision(ctx, id, 1)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+}
+
+// checkClusterConfigurationRevision checks that profile created together
+// with cluster configuration has its first revision
+func checkClusterConfigurationRevision(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+
+	configurations, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "reason", "description", `{"b":1}`)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 1)
+
+	profiles, err := s.ListConfigurationProfiles(ctx, false)
+	FailOnError(t, err)
+	assert.Len(t, profiles, 1)
+
+	revision, err := s.GetConfigurationProfileRevision(ctx, int(profiles[0].ID), 1)
+	FailOnError(t, err)
+	assert.Equal(t, `{"b":1}`, revision.Configuration)
+}
+
+// checkClusterConfigurationAssignment checks that existing profile can be
+// assigned to clusters that either follow its latest revision or that are
+// pinned to one revision
+func checkClusterConfigurationAssignment(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "following"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "pinned"))
+
+	profiles, err := s.StoreConfigurationProfile(ctx, "user", "description", `{"a":1}`)
+	FailOnError(t, err)
+	id := int(profiles[0].ID)
+
+	configurations, err := s.AssignClusterConfiguration(ctx, "following", "user", "follow", id, storage.LatestRevision)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 1)
+	assert.Equal(t, 0, configurations[0].Revision)
+
+	configurations, err = s.AssignClusterConfiguration(ctx, "pinned", "user", "pin", id, 1)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 1)
+	assert.Equal(t, 1, configurations[0].Revision)
+	assert.Equal(t, "pin", configurations[0].Reason)
+
+	_, err = s.ChangeConfigurationProfile(ctx, id, storage.AnyVersion, "user", "description", `{"a":2}`)
+	FailOnError(t, err)
+
+	configuration, err := s.GetClusterActiveConfiguration(ctx, "following")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":2}`, configuration)
+
+	configuration, err = s.GetClusterActiveConfiguration(ctx, "pinned")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":1}`, configuration)
+
+	configuration, _, err = s.GetClusterConfigurationByID(ctx, int64(configurations[0].ID))
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":1}`, configuration)
+
+	// assigning another profile deactivates the previous configuration
+	configurations, err = s.AssignClusterConfiguration(ctx, "pinned", "user", "repin", id, 2)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 2)
+	for _, c := range configurations {
+		if c.Reason == "repin" {
+			assert.Equal(t, "1", c.Active)
+		} else {
+			assert.Equal(t, "0", c.Active)
+		}
+	}
+
+	configuration, err = s.GetClusterActiveConfiguration(ctx, "pinned")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":2}`, configuration)
+
+	// profile is shared, not cloned
+	profiles, err = s.ListConfigurationProfiles(ctx, false)
+	FailOnError(t, err)
+	assert.Len(t, profiles, 1)
+
+	_, err = s.AssignClusterConfiguration(ctx, "pinned", "user", "reason", id, 3)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.AssignClusterConfiguration(ctx, "pinned", "user", "reason", id+1, storage.LatestRevision)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.AssignClusterConfiguration(ctx, "unknown", "user", "reason", id, storage.LatestRevision)
+	assert.Error(t, err)
+}
+
+// TestConfigurationProfileRevisions checks revision history of profiles stored in SQL database
+func TestConfigurationProfileRevisions(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkConfigurationProfileRevisions(t, mockStorage)
+}
+
+// TestClusterConfigurationRevision checks revision of profile created with cluster configuration in SQL database
+func TestClusterConfigurationRevision(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkClusterConfigurationRevision(t, mockStorage)
+}
+
+// TestClusterConfigurationAssignment checks assignment of profiles stored in SQL database
+func TestClusterConfigurationAssignment(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkClusterConfigurationAssignment(t, mockStorage)
+}
+
+// TestMemoryStorageConfigurationProfileRevisions checks revision history of profiles stored in memory
+func TestMemoryStorageConfigurationProfileRevisions(t *testing.T) {
+	checkConfigurationProfileRevisions(t, storage.NewMemoryStorage())
+}
+
+// TestMemoryStorageClusterConfigurationRevision checks revision of profile created with cluster configuration in memory
+func TestMemoryStorageClusterConfigurationRevision(t *testing.T) {
+	checkClusterConfigurationRevision(t, storage.NewMemoryStorage())
+}
+
+// TestMemoryStorageClusterConfigurationAssignment checks assignment of profiles stored in memory
+func TestMemoryStorageClusterConfigurationAssignment(t *testing.T) {
+	checkClusterConfigurationAssignment(t, storage.NewMemoryStorage())
+}
+
+// TestMigratedProfileRevision checks that profiles existing before the
+// migration get their first revision
+func TestMigratedProfileRevision(t *testing.T) {
+	s, db := mustGetSqliteStorage(t, dataSource, false)
+	defer MustCloseStorage(t, s)
+
+	initializeDatabase(t, db)
+
+	_, err := db.Exec(`INSERT INTO configuration_profile (id, configuration, changed_at, changed_by, description)
+VALUES (1, '{}', '2019-01-01', 'tester', 'cfg')`)
+	FailOnError(t, err)
+
+	FailOnError(t, s.MigrateToLatest(context.Background()))
+
+	revisions, err := s.ListConfigurationProfileRevisions(context.Background(), 1)
+	FailOnError(t, err)
+	assert.Len(t, revisions, 1)
+	assert.Equal(t, "tester", revisions[0].ChangedBy)
+}
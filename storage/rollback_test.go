@@ -0,0 +1,117 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/rollback_test.html
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// activeConfigurations returns reasons of all active configurations
+func activeConfigurations(configurations []storage.ClusterConfiguration) []string {
+	active := []string{}
+	for _, configuration := range configurations {
+		if configuration.Active == "1" {
+			active = append(active, configuration.Reason)
+		}
+	}
+	return active
+}
+
+// checkClusterConfigurationRollback checks that cluster can be rolled back
+// to the previous or to any historical configuration
+func checkClusterConfigurationRollback(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "other"))
+
+	// nothing to roll back to
+	_, err := s.RollbackClusterConfiguration(ctx, "cluster", "user", "rollback", storage.PreviousConfiguration)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.CreateClusterConfiguration(ctx, "cluster", "user", "first", "description", `{"a":1}`)
+	FailOnError(t, err)
+	_, err = s.CreateClusterConfiguration(ctx, "cluster", "user", "second", "description", `{"a":2}`)
+	FailOnError(t, err)
+	configurations, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "third", "description", `{"a":3}`)
+	FailOnError(t, err)
+	assert.Equal(t, []string{"third"}, activeConfigurations(configurations))
+	first := configurations[0].ID
+
+	// previous configuration is the one preceding the active one
+	configurations, err = s.RollbackClusterConfiguration(ctx, "cluster", "admin", "rollback", storage.PreviousConfiguration)
+	FailOnError(t, err)
+	assert.Equal(t, []string{"rollback"}, activeConfigurations(configurations))
+	assert.Equal(t, "admin", configurations[1].ChangedBy)
+
+	configuration, err := s.GetClusterActiveConfiguration(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":2}`, configuration)
+
+	configurations, err = s.RollbackClusterConfiguration(ctx, "cluster", "admin", "again", storage.PreviousConfiguration)
+	FailOnError(t, err)
+	assert.Equal(t, []string{"again"}, activeConfigurations(configurations))
+
+	configuration, err = s.GetClusterActiveConfiguration(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":1}`, configuration)
+
+	// the first configuration has no predecessor
+	_, err = s.RollbackClusterConfiguration(ctx, "cluster", "admin", "rollback", storage.PreviousConfiguration)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// historical configuration selected by its ID
+	configurations, err = s.RollbackClusterConfiguration(ctx, "cluster", "admin", "latest", int64(configurations[2].ID))
+	FailOnError(t, err)
+	assert.Equal(t, []string{"latest"}, activeConfigurations(configurations))
+
+	configuration, err = s.GetClusterActiveConfiguration(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Equal(t, `{"a":3}`, configuration)
+
+	// configurations of other clusters and deleted configurations can not be used
+	_, err = s.RollbackClusterConfiguration(ctx, "other", "admin", "rollback", int64(first))
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	FailOnError(t, s.DeleteClusterConfigurationByID(ctx, int64(first), storage.AnyVersion, "admin"))
+	_, err = s.RollbackClusterConfiguration(ctx, "cluster", "admin", "rollback", int64(first))
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.RollbackClusterConfiguration(ctx, "unknown", "admin", "rollback", storage.PreviousConfiguration)
+	assert.Error(t, err)
+}
+
+// TestClusterConfigurationRollback checks rollback of cluster configurations stored in SQL database
+func TestClusterConfigurationRollback(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkClusterConfigurationRollback(t, mockStorage)
+}
+
+// TestMemoryStorageClusterConfigurationRollback checks rollback of cluster configurations stored in memory
+func TestMemoryStorageClusterConfigurationRollback(t *testing.T) {
+	checkClusterConfigurationRollback(t, storage.NewMemoryStorage())
+}
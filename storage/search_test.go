@@ -0,0 +1,115 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/search_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// TestSearchTriggers checks that triggers stored in SQL database can be searched
+func TestSearchTriggers(t *testing.T) {
+	ctx := context.Background()
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	FailOnError(t, mockStorage.RegisterNewCluster(ctx, "cluster1"))
+	FailOnError(t, mockStorage.RegisterNewCluster(ctx, "cluster2"))
+	FailOnError(t, mockStorage.NewTriggerType(ctx, "must-gather", "must-gather", ""))
+	FailOnError(t, mockStorage.NewTriggerType(ctx, "other", "other", ""))
+	FailOnError(t, mockStorage.NewTrigger(ctx, "cluster1", "must-gather", "user", "first reason", "link", "", time.Time{}, ""))
+	FailOnError(t, mockStorage.NewTrigger(ctx, "cluster2", "other", "user", "second reason", "link", "", time.Time{}, ""))
+	FailOnError(t, mockStorage.NewTrigger(ctx, "cluster2", "must-gather", "admin", "third", "link", "", time.Time{}, ""))
+
+	query := storage.NewTriggerQuery(mockStorage)
+
+	triggers, err := query.QueryMany(ctx, storage.SearchTriggerRequest{Cluster: "cluster2"})
+	FailOnError(t, err)
+	assert.Len(t, triggers, 2)
+	assert.Equal(t, "cluster2", triggers[0].Cluster)
+	assert.Equal(t, "other", triggers[0].Type)
+
+	triggers, err = query.QueryMany(ctx, storage.SearchTriggerRequest{Reason: "reason", OrderBy: "id", Order: "desc"})
+	FailOnError(t, err)
+	assert.Len(t, triggers, 2)
+	assert.Equal(t, "second reason", triggers[0].Reason)
+
+	triggers, err = query.QueryMany(ctx, storage.SearchTriggerRequest{Type: "must-gather,unknown", TriggeredBy: "admin"})
+	FailOnError(t, err)
+	assert.Len(t, triggers, 1)
+	assert.Equal(t, "third", triggers[0].Reason)
+
+	FailOnError(t, mockStorage.ChangeClusterTriggerState(ctx, "cluster2", 2, storage.TriggerRunning))
+	triggers, err = query.QueryMany(ctx, storage.SearchTriggerRequest{State: "running,failed"})
+	FailOnError(t, err)
+	assert.Len(t, triggers, 1)
+	assert.Equal(t, storage.TriggerRunning, triggers[0].State)
+
+	// active flag is derived from the state
+	triggers, err = query.QueryMany(ctx, storage.SearchTriggerRequest{Active: "1"})
+	FailOnError(t, err)
+	assert.Len(t, triggers, 2)
+	for _, trigger := range triggers {
+		assert.Equal(t, storage.TriggerPending, trigger.State)
+		assert.Equal(t, 1, trigger.Active)
+	}
+}
+
+// TestSearchClusterConfigurations checks that cluster configurations stored
+// in SQL database can be searched
+func TestSearchClusterConfigurations(t *testing.T) {
+	ctx := context.Background()
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	FailOnError(t, mockStorage.RegisterNewCluster(ctx, "cluster1"))
+	FailOnError(t, mockStorage.RegisterNewCluster(ctx, "cluster2"))
+	_, err := mockStorage.CreateClusterConfiguration(ctx, "cluster1", "user", "first reason", "description", "{}")
+	FailOnError(t, err)
+	_, err = mockStorage.CreateClusterConfiguration(ctx, "cluster2", "admin", "second reason", "description", "{}")
+	FailOnError(t, err)
+	configurations, err := mockStorage.CreateClusterConfiguration(ctx, "cluster2", "admin", "third", "description", "{}")
+	FailOnError(t, err)
+
+	query := storage.NewClusterConfigurationQuery(mockStorage)
+
+	found, err := query.QueryMany(ctx, storage.SearchClusterConfigurationRequest{Cluster: "cluster1,cluster2", ChangedBy: "admin"})
+	FailOnError(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, "cluster2", found[0].Cluster)
+
+	found, err = query.QueryMany(ctx, storage.SearchClusterConfigurationRequest{Reason: "reason", OrderBy: "id", Order: "desc"})
+	FailOnError(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, "second reason", found[0].Reason)
+
+	// deleted configurations are not searched
+	last := configurations[len(configurations)-1]
+	FailOnError(t, mockStorage.DeleteClusterConfigurationByID(ctx, int64(last.ID), storage.AnyVersion, "user"))
+
+	found, err = query.QueryMany(ctx, storage.SearchClusterConfigurationRequest{Cluster: "cluster2"})
+	FailOnError(t, err)
+	assert.Len(t, found, 1)
+}
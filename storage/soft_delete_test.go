@@ -0,0 +1,245 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/soft_delete_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkClusterSoftDelete checks that deleted cluster is hidden, but kept
+// together with its configurations until it is purged
+func checkClusterSoftDelete(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.CreateNewCluster(ctx, 100, "cluster"))
+	_, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "reason", "description", "{}")
+	FailOnError(t, err)
+
+	FailOnError(t, s.DeleteCluster(ctx, 100, "admin"))
+
+	_, err = s.GetCluster(ctx, 100)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.GetClusterByName(ctx, "cluster")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.GetClusterActiveConfiguration(ctx, "cluster")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// cluster can not be deleted twice
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.DeleteCluster(ctx, 100, "admin"))
+
+	clusters, err := s.ListOfClusters(ctx, false)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 0)
+
+	configurations, err := s.ListAllClusterConfigurations(ctx, false)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 0)
+
+	clusters, err = s.ListOfClusters(ctx, true)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 1)
+	assert.Equal(t, "admin", clusters[0].DeletedBy)
+	assert.NotEmpty(t, clusters[0].DeletedAt)
+
+	configurations, err = s.ListAllClusterConfigurations(ctx, true)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 1)
+
+	// restored cluster has all its configurations
+	FailOnError(t, s.RestoreCluster(ctx, 100))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.RestoreCluster(ctx, 100))
+
+	configuration, err := s.GetClusterActiveConfiguration(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Equal(t, "{}", configuration)
+
+	clusters, err = s.ListOfClusters(ctx, false)
+	FailOnError(t, err)
+	assert.Equal(t, []storage.Cluster{{ID: 100, Name: "cluster"}}, clusters)
+
+	// only items deleted before the given time are purged
+	FailOnError(t, s.DeleteClusterByName(ctx, "cluster", "admin"))
+
+	purged, err := s.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+	FailOnError(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	purged, err = s.PurgeDeleted(ctx, time.Now().Add(time.Hour))
+	FailOnError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.RestoreCluster(ctx, 100))
+
+	configurations, err = s.ListAllClusterConfigurations(ctx, true)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 0)
+
+	// cluster can not be restored when other cluster with the same name has
+	// been registered in the meantime
+	FailOnError(t, s.CreateNewCluster(ctx, 101, "cluster"))
+	FailOnError(t, s.DeleteCluster(ctx, 101, "admin"))
+	FailOnError(t, s.CreateNewCluster(ctx, 102, "cluster"))
+	assert.IsType(t, &storage.ItemAlreadyExistsError{}, s.RestoreCluster(ctx, 101))
+
+	clusters, err = s.ListOfClusters(ctx, false)
+	FailOnError(t, err)
+	assert.Equal(t, []storage.Cluster{{ID: 102, Name: "cluster"}}, clusters)
+}
+
+// checkConfigurationProfileSoftDelete checks that deleted configuration
+// profile is hidden and that it can be restored
+func checkConfigurationProfileSoftDelete(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	profiles, err := s.StoreConfigurationProfile(ctx, "user", "description", "{}")
+	FailOnError(t, err)
+	id := int(profiles[len(profiles)-1].ID)
+
+	profiles, err = s.DeleteConfigurationProfile(ctx, id, 1, "admin")
+	FailOnError(t, err)
+	assert.Len(t, profiles, 0)
+
+	_, err = s.GetConfigurationProfile(ctx, id)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// deleted profile can not be changed
+	_, err = s.ChangeConfigurationProfile(ctx, id, storage.AnyVersion, "user", "description", "{}")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	profiles, err = s.ListConfigurationProfiles(ctx, true)
+	FailOnError(t, err)
+	assert.Len(t, profiles, 1)
+	assert.Equal(t, "admin", profiles[0].DeletedBy)
+	assert.Equal(t, 2, profiles[0].Version)
+
+	FailOnError(t, s.RestoreConfigurationProfile(ctx, id))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.RestoreConfigurationProfile(ctx, id))
+
+	profile, err := s.GetConfigurationProfile(ctx, id)
+	FailOnError(t, err)
+	assert.Equal(t, 3, profile.Version)
+	assert.Empty(t, profile.DeletedAt)
+	assert.Empty(t, profile.DeletedBy)
+
+	revisions, err := s.ListConfigurationProfileRevisions(ctx, id)
+	FailOnError(t, err)
+	assert.Len(t, revisions, 1)
+
+	// profile used by configuration of live cluster is not purged
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	_, err = s.AssignClusterConfiguration(ctx, "cluster", "user", "reason", id, 0)
+	FailOnError(t, err)
+	_, err = s.DeleteConfigurationProfile(ctx, id, storage.AnyVersion, "admin")
+	FailOnError(t, err)
+
+	purged, err := s.PurgeDeleted(ctx, time.Now().Add(time.Hour))
+	FailOnError(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	configurations, err := s.ListClusterConfiguration(ctx, "cluster", true)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 1)
+}
+
+// checkClusterConfigurationSoftDelete checks that deleted cluster
+// configuration is hidden and that it can be restored
+func checkClusterConfigurationSoftDelete(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+
+	configurations, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "reason", "description", "{}")
+	FailOnError(t, err)
+	id := int64(configurations[0].ID)
+
+	FailOnError(t, s.DeleteClusterConfigurationByID(ctx, id, 1, "admin"))
+
+	_, _, err = s.GetClusterConfigurationByID(ctx, id)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.GetClusterActiveConfiguration(ctx, "cluster")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	err = s.EnableOrDisableClusterConfigurationByID(ctx, id, storage.AnyVersion, "1")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	configurations, err = s.ListClusterConfiguration(ctx, "cluster", false)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 0)
+
+	configurations, err = s.ListClusterConfiguration(ctx, "cluster", true)
+	FailOnError(t, err)
+	assert.Len(t, configurations, 1)
+	assert.Equal(t, "admin", configurations[0].DeletedBy)
+
+	FailOnError(t, s.RestoreClusterConfiguration(ctx, id))
+	assert.IsType(t, &storage.ItemNotFoundError{}, s.RestoreClusterConfiguration(ctx, id))
+
+	_, version, err := s.GetClusterConfigurationByID(ctx, id)
+	FailOnError(t, err)
+	assert.Equal(t, 3, version)
+}
+
+// TestClusterSoftDelete checks deletion of clusters stored in SQL database
+func TestClusterSoftDelete(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkClusterSoftDelete(t, mockStorage)
+}
+
+// TestConfigurationProfileSoftDelete checks deletion of profiles stored in SQL database
+func TestConfigurationProfileSoftDelete(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkConfigurationProfileSoftDelete(t, mockStorage)
+}
+
+// TestClusterConfigurationSoftDelete checks deletion of cluster configurations stored in SQL database
+func TestClusterConfigurationSoftDelete(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkClusterConfigurationSoftDelete(t, mockStorage)
+}
+
+// TestMemoryStorageClusterSoftDelete checks deletion of clusters stored in memory
+func TestMemoryStorageClusterSoftDelete(t *testing.T) {
+	checkClusterSoftDelete(t, storage.NewMemoryStorage())
+}
+
+// TestMemoryStorageConfigurationProfileSoftDelete checks deletion of profiles stored in memory
+func TestMemoryStorageConfigurationProfileSoftDelete(t *testing.T) {
+	checkConfigurationProfileSoftDelete(t, storage.NewMemoryStorage())
+}
+
+// TestMemoryStorageClusterConfigurationSoftDelete checks deletion of cluster configurations stored in memory
+func TestMemoryStorageClusterConfigurationSoftDelete(t *testing.T) {
+	checkClusterConfigurationSoftDelete(t, storage.NewMemoryStorage())
+}
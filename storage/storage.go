@@ -17,7 +17,9 @@ limitations under the License.
 // Package storage contains an implementation of interface between Go code and
 // (almost any) SQL database like PostgreSQL, SQLite, or MariaDB. An implementation
 // named DBStorage is constructed via New function and it is mandatory to call Close
-// for any opened connection to database.
+// for any opened connection to database. Another implementation named
+// MemoryStorage is constructed via NewMemoryStorage function and keeps all data
+// in memory only.
 package storage
 
 // Generated documentation is available at:
@@ -29,24 +31,138 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
-	_ "github.com/lib/pq"           // PostgreSQL database driver
-	_ "github.com/mattn/go-sqlite3" // SQLite database driver
+	"github.com/lib/pq"           // PostgreSQL database driver
+	"github.com/mattn/go-sqlite3" // SQLite database driver
 )
 
-// Storage represents an interface to any relational database based on SQL language
-type Storage struct {
-	connections *sql.DB
-	driver      string
-	placeholder sq.PlaceholderFormat
+// Storage represents an interface to any data storage used by the controller
+// service. It covers clusters, configuration profiles, cluster
+// configurations, triggers, and trigger types. DBStorage is an implementation
+// based on SQL databases, MemoryStorage keeps all data in memory.
+type Storage interface {
+	Close()
+	Ping(ctx context.Context) error
+
+	ListOfClusters(ctx context.Context, includeDeleted bool) ([]Cluster, error)
+	ListOfClustersPage(ctx context.Context, includeDeleted bool, page Page) ([]Cluster, int, error)
+	GetCluster(ctx context.Context, id int) (Cluster, error)
+	RegisterNewCluster(ctx context.Context, name string) error
+	CreateNewCluster(ctx context.Context, id int64, name string) error
+	DeleteCluster(ctx context.Context, id int64, username string) error
+	DeleteClusterByName(ctx context.Context, name, username string) error
+	RestoreCluster(ctx context.Context, id int64) error
+	GetClusterByName(ctx context.Context, name string) (Cluster, error)
+	ListClusterLabels(ctx context.Context, clusterName string) (map[string]string, error)
+	SetClusterLabel(ctx context.Context, clusterName, key, value string) error
+	DeleteClusterLabel(ctx context.Context, clusterName, key string) error
+	ListClustersBySelector(ctx context.Context, selector LabelSelector) ([]Cluster, error)
+	RecordClusterHeartbeat(ctx context.Context, clusterName, address, operatorVersion string, seenAt time.Time) error
+	GetClusterHeartbeat(ctx context.Context, clusterID int) (ClusterHeartbeat, error)
+	ListStaleClusters(ctx context.Context, seenBefore time.Time) ([]ClusterHeartbeat, error)
+	SetClusterMetadata(ctx context.Context, clusterName string, metadata ClusterMetadata) error
+	GetClusterMetadata(ctx context.Context, clusterID int) (ClusterMetadata, error)
+
+	ListConfigurationProfiles(ctx context.Context, includeDeleted bool) ([]ConfigurationProfile, error)
+	ListConfigurationProfilesPage(ctx context.Context, includeDeleted bool, page Page) ([]ConfigurationProfile, int, error)
+	GetConfigurationProfile(ctx context.Context, id int) (ConfigurationProfile, error)
+	StoreConfigurationProfile(ctx context.Context, username, description, configuration string) ([]ConfigurationProfile, error)
+	ChangeConfigurationProfile(ctx context.Context, id, version int, username, description, configuration string) ([]ConfigurationProfile, error)
+	DeleteConfigurationProfile(ctx context.Context, id, version int, username string) ([]ConfigurationProfile, error)
+	RestoreConfigurationProfile(ctx context.Context, id int) error
+	ListConfigurationProfileRevisions(ctx context.Context, id int) ([]ConfigurationProfileRevision, error)
+	ListConfigurationProfileRevisionsPage(ctx context.Context, id int, page Page) ([]ConfigurationProfileRevision, int, error)
+	GetConfigurationProfileRevision(ctx context.Context, id, revision int) (ConfigurationProfileRevision, error)
+
+	ListAllClusterConfigurations(ctx context.Context, includeDeleted bool) ([]ClusterConfiguration, error)
+	ListAllClusterConfigurationsPage(ctx context.Context, includeDeleted bool, page Page) ([]ClusterConfiguration, int, error)
+	ListClusterConfiguration(ctx context.Context, cluster string, includeDeleted bool) ([]ClusterConfiguration, error)
+	ListClusterConfigurationPage(ctx context.Context, cluster string, includeDeleted bool, page Page) ([]ClusterConfiguration, int, error)
+	GetClusterConfigurationByID(ctx context.Context, id int64) (string, int, error)
+	GetClusterActiveConfiguration(ctx context.Context, cluster string) (string, error)
+	GetConfigurationIDForCluster(ctx context.Context, cluster string) (int, error)
+	CreateClusterConfiguration(ctx context.Context, cluster, username, reason, description, configuration string) ([]ClusterConfiguration, error)
+	CreateScheduledClusterConfiguration(ctx context.Context, cluster, username, reason, description, configuration string, window ConfigurationWindow) ([]ClusterConfiguration, error)
+	SetClusterConfigurationWindow(ctx context.Context, id int64, window ConfigurationWindow) error
+	RecordConfigurationTransitions(ctx context.Context, now time.Time) (int64, error)
+	ListConfigurationTransitions(ctx context.Context, id int64) ([]ConfigurationTransition, error)
+	AssignClusterConfiguration(ctx context.Context, cluster, username, reason string, profileID, revision int) ([]ClusterConfiguration, error)
+	RollbackClusterConfiguration(ctx context.Context, cluster, username, reason string, id int64) ([]ClusterConfiguration, error)
+	RepairActiveConfigurations(ctx context.Context) (int64, error)
+	EnableClusterConfiguration(ctx context.Context, cluster string, version int, username, reason string) ([]ClusterConfiguration, error)
+	DisableClusterConfiguration(ctx context.Context, cluster string, version int, username, reason string) ([]ClusterConfiguration, error)
+	EnableOrDisableClusterConfigurationByID(ctx context.Context, id int64, version int, active string) error
+	DeleteClusterConfigurationByID(ctx context.Context, id int64, version int, username string) error
+	RestoreClusterConfiguration(ctx context.Context, id int64) error
+	GetConfigurationSchema(ctx context.Context) (ConfigurationSchema, error)
+	SetConfigurationSchema(ctx context.Context, schema, username string) error
+	GetDefaultConfiguration(ctx context.Context) (DefaultConfiguration, error)
+	SetDefaultConfiguration(ctx context.Context, profileID int, username string) error
+	DeleteDefaultConfiguration(ctx context.Context) error
+	ListConfigurationGroups(ctx context.Context) ([]ConfigurationGroup, error)
+	NewConfigurationGroup(ctx context.Context, name, selector string, priority, profileID int, username string) error
+	DeleteConfigurationGroup(ctx context.Context, name string) error
+	ListConfigurationRollouts(ctx context.Context) ([]ConfigurationRollout, error)
+	GetConfigurationRollout(ctx context.Context, id int) (ConfigurationRollout, error)
+	NewConfigurationRollout(ctx context.Context, profileID int, selector string, waves []int, username, reason string) (ConfigurationRollout, error)
+	UpdateConfigurationRollout(ctx context.Context, id int, state RolloutState, currentWave int, username string) error
+	AddConfigurationRolloutCluster(ctx context.Context, id int, clusterName string, wave int, configurationID ClusterConfigurationID) error
+	ListConfigurationRolloutClusters(ctx context.Context, id int) ([]ConfigurationRolloutCluster, error)
+	AckClusterConfiguration(ctx context.Context, clusterName string, ackedAt time.Time) error
+
+	GetTriggerByID(ctx context.Context, id int64) (Trigger, error)
+	DeleteTriggerByID(ctx context.Context, id int64) error
+	ChangeStateOfTriggerByID(ctx context.Context, id int64, active int) error
+	ChangeTriggerState(ctx context.Context, id int64, state TriggerState, username string) error
+	ChangeClusterTriggerState(ctx context.Context, clusterName string, triggerID int64, state TriggerState) error
+	SetTriggerResult(ctx context.Context, clusterName string, triggerID int64, result TriggerResult) error
+	ListTriggerTransitions(ctx context.Context, id int64) ([]TriggerTransition, error)
+	ListAllTriggers(ctx context.Context) ([]Trigger, error)
+	ListAllTriggersPage(ctx context.Context, states []TriggerState, page Page) ([]Trigger, int, error)
+	ListClusterTriggers(ctx context.Context, clusterName string) ([]Trigger, error)
+	ListClusterTriggersPage(ctx context.Context, clusterName string, states []TriggerState, page Page) ([]Trigger, int, error)
+	ListActiveClusterTriggers(ctx context.Context, clusterName string) ([]Trigger, error)
+	AckTrigger(ctx context.Context, clusterName string, triggerID int64) error
+	NewTrigger(ctx context.Context, clusterName, triggerType, userName, reason, link, parameters string, expiresAt time.Time, caseNumber string) error
+	ExpireTriggers(ctx context.Context, now time.Time) (int64, error)
+
+	GetTriggerID(ctx context.Context, triggerType string) (int, error)
+	ListTriggerTypes(ctx context.Context) ([]TriggerType, error)
+	GetTriggerType(ctx context.Context, triggerType string) (TriggerType, error)
+	NewTriggerType(ctx context.Context, ttype, description, parametersSchema string) error
+	ChangeTriggerTypeDescription(ctx context.Context, ttype, description string) error
+	DeprecateTriggerType(ctx context.Context, ttype, username string) error
+	SetTriggerTypeDefaultTTL(ctx context.Context, ttype string, ttl time.Duration) error
+	SetTriggerTypeRequiresCase(ctx context.Context, ttype string, required bool) error
+
+	ListSupportCases(ctx context.Context) ([]SupportCase, error)
+	GetSupportCase(ctx context.Context, caseNumber string) (SupportCase, error)
+	NewSupportCase(ctx context.Context, caseNumber, account, consentLink, username string) error
+	ChangeSupportCaseStatus(ctx context.Context, caseNumber string, status SupportCaseStatus) error
+	ListSupportCaseTriggers(ctx context.Context, caseNumber string) ([]Trigger, error)
+
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
+// DBStorage represents an interface to any relational database based on SQL language
+type DBStorage struct {
+	connections  *sql.DB
+	driver       string
+	placeholder  sq.PlaceholderFormat
+	queryTimeout time.Duration
+}
+
+// make sure DBStorage implements the Storage interface
+var _ Storage = DBStorage{}
+
 // Column is typed reference to a sql column, which is further used by particular storage objects
 type Column string
 
@@ -73,48 +189,104 @@ func enableForeignKeys(connections *sql.DB) {
 	}
 }
 
-// New function creates and initializes a new instance of Storage structure
-func New(driverName, dataSourceName string) (Storage, error) {
+// New function creates and initializes a new instance of DBStorage structure
+func New(driverName, dataSourceName string) (DBStorage, error) {
 	log.Printf("Making connection to data storage, driver=%s datasource=%s", driverName, dataSourceName)
 	connections, err := sql.Open(driverName, dataSourceName)
 
 	if err != nil {
 		log.Println("Can not connect to data storage", err)
-		return Storage{}, err
+		return DBStorage{}, err
 	}
-	s := Storage{connections: connections, driver: driverName}
+	s := DBStorage{connections: connections, driver: driverName, placeholder: placeholderFormat(driverName)}
 
-	switch driverName {
-	case "sqlite3":
+	if driverName == "sqlite3" {
 		enableForeignKeys(connections)
-		s.placeholder = sq.Question
-	case "postgres":
-		s.placeholder = sq.Dollar
 	}
 	return s, nil
 }
 
-// NewFromConnection function creates and initializes a new instance of Storage interface from prepared connection
-func NewFromConnection(connection *sql.DB, driverName string) Storage {
-	return Storage{
+// placeholderFormat returns query argument placeholder format used by the
+// given database driver
+func placeholderFormat(driverName string) sq.PlaceholderFormat {
+	if driverName == "postgres" {
+		return sq.Dollar
+	}
+	return sq.Question
+}
+
+// NewFromConnection function creates and initializes a new instance of DBStorage structure from prepared connection
+func NewFromConnection(connection *sql.DB, driverName string) DBStorage {
+	return DBStorage{
 		connections: connection,
 		driver:      driverName,
+		placeholder: placeholderFormat(driverName),
+	}
+}
+
+// WithQueryTimeout returns a copy of storage that limits duration of each
+// storage operation to the specified timeout. Zero timeout means no limit.
+func (storage DBStorage) WithQueryTimeout(timeout time.Duration) DBStorage {
+	storage.queryTimeout = timeout
+	return storage
+}
+
+// withQueryTimeout derives context limited by the configured query timeout
+func (storage DBStorage) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if storage.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, storage.queryTimeout)
+}
+
+// queryError converts error returned by database driver to
+// QueryCancelledError when the context has been cancelled or its deadline
+// has been exceeded. Drivers do not report it consistently, so context state
+// is checked instead of the error itself.
+func queryError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	if _, ok := err.(*QueryCancelledError); ok {
+		return err
+	}
+	return &QueryCancelledError{Reason: ctx.Err()}
+}
+
+// isUniqueViolation checks whether the error returned by database driver is
+// caused by violation of unique constraint or unique index
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
 	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	return false
+}
+
+// utcTime converts timestamp into UTC. All timestamps are stored and compared
+// in UTC, because SQLite compares them as strings and timestamps with
+// different time zones would not be ordered correctly.
+func utcTime(t time.Time) time.Time {
+	return t.UTC()
 }
 
 // Placeholder returns current query argument placeholder
 // (?, or $).It depends on driver used. In squirrel format
-func (storage Storage) Placeholder() sq.PlaceholderFormat {
+func (storage DBStorage) Placeholder() sq.PlaceholderFormat {
 	return storage.placeholder
 }
 
 // Connections is sql.DB connection
-func (storage Storage) Connections() *sql.DB {
+func (storage DBStorage) Connections() *sql.DB {
 	return storage.connections
 }
 
 // Close method closes the connection to database. Needs to be called at the end of application lifecycle.
-func (storage Storage) Close() {
+func (storage DBStorage) Close() {
 	log.Println("Closing connection to data storage")
 	if storage.connections != nil {
 		err := storage.connections.Close()
@@ -140,9 +312,13 @@ type ClusterName Name
 //     ID: unique key
 //     Name: cluster GUID in the following format:
 //         c8590f31-e97e-4b85-b506-c45ce1911a12
+//     DeletedAt: timestamp of deletion, empty for clusters that are not deleted
+//     DeletedBy: username of admin that deleted the cluster
 type Cluster struct {
-	ID   ClusterID   `json:"id"`
-	Name ClusterName `json:"name"`
+	ID        ClusterID   `json:"id"`
+	Name      ClusterName `json:"name"`
+	DeletedAt string      `json:"deleted_at,omitempty"`
+	DeletedBy string      `json:"deleted_by,omitempty"`
 }
 
 // ConfigurationID represents unique key of configuration stored in database.
@@ -154,12 +330,34 @@ type ConfigurationID ID
 //     ChangeAt: username of admin that created or updated the configuration
 //     ChangeBy: timestamp of the last configuration change
 //     Description: a string with any comment(s) about the configuration
+//     Version: version of the profile, increased by each change
+//     DeletedAt: timestamp of deletion, empty for profiles that are not deleted
+//     DeletedBy: username of admin that deleted the profile
 type ConfigurationProfile struct {
 	ID            ConfigurationID `json:"id"`
 	Configuration string          `json:"configuration"`
 	ChangedAt     string          `json:"changed_at"`
 	ChangedBy     string          `json:"changed_by"`
 	Description   string          `json:"description"`
+	Version       int             `json:"version"`
+	DeletedAt     string          `json:"deleted_at,omitempty"`
+	DeletedBy     string          `json:"deleted_by,omitempty"`
+}
+
+// ConfigurationProfileRevision represents one immutable revision of configuration profile.
+//     Profile: ID of configuration profile
+//     Revision: revision number, starting from 1
+//     Configuration: a JSON structure stored in a string
+//     ChangeAt: timestamp of the change
+//     ChangeBy: username of admin that made the change
+//     Description: a string with any comment(s) about the configuration
+type ConfigurationProfileRevision struct {
+	Profile       ConfigurationID `json:"profile"`
+	Revision      int             `json:"revision"`
+	Configuration string          `json:"configuration"`
+	ChangedAt     string          `json:"changed_at"`
+	ChangedBy     string          `json:"changed_by"`
+	Description   string          `json:"description"`
 }
 
 // ClusterConfigurationID represents unique key of cluster configuration stored in database.
@@ -173,14 +371,103 @@ type ClusterConfigurationID ID
 //     ChangeBy: username of admin that created or updated the configuration
 //     Active: flag indicating whether the configuration is active or not
 //     Reason: a string with any comment(s) about the cluster configuration
+//     Version: version of the cluster configuration, increased by each change
+//     DeletedAt: timestamp of deletion, empty for configurations that are not deleted
+//     DeletedBy: username of admin that deleted the cluster configuration
+//     Revision: revision of profile the configuration is pinned to, 0 when it follows the latest revision
+//     EffectiveFrom: start of time window when the configuration is effective, empty when it is effective immediately
+//     EffectiveUntil: end of time window when the configuration is effective, empty when it does not expire
 type ClusterConfiguration struct {
-	ID            ClusterConfigurationID `json:"id"`
-	Cluster       string                 `json:"cluster"`
-	Configuration string                 `json:"configuration"`
-	ChangedAt     string                 `json:"changed_at"`
-	ChangedBy     string                 `json:"changed_by"`
-	Active        string                 `json:"active"`
-	Reason        string                 `json:"reason"`
+	ID             ClusterConfigurationID `json:"id"`
+	Cluster        string                 `json:"cluster"`
+	Configuration  string                 `json:"configuration"`
+	ChangedAt      string                 `json:"changed_at"`
+	ChangedBy      string                 `json:"changed_by"`
+	Active         string                 `json:"active"`
+	Reason         string                 `json:"reason"`
+	Version        int                    `json:"version"`
+	DeletedAt      string                 `json:"deleted_at,omitempty"`
+	DeletedBy      string                 `json:"deleted_by,omitempty"`
+	Revision       int                    `json:"revision"`
+	EffectiveFrom  string                 `json:"effective_from,omitempty"`
+	EffectiveUntil string                 `json:"effective_until,omitempty"`
+}
+
+// LatestRevision is used instead of revision of configuration profile to
+// assign profile to cluster that follows all later revisions of the profile.
+const LatestRevision = 0
+
+// AnyVersion can be used instead of the expected version of configuration
+// profile or cluster configuration to change or delete it unconditionally.
+const AnyVersion = 0
+
+// PreviousConfiguration is used instead of ID of cluster configuration to
+// roll the cluster back to the configuration that precedes the active one.
+const PreviousConfiguration = 0
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// activeConfigurationConflict checks that no other configuration of the
+// same cluster is active, so the cluster configuration specified by its ID
+// can be activated
+func activeConfigurationConflict(ctx context.Context, q rowQuerier, id int64) error {
+	var activeID int64
+	err := q.QueryRowContext(ctx, `
+SELECT other.id
+  FROM operator_configuration AS this JOIN operator_configuration AS other
+    ON (other.cluster = this.cluster)
+ WHERE this.id = $1 AND other.id <> this.id
+   AND other.active = 1 AND other.deleted_at IS NULL
+ LIMIT 1`, id).Scan(&activeID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return queryError(ctx, err)
+	}
+	return &ActiveConfigurationConflictError{
+		ID:       ClusterConfigurationID(id),
+		ActiveID: ClusterConfigurationID(activeID),
+	}
+}
+
+// activationError converts error returned when cluster configuration
+// specified by its ID has been activated. Other configuration of the same
+// cluster might be activated concurrently after activeConfigurationConflict
+// has been checked, the unique index on active configurations refuses the
+// change in that case and it is reported as ActiveConfigurationConflictError.
+func activationError(ctx context.Context, q rowQuerier, id int64, err error) error {
+	if !isUniqueViolation(err) {
+		return queryError(ctx, err)
+	}
+	if conflict := activeConfigurationConflict(ctx, q, id); conflict != nil {
+		return conflict
+	}
+	return &ActiveConfigurationConflictError{ID: ClusterConfigurationID(id)}
+}
+
+// versionMismatchError finds out why conditional change or deletion of item
+// has not affected any row: either the item does not exist (or it has been
+// deleted) or it is not at the expected version.
+func versionMismatchError(ctx context.Context, querier rowQuerier, table string, id interface{}, version int) error {
+	var current int
+
+	err := querier.QueryRowContext(ctx, "SELECT version FROM "+table+" WHERE id = $1 AND deleted_at IS NULL", id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	if err != nil {
+		return queryError(ctx, err)
+	}
+	return &VersionMismatchError{
+		ItemID:  id,
+		Version: version,
+	}
 }
 
 // TriggerID represents unique key of trigger stored in database.
@@ -194,30 +481,95 @@ type TriggerID ID
 //     Link: link to any document with customer ACK with the trigger
 //     TriggeredAt: timestamp of the last configuration change
 //     TriggeredBy: username of admin that created or updated the trigger
-//     AckedAt: timestamp where the insights operator acked the trigger
 //     Parameters: parameters that needs to be pass to trigger code
-//     Active: flag indicating whether the trigger is still active or not
+//     Active: flag indicating whether the trigger is still active or not,
+//             only pending triggers are active, it is derived from State
+//     ExpiresAt: timestamp when the trigger expires, empty for triggers that
+//                do not expire
+//     Expired: flag indicating whether the trigger has been deactivated,
+//              because it expired, it is derived from State
+//     State: state of the trigger in its lifecycle
+//     Result: result reported by the operator, nil when the result has not
+//             been reported yet
+//     CaseNumber: number of support case the trigger is linked to, empty
+//                 when the trigger is not linked to any case
 type Trigger struct {
-	ID          TriggerID `json:"id"`
-	Type        string    `json:"type"`
-	Cluster     string    `json:"cluster"`
-	Reason      string    `json:"reason"`
-	Link        string    `json:"link"`
-	TriggeredAt string    `json:"triggered_at"`
-	TriggeredBy string    `json:"triggered_by"`
-	AckedAt     string    `json:"acked_at"`
-	Parameters  string    `json:"parameters"`
-	Active      int       `json:"active"`
-}
-
-// ListOfClusters method selects all clusters from database.
-func (storage Storage) ListOfClusters() ([]Cluster, error) {
-	clusters := []Cluster{}
+	ID          TriggerID      `json:"id"`
+	Type        string         `json:"type"`
+	Cluster     string         `json:"cluster"`
+	Reason      string         `json:"reason"`
+	Link        string         `json:"link"`
+	TriggeredAt string         `json:"triggered_at"`
+	TriggeredBy string         `json:"triggered_by"`
+	Parameters  string         `json:"parameters"`
+	Active      int            `json:"active"`
+	ExpiresAt   string         `json:"expires_at,omitempty"`
+	Expired     int            `json:"expired"`
+	State       TriggerState   `json:"state"`
+	Result      *TriggerResult `json:"result,omitempty"`
+	CaseNumber  string         `json:"case_number,omitempty"`
+}
+
+// TriggerType represents type of trigger, for example must-gather
+//     ID: unique key
+//     Type: name of trigger type
+//     Description: description of what the trigger does
+//     ParametersSchema: JSON schema that parameters of triggers of this type
+//                       need to conform to, any JSON object is accepted when
+//                       it is empty
+//     DeprecatedAt: timestamp of deprecation, empty for trigger types that
+//                   are not deprecated
+//     DeprecatedBy: username of admin that deprecated the trigger type
+//     DefaultTTL: default time to live of new triggers in seconds, triggers
+//                 do not expire by default when it is zero
+//     RequiresCase: flag indicating whether new triggers need to be linked
+//                   to open support case
+type TriggerType struct {
+	ID               int    `json:"id"`
+	Type             string `json:"type"`
+	Description      string `json:"description"`
+	ParametersSchema string `json:"parameters_schema,omitempty"`
+	DeprecatedAt     string `json:"deprecated_at,omitempty"`
+	DeprecatedBy     string `json:"deprecated_by,omitempty"`
+	DefaultTTL       int    `json:"default_ttl,omitempty"`
+	RequiresCase     bool   `json:"requires_case"`
+}
+
+// clusterListQuery selects all clusters, deleted clusters are selected only
+// when the first parameter is set
+const clusterListQuery = "SELECT id, name, deleted_at, deleted_by FROM cluster WHERE ($1 OR deleted_at IS NULL)"
 
-	rows, err := storage.connections.Query("SELECT id, name FROM cluster")
+// ListOfClusters method selects all clusters from database. Deleted clusters
+// are selected only when includeDeleted is set.
+func (storage DBStorage) ListOfClusters(ctx context.Context, includeDeleted bool) ([]Cluster, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := storage.connections.QueryContext(ctx, clusterListQuery, includeDeleted)
 	if err != nil {
-		return clusters, err
+		return []Cluster{}, queryError(ctx, err)
+	}
+	return storage.readClusters(ctx, rows)
+}
+
+// ListOfClustersPage method selects one page of clusters from database
+// together with total number of clusters. Deleted clusters are selected only
+// when includeDeleted is set.
+func (storage DBStorage) ListOfClustersPage(ctx context.Context, includeDeleted bool, page Page) ([]Cluster, int, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, total, err := storage.queryPage(ctx, clusterListQuery, []interface{}{includeDeleted}, clusterPageColumns, "id", page)
+	if err != nil {
+		return []Cluster{}, 0, err
 	}
+	clusters, err := storage.readClusters(ctx, rows)
+	return clusters, total, err
+}
+
+// readClusters reads all clusters selected by the query and closes the rows
+func (storage DBStorage) readClusters(ctx context.Context, rows *sql.Rows) ([]Cluster, error) {
+	clusters := []Cluster{}
 
 	// close the query at function exit
 	defer func() {
@@ -230,24 +582,30 @@ func (storage Storage) ListOfClusters() ([]Cluster, error) {
 	for rows.Next() {
 		var id int
 		var name string
+		var deletedAt sql.NullString
+		var deletedBy sql.NullString
 
-		err = rows.Scan(&id, &name)
+		err := rows.Scan(&id, &name, &deletedAt, &deletedBy)
 		if err == nil {
-			clusters = append(clusters, Cluster{ClusterID(id), ClusterName(name)})
+			clusters = append(clusters, Cluster{ClusterID(id), ClusterName(name), deletedAt.String, deletedBy.String})
 		} else {
 			log.Println("error", err)
 		}
 	}
-	return clusters, nil
+	return clusters, queryError(ctx, rows.Err())
 }
 
-// GetCluster method selects the specified cluster from database. Also see GetClusterByName.
-func (storage Storage) GetCluster(id int) (Cluster, error) {
+// GetCluster method selects the specified cluster from database. Deleted
+// clusters are not selected. Also see GetClusterByName.
+func (storage DBStorage) GetCluster(ctx context.Context, id int) (Cluster, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
 	var cluster Cluster
 
-	rows, err := storage.connections.Query("SELECT id, name FROM cluster WHERE id = $1", id)
+	rows, err := storage.connections.QueryContext(ctx, "SELECT id, name FROM cluster WHERE id = $1 AND deleted_at IS NULL", id)
 	if err != nil {
-		return cluster, err
+		return cluster, queryError(ctx, err)
 	}
 
 	// close the query at function exit
@@ -274,15 +632,22 @@ func (storage Storage) GetCluster(id int) (Cluster, error) {
 			ItemID: id,
 		}
 	}
-	return cluster, err
+	return cluster, queryError(ctx, err)
 }
 
 // RegisterNewCluster inserts information about new cluster into the database.
 // It differs from CreateNewCluster, because ID is not specified explicitly here.
-func (storage Storage) RegisterNewCluster(name string) error {
-	statement, err := storage.connections.Prepare("INSERT INTO cluster(name) VALUES ($1)")
+// Nothing is inserted when the cluster is registered already, so operators
+// can register their clusters repeatedly.
+func (storage DBStorage) RegisterNewCluster(ctx context.Context, name string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := storage.connections.PrepareContext(ctx, `
+INSERT INTO cluster(name)
+SELECT $1 WHERE NOT EXISTS (SELECT 1 FROM cluster WHERE name = $1 AND deleted_at IS NULL)`)
 	if err != nil {
-		return err
+		return queryError(ctx, err)
 	}
 
 	// statement has to be closed at function exit
@@ -295,17 +660,24 @@ func (storage Storage) RegisterNewCluster(name string) error {
 		}
 	}()
 
-	_, err = statement.Exec(name)
-	return err
+	// the same cluster might be registered concurrently
+	_, err = statement.ExecContext(ctx, name)
+	if isUniqueViolation(err) {
+		return nil
+	}
+	return queryError(ctx, err)
 }
 
 // CreateNewCluster creates a new cluster with specified ID and name.
 // It differs from RegisterNewCluster, because ID is specified explicitly here.
-func (storage Storage) CreateNewCluster(id int64, name string) error {
-	statement, err := storage.connections.Prepare("INSERT INTO cluster(id, name) VALUES ($1, $2)")
+func (storage DBStorage) CreateNewCluster(ctx context.Context, id int64, name string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := storage.connections.PrepareContext(ctx, "INSERT INTO cluster(id, name) VALUES ($1, $2)")
 	if err != nil {
 		log.Print(err)
-		return err
+		return queryError(ctx, err)
 	}
 
 	// statement has to be closed at function exit
@@ -318,78 +690,124 @@ func (storage Storage) CreateNewCluster(id int64, name string) error {
 		}
 	}()
 
-	_, err = statement.Exec(id, name)
-	return err
+	// names of clusters that are not deleted are unique
+	_, err = statement.ExecContext(ctx, id, name)
+	if isUniqueViolation(err) {
+		return &ItemAlreadyExistsError{ItemID: name}
+	}
+	return queryError(ctx, err)
 }
 
-// DeleteCluster deletes cluster with specified ID from the database.
-func (storage Storage) DeleteCluster(id int64) error {
-	statement, err := storage.connections.Prepare("DELETE FROM cluster WHERE id = $1")
+// DeleteCluster marks cluster with specified ID as deleted. Configurations
+// and triggers of the cluster are kept until the cluster is purged.
+func (storage DBStorage) DeleteCluster(ctx context.Context, id int64, username string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx,
+		"UPDATE cluster SET deleted_at = $1, deleted_by = $2 WHERE id = $3 AND deleted_at IS NULL",
+		utcTime(time.Now()), username, id)
 	if err != nil {
 		log.Print(err)
-		return err
+		return queryError(ctx, err)
 	}
-
-	// statement has to be closed at function exit
-	defer func() {
-		// try to close the statement
-		err := statement.Close()
-		// in case of error all we can do is to just log the error
-		if err != nil {
-			log.Println(err)
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{
+			ItemID: id,
 		}
-	}()
+	}
+	return nil
+}
 
-	rowsAffected, err := execStatementAndGetRowsAffected(statement, id)
+// DeleteClusterByName marks cluster with specified name as deleted.
+func (storage DBStorage) DeleteClusterByName(ctx context.Context, name, username string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx,
+		"UPDATE cluster SET deleted_at = $1, deleted_by = $2 WHERE name = $3 AND deleted_at IS NULL",
+		utcTime(time.Now()), username, name)
 	if err != nil {
-		return err
+		log.Print(err)
+		return queryError(ctx, err)
 	}
 	if rowsAffected == 0 {
 		return &ItemNotFoundError{
-			ItemID: id,
+			ItemID: name,
 		}
 	}
 	return nil
 }
 
-// DeleteClusterByName deletes cluster with specified name from the database.
-func (storage Storage) DeleteClusterByName(name string) error {
-	statement, err := storage.connections.Prepare("DELETE FROM cluster WHERE name = $1")
+// RestoreCluster restores cluster with specified ID that has been deleted
+// (and not purged yet). Cluster can not be restored when other cluster with
+// the same name has been registered in the meantime.
+func (storage DBStorage) RestoreCluster(ctx context.Context, id int64) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
 	if err != nil {
 		log.Print(err)
-		return err
+		return queryError(ctx, err)
 	}
 
-	// statement has to be closed at function exit
-	defer func() {
-		// try to close the statement
-		err := statement.Close()
-		// in case of error all we can do is to just log the error
-		if err != nil {
-			log.Println(err)
+	var name string
+	err = tx.QueryRowContext(ctx, "SELECT name FROM cluster WHERE id = $1 AND deleted_at IS NOT NULL", id).Scan(&name)
+	if err == sql.ErrNoRows {
+		_ = tx.Rollback()
+		return &ItemNotFoundError{
+			ItemID: id,
 		}
-	}()
+	}
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return queryError(ctx, err)
+	}
 
-	rowsAffected, err := execStatementAndGetRowsAffected(statement, name)
+	var live int
+	err = tx.QueryRowContext(ctx, "SELECT count(*) FROM cluster WHERE name = $1 AND deleted_at IS NULL", name).Scan(&live)
 	if err != nil {
-		return err
+		log.Print(err)
+		_ = tx.Rollback()
+		return queryError(ctx, err)
 	}
-	if rowsAffected == 0 {
-		return &ItemNotFoundError{
+	if live > 0 {
+		_ = tx.Rollback()
+		return &ItemAlreadyExistsError{
 			ItemID: name,
 		}
 	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE cluster SET deleted_at = NULL, deleted_by = NULL WHERE id = $1", id)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return queryError(ctx, err)
+	}
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
 	return nil
 }
 
-// GetClusterByName selects a cluster specified by its name. Also see GetCluster.
-func (storage Storage) GetClusterByName(name string) (Cluster, error) {
+// GetClusterByName selects a cluster specified by its name. Deleted clusters
+// are not selected. Also see GetCluster.
+func (storage DBStorage) GetClusterByName(ctx context.Context, name string) (Cluster, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
 	var cluster Cluster
 
-	rows, err := storage.connections.Query("SELECT id, name FROM cluster WHERE name = $1", name)
+	rows, err := storage.connections.QueryContext(ctx, "SELECT id, name FROM cluster WHERE name = $1 AND deleted_at IS NULL", name)
 	if err != nil {
 		log.Print(err)
-		return cluster, err
+		return cluster, queryError(ctx, err)
 	}
 
 	// close the query at function exit
@@ -417,17 +835,25 @@ func (storage Storage) GetClusterByName(name string) (Cluster, error) {
 			ItemID: name,
 		}
 	}
-	return cluster, err
+	return cluster, queryError(ctx, err)
 }
 
-// ListConfigurationProfiles selects list of all configuration profiles from database.
-func (storage Storage) ListConfigurationProfiles() ([]ConfigurationProfile, error) {
-	profiles := []ConfigurationProfile{}
+// ListClusterLabels selects all labels of the cluster specified by its name.
+func (storage DBStorage) ListClusterLabels(ctx context.Context, clusterName string) (map[string]string, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	labels := make(map[string]string)
 
-	rows, err := storage.connections.Query("SELECT id, configuration, changed_at, changed_by, description FROM configuration_profile")
+	cluster, err := storage.GetClusterByName(ctx, clusterName)
 	if err != nil {
-		log.Print(err)
-		return profiles, err
+		return labels, err
+	}
+
+	rows, err := storage.connections.QueryContext(ctx,
+		"SELECT name, value FROM cluster_label WHERE cluster = $1", cluster.ID)
+	if err != nil {
+		return labels, queryError(ctx, err)
 	}
 
 	// close the query at function exit
@@ -439,164 +865,285 @@ func (storage Storage) ListConfigurationProfiles() ([]ConfigurationProfile, erro
 	}()
 
 	for rows.Next() {
-		var id int
-		var configuration string
-		var changedAt string
-		var changedBy string
-		var description string
-
-		err = rows.Scan(&id, &configuration, &changedAt, &changedBy, &description)
-		if err == nil {
-			profiles = append(profiles, ConfigurationProfile{ConfigurationID(id), configuration, changedAt, changedBy, description})
-		} else {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
 			log.Println("error", err)
+			return labels, queryError(ctx, err)
 		}
+		labels[key] = value
 	}
-
-	return profiles, nil
+	return labels, queryError(ctx, rows.Err())
 }
 
-// GetConfigurationProfile selects one configuration profile identified by its ID.
-func (storage Storage) GetConfigurationProfile(id int) (ConfigurationProfile, error) {
-	var profile ConfigurationProfile
+// SetClusterLabel sets value of the label of cluster specified by its name.
+// Label that already exists gets the new value.
+func (storage DBStorage) SetClusterLabel(ctx context.Context, clusterName, key, value string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	if err := CheckClusterLabel(key, value); err != nil {
+		return err
+	}
 
-	rows, err := storage.connections.Query("SELECT id, configuration, changed_at, changed_by, description FROM configuration_profile WHERE id = $1", id)
+	cluster, err := storage.GetClusterByName(ctx, clusterName)
 	if err != nil {
-		return profile, err
+		return err
 	}
 
-	// close the query at function exit
-	defer func() {
-		err := rows.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
+	_, err = storage.connections.ExecContext(ctx, `
+INSERT INTO cluster_label(cluster, name, value) VALUES ($1, $2, $3)
+    ON CONFLICT (cluster, name) DO UPDATE SET value = excluded.value`, cluster.ID, key, value)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	return nil
+}
 
-	if rows.Next() {
-		var id int
-		var configuration string
-		var changedAt string
-		var changedBy string
-		var description string
+// DeleteClusterLabel removes the label from cluster specified by its name.
+// ItemNotFoundError is returned when the cluster does not have the label.
+func (storage DBStorage) DeleteClusterLabel(ctx context.Context, clusterName, key string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-		err = rows.Scan(&id, &configuration, &changedAt, &changedBy, &description)
-		if err == nil {
-			profile.ID = ConfigurationID(id)
-			profile.Configuration = configuration
-			profile.ChangedAt = changedAt
-			profile.ChangedBy = changedBy
-			profile.Description = description
-		} else {
-			log.Println("error", err)
-		}
-	} else {
-		return profile, &ItemNotFoundError{
-			ItemID: id,
+	cluster, err := storage.GetClusterByName(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx,
+		"DELETE FROM cluster_label WHERE cluster = $1 AND name = $2", cluster.ID, key)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{
+			ItemID: key,
 		}
 	}
-	return profile, err
+	return nil
 }
 
-// StoreConfigurationProfile stores a given configuration profile (string ATM) into the database.
-func (storage Storage) StoreConfigurationProfile(username, description, configuration string) ([]ConfigurationProfile, error) {
-	var profiles []ConfigurationProfile
+// ListClustersBySelector selects all clusters (except deleted ones) with
+// labels that meet all requirements of the label selector.
+func (storage DBStorage) ListClustersBySelector(ctx context.Context, selector LabelSelector) ([]Cluster, error) {
+	query := NewClusterQuery(storage)
+	return query.queryMany(ctx, query.Query().Matching(selector).NotDeleted())
+}
 
-	t := time.Now()
+// RecordClusterHeartbeat records the call of operator running on cluster
+// specified by its name. Previous heartbeat of the cluster is replaced.
+func (storage DBStorage) RecordClusterHeartbeat(ctx context.Context, clusterName, address, operatorVersion string, seenAt time.Time) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-	statement, err := storage.connections.Prepare("INSERT INTO configuration_profile(configuration, changed_at, changed_by, description) VALUES ($1, $2, $3, $4)")
+	cluster, err := storage.GetClusterByName(ctx, clusterName)
 	if err != nil {
-		log.Print(err)
-		return profiles, err
+		return err
 	}
 
-	// statement has to be closed at function exit
-	defer func() {
-		// try to close the statement
-		err := statement.Close()
-		// in case of error all we can do is to just log the error
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-
-	_, err = statement.Exec(configuration, t, username, description)
+	_, err = storage.connections.ExecContext(ctx, `
+INSERT INTO cluster_heartbeat(cluster, last_seen_at, address, operator_version) VALUES ($1, $2, $3, $4)
+    ON CONFLICT (cluster) DO UPDATE SET last_seen_at = excluded.last_seen_at,
+                                        address = excluded.address,
+                                        operator_version = excluded.operator_version`,
+		cluster.ID, utcTime(seenAt), address, operatorVersion)
 	if err != nil {
 		log.Print(err)
-		return profiles, err
+		return queryError(ctx, err)
 	}
-
-	return storage.ListConfigurationProfiles()
+	return nil
 }
 
-// ChangeConfigurationProfile updates the existing configuration profile specified by its ID.
-func (storage Storage) ChangeConfigurationProfile(id int, username, description, configuration string) ([]ConfigurationProfile, error) {
-	var profiles []ConfigurationProfile
+// GetClusterHeartbeat selects the last heartbeat of cluster specified by its
+// ID. ItemNotFoundError is returned for clusters that have never been seen.
+func (storage DBStorage) GetClusterHeartbeat(ctx context.Context, clusterID int) (ClusterHeartbeat, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-	t := time.Now()
+	row := storage.connections.QueryRowContext(ctx, `
+SELECT `+clusterHeartbeatColumns+`
+  FROM cluster JOIN cluster_heartbeat ON cluster_heartbeat.cluster = cluster.id
+ WHERE cluster.id = $1 AND cluster.deleted_at IS NULL`, clusterID)
+
+	result, err := scanClusterHeartbeat(row.Scan)
+	if err == sql.ErrNoRows {
+		return ClusterHeartbeat{}, &ItemNotFoundError{ItemID: clusterID}
+	}
+	return result, queryError(ctx, err)
+}
 
-	statement, err := storage.connections.Prepare("UPDATE configuration_profile SET configuration = $1, changed_at = $2, changed_by = $3, description = $4 WHERE id = $5")
+// ListStaleClusters selects all clusters (except deleted ones) that have not
+// been seen since the specified time, including clusters that have never
+// been seen.
+func (storage DBStorage) ListStaleClusters(ctx context.Context, seenBefore time.Time) ([]ClusterHeartbeat, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	heartbeats := []ClusterHeartbeat{}
+
+	rows, err := storage.connections.QueryContext(ctx, `
+SELECT `+clusterHeartbeatColumns+`
+  FROM cluster LEFT JOIN cluster_heartbeat ON cluster_heartbeat.cluster = cluster.id
+ WHERE cluster.deleted_at IS NULL
+   AND (cluster_heartbeat.last_seen_at IS NULL OR cluster_heartbeat.last_seen_at < $1)
+ ORDER BY cluster.id`, utcTime(seenBefore))
 	if err != nil {
-		log.Print(err)
-		return profiles, err
+		return heartbeats, queryError(ctx, err)
 	}
 
-	// close the statement at function exit
+	// rows has to be closed at function exit
 	defer func() {
-		err := statement.Close()
+		// try to close the statement
+		err := rows.Close()
+		// in case of error all we can do is to just log the error
 		if err != nil {
 			log.Println(err)
 		}
 	}()
 
-	rowsAffected, err := execStatementAndGetRowsAffected(statement, configuration, t, username, description, id)
-	if err != nil {
-		log.Print(err)
-		return profiles, err
-	}
-	if rowsAffected == 0 {
-		return profiles, &ItemNotFoundError{
-			ItemID: id,
+	for rows.Next() {
+		heartbeat, err := scanClusterHeartbeat(rows.Scan)
+		if err != nil {
+			log.Println("error", err)
+			return heartbeats, queryError(ctx, err)
 		}
+		heartbeats = append(heartbeats, heartbeat)
 	}
-
-	return storage.ListConfigurationProfiles()
+	return heartbeats, queryError(ctx, rows.Err())
 }
 
-// DeleteConfigurationProfile deletes a configuration profile specified by its name.
-func (storage Storage) DeleteConfigurationProfile(id int) ([]ConfigurationProfile, error) {
-	var profiles []ConfigurationProfile
+// SetClusterMetadata stores metadata reported by the operator running on
+// cluster specified by its name. Metadata reported before are replaced.
+func (storage DBStorage) SetClusterMetadata(ctx context.Context, clusterName string, metadata ClusterMetadata) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-	statement, err := storage.connections.Prepare("DELETE FROM configuration_profile WHERE id = $1")
+	if err := CheckClusterMetadata(clusterName, metadata); err != nil {
+		return err
+	}
+
+	cluster, err := storage.GetClusterByName(ctx, clusterName)
 	if err != nil {
-		log.Print(err)
-		return profiles, err
+		return err
 	}
 
-	// close the statement at function exit
-	defer func() {
-		err := statement.Close()
+	_, err = storage.connections.ExecContext(ctx, `
+INSERT INTO cluster_metadata(cluster, `+clusterMetadataColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)
+    ON CONFLICT (cluster) DO UPDATE SET ocp_version = excluded.ocp_version,
+                                        platform = excluded.platform,
+                                        operator_version = excluded.operator_version,
+                                        region = excluded.region,
+                                        node_count = excluded.node_count,
+                                        updated_at = excluded.updated_at`,
+		cluster.ID, metadata.OCPVersion, metadata.Platform, metadata.OperatorVersion,
+		metadata.Region, metadata.NodeCount, utcTime(time.Now()))
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	return nil
+}
+
+// GetClusterMetadata selects metadata of cluster specified by its ID.
+// ItemNotFoundError is returned for clusters without metadata.
+func (storage DBStorage) GetClusterMetadata(ctx context.Context, clusterID int) (ClusterMetadata, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	row := storage.connections.QueryRowContext(ctx,
+		"SELECT "+clusterMetadataColumns+" FROM cluster_metadata WHERE cluster = $1", clusterID)
+
+	result, err := scanClusterMetadata(row.Scan)
+	if err == sql.ErrNoRows {
+		return ClusterMetadata{}, &ItemNotFoundError{ItemID: clusterID}
+	}
+	return result, queryError(ctx, err)
+}
+
+// profileListQuery selects all configuration profiles, deleted profiles are
+// selected only when the first parameter is set
+const profileListQuery = `
+SELECT id, configuration, changed_at, changed_by, description, version, deleted_at, deleted_by
+  FROM configuration_profile
+ WHERE ($1 OR deleted_at IS NULL)`
+
+// ListConfigurationProfiles selects list of all configuration profiles from
+// database. Deleted profiles are selected only when includeDeleted is set.
+func (storage DBStorage) ListConfigurationProfiles(ctx context.Context, includeDeleted bool) ([]ConfigurationProfile, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := storage.connections.QueryContext(ctx, profileListQuery, includeDeleted)
+	if err != nil {
+		log.Print(err)
+		return []ConfigurationProfile{}, queryError(ctx, err)
+	}
+	return storage.readConfigurationProfiles(ctx, rows)
+}
+
+// ListConfigurationProfilesPage selects one page of configuration profiles
+// from database together with total number of profiles. Deleted profiles are
+// selected only when includeDeleted is set.
+func (storage DBStorage) ListConfigurationProfilesPage(ctx context.Context, includeDeleted bool, page Page) ([]ConfigurationProfile, int, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, total, err := storage.queryPage(ctx, profileListQuery, []interface{}{includeDeleted}, profilePageColumns, "id", page)
+	if err != nil {
+		return []ConfigurationProfile{}, 0, err
+	}
+	profiles, err := storage.readConfigurationProfiles(ctx, rows)
+	return profiles, total, err
+}
+
+// readConfigurationProfiles reads all configuration profiles selected by the
+// query and closes the rows
+func (storage DBStorage) readConfigurationProfiles(ctx context.Context, rows *sql.Rows) ([]ConfigurationProfile, error) {
+	profiles := []ConfigurationProfile{}
+
+	// close the query at function exit
+	defer func() {
+		err := rows.Close()
 		if err != nil {
 			log.Println(err)
 		}
 	}()
 
-	rowsAffected, err := execStatementAndGetRowsAffected(statement, id)
-	if err != nil {
-		log.Print(err)
-		return profiles, err
-	}
-	if rowsAffected == 0 {
-		return profiles, &ItemNotFoundError{
-			ItemID: id,
+	for rows.Next() {
+		var id int
+		var configuration string
+		var changedAt string
+		var changedBy sql.NullString
+		var description sql.NullString
+		var version int
+		var deletedAt sql.NullString
+		var deletedBy sql.NullString
+
+		err := rows.Scan(&id, &configuration, &changedAt, &changedBy, &description, &version, &deletedAt, &deletedBy)
+		if err == nil {
+			profiles = append(profiles, ConfigurationProfile{ConfigurationID(id), configuration, changedAt, changedBy.String, description.String, version, deletedAt.String, deletedBy.String})
+		} else {
+			log.Println("error", err)
 		}
 	}
 
-	return storage.ListConfigurationProfiles()
+	return profiles, queryError(ctx, rows.Err())
 }
 
-func (storage Storage) readClusterConfigurations(rows *sql.Rows) ([]ClusterConfiguration, error) {
-	configurations := []ClusterConfiguration{}
+// GetConfigurationProfile selects one configuration profile identified by its
+// ID. Deleted profiles are not selected.
+func (storage DBStorage) GetConfigurationProfile(ctx context.Context, id int) (ConfigurationProfile, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	var profile ConfigurationProfile
+
+	rows, err := storage.connections.QueryContext(ctx, "SELECT id, configuration, changed_at, changed_by, description, version FROM configuration_profile WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return profile, queryError(ctx, err)
+	}
 
 	// close the query at function exit
 	defer func() {
@@ -606,173 +1153,163 @@ func (storage Storage) readClusterConfigurations(rows *sql.Rows) ([]ClusterConfi
 		}
 	}()
 
-	for rows.Next() {
+	if rows.Next() {
 		var id int
-		var cluster string
 		var configuration string
 		var changedAt string
 		var changedBy string
-		var active string
-		var reason string
+		var description string
+		var version int
 
-		err := rows.Scan(&id, &cluster, &configuration, &changedAt, &changedBy, &active, &reason)
+		err = rows.Scan(&id, &configuration, &changedAt, &changedBy, &description, &version)
 		if err == nil {
-			configurations = append(configurations, ClusterConfiguration{ClusterConfigurationID(id), cluster, configuration, changedAt, changedBy, active, reason})
+			profile.ID = ConfigurationID(id)
+			profile.Configuration = configuration
+			profile.ChangedAt = changedAt
+			profile.ChangedBy = changedBy
+			profile.Description = description
+			profile.Version = version
 		} else {
 			log.Println("error", err)
 		}
+	} else {
+		return profile, &ItemNotFoundError{
+			ItemID: id,
+		}
 	}
-
-	return configurations, nil
+	return profile, queryError(ctx, err)
 }
 
-// ListAllClusterConfigurations selects all cluster configurations from the database.
-func (storage Storage) ListAllClusterConfigurations() ([]ClusterConfiguration, error) {
-	rows, err := storage.connections.Query(`
-SELECT operator_configuration.id, cluster.name, configuration, changed_at, changed_by, active, reason
-  FROM operator_configuration JOIN cluster
-    ON (cluster.id = operator_configuration.cluster)
-ORDER BY operator_configuration.id`)
+// StoreConfigurationProfile stores a given configuration profile (string ATM) into the database.
+// The profile is stored together with its first revision.
+func (storage DBStorage) StoreConfigurationProfile(ctx context.Context, username, description, configuration string) ([]ConfigurationProfile, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
+	var profiles []ConfigurationProfile
+
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
 	if err != nil {
 		log.Print(err)
-		return []ClusterConfiguration{}, err
+		return profiles, queryError(ctx, err)
 	}
-	return storage.readClusterConfigurations(rows)
-}
 
-// ListClusterConfiguration selects cluster configuration from the database for the specified cluster.
-func (storage Storage) ListClusterConfiguration(cluster string) ([]ClusterConfiguration, error) {
-	if _, err := storage.GetClusterByName(cluster); err != nil {
-		return nil, err
+	// insert new configuration profile
+	err = storage.InsertNewConfigurationProfile(ctx, tx, configuration, username, description)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return profiles, err
 	}
 
-	rows, err := storage.connections.Query(`
-SELECT operator_configuration.id, cluster.name, configuration, changed_at, changed_by, active, reason
-  FROM operator_configuration JOIN cluster
-    ON (cluster.id = operator_configuration.cluster)
- WHERE cluster.name = $1`, cluster)
+	// retrieve ID of newly created configuration profile
+	profileID, err := storage.SelectConfigurationProfileID(ctx, tx)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return profiles, queryError(ctx, err)
+	}
 
+	// and store its first revision
+	err = storage.InsertConfigurationProfileRevision(ctx, tx, profileID)
 	if err != nil {
 		log.Print(err)
-		return []ClusterConfiguration{}, err
+		_ = tx.Rollback()
+		return profiles, queryError(ctx, err)
+	}
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return profiles, queryError(ctx, err)
 	}
 
-	return storage.readClusterConfigurations(rows)
+	return storage.ListConfigurationProfiles(ctx, false)
 }
 
-// GetClusterConfigurationByID reads cluster configuration for the specified configuration ID.
-func (storage Storage) GetClusterConfigurationByID(id int64) (string, error) {
-	var configuration string
+// ChangeConfigurationProfile updates the existing configuration profile specified by its ID.
+// Profile is changed only when it is at the expected version (or AnyVersion is used).
+// Previous content of the profile is kept in its revision history.
+func (storage DBStorage) ChangeConfigurationProfile(ctx context.Context, id, version int, username, description, configuration string) ([]ConfigurationProfile, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-	row, err := storage.connections.Query(`
-SELECT configuration_profile.configuration
-  FROM operator_configuration JOIN configuration_profile
-    ON (configuration_profile.id = operator_configuration.configuration)
- WHERE operator_configuration.id = $1`, id)
+	var profiles []ConfigurationProfile
 
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
 	if err != nil {
 		log.Print(err)
-		return configuration, err
+		return profiles, queryError(ctx, err)
 	}
 
-	// close the query at function exit
-	defer func() {
-		err := row.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-
-	if row.Next() {
-		err = row.Scan(&configuration)
-		if err != nil {
-			log.Println("error", err)
-		}
-		return configuration, err
+	rowsAffected, err := storage.updateConfigurationProfile(ctx, tx, id, version, username, description, configuration)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return profiles, queryError(ctx, err)
 	}
-	return configuration, &ItemNotFoundError{
-		ItemID: id,
+	if rowsAffected == 0 {
+		err = versionMismatchError(ctx, tx, "configuration_profile", id, version)
+		_ = tx.Rollback()
+		return profiles, err
 	}
-}
-
-// GetClusterActiveConfiguration reads one active configuration for the selected cluster.
-func (storage Storage) GetClusterActiveConfiguration(cluster string) (string, error) {
-	var configuration string
-
-	row, err := storage.connections.Query(`
-SELECT configuration_profile.configuration
-  FROM operator_configuration, cluster, configuration_profile
- WHERE cluster.id = operator_configuration.cluster
-   AND configuration_profile.id = operator_configuration.configuration
-   AND operator_configuration.active = '1' AND cluster.name = $1
- LIMIT 1`, cluster)
 
+	// store the new content as the next revision
+	err = storage.InsertConfigurationProfileRevision(ctx, tx, id)
 	if err != nil {
 		log.Print(err)
-		return configuration, err
+		_ = tx.Rollback()
+		return profiles, queryError(ctx, err)
 	}
 
-	// query has to be closed at function exit
-	defer func() {
-		// try to close the query
-		err := row.Close()
-		// in case of error all we can do is to just log the error
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-
-	if row.Next() {
-		err = row.Scan(&configuration)
-		if err != nil {
-			log.Println("error", err)
-		}
-		return configuration, err
-	}
-	return configuration, &ItemNotFoundError{
-		ItemID: cluster,
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return profiles, queryError(ctx, err)
 	}
+
+	return storage.ListConfigurationProfiles(ctx, false)
 }
 
-// GetConfigurationIDForCluster reads the ID for the specified cluster name.
-func (storage Storage) GetConfigurationIDForCluster(cluster string) (int, error) {
-	rows, err := storage.connections.Query(`
-SELECT operator_configuration.id
-  FROM operator_configuration, cluster
-    ON cluster.id = operator_configuration.cluster
- WHERE cluster.name = $1`, cluster)
+// updateConfigurationProfile updates content of configuration profile. To be used in transaction.
+func (storage DBStorage) updateConfigurationProfile(ctx context.Context, tx *sql.Tx, id, version int, username, description, configuration string) (int64, error) {
+	t := utcTime(time.Now())
 
+	statement, err := tx.PrepareContext(ctx, `
+UPDATE configuration_profile
+   SET configuration = $1, changed_at = $2, changed_by = $3, description = $4, version = version + 1
+ WHERE id = $5 AND ($6 = 0 OR version = $6) AND deleted_at IS NULL`)
 	if err != nil {
 		return 0, err
 	}
 
-	// query has to be closed at function exit
+	// statement has to be closed at function exit
 	defer func() {
-		// try to close the query
-		err := rows.Close()
+		// try to close the statement
+		err := statement.Close()
 		// in case of error all we can do is to just log the error
 		if err != nil {
 			log.Println(err)
 		}
 	}()
 
-	if rows.Next() {
-		var id int
-
-		err = rows.Scan(&id)
-		return id, err
-	}
-	return 0, errors.New("Unknown operator name provided")
+	return execStatementAndGetRowsAffected(ctx, statement, configuration, t, username, description, id, version)
 }
 
-// InsertNewConfigurationProfile inserts new configuration profile into a database (in transaction).
-func (storage Storage) InsertNewConfigurationProfile(tx *sql.Tx, configuration, username, description string) bool {
-	t := time.Now()
-
-	statement, err := tx.Prepare("INSERT INTO configuration_profile(configuration, changed_at, changed_by, description) VALUES ($1, $2, $3, $4)")
+// InsertConfigurationProfileRevision stores the current content of
+// configuration profile as its next revision. To be used in transaction.
+func (storage DBStorage) InsertConfigurationProfileRevision(ctx context.Context, tx *sql.Tx, profileID int) error {
+	statement, err := tx.PrepareContext(ctx, `
+INSERT INTO configuration_profile_revision(profile, revision, configuration, changed_at, changed_by, description)
+SELECT id,
+       (SELECT COALESCE(MAX(revision), 0) + 1 FROM configuration_profile_revision WHERE profile = $1),
+       configuration, changed_at, changed_by, description
+  FROM configuration_profile
+ WHERE id = $1`)
 	if err != nil {
-		return false
+		return err
 	}
 
 	// statement has to be closed at function exit
@@ -785,232 +1322,449 @@ func (storage Storage) InsertNewConfigurationProfile(tx *sql.Tx, configuration,
 		}
 	}()
 
-	_, err = statement.Exec(configuration, t, username, description)
-	return err == nil
+	_, err = statement.ExecContext(ctx, profileID)
+	if err == nil {
+		log.Printf("New revision of configuration profile %d has been stored\n", profileID)
+	}
+	return err
 }
 
-// SelectConfigurationProfileID selects the ID of lately inserted/created configuration profile. To be used in transaction.
-func (storage Storage) SelectConfigurationProfileID(tx *sql.Tx) (int, error) {
-	var rows *sql.Rows
-	var err error
-
-	// We need to get the ID from the last insert. Unfortunately it seems there is not
-	// one existing solution that works for all databases.
-	switch storage.driver {
-	case "sqlite3":
-		rows, err = tx.Query(`SELECT rowid FROM configuration_profile ORDER BY rowid DESC limit 1`)
-	case "postgres":
-		rows, err = tx.Query(`SELECT currval('configuration_profile_id_seq')`)
-	default:
-		return -1, errors.New("unknown DB driver:" + storage.driver)
+// revisionListQuery selects all revisions of configuration profile specified
+// by the first parameter
+const revisionListQuery = `
+SELECT profile, revision, configuration, changed_at, changed_by, description
+  FROM configuration_profile_revision
+ WHERE profile = $1`
+
+// ListConfigurationProfileRevisions selects all revisions of configuration
+// profile specified by its ID, the oldest revision first.
+func (storage DBStorage) ListConfigurationProfileRevisions(ctx context.Context, id int) ([]ConfigurationProfileRevision, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// check that profile exist
+	if _, err := storage.GetConfigurationProfile(ctx, id); err != nil {
+		return []ConfigurationProfileRevision{}, err
 	}
+
+	rows, err := storage.connections.QueryContext(ctx, revisionListQuery+" ORDER BY revision", id)
 	if err != nil {
 		log.Print(err)
-		return -1, err
+		return []ConfigurationProfileRevision{}, queryError(ctx, err)
 	}
+	return storage.readConfigurationProfileRevisions(ctx, rows)
+}
 
-	// query has to be closed at function exit
-	defer func() {
-		// try to close the query
-		err := rows.Close()
-		// in case of error all we can do is to just log the error
-		if err != nil {
-			log.Println(err)
-		}
-	}()
+// ListConfigurationProfileRevisionsPage selects one page of revisions of
+// configuration profile specified by its ID together with total number of
+// its revisions.
+func (storage DBStorage) ListConfigurationProfileRevisionsPage(ctx context.Context, id int, page Page) ([]ConfigurationProfileRevision, int, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-	if rows.Next() {
-		var configurationID int
-		err = rows.Scan(&configurationID)
-		if err != nil {
-			return -1, err
-		}
-		log.Printf("Configuration stored under ID=%d\n", configurationID)
-		return configurationID, nil
+	// check that profile exist
+	if _, err := storage.GetConfigurationProfile(ctx, id); err != nil {
+		return []ConfigurationProfileRevision{}, 0, err
 	}
-	return -1, errors.New("can not retrieve last configuration ID")
+
+	rows, total, err := storage.queryPage(ctx, revisionListQuery, []interface{}{id}, revisionPageColumns, "revision", page)
+	if err != nil {
+		return []ConfigurationProfileRevision{}, 0, err
+	}
+	revisions, err := storage.readConfigurationProfileRevisions(ctx, rows)
+	return revisions, total, err
 }
 
-// DeactivatePreviousConfigurations deactivate all previous configurations for the specified trigger.
-// To be called inside transaction.
-func (storage Storage) DeactivatePreviousConfigurations(tx *sql.Tx, clusterID ClusterID) error {
-	statement, err := tx.Prepare("UPDATE operator_configuration SET active=0 WHERE cluster = $1")
+// readConfigurationProfileRevisions reads all configuration profile
+// revisions selected by the query and closes the rows
+func (storage DBStorage) readConfigurationProfileRevisions(ctx context.Context, rows *sql.Rows) ([]ConfigurationProfileRevision, error) {
+	revisions := []ConfigurationProfileRevision{}
 
-	// statement has to be closed at function exit
+	// close the query at function exit
 	defer func() {
-		// try to close the statement
-		err := statement.Close()
-		// in case of error all we can do is to just log the error
+		err := rows.Close()
 		if err != nil {
 			log.Println(err)
 		}
 	}()
 
-	if err != nil {
-		return err
-	}
-	_, err = statement.Exec(clusterID)
-	if err == nil {
-		log.Printf("All previous configuration has been deactivated for clusterID %d\n", clusterID)
+	for rows.Next() {
+		var revision ConfigurationProfileRevision
+
+		err := rows.Scan(&revision.Profile, &revision.Revision, &revision.Configuration,
+			&revision.ChangedAt, &revision.ChangedBy, &revision.Description)
+		if err == nil {
+			revisions = append(revisions, revision)
+		} else {
+			log.Println("error", err)
+		}
 	}
-	return err
+
+	return revisions, queryError(ctx, rows.Err())
 }
 
-// InsertNewOperatorConfiguration inserts the new configuration for selected operator/cluster.
-// To be called inside transaction.
-func (storage Storage) InsertNewOperatorConfiguration(tx *sql.Tx, clusterID ClusterID, configurationID int, username, reason string) error {
-	t := time.Now()
-	statement, err := tx.Prepare("INSERT INTO operator_configuration(cluster, configuration, changed_at, changed_by, active, reason) VALUES ($1, $2, $3, $4, $5, $6)")
+// GetConfigurationProfileRevision selects one revision of configuration profile.
+func (storage DBStorage) GetConfigurationProfileRevision(ctx context.Context, id, revision int) (ConfigurationProfileRevision, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-	// statement has to be closed at function exit
-	defer func() {
-		// try to close the statement
-		err := statement.Close()
-		// in case of error all we can do is to just log the error
-		if err != nil {
-			log.Println(err)
-		}
-	}()
+	var profileRevision ConfigurationProfileRevision
 
-	if err != nil {
-		return err
-	}
+	err := storage.connections.QueryRowContext(ctx, `
+SELECT profile, revision, configuration, changed_at, changed_by, description
+  FROM configuration_profile_revision
+ WHERE profile = $1 AND revision = $2`, id, revision).Scan(
+		&profileRevision.Profile, &profileRevision.Revision, &profileRevision.Configuration,
+		&profileRevision.ChangedAt, &profileRevision.ChangedBy, &profileRevision.Description)
 
-	_, err = statement.Exec(clusterID, configurationID, t, username, "1", reason)
-	if err == nil {
-		log.Printf("New operator configuration %d has been assigned to cluster %d\n", configurationID, clusterID)
+	if err == sql.ErrNoRows {
+		return profileRevision, &ItemNotFoundError{
+			ItemID: fmt.Sprintf("%v/%v", id, revision),
+		}
 	}
-	return err
+	return profileRevision, queryError(ctx, err)
 }
 
-// CreateClusterConfiguration creates new configuration for specified cluster.
-func (storage Storage) CreateClusterConfiguration(cluster, username, reason, description, configuration string) ([]ClusterConfiguration, error) {
-	// retrieve cluster ID
-	clusterInfo, err := storage.GetClusterByName(cluster)
+// DeleteConfigurationProfile marks a configuration profile specified by its
+// ID as deleted. Profile is deleted only when it is at the expected version
+// (or AnyVersion is used). Revisions of the profile and cluster
+// configurations that use it are kept until the profile is purged.
+func (storage DBStorage) DeleteConfigurationProfile(ctx context.Context, id, version int, username string) ([]ConfigurationProfile, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	var profiles []ConfigurationProfile
 
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx, `
+UPDATE configuration_profile
+   SET deleted_at = $1, deleted_by = $2, version = version + 1
+ WHERE id = $3 AND ($4 = 0 OR version = $4) AND deleted_at IS NULL`,
+		utcTime(time.Now()), username, id, version)
 	if err != nil {
 		log.Print(err)
-		return []ClusterConfiguration{}, err
+		return profiles, queryError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return profiles, versionMismatchError(ctx, storage.connections, "configuration_profile", id, version)
 	}
 
-	clusterID := clusterInfo.ID
+	return storage.ListConfigurationProfiles(ctx, false)
+}
 
-	// begin transaction
-	tx, err := storage.connections.Begin()
+// RestoreConfigurationProfile restores configuration profile specified by its
+// ID that has been deleted (and not purged yet).
+func (storage DBStorage) RestoreConfigurationProfile(ctx context.Context, id int) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx, `
+UPDATE configuration_profile
+   SET deleted_at = NULL, deleted_by = NULL, version = version + 1
+ WHERE id = $1 AND deleted_at IS NOT NULL`, id)
 	if err != nil {
 		log.Print(err)
-		log.Println("Transaction failed")
-		return []ClusterConfiguration{}, err
+		return queryError(ctx, err)
 	}
-
-	// insert new configuration profile
-	if !storage.InsertNewConfigurationProfile(tx, configuration, username, description) {
-		log.Print(err)
-		_ = tx.Rollback()
-		return []ClusterConfiguration{}, err
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
 	}
+	return nil
+}
 
-	// retrieve configuration ID for newly created configuration
-	configurationID, err := storage.SelectConfigurationProfileID(tx)
-	if err != nil {
-		log.Print(err)
-		_ = tx.Rollback()
-		return []ClusterConfiguration{}, err
+func (storage DBStorage) readClusterConfigurations(ctx context.Context, rows *sql.Rows) ([]ClusterConfiguration, error) {
+	configurations := []ClusterConfiguration{}
+
+	// close the query at function exit
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		var id int
+		var cluster string
+		var configuration string
+		var changedAt string
+		var changedBy string
+		var active string
+		var reason string
+		var version int
+		var deletedAt sql.NullString
+		var deletedBy sql.NullString
+		var revision int
+		var effectiveFrom sql.NullString
+		var effectiveUntil sql.NullString
+
+		err := rows.Scan(&id, &cluster, &configuration, &changedAt, &changedBy, &active, &reason, &version, &deletedAt, &deletedBy, &revision, &effectiveFrom, &effectiveUntil)
+		if err == nil {
+			configurations = append(configurations, ClusterConfiguration{ClusterConfigurationID(id), cluster, configuration, changedAt, changedBy, active, reason, version, deletedAt.String, deletedBy.String, revision, effectiveFrom.String, effectiveUntil.String})
+		} else {
+			log.Println("error", err)
+		}
 	}
 
-	// deactivate all previous configurations
-	err = storage.DeactivatePreviousConfigurations(tx, clusterID)
+	return configurations, queryError(ctx, rows.Err())
+}
+
+// configurationListQuery selects cluster configurations together with names
+// of their clusters, conditions need to be appended to it
+const configurationListQuery = `
+SELECT operator_configuration.id, cluster.name, configuration, changed_at, changed_by, active, reason, operator_configuration.version,
+       operator_configuration.deleted_at, operator_configuration.deleted_by, operator_configuration.revision,
+       operator_configuration.effective_from, operator_configuration.effective_until
+  FROM operator_configuration JOIN cluster
+    ON (cluster.id = operator_configuration.cluster)`
+
+// allConfigurationsQuery selects all cluster configurations, deleted
+// configurations and configurations of deleted clusters are selected only
+// when the first parameter is set
+const allConfigurationsQuery = configurationListQuery + `
+ WHERE ($1 OR (operator_configuration.deleted_at IS NULL AND cluster.deleted_at IS NULL))`
+
+// clusterConfigurationsQuery selects configurations of cluster specified by
+// the first parameter, deleted configurations are selected only when the
+// second parameter is set
+const clusterConfigurationsQuery = configurationListQuery + `
+ WHERE cluster.name = $1 AND cluster.deleted_at IS NULL
+   AND ($2 OR operator_configuration.deleted_at IS NULL)`
+
+// ListAllClusterConfigurations selects all cluster configurations from the
+// database. Deleted configurations and configurations of deleted clusters are
+// selected only when includeDeleted is set.
+func (storage DBStorage) ListAllClusterConfigurations(ctx context.Context, includeDeleted bool) ([]ClusterConfiguration, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := storage.connections.QueryContext(ctx, allConfigurationsQuery+`
+ORDER BY operator_configuration.id`, includeDeleted)
+
 	if err != nil {
 		log.Print(err)
-		_ = tx.Rollback()
-		return []ClusterConfiguration{}, err
+		return []ClusterConfiguration{}, queryError(ctx, err)
 	}
+	return storage.readClusterConfigurations(ctx, rows)
+}
 
-	// and insert new one that will be activated
-	err = storage.InsertNewOperatorConfiguration(tx, clusterID, configurationID, username, reason)
+// ListAllClusterConfigurationsPage selects one page of cluster
+// configurations from the database together with total number of
+// configurations. Deleted configurations and configurations of deleted
+// clusters are selected only when includeDeleted is set.
+func (storage DBStorage) ListAllClusterConfigurationsPage(ctx context.Context, includeDeleted bool, page Page) ([]ClusterConfiguration, int, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, total, err := storage.queryPage(ctx, allConfigurationsQuery, []interface{}{includeDeleted}, configurationPageColumns, "id", page)
 	if err != nil {
-		log.Print(err)
-		_ = tx.Rollback()
-		return []ClusterConfiguration{}, err
+		return []ClusterConfiguration{}, 0, err
+	}
+	configurations, err := storage.readClusterConfigurations(ctx, rows)
+	return configurations, total, err
+}
+
+// ListClusterConfiguration selects cluster configuration from the database for the specified cluster.
+// Deleted configurations are selected only when includeDeleted is set.
+func (storage DBStorage) ListClusterConfiguration(ctx context.Context, cluster string, includeDeleted bool) ([]ClusterConfiguration, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := storage.GetClusterByName(ctx, cluster); err != nil {
+		return nil, queryError(ctx, err)
 	}
 
-	// end the transaction
-	if err := tx.Commit(); err != nil {
+	rows, err := storage.connections.QueryContext(ctx, clusterConfigurationsQuery, cluster, includeDeleted)
+
+	if err != nil {
 		log.Print(err)
-		return []ClusterConfiguration{}, err
+		return []ClusterConfiguration{}, queryError(ctx, err)
 	}
 
-	return storage.ListClusterConfiguration(cluster)
+	return storage.readClusterConfigurations(ctx, rows)
 }
 
-// EnableClusterConfiguration enables the specified cluster configuration (set the 'active' flag).
-func (storage Storage) EnableClusterConfiguration(cluster, username, reason string) ([]ClusterConfiguration, error) {
-	id, err := storage.GetConfigurationIDForCluster(cluster)
+// ListClusterConfigurationPage selects one page of configurations of the
+// specified cluster together with total number of its configurations.
+// Deleted configurations are selected only when includeDeleted is set.
+func (storage DBStorage) ListClusterConfigurationPage(ctx context.Context, cluster string, includeDeleted bool, page Page) ([]ClusterConfiguration, int, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := storage.GetClusterByName(ctx, cluster); err != nil {
+		return nil, 0, queryError(ctx, err)
+	}
+
+	rows, total, err := storage.queryPage(ctx, clusterConfigurationsQuery, []interface{}{cluster, includeDeleted}, configurationPageColumns, "id", page)
 	if err != nil {
-		return []ClusterConfiguration{}, err
+		return []ClusterConfiguration{}, 0, err
 	}
+	configurations, err := storage.readClusterConfigurations(ctx, rows)
+	return configurations, total, err
+}
+
+// GetClusterConfigurationByID reads cluster configuration for the specified
+// configuration ID together with version of the cluster configuration.
+func (storage DBStorage) GetClusterConfigurationByID(ctx context.Context, id int64) (string, int, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	var configuration string
+	var version int
+
+	row, err := storage.connections.QueryContext(ctx, `
+SELECT COALESCE(configuration_profile_revision.configuration, configuration_profile.configuration), operator_configuration.version
+  FROM operator_configuration JOIN configuration_profile
+    ON (configuration_profile.id = operator_configuration.configuration)
+  LEFT JOIN configuration_profile_revision
+    ON (configuration_profile_revision.profile = operator_configuration.configuration
+        AND configuration_profile_revision.revision = operator_configuration.revision)
+ WHERE operator_configuration.id = $1 AND operator_configuration.deleted_at IS NULL`, id)
 
-	statement, err := storage.connections.Prepare("UPDATE operator_configuration SET active=1, changed_at = $1, changed_by = $2, reason = $3 WHERE id = $4")
 	if err != nil {
-		return []ClusterConfiguration{}, err
+		log.Print(err)
+		return configuration, version, queryError(ctx, err)
 	}
 
-	// statement has to be closed at function exit
+	// close the query at function exit
 	defer func() {
-		// try to close the statement
-		err := statement.Close()
-		// in case of error all we can do is to just log the error
+		err := row.Close()
 		if err != nil {
 			log.Println(err)
 		}
 	}()
 
-	t := time.Now()
+	if row.Next() {
+		err = row.Scan(&configuration, &version)
+		if err != nil {
+			log.Println("error", err)
+		}
+		return configuration, version, queryError(ctx, err)
+	}
+	return configuration, version, &ItemNotFoundError{
+		ItemID: id,
+	}
+}
+
+// GetClusterActiveConfiguration reads one active configuration for the selected cluster.
+// When the active configuration is outside of its time window, the
+// configuration that was active just before it is read instead, provided it
+// is effective now.
+func (storage DBStorage) GetClusterActiveConfiguration(ctx context.Context, cluster string) (string, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	var configuration string
+
+	row, err := storage.connections.QueryContext(ctx, `
+SELECT COALESCE(configuration_profile_revision.configuration, configuration_profile.configuration)
+  FROM cluster JOIN operator_configuration active
+    ON (active.cluster = cluster.id AND active.active = '1' AND active.deleted_at IS NULL)
+  JOIN operator_configuration
+    ON (operator_configuration.id IN (active.id, active.previous_configuration))
+  JOIN configuration_profile
+    ON (configuration_profile.id = operator_configuration.configuration)
+  LEFT JOIN configuration_profile_revision
+    ON (configuration_profile_revision.profile = operator_configuration.configuration
+        AND configuration_profile_revision.revision = operator_configuration.revision)
+ WHERE cluster.name = $1
+   AND (operator_configuration.effective_from IS NULL OR operator_configuration.effective_from <= $2)
+   AND (operator_configuration.effective_until IS NULL OR operator_configuration.effective_until > $2)
+   AND operator_configuration.deleted_at IS NULL AND cluster.deleted_at IS NULL
+   AND configuration_profile.deleted_at IS NULL
+ ORDER BY operator_configuration.active DESC
+ LIMIT 1`, cluster, utcTime(time.Now()))
 
-	_, err = statement.Exec(t, username, reason, id)
 	if err != nil {
-		return []ClusterConfiguration{}, err
+		log.Print(err)
+		return configuration, queryError(ctx, err)
+	}
+
+	// query has to be closed at function exit
+	defer func() {
+		// try to close the query
+		err := row.Close()
+		// in case of error all we can do is to just log the error
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	if row.Next() {
+		err = row.Scan(&configuration)
+		if err != nil {
+			log.Println("error", err)
+		}
+		return configuration, queryError(ctx, err)
+	}
+	return configuration, &ItemNotFoundError{
+		ItemID: cluster,
 	}
-	return storage.ListClusterConfiguration(cluster)
 }
 
-// DisableClusterConfiguration disables the specified cluster configuration (reset the 'active' flag).
-// TODO: copy & paste, needs to be refactored later
-func (storage Storage) DisableClusterConfiguration(cluster, username, reason string) ([]ClusterConfiguration, error) {
-	id, err := storage.GetConfigurationIDForCluster(cluster)
-	if err != nil {
-		return []ClusterConfiguration{}, err
+// GetConfigurationIDForCluster reads ID of the newest configuration for the specified cluster name.
+func (storage DBStorage) GetConfigurationIDForCluster(ctx context.Context, cluster string) (int, error) {
+	return storage.configurationIDForCluster(ctx, cluster, false)
+}
+
+// configurationIDForCluster reads ID of the newest configuration for the
+// specified cluster name, only active configuration is read when requested.
+func (storage DBStorage) configurationIDForCluster(ctx context.Context, cluster string, activeOnly bool) (int, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+SELECT operator_configuration.id
+  FROM operator_configuration JOIN cluster
+    ON (cluster.id = operator_configuration.cluster)
+ WHERE cluster.name = $1
+   AND operator_configuration.deleted_at IS NULL AND cluster.deleted_at IS NULL`
+	if activeOnly {
+		query += `
+   AND operator_configuration.active = 1`
 	}
-	statement, err := storage.connections.Prepare("UPDATE operator_configuration SET active=0, changed_at = $1, changed_by = $2, reason = $3 WHERE id = $4")
+	query += `
+ ORDER BY operator_configuration.id DESC
+ LIMIT 1`
+
+	rows, err := storage.connections.QueryContext(ctx, query, cluster)
+
 	if err != nil {
-		return []ClusterConfiguration{}, err
+		return 0, queryError(ctx, err)
 	}
 
-	// statement has to be closed at function exit
+	// query has to be closed at function exit
 	defer func() {
-		// try to close the statement
-		err := statement.Close()
+		// try to close the query
+		err := rows.Close()
 		// in case of error all we can do is to just log the error
 		if err != nil {
 			log.Println(err)
 		}
 	}()
 
-	t := time.Now()
+	if rows.Next() {
+		var id int
 
-	_, err = statement.Exec(t, username, reason, id)
-	if err != nil {
-		return []ClusterConfiguration{}, err
+		err = rows.Scan(&id)
+		return id, queryError(ctx, err)
+	}
+	if activeOnly {
+		return 0, &ItemNotFoundError{
+			ItemID: cluster,
+		}
 	}
-	return storage.ListClusterConfiguration(cluster)
+	return 0, errors.New("Unknown operator name provided")
 }
 
-// EnableOrDisableClusterConfigurationByID enables or disables the specified cluster configuration (set or reset the 'active' flag).
-// Please see also EnableClusterConfiguration and DisableClusterConfiguration
-func (storage Storage) EnableOrDisableClusterConfigurationByID(id int64, active string) error {
-	statement, err := storage.connections.Prepare("UPDATE operator_configuration SET active = $1, changed_at = $2 WHERE id = $3")
+// InsertNewConfigurationProfile inserts new configuration profile into a database (in transaction).
+func (storage DBStorage) InsertNewConfigurationProfile(ctx context.Context, tx *sql.Tx, configuration, username, description string) error {
+	t := utcTime(time.Now())
+
+	statement, err := tx.PrepareContext(ctx, "INSERT INTO configuration_profile(configuration, changed_at, changed_by, description) VALUES ($1, $2, $3, $4)")
 	if err != nil {
-		return err
+		return queryError(ctx, err)
 	}
 
 	// statement has to be closed at function exit
@@ -1023,27 +1777,90 @@ func (storage Storage) EnableOrDisableClusterConfigurationByID(id int64, active
 		}
 	}()
 
-	t := time.Now()
+	_, err = statement.ExecContext(ctx, configuration, t, username, description)
+	return queryError(ctx, err)
+}
+
+// SelectConfigurationProfileID selects the ID of lately inserted/created configuration profile. To be used in transaction.
+func (storage DBStorage) SelectConfigurationProfileID(ctx context.Context, tx *sql.Tx) (int, error) {
+	var rows *sql.Rows
+	var err error
 
-	rowsAffected, err := execStatementAndGetRowsAffected(statement, active, t, id)
+	// We need to get the ID from the last insert. Unfortunately it seems there is not
+	// one existing solution that works for all databases.
+	switch storage.driver {
+	case "sqlite3":
+		rows, err = tx.QueryContext(ctx, `SELECT rowid FROM configuration_profile ORDER BY rowid DESC limit 1`)
+	case "postgres":
+		rows, err = tx.QueryContext(ctx, `SELECT currval('configuration_profile_id_seq')`)
+	default:
+		return -1, errors.New("unknown DB driver:" + storage.driver)
+	}
 	if err != nil {
-		return err
+		log.Print(err)
+		return -1, queryError(ctx, err)
 	}
-	if rowsAffected == 0 {
-		return &ItemNotFoundError{
-			ItemID: id,
+
+	// query has to be closed at function exit
+	defer func() {
+		// try to close the query
+		err := rows.Close()
+		// in case of error all we can do is to just log the error
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	if rows.Next() {
+		var configurationID int
+		err = rows.Scan(&configurationID)
+		if err != nil {
+			return -1, queryError(ctx, err)
 		}
+		log.Printf("Configuration stored under ID=%d\n", configurationID)
+		return configurationID, nil
 	}
-	return nil
+	return -1, errors.New("can not retrieve last configuration ID")
 }
 
-// DeleteClusterConfigurationByID deletes cluster configuration specified by its ID.
-// TODO: copy & paste, needs to be refactored later
-func (storage Storage) DeleteClusterConfigurationByID(id int64) error {
-	statement, err := storage.connections.Prepare("DELETE FROM operator_configuration WHERE id = $1")
-	if err != nil {
-		return err
+// selectLastInsertedID selects the ID of record lately inserted into the
+// specified table. To be used in transaction.
+func (storage DBStorage) selectLastInsertedID(ctx context.Context, tx *sql.Tx, table string) (int64, error) {
+	var query string
+
+	// see SelectConfigurationProfileID
+	switch storage.driver {
+	case "sqlite3":
+		query = "SELECT rowid FROM " + table + " ORDER BY rowid DESC limit 1"
+	case "postgres":
+		query = "SELECT currval('" + table + "_id_seq')"
+	default:
+		return -1, errors.New("unknown DB driver:" + storage.driver)
+	}
+
+	var id int64
+	if err := tx.QueryRowContext(ctx, query).Scan(&id); err != nil {
+		log.Print(err)
+		return -1, queryError(ctx, err)
 	}
+	return id, nil
+}
+
+// activeConfigurationID returns ID of the active configuration of the
+// cluster, it is not valid when no configuration of the cluster is active.
+// To be called inside transaction.
+func (storage DBStorage) activeConfigurationID(ctx context.Context, tx *sql.Tx, clusterID ClusterID) (sql.NullInt64, error) {
+	var id sql.NullInt64
+	err := tx.QueryRowContext(ctx, `
+SELECT MAX(id) FROM operator_configuration
+ WHERE cluster = $1 AND active = 1 AND deleted_at IS NULL`, clusterID).Scan(&id)
+	return id, queryError(ctx, err)
+}
+
+// DeactivatePreviousConfigurations deactivate all previous configurations for the specified trigger.
+// To be called inside transaction.
+func (storage DBStorage) DeactivatePreviousConfigurations(ctx context.Context, tx *sql.Tx, clusterID ClusterID) error {
+	statement, err := tx.PrepareContext(ctx, "UPDATE operator_configuration SET active=0, version = version + 1 WHERE cluster = $1")
 
 	// statement has to be closed at function exit
 	defer func() {
@@ -1055,93 +1872,1796 @@ func (storage Storage) DeleteClusterConfigurationByID(id int64) error {
 		}
 	}()
 
-	rowsAffected, err := execStatementAndGetRowsAffected(statement, id)
 	if err != nil {
-		return err
+		return queryError(ctx, err)
 	}
-	if rowsAffected == 0 {
-		return &ItemNotFoundError{
-			ItemID: id,
-		}
+	_, err = statement.ExecContext(ctx, clusterID)
+	if err == nil {
+		log.Printf("All previous configuration has been deactivated for clusterID %d\n", clusterID)
 	}
-	return nil
+	return queryError(ctx, err)
 }
 
-func (storage Storage) getTriggers(rows *sql.Rows) ([]Trigger, error) {
-	triggers := []Trigger{}
+// InsertNewOperatorConfiguration inserts the new configuration for selected operator/cluster.
+// To be called inside transaction.
+func (storage DBStorage) InsertNewOperatorConfiguration(ctx context.Context, tx *sql.Tx, clusterID ClusterID, configurationID int, username, reason string) error {
+	previous, err := storage.activeConfigurationID(ctx, tx, clusterID)
+	if err != nil {
+		return err
+	}
+	return storage.insertOperatorConfiguration(ctx, tx, clusterID, configurationID, LatestRevision, ConfigurationWindow{}, previous, username, reason)
+}
 
-	// close the query at function exit
+// insertOperatorConfiguration inserts the new configuration that uses the
+// specified revision of configuration profile for selected cluster. The
+// configuration that was active before is served outside the time window.
+// To be called inside transaction.
+func (storage DBStorage) insertOperatorConfiguration(ctx context.Context, tx *sql.Tx, clusterID ClusterID, configurationID, revision int, window ConfigurationWindow, previous sql.NullInt64, username, reason string) error {
+	t := utcTime(time.Now())
+	statement, err := tx.PrepareContext(ctx, "INSERT INTO operator_configuration(cluster, configuration, revision, effective_from, effective_until, previous_configuration, changed_at, changed_by, active, reason) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)")
+
+	// statement has to be closed at function exit
 	defer func() {
-		err := rows.Close()
+		// try to close the statement
+		err := statement.Close()
+		// in case of error all we can do is to just log the error
 		if err != nil {
 			log.Println(err)
 		}
 	}()
 
-	for rows.Next() {
-		var trigger Trigger
+	if err != nil {
+		return queryError(ctx, err)
+	}
 
-		err := rows.Scan(&trigger.ID, &trigger.Type, &trigger.Cluster,
-			&trigger.Reason, &trigger.Link,
-			&trigger.TriggeredAt, &trigger.TriggeredBy,
-			&trigger.Parameters, &trigger.Active, &trigger.AckedAt)
-		if err == nil {
-			triggers = append(triggers, trigger)
-		} else {
-			log.Println("error", err)
-		}
+	_, err = statement.ExecContext(ctx, clusterID, configurationID, revision,
+		nullableTime(window.EffectiveFrom), nullableTime(window.EffectiveUntil), previous, t, username, "1", reason)
+	if err == nil {
+		log.Printf("New operator configuration %d has been assigned to cluster %d\n", configurationID, clusterID)
 	}
+	return queryError(ctx, err)
+}
 
-	return triggers, nil
+// CreateClusterConfiguration creates new configuration for specified cluster.
+func (storage DBStorage) CreateClusterConfiguration(ctx context.Context, cluster, username, reason, description, configuration string) ([]ClusterConfiguration, error) {
+	return storage.CreateScheduledClusterConfiguration(ctx, cluster, username, reason, description, configuration, ConfigurationWindow{})
 }
 
-// GetTriggerByID selects all informations about the trigger specified by its ID.
-func (storage Storage) GetTriggerByID(id int64) (Trigger, error) {
-	rows, err := storage.connections.Query(`
-SELECT trigger.id, trigger_type.type, cluster.name,
-       trigger.reason, trigger.link, trigger.triggered_at, trigger.triggered_by,
-       trigger.parameters, trigger.active, trigger.acked_at
-  FROM trigger JOIN trigger_type ON trigger.type=trigger_type.id
-               JOIN cluster ON trigger.cluster=cluster.id
- WHERE trigger.id = $1`, id)
+// CreateScheduledClusterConfiguration creates new configuration for
+// specified cluster that is effective only within the time window. Previous
+// configuration of the cluster is served outside the window.
+func (storage DBStorage) CreateScheduledClusterConfiguration(ctx context.Context, cluster, username, reason, description, configuration string, window ConfigurationWindow) ([]ClusterConfiguration, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	if err := CheckConfigurationWindow(window); err != nil {
+		return []ClusterConfiguration{}, err
+	}
+
+	// retrieve cluster ID
+	clusterInfo, err := storage.GetClusterByName(ctx, cluster)
+
+	if err != nil {
+		log.Print(err)
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	clusterID := clusterInfo.ID
+
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
+	if err != nil {
+		log.Print(err)
+		log.Println("Transaction failed")
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// insert new configuration profile
+	err = storage.InsertNewConfigurationProfile(ctx, tx, configuration, username, description)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, err
+	}
+
+	// retrieve configuration ID for newly created configuration
+	configurationID, err := storage.SelectConfigurationProfileID(ctx, tx)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// new profile has its first revision
+	err = storage.InsertConfigurationProfileRevision(ctx, tx, configurationID)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// remember the active configuration
+	previous, err := storage.activeConfigurationID(ctx, tx, clusterID)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, err
+	}
+
+	// deactivate all previous configurations
+	err = storage.DeactivatePreviousConfigurations(ctx, tx, clusterID)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// and insert new one that will be activated
+	err = storage.insertOperatorConfiguration(ctx, tx, clusterID, configurationID, LatestRevision, window, previous, username, reason)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	return storage.ListClusterConfiguration(ctx, cluster, false)
+}
+
+// AssignClusterConfiguration assigns existing configuration profile to
+// specified cluster. The cluster either follows the latest revision of the
+// profile (LatestRevision) or it is pinned to the given revision.
+func (storage DBStorage) AssignClusterConfiguration(ctx context.Context, cluster, username, reason string, profileID, revision int) ([]ClusterConfiguration, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// retrieve cluster ID
+	clusterInfo, err := storage.GetClusterByName(ctx, cluster)
+	if err != nil {
+		log.Print(err)
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// deleted profiles can not be assigned
+	if _, err := storage.GetConfigurationProfile(ctx, profileID); err != nil {
+		return []ClusterConfiguration{}, err
+	}
+
+	if revision != LatestRevision {
+		if _, err := storage.GetConfigurationProfileRevision(ctx, profileID, revision); err != nil {
+			return []ClusterConfiguration{}, err
+		}
+	}
+
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
+	if err != nil {
+		log.Print(err)
+		log.Println("Transaction failed")
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// remember the active configuration
+	previous, err := storage.activeConfigurationID(ctx, tx, clusterInfo.ID)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, err
+	}
+
+	// deactivate all previous configurations
+	err = storage.DeactivatePreviousConfigurations(ctx, tx, clusterInfo.ID)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// and insert new one that will be activated
+	err = storage.insertOperatorConfiguration(ctx, tx, clusterInfo.ID, profileID, revision, ConfigurationWindow{}, previous, username, reason)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	return storage.ListClusterConfiguration(ctx, cluster, false)
+}
+
+// RollbackClusterConfiguration re-activates historical configuration of the
+// cluster specified by its ID, or the configuration that precedes the active
+// one when PreviousConfiguration is used. All other configurations of the
+// cluster are deactivated.
+func (storage DBStorage) RollbackClusterConfiguration(ctx context.Context, cluster, username, reason string, id int64) ([]ClusterConfiguration, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// retrieve cluster ID
+	clusterInfo, err := storage.GetClusterByName(ctx, cluster)
+	if err != nil {
+		log.Print(err)
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
+	if err != nil {
+		log.Print(err)
+		log.Println("Transaction failed")
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// find the configuration to roll back to
+	configurationID, err := storage.selectRollbackConfiguration(ctx, tx, clusterInfo.ID, id)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, err
+	}
+
+	// remember the active configuration
+	previous, err := storage.activeConfigurationID(ctx, tx, clusterInfo.ID)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, err
+	}
+
+	// deactivate all previous configurations
+	err = storage.DeactivatePreviousConfigurations(ctx, tx, clusterInfo.ID)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// re-activated configuration keeps its previous configuration
+	if previous.Int64 == configurationID {
+		previous = sql.NullInt64{}
+	}
+
+	// and activate the selected one
+	_, err = tx.ExecContext(ctx, `
+UPDATE operator_configuration
+   SET active = '1', changed_at = $1, changed_by = $2, reason = $3,
+       previous_configuration = COALESCE($4, previous_configuration)
+ WHERE id = $5`,
+		utcTime(time.Now()), username, reason, previous, configurationID)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	return storage.ListClusterConfiguration(ctx, cluster, false)
+}
+
+// selectRollbackConfiguration returns ID of configuration of the cluster
+// that the cluster is rolled back to. To be called inside transaction.
+func (storage DBStorage) selectRollbackConfiguration(ctx context.Context, tx *sql.Tx, clusterID ClusterID, id int64) (int64, error) {
+	var configurationID int64
+	var err error
+
+	if id == PreviousConfiguration {
+		err = tx.QueryRowContext(ctx, `
+SELECT id FROM operator_configuration
+ WHERE cluster = $1 AND deleted_at IS NULL
+   AND id < (SELECT MAX(id) FROM operator_configuration
+              WHERE cluster = $1 AND active = '1' AND deleted_at IS NULL)
+ ORDER BY id DESC LIMIT 1`, clusterID).Scan(&configurationID)
+	} else {
+		err = tx.QueryRowContext(ctx, `
+SELECT id FROM operator_configuration
+ WHERE id = $1 AND cluster = $2 AND deleted_at IS NULL`, id, clusterID).Scan(&configurationID)
+	}
+
+	if err == sql.ErrNoRows {
+		return 0, &ItemNotFoundError{
+			ItemID: rollbackConfigurationItemID(id),
+		}
+	}
+	return configurationID, queryError(ctx, err)
+}
+
+// rollbackConfigurationItemID returns ID of configuration that the cluster
+// is rolled back to as used in ItemNotFoundError
+func rollbackConfigurationItemID(id int64) interface{} {
+	if id == PreviousConfiguration {
+		return "previous"
+	}
+	return id
+}
+
+// EnableClusterConfiguration enables the newest configuration of the specified cluster (set the 'active' flag).
+// Cluster configuration is changed only when it is at the expected version (or AnyVersion is used).
+func (storage DBStorage) EnableClusterConfiguration(ctx context.Context, cluster string, version int, username, reason string) ([]ClusterConfiguration, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	id, err := storage.GetConfigurationIDForCluster(ctx, cluster)
+	if err != nil {
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// only one configuration of the cluster can be active
+	if err := activeConfigurationConflict(ctx, storage.connections, int64(id)); err != nil {
+		return []ClusterConfiguration{}, err
+	}
+
+	statement, err := storage.connections.PrepareContext(ctx, "UPDATE operator_configuration SET active=1, changed_at = $1, changed_by = $2, reason = $3, version = version + 1 WHERE id = $4 AND ($5 = 0 OR version = $5)")
+	if err != nil {
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// statement has to be closed at function exit
+	defer func() {
+		// try to close the statement
+		err := statement.Close()
+		// in case of error all we can do is to just log the error
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	t := utcTime(time.Now())
+
+	rowsAffected, err := execStatementAndGetRowsAffected(ctx, statement, t, username, reason, id, version)
+	if err != nil {
+		return []ClusterConfiguration{}, activationError(ctx, storage.connections, int64(id), err)
+	}
+	if rowsAffected == 0 {
+		return []ClusterConfiguration{}, versionMismatchError(ctx, storage.connections, "operator_configuration", id, version)
+	}
+	return storage.ListClusterConfiguration(ctx, cluster, false)
+}
+
+// DisableClusterConfiguration disables the active configuration of the specified cluster (reset the 'active' flag).
+// Cluster configuration is changed only when it is at the expected version (or AnyVersion is used).
+// TODO: copy & paste, needs to be refactored later
+func (storage DBStorage) DisableClusterConfiguration(ctx context.Context, cluster string, version int, username, reason string) ([]ClusterConfiguration, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	id, err := storage.configurationIDForCluster(ctx, cluster, true)
+	if err != nil {
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+	statement, err := storage.connections.PrepareContext(ctx, "UPDATE operator_configuration SET active=0, changed_at = $1, changed_by = $2, reason = $3, version = version + 1 WHERE id = $4 AND ($5 = 0 OR version = $5)")
+	if err != nil {
+		return []ClusterConfiguration{}, queryError(ctx, err)
+	}
+
+	// statement has to be closed at function exit
+	defer func() {
+		// try to close the statement
+		err := statement.Close()
+		// in case of error all we can do is to just log the error
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	t := utcTime(time.Now())
+
+	rowsAffected, err := execStatementAndGetRowsAffected(ctx, statement, t, username, reason, id, version)
+	if err != nil {
+		return []ClusterConfiguration{}, err
+	}
+	if rowsAffected == 0 {
+		return []ClusterConfiguration{}, versionMismatchError(ctx, storage.connections, "operator_configuration", id, version)
+	}
+	return storage.ListClusterConfiguration(ctx, cluster, false)
+}
+
+// EnableOrDisableClusterConfigurationByID enables or disables the specified cluster configuration (set or reset the 'active' flag).
+// Cluster configuration is changed only when it is at the expected version (or AnyVersion is used).
+// Please see also EnableClusterConfiguration and DisableClusterConfiguration
+func (storage DBStorage) EnableOrDisableClusterConfigurationByID(ctx context.Context, id int64, version int, active string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// only one configuration of the cluster can be active
+	if active == "1" {
+		if err := activeConfigurationConflict(ctx, storage.connections, id); err != nil {
+			return err
+		}
+	}
+
+	statement, err := storage.connections.PrepareContext(ctx, `
+UPDATE operator_configuration
+   SET active = $1, changed_at = $2, version = version + 1
+ WHERE id = $3 AND ($4 = 0 OR version = $4) AND deleted_at IS NULL`)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+
+	// statement has to be closed at function exit
+	defer func() {
+		// try to close the statement
+		err := statement.Close()
+		// in case of error all we can do is to just log the error
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	t := utcTime(time.Now())
+
+	rowsAffected, err := execStatementAndGetRowsAffected(ctx, statement, active, t, id, version)
+	if err != nil {
+		return activationError(ctx, storage.connections, id, err)
+	}
+	if rowsAffected == 0 {
+		return versionMismatchError(ctx, storage.connections, "operator_configuration", id, version)
+	}
+	return nil
+}
+
+// DeleteClusterConfigurationByID marks cluster configuration specified by its ID as deleted.
+// Cluster configuration is deleted only when it is at the expected version (or AnyVersion is used).
+func (storage DBStorage) DeleteClusterConfigurationByID(ctx context.Context, id int64, version int, username string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx, `
+UPDATE operator_configuration
+   SET deleted_at = $1, deleted_by = $2, version = version + 1
+ WHERE id = $3 AND ($4 = 0 OR version = $4) AND deleted_at IS NULL`,
+		utcTime(time.Now()), username, id, version)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return versionMismatchError(ctx, storage.connections, "operator_configuration", id, version)
+	}
+	return nil
+}
+
+// RestoreClusterConfiguration restores cluster configuration specified by its
+// ID that has been deleted (and not purged yet).
+func (storage DBStorage) RestoreClusterConfiguration(ctx context.Context, id int64) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// active configuration can be restored only when no other configuration
+	// of the cluster is active
+	var active sql.NullInt64
+	err := storage.connections.QueryRowContext(ctx, "SELECT active FROM operator_configuration WHERE id = $1", id).Scan(&active)
+	if err != nil && err != sql.ErrNoRows {
+		return queryError(ctx, err)
+	}
+	if active.Int64 == 1 {
+		if err := activeConfigurationConflict(ctx, storage.connections, id); err != nil {
+			return err
+		}
+	}
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx, `
+UPDATE operator_configuration
+   SET deleted_at = NULL, deleted_by = NULL, version = version + 1
+ WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return activationError(ctx, storage.connections, id, err)
+	}
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	return nil
+}
+
+// RepairActiveConfigurations deactivates all but the newest active
+// configuration of each cluster, so at most one configuration of each
+// cluster stays active. Number of deactivated configurations is returned.
+func (storage DBStorage) RepairActiveConfigurations(ctx context.Context) (int64, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx, `
+UPDATE operator_configuration
+   SET active = 0, version = version + 1
+ WHERE active = 1 AND deleted_at IS NULL
+   AND id < (SELECT MAX(other.id) FROM operator_configuration AS other
+              WHERE other.cluster = operator_configuration.cluster
+                AND other.active = 1 AND other.deleted_at IS NULL)`)
+	if err != nil {
+		log.Print(err)
+		return 0, queryError(ctx, err)
+	}
+	return rowsAffected, nil
+}
+
+// SetClusterConfigurationWindow sets time window when cluster configuration
+// specified by its ID is effective. Zero time at any end of the window
+// removes the restriction.
+func (storage DBStorage) SetClusterConfigurationWindow(ctx context.Context, id int64, window ConfigurationWindow) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	if err := CheckConfigurationWindow(window); err != nil {
+		return err
+	}
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx, `
+UPDATE operator_configuration
+   SET effective_from = $1, effective_until = $2, version = version + 1
+ WHERE id = $3 AND deleted_at IS NULL`,
+		nullableTime(window.EffectiveFrom), nullableTime(window.EffectiveUntil), id)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+	return nil
+}
+
+// RecordConfigurationTransitions records activation of active cluster
+// configurations whose time window has started and expiry of active
+// configurations whose time window has ended. Each event is recorded only
+// once for each configuration. Number of recorded events is returned.
+func (storage DBStorage) RecordConfigurationTransitions(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	now = utcTime(now)
+
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
+	if err != nil {
+		log.Print(err)
+		return 0, queryError(ctx, err)
+	}
+
+	activated, err := tx.ExecContext(ctx, `
+INSERT INTO configuration_transition(configuration, event, changed_at)
+SELECT id, $1, $2
+  FROM operator_configuration
+ WHERE active = 1 AND deleted_at IS NULL
+   AND effective_from IS NOT NULL AND effective_from <= $2
+   AND (effective_until IS NULL OR effective_until > $2)
+   AND NOT EXISTS (SELECT 1 FROM configuration_transition
+                    WHERE configuration = operator_configuration.id AND event = $1)`,
+		ConfigurationActivated, now)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return 0, queryError(ctx, err)
+	}
+
+	expired, err := tx.ExecContext(ctx, `
+INSERT INTO configuration_transition(configuration, event, changed_at)
+SELECT id, $1, $2
+  FROM operator_configuration
+ WHERE active = 1 AND deleted_at IS NULL
+   AND effective_until IS NOT NULL AND effective_until <= $2
+   AND NOT EXISTS (SELECT 1 FROM configuration_transition
+                    WHERE configuration = operator_configuration.id AND event = $1)`,
+		ConfigurationExpired, now)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return 0, queryError(ctx, err)
+	}
+
+	var recorded int64
+	for _, result := range []sql.Result{activated, expired} {
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, queryError(ctx, err)
+		}
+		recorded += rowsAffected
+	}
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return 0, queryError(ctx, err)
+	}
+	return recorded, nil
+}
+
+// ListConfigurationTransitions selects all events recorded for cluster
+// configuration specified by its ID. Events of deleted configurations are
+// kept.
+func (storage DBStorage) ListConfigurationTransitions(ctx context.Context, id int64) ([]ConfigurationTransition, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	transitions := []ConfigurationTransition{}
+
+	// check that configuration exist
+	var exists int
+	err := storage.connections.QueryRowContext(ctx,
+		"SELECT count(*) FROM operator_configuration WHERE id = $1", id).Scan(&exists)
+	if err != nil {
+		return transitions, queryError(ctx, err)
+	}
+	if exists == 0 {
+		return transitions, &ItemNotFoundError{
+			ItemID: id,
+		}
+	}
+
+	rows, err := storage.connections.QueryContext(ctx, `
+SELECT event, changed_at
+  FROM configuration_transition
+ WHERE configuration = $1
+ ORDER BY id`, id)
+	if err != nil {
+		return transitions, queryError(ctx, err)
+	}
+
+	// close the query at function exit
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		var transition ConfigurationTransition
+		if err := rows.Scan(&transition.Event, &transition.ChangedAt); err != nil {
+			return transitions, queryError(ctx, err)
+		}
+		transitions = append(transitions, transition)
+	}
+
+	return transitions, queryError(ctx, rows.Err())
+}
+
+// GetConfigurationSchema selects JSON schema that configurations are
+// validated against. Built-in schema is returned when no schema is set.
+func (storage DBStorage) GetConfigurationSchema(ctx context.Context) (ConfigurationSchema, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	var result ConfigurationSchema
+	var changedAt, changedBy sql.NullString
+
+	err := storage.connections.QueryRowContext(ctx,
+		"SELECT json_schema, changed_at, changed_by FROM configuration_schema WHERE id = $1",
+		configurationSchemaID).Scan(&result.Schema, &changedAt, &changedBy)
+	if err == sql.ErrNoRows {
+		return defaultConfigurationSchema(), nil
+	}
+	result.ChangedAt = changedAt.String
+	result.ChangedBy = changedBy.String
+	return result, queryError(ctx, err)
+}
+
+// SetConfigurationSchema sets JSON schema that configurations are validated
+// against. Configurations stored before are not validated again.
+func (storage DBStorage) SetConfigurationSchema(ctx context.Context, schema, username string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	if err := CheckConfigurationSchema(schema); err != nil {
+		return err
+	}
+
+	_, err := storage.connections.ExecContext(ctx, `
+INSERT INTO configuration_schema(id, json_schema, changed_at, changed_by) VALUES ($1, $2, $3, $4)
+    ON CONFLICT (id) DO UPDATE SET json_schema = excluded.json_schema,
+                                   changed_at = excluded.changed_at,
+                                   changed_by = excluded.changed_by`,
+		configurationSchemaID, schema, utcTime(time.Now()), username)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	return nil
+}
+
+// GetDefaultConfiguration selects global default configuration profile that
+// is applied to all clusters. ItemNotFoundError is returned when no default
+// profile is set.
+func (storage DBStorage) GetDefaultConfiguration(ctx context.Context) (DefaultConfiguration, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	var result DefaultConfiguration
+	var changedAt, changedBy sql.NullString
+
+	err := storage.connections.QueryRowContext(ctx,
+		"SELECT configuration, changed_at, changed_by FROM configuration_default WHERE id = $1",
+		defaultConfigurationID).Scan(&result.Profile, &changedAt, &changedBy)
+	if err == sql.ErrNoRows {
+		return DefaultConfiguration{}, &ItemNotFoundError{ItemID: DefaultConfigurationLayer}
+	}
+	result.ChangedAt = changedAt.String
+	result.ChangedBy = changedBy.String
+	return result, queryError(ctx, err)
+}
+
+// SetDefaultConfiguration sets global default configuration profile that is
+// applied to all clusters. Default profile set before is replaced.
+func (storage DBStorage) SetDefaultConfiguration(ctx context.Context, profileID int, username string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// deleted profiles can not be used
+	if _, err := storage.GetConfigurationProfile(ctx, profileID); err != nil {
+		return err
+	}
+
+	_, err := storage.connections.ExecContext(ctx, `
+INSERT INTO configuration_default(id, configuration, changed_at, changed_by) VALUES ($1, $2, $3, $4)
+    ON CONFLICT (id) DO UPDATE SET configuration = excluded.configuration,
+                                   changed_at = excluded.changed_at,
+                                   changed_by = excluded.changed_by`,
+		defaultConfigurationID, profileID, utcTime(time.Now()), username)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	return nil
+}
+
+// DeleteDefaultConfiguration unsets global default configuration profile.
+// ItemNotFoundError is returned when no default profile is set.
+func (storage DBStorage) DeleteDefaultConfiguration(ctx context.Context) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx,
+		"DELETE FROM configuration_default WHERE id = $1", defaultConfigurationID)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{ItemID: DefaultConfigurationLayer}
+	}
+	return nil
+}
+
+// ListConfigurationGroups selects all configuration groups in the order they
+// are applied, the group with the highest priority is the last one.
+func (storage DBStorage) ListConfigurationGroups(ctx context.Context) ([]ConfigurationGroup, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	groups := []ConfigurationGroup{}
+
+	rows, err := storage.connections.QueryContext(ctx,
+		"SELECT "+configurationGroupColumns+" FROM configuration_group ORDER BY priority, id")
+	if err != nil {
+		return groups, queryError(ctx, err)
+	}
+
+	// close the query at function exit
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		group, err := scanConfigurationGroup(rows.Scan)
+		if err != nil {
+			log.Println("error", err)
+			return groups, queryError(ctx, err)
+		}
+		groups = append(groups, group)
+	}
+	return groups, queryError(ctx, rows.Err())
+}
+
+// NewConfigurationGroup creates new configuration group that applies the
+// configuration profile to all clusters selected by label selector.
+func (storage DBStorage) NewConfigurationGroup(ctx context.Context, name, selector string, priority, profileID int, username string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	selector, err := CheckConfigurationGroupSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	// deleted profiles can not be used
+	if _, err := storage.GetConfigurationProfile(ctx, profileID); err != nil {
+		return err
+	}
+
+	var exists int
+	err = storage.connections.QueryRowContext(ctx,
+		"SELECT count(*) FROM configuration_group WHERE name = $1", name).Scan(&exists)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+	if exists > 0 {
+		return &ItemAlreadyExistsError{ItemID: name}
+	}
+
+	_, err = storage.connections.ExecContext(ctx, `
+INSERT INTO configuration_group(name, selector, priority, configuration, changed_at, changed_by)
+     VALUES ($1, $2, $3, $4, $5, $6)`,
+		name, selector, priority, profileID, utcTime(time.Now()), username)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	return nil
+}
+
+// DeleteConfigurationGroup deletes configuration group specified by its name.
+func (storage DBStorage) DeleteConfigurationGroup(ctx context.Context, name string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx,
+		"DELETE FROM configuration_group WHERE name = $1", name)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{ItemID: name}
+	}
+	return nil
+}
+
+// ListConfigurationRollouts selects all configuration rollouts ordered by
+// their IDs.
+func (storage DBStorage) ListConfigurationRollouts(ctx context.Context) ([]ConfigurationRollout, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rollouts := []ConfigurationRollout{}
+
+	rows, err := storage.connections.QueryContext(ctx,
+		"SELECT "+configurationRolloutColumns+" FROM configuration_rollout ORDER BY id")
+	if err != nil {
+		return rollouts, queryError(ctx, err)
+	}
+
+	// close the query at function exit
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		rollout, err := scanConfigurationRollout(rows.Scan)
+		if err != nil {
+			log.Println("error", err)
+			return rollouts, queryError(ctx, err)
+		}
+		rollouts = append(rollouts, rollout)
+	}
+	return rollouts, queryError(ctx, rows.Err())
+}
+
+// GetConfigurationRollout selects configuration rollout specified by its ID.
+func (storage DBStorage) GetConfigurationRollout(ctx context.Context, id int) (ConfigurationRollout, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	row := storage.connections.QueryRowContext(ctx,
+		"SELECT "+configurationRolloutColumns+" FROM configuration_rollout WHERE id = $1", id)
+
+	rollout, err := scanConfigurationRollout(row.Scan)
+	if err == sql.ErrNoRows {
+		return ConfigurationRollout{}, &ItemNotFoundError{ItemID: id}
+	}
+	return rollout, queryError(ctx, err)
+}
+
+// NewConfigurationRollout creates new running rollout of the latest revision
+// of configuration profile to clusters selected by label selector. No
+// cluster is touched until the first wave is applied.
+func (storage DBStorage) NewConfigurationRollout(ctx context.Context, profileID int, selector string, waves []int, username, reason string) (ConfigurationRollout, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	parsed, err := ParseLabelSelector(selector)
+	if err != nil {
+		return ConfigurationRollout{}, err
+	}
+	if err := CheckRolloutWaves(waves); err != nil {
+		return ConfigurationRollout{}, err
+	}
+
+	// deleted profiles can not be rolled out
+	if _, err := storage.GetConfigurationProfile(ctx, profileID); err != nil {
+		return ConfigurationRollout{}, err
+	}
+
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
+	if err != nil {
+		log.Print(err)
+		return ConfigurationRollout{}, queryError(ctx, err)
+	}
+
+	// the rollout is pinned to the latest revision of the profile
+	var revision int
+	err = tx.QueryRowContext(ctx,
+		"SELECT MAX(revision) FROM configuration_profile_revision WHERE profile = $1", profileID).Scan(&revision)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return ConfigurationRollout{}, queryError(ctx, err)
+	}
+
+	now := utcTime(time.Now())
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO configuration_rollout(configuration, revision, selector, waves, current_wave, state,
+                                  created_at, created_by, changed_at, changed_by, reason)
+     VALUES ($1, $2, $3, $4, 0, $5, $6, $7, $8, $9, $10)`,
+		profileID, revision, parsed.String(), formatRolloutWaves(waves), RolloutRunning,
+		now, username, now, username, reason)
+	if err != nil {
+		log.Print(err)
+		_ = tx.Rollback()
+		return ConfigurationRollout{}, queryError(ctx, err)
+	}
+
+	id, err := storage.selectLastInsertedID(ctx, tx, "configuration_rollout")
+	if err != nil {
+		_ = tx.Rollback()
+		return ConfigurationRollout{}, err
+	}
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return ConfigurationRollout{}, queryError(ctx, err)
+	}
+
+	return storage.GetConfigurationRollout(ctx, int(id))
+}
+
+// UpdateConfigurationRollout changes state and the current wave of
+// configuration rollout specified by its ID. Transitions between states are
+// checked by ChangeConfigurationRolloutState.
+func (storage DBStorage) UpdateConfigurationRollout(ctx context.Context, id int, state RolloutState, currentWave int, username string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx, `
+UPDATE configuration_rollout
+   SET state = $1, current_wave = $2, changed_at = $3, changed_by = $4
+ WHERE id = $5`,
+		state, currentWave, utcTime(time.Now()), username, id)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{ItemID: id}
+	}
+	return nil
+}
+
+// AddConfigurationRolloutCluster records cluster touched by the given wave
+// of configuration rollout together with configuration created for it.
+func (storage DBStorage) AddConfigurationRolloutCluster(ctx context.Context, id int, clusterName string, wave int, configurationID ClusterConfigurationID) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	cluster, err := storage.GetClusterByName(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := storage.GetConfigurationRollout(ctx, id); err != nil {
+		return err
+	}
+
+	_, err = storage.connections.ExecContext(ctx, `
+INSERT INTO configuration_rollout_cluster(rollout, cluster, wave, configuration, applied_at)
+     VALUES ($1, $2, $3, $4, $5)`,
+		id, cluster.ID, wave, configurationID, utcTime(time.Now()))
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	return nil
+}
+
+// ListConfigurationRolloutClusters selects all clusters (except deleted ones)
+// touched by configuration rollout specified by its ID. Status of each
+// cluster is based on its last heartbeat and on acknowledge of its
+// configuration.
+func (storage DBStorage) ListConfigurationRolloutClusters(ctx context.Context, id int) ([]ConfigurationRolloutCluster, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	clusters := []ConfigurationRolloutCluster{}
+
+	if _, err := storage.GetConfigurationRollout(ctx, id); err != nil {
+		return clusters, err
+	}
+
+	rows, err := storage.connections.QueryContext(ctx, `
+SELECT `+configurationRolloutClusterColumns+`
+  FROM configuration_rollout_cluster
+  JOIN cluster ON cluster.id = configuration_rollout_cluster.cluster
+  JOIN operator_configuration ON operator_configuration.id = configuration_rollout_cluster.configuration
+  LEFT JOIN cluster_heartbeat ON cluster_heartbeat.cluster = cluster.id
+ WHERE configuration_rollout_cluster.rollout = $1 AND cluster.deleted_at IS NULL
+ ORDER BY configuration_rollout_cluster.wave, cluster.id`, id)
+	if err != nil {
+		return clusters, queryError(ctx, err)
+	}
+
+	// close the query at function exit
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		cluster, err := scanConfigurationRolloutCluster(rows.Scan)
+		if err != nil {
+			log.Println("error", err)
+			return clusters, queryError(ctx, err)
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, queryError(ctx, rows.Err())
+}
+
+// AckClusterConfiguration records that the operator running on cluster
+// specified by its name has read its active configuration. Only the first
+// read of each configuration is recorded.
+func (storage DBStorage) AckClusterConfiguration(ctx context.Context, clusterName string, ackedAt time.Time) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	cluster, err := storage.GetClusterByName(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+
+	_, err = storage.connections.ExecContext(ctx, `
+UPDATE operator_configuration SET acked_at = $1
+ WHERE cluster = $2 AND active = 1 AND deleted_at IS NULL AND acked_at IS NULL`,
+		utcTime(ackedAt), cluster.ID)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	return nil
+}
+
+func (storage DBStorage) getTriggers(ctx context.Context, rows *sql.Rows) ([]Trigger, error) {
+	triggers := []Trigger{}
+
+	// close the query at function exit
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		var trigger Trigger
+		var expiresAt, caseNumber sql.NullString
+
+		err := rows.Scan(&trigger.ID, &trigger.Type, &trigger.Cluster,
+			&trigger.Reason, &trigger.Link,
+			&trigger.TriggeredAt, &trigger.TriggeredBy,
+			&trigger.Parameters, &trigger.Active,
+			&expiresAt, &trigger.Expired, &trigger.State,
+			triggerResultColumn{&trigger.Result}, &caseNumber)
+		trigger.ExpiresAt = expiresAt.String
+		trigger.CaseNumber = caseNumber.String
+		if err == nil {
+			triggers = append(triggers, trigger)
+		} else {
+			log.Println("error", err)
+		}
+	}
+
+	return triggers, queryError(ctx, rows.Err())
+}
+
+// GetTriggerByID selects all informations about the trigger specified by its ID.
+func (storage DBStorage) GetTriggerByID(ctx context.Context, id int64) (Trigger, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := storage.connections.QueryContext(ctx, `
+SELECT `+triggerColumns+`
+  FROM trigger JOIN trigger_type ON trigger.type=trigger_type.id
+               JOIN cluster ON trigger.cluster=cluster.id
+               LEFT JOIN support_case ON trigger.support_case=support_case.id
+ WHERE trigger.id = $1`, id)
+
+	if err != nil {
+		return Trigger{}, queryError(ctx, err)
+	}
+
+	triggers, err := storage.getTriggers(ctx, rows)
+	if err != nil {
+		return Trigger{}, queryError(ctx, err)
+	}
+
+	if len(triggers) >= 1 {
+		return triggers[0], nil
+	}
+	return Trigger{}, ErrNoSuchObj
+}
+
+func execStatementAndGetRowsAffected(ctx context.Context, statement *sql.Stmt, args ...interface{}) (int64, error) {
+	res, err := statement.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, queryError(ctx, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, queryError(ctx, err)
+	}
+
+	return rowsAffected, nil
+}
+
+// execAndGetRowsAffected prepares and executes the statement and returns
+// number of rows affected by it
+func (storage DBStorage) execAndGetRowsAffected(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	statement, err := storage.connections.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	// statement has to be closed at function exit
+	defer func() {
+		// try to close the statement
+		err := statement.Close()
+		// in case of error all we can do is to just log the error
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	return execStatementAndGetRowsAffected(ctx, statement, args...)
+}
+
+// DeleteTriggerByID deletes trigger specified by its ID
+// returns ItemNotFoundError if trigger didn't exist
+func (storage DBStorage) DeleteTriggerByID(ctx context.Context, id int64) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := storage.connections.PrepareContext(ctx, `
+DELETE FROM trigger WHERE trigger.id = $1`)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+
+	// statement has to be closed at function exit
+	defer func() {
+		// try to close the statement
+		err := statement.Close()
+		// in case of error all we can do is to just log the error
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	rowsAffected, err := execStatementAndGetRowsAffected(ctx, statement, id)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+
+	// non-existent trigger ID has been used
+	if rowsAffected == 0 {
+		// convert ID (numeric value) to string for proper logging
+		IDstr := strconv.Itoa(int(id))
+		return &ItemNotFoundError{
+			ItemID: IDstr,
+		}
+	}
+
+	return nil
+}
+
+// ChangeStateOfTriggerByID change the state ('active', 'inactive') of trigger specified by its ID.
+// Activated triggers become pending, deactivated triggers are cancelled.
+// returns ItemNotFoundError if there weren't rows with such id
+func (storage DBStorage) ChangeStateOfTriggerByID(ctx context.Context, id int64, active int) error {
+	state := TriggerCancelled
+	if active == 1 {
+		state = TriggerPending
+	}
+	return storage.ChangeTriggerState(ctx, id, state, "")
+}
+
+// ChangeTriggerState moves trigger specified by its ID into the given state
+// and records the transition. InvalidTriggerTransitionError is returned when
+// the trigger can not move into the state from its current state, changing
+// the trigger into its current state does nothing.
+func (storage DBStorage) ChangeTriggerState(ctx context.Context, id int64, state TriggerState, username string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+
+	_, err = storage.changeTriggerState(ctx, tx, id, state, username, utcTime(time.Now()))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	return nil
+}
+
+// ChangeClusterTriggerState moves trigger assigned to the specified cluster
+// into the given state. It is used by operators to report progress of
+// triggers, so the transition is recorded with name of the cluster.
+func (storage DBStorage) ChangeClusterTriggerState(ctx context.Context, clusterName string, triggerID int64, state TriggerState) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	return storage.changeClusterTrigger(ctx, clusterName, triggerID, state, nil)
+}
+
+// SetTriggerResult stores result reported by the operator with trigger
+// assigned to the specified cluster. The trigger is moved into the state
+// given by status of the result, result reported again replaces the previous
+// one.
+func (storage DBStorage) SetTriggerResult(ctx context.Context, clusterName string, triggerID int64, result TriggerResult) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	if err := checkTriggerResult(result); err != nil {
+		return err
+	}
+	return storage.changeClusterTrigger(ctx, clusterName, triggerID, result.Status, &result)
+}
+
+// changeClusterTrigger moves trigger assigned to the specified cluster into
+// the given state and stores the result when it is not nil
+func (storage DBStorage) changeClusterTrigger(ctx context.Context, clusterName string, triggerID int64, state TriggerState, result *TriggerResult) error {
+	// retrieve cluster ID
+	clusterInfo, err := storage.GetClusterByName(ctx, clusterName)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+
+	// trigger needs to be assigned to the cluster
+	var found int
+	err = tx.QueryRowContext(ctx, "SELECT count(*) FROM trigger WHERE cluster = $1 AND id = $2", clusterInfo.ID, triggerID).Scan(&found)
+	if err == nil && found == 0 {
+		err = &ItemNotFoundError{
+			ItemID: fmt.Sprintf("%v/%v", clusterName, triggerID),
+		}
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return queryError(ctx, err)
+	}
+
+	t := utcTime(time.Now())
+	_, err = storage.changeTriggerState(ctx, tx, triggerID, state, clusterName, t)
+	if err == nil && result != nil {
+		var document []byte
+		result.ReportedAt = t.Format(time.RFC3339)
+		if document, err = json.Marshal(result); err == nil {
+			_, err = tx.ExecContext(ctx, "UPDATE trigger SET result = $1 WHERE id = $2", string(document), triggerID)
+		}
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return queryError(ctx, err)
+	}
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	return nil
+}
+
+// changeTriggerState moves trigger into the given state and records the
+// transition. It returns false when the trigger is in the state already. To
+// be called inside transaction.
+func (storage DBStorage) changeTriggerState(ctx context.Context, tx *sql.Tx, id int64, state TriggerState, username string, t time.Time) (bool, error) {
+	var current TriggerState
+	err := tx.QueryRowContext(ctx, "SELECT state FROM trigger WHERE id = $1", id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return false, &ItemNotFoundError{
+			ItemID: strconv.Itoa(int(id)),
+		}
+	}
+	if err != nil {
+		log.Print(err)
+		return false, queryError(ctx, err)
+	}
+
+	if current == state {
+		return false, nil
+	}
+	if !CanChangeTriggerState(current, state) {
+		return false, &InvalidTriggerTransitionError{TriggerID: id, From: current, To: state}
+	}
+
+	// the state is checked again, because it might be changed in the meantime
+	result, err := tx.ExecContext(ctx, `
+UPDATE trigger SET state = $1 WHERE id = $2 AND state = $3`,
+		state, id, current)
+	if err != nil {
+		log.Print(err)
+		return false, queryError(ctx, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, queryError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return false, &InvalidTriggerTransitionError{TriggerID: id, From: current, To: state}
+	}
+
+	err = insertTriggerTransition(ctx, tx, id, state, username, t)
+	return err == nil, err
+}
+
+// insertTriggerTransition records change of trigger state. To be called
+// inside transaction.
+func insertTriggerTransition(ctx context.Context, tx *sql.Tx, id int64, state TriggerState, username string, t time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+INSERT INTO trigger_transition(trigger, state, changed_at, changed_by) VALUES ($1, $2, $3, $4)`,
+		id, state, t, username)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	return nil
+}
+
+// ListTriggerTransitions selects all changes of state of trigger specified by
+// its ID, the oldest change is the first one.
+func (storage DBStorage) ListTriggerTransitions(ctx context.Context, id int64) ([]TriggerTransition, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	transitions := []TriggerTransition{}
+
+	// check that trigger exist
+	if _, err := storage.GetTriggerByID(ctx, id); err == ErrNoSuchObj {
+		return transitions, &ItemNotFoundError{
+			ItemID: strconv.Itoa(int(id)),
+		}
+	} else if err != nil {
+		return transitions, err
+	}
+
+	rows, err := storage.connections.QueryContext(ctx, `
+SELECT state, changed_at, changed_by
+  FROM trigger_transition
+ WHERE trigger = $1
+ ORDER BY id`, id)
+	if err != nil {
+		return transitions, queryError(ctx, err)
+	}
+
+	// close the query at function exit
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		var transition TriggerTransition
+		err := rows.Scan(&transition.State,
+			nullableString{&transition.ChangedAt},
+			nullableString{&transition.ChangedBy})
+		if err != nil {
+			return transitions, queryError(ctx, err)
+		}
+		transitions = append(transitions, transition)
+	}
+
+	return transitions, queryError(ctx, rows.Err())
+}
+
+// triggerColumns contains columns selected for triggers. Active and expired
+// flags are kept for clients that do not know trigger states yet, they are
+// derived from the state.
+const triggerColumns = `trigger.id, trigger_type.type, cluster.name,
+       trigger.reason, trigger.link, trigger.triggered_at, trigger.triggered_by,
+       trigger.parameters, ` + triggerActiveColumn + ` AS active,
+       trigger.expires_at, ` + triggerExpiredColumn + ` AS expired,
+       trigger.state, trigger.result, support_case.case_number`
+
+// triggerListQuery selects triggers together with their types, names of
+// their clusters, and numbers of linked support cases
+const triggerListQuery = `
+SELECT ` + triggerColumns + `
+  FROM trigger JOIN trigger_type ON trigger.type=trigger_type.id
+               JOIN cluster ON trigger.cluster=cluster.id
+               LEFT JOIN support_case ON trigger.support_case=support_case.id`
+
+// ListAllTriggers selects all triggers from the database.
+func (storage DBStorage) ListAllTriggers(ctx context.Context) ([]Trigger, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	triggers := []Trigger{}
+
+	rows, err := storage.connections.QueryContext(ctx, triggerListQuery+`
+ ORDER BY trigger.id`)
+
+	if err != nil {
+		return triggers, queryError(ctx, err)
+	}
+
+	return storage.getTriggers(ctx, rows)
+}
+
+// ListAllTriggersPage selects one page of triggers that are in one of the
+// specified states together with total number of such triggers. Triggers in
+// all states are selected when no state is specified.
+func (storage DBStorage) ListAllTriggersPage(ctx context.Context, states []TriggerState, page Page) ([]Trigger, int, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	return storage.listTriggersPage(ctx, nil, nil, states, page)
+}
+
+// listTriggersPage selects one page of triggers that fulfil the conditions
+// and that are in one of the specified states, together with total number of
+// such triggers
+func (storage DBStorage) listTriggersPage(ctx context.Context, conditions []string, args []interface{}, states []TriggerState, page Page) ([]Trigger, int, error) {
+	if len(states) > 0 {
+		placeholders := make([]string, len(states))
+		for i, state := range states {
+			args = append(args, state)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, "trigger.state IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	query := triggerListQuery
+	if len(conditions) > 0 {
+		query += "\n WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, total, err := storage.queryPage(ctx, query, args, triggerPageColumns, "id", page)
+	if err != nil {
+		return []Trigger{}, 0, err
+	}
+	triggers, err := storage.getTriggers(ctx, rows)
+	return triggers, total, err
+}
+
+// ListClusterTriggers selects all triggers assigned to the specified cluster.
+func (storage DBStorage) ListClusterTriggers(ctx context.Context, clusterName string) ([]Trigger, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	triggers := []Trigger{}
+
+	// check that cluster exist
+	if _, err := storage.GetClusterByName(ctx, clusterName); err != nil {
+		return triggers, queryError(ctx, err)
+	}
+
+	rows, err := storage.connections.QueryContext(ctx, triggerListQuery+`
+ WHERE cluster.name = $1
+ ORDER BY trigger.id`, clusterName)
+
+	if err != nil {
+		return triggers, queryError(ctx, err)
+	}
+
+	return storage.getTriggers(ctx, rows)
+}
+
+// ListClusterTriggersPage selects one page of triggers of the specified
+// cluster that are in one of the specified states together with total number
+// of such triggers. Triggers in all states are selected when no state is
+// specified.
+func (storage DBStorage) ListClusterTriggersPage(ctx context.Context, clusterName string, states []TriggerState, page Page) ([]Trigger, int, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// check that cluster exist
+	if _, err := storage.GetClusterByName(ctx, clusterName); err != nil {
+		return []Trigger{}, 0, queryError(ctx, err)
+	}
+
+	return storage.listTriggersPage(ctx, []string{"cluster.name = $1"}, []interface{}{clusterName}, states, page)
+}
+
+// ListActiveClusterTriggers selects all active triggers assigned to the
+// specified cluster. Triggers that already expired are not selected even when
+// they have not been marked as expired yet.
+func (storage DBStorage) ListActiveClusterTriggers(ctx context.Context, clusterName string) ([]Trigger, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	triggers := []Trigger{}
+
+	// check that cluster exist
+	if _, err := storage.GetClusterByName(ctx, clusterName); err != nil {
+		return triggers, queryError(ctx, err)
+	}
+
+	rows, err := storage.connections.QueryContext(ctx, `
+SELECT `+triggerColumns+`
+  FROM trigger JOIN trigger_type ON trigger.type=trigger_type.id
+               JOIN cluster ON trigger.cluster=cluster.id
+               LEFT JOIN support_case ON trigger.support_case=support_case.id
+ WHERE trigger.state = 'pending'
+   AND cluster.name = $1
+   AND (trigger.expires_at IS NULL OR trigger.expires_at > $2)`, clusterName, utcTime(time.Now()))
+
+	if err != nil {
+		return triggers, queryError(ctx, err)
+	}
+
+	return storage.getTriggers(ctx, rows)
+}
+
+// GetTriggerID select ID for specified trigger type (name).
+func (storage DBStorage) GetTriggerID(ctx context.Context, triggerType string) (int, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	var id int
+
+	rows, err := storage.connections.QueryContext(ctx, "SELECT id FROM trigger_type WHERE type = $1", triggerType)
+	if err != nil {
+		return 0, queryError(ctx, err)
+	}
+
+	// rows has to be closed at function exit
+	defer func() {
+		// try to close the statement
+		err := rows.Close()
+		// in case of error all we can do is to just log the error
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	if rows.Next() {
+
+		err = rows.Scan(&id)
+		if err == nil {
+			log.Printf("Trigger type %s has id %d\n", triggerType, id)
+		} else {
+			log.Println("error", err)
+		}
+	} else {
+		return 0, &ItemNotFoundError{ItemID: triggerType}
+	}
+	return id, queryError(ctx, err)
+}
+
+// triggerTypeColumns are columns of trigger_type table read by scanTriggerType
+const triggerTypeColumns = "id, type, description, parameters_schema, deprecated_at, deprecated_by, default_ttl, requires_case"
+
+// scanTriggerType reads one trigger type using the provided scan function
+// of selected row
+func scanTriggerType(scan func(dest ...interface{}) error) (TriggerType, error) {
+	var result TriggerType
+	var description, parametersSchema, deprecatedAt, deprecatedBy sql.NullString
+	var defaultTTL sql.NullInt64
+
+	err := scan(&result.ID, &result.Type, &description, &parametersSchema, &deprecatedAt, &deprecatedBy, &defaultTTL, &result.RequiresCase)
+	result.DefaultTTL = int(defaultTTL.Int64)
+	result.Description = description.String
+	result.ParametersSchema = parametersSchema.String
+	result.DeprecatedAt = deprecatedAt.String
+	result.DeprecatedBy = deprecatedBy.String
+	return result, err
+}
+
+// ListTriggerTypes selects all trigger types including deprecated ones.
+func (storage DBStorage) ListTriggerTypes(ctx context.Context) ([]TriggerType, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	triggerTypes := []TriggerType{}
+
+	rows, err := storage.connections.QueryContext(ctx,
+		"SELECT "+triggerTypeColumns+" FROM trigger_type ORDER BY id")
+	if err != nil {
+		return triggerTypes, queryError(ctx, err)
+	}
+
+	// rows has to be closed at function exit
+	defer func() {
+		// try to close the statement
+		err := rows.Close()
+		// in case of error all we can do is to just log the error
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		triggerType, err := scanTriggerType(rows.Scan)
+		if err != nil {
+			log.Println("error", err)
+			return triggerTypes, queryError(ctx, err)
+		}
+		triggerTypes = append(triggerTypes, triggerType)
+	}
+	return triggerTypes, queryError(ctx, rows.Err())
+}
+
+// GetTriggerType selects trigger type specified by its name.
+func (storage DBStorage) GetTriggerType(ctx context.Context, triggerType string) (TriggerType, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	row := storage.connections.QueryRowContext(ctx,
+		"SELECT "+triggerTypeColumns+" FROM trigger_type WHERE type = $1", triggerType)
+
+	result, err := scanTriggerType(row.Scan)
+	if err == sql.ErrNoRows {
+		return result, &ItemNotFoundError{ItemID: triggerType}
+	}
+	return result, queryError(ctx, err)
+}
+
+// NewTrigger constructs new trigger in a database. Parameters of the trigger
+// need to be JSON object that conforms to JSON schema of the trigger type.
+// Default time to live of the trigger type is used when expiresAt is zero.
+// Trigger is linked to the support case when its number is not empty, the
+// case needs to be open.
+func (storage DBStorage) NewTrigger(ctx context.Context, clusterName, triggerType, userName, reason, link, parameters string, expiresAt time.Time, caseNumber string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// retrieve cluster ID
+	clusterInfo, err := storage.GetClusterByName(ctx, clusterName)
+	clusterID := clusterInfo.ID
+
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+
+	triggerTypeInfo, err := storage.GetTriggerType(ctx, triggerType)
+
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+
+	if triggerTypeInfo.DeprecatedAt != "" {
+		return &DeprecatedTriggerTypeError{TriggerType: triggerType}
+	}
+
+	parameters, err = triggerParameters(triggerTypeInfo, parameters)
+	if err != nil {
+		return err
+	}
+
+	supportCase, err := triggerSupportCase(triggerTypeInfo, caseNumber, func(caseNumber string) (SupportCase, error) {
+		return storage.GetSupportCase(ctx, caseNumber)
+	})
+	if err != nil {
+		return err
+	}
+
+	// NULL is stored for triggers that are not linked to any case
+	var supportCaseID interface{}
+	if supportCase.ID != 0 {
+		supportCaseID = supportCase.ID
+	}
+	t := utcTime(time.Now())
+
+	// NULL is stored for triggers that do not expire
+	var expiration interface{}
+	if expiresAt = triggerExpiration(triggerTypeInfo, t, expiresAt); !expiresAt.IsZero() {
+		expiration = utcTime(expiresAt)
+	}
+
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
 
+	_, err = tx.ExecContext(ctx, "INSERT INTO trigger(type, cluster, reason, link, triggered_at, triggered_by, parameters, expires_at, state, support_case) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		triggerTypeInfo.ID, clusterID, reason, link, t, userName, parameters, expiration, TriggerPending, supportCaseID)
 	if err != nil {
-		return Trigger{}, err
+		log.Print(err)
+		_ = tx.Rollback()
+		return queryError(ctx, err)
 	}
 
-	triggers, err := storage.getTriggers(rows)
+	// creation of the trigger is its first transition
+	triggerID, err := storage.selectLastInsertedID(ctx, tx, "trigger")
+	if err == nil {
+		err = insertTriggerTransition(ctx, tx, triggerID, TriggerPending, userName, t)
+	}
 	if err != nil {
-		return Trigger{}, err
+		_ = tx.Rollback()
+		return err
 	}
 
-	if len(triggers) >= 1 {
-		return triggers[0], nil
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
 	}
-	return Trigger{}, ErrNoSuchObj
+	return nil
 }
 
-func execStatementAndGetRowsAffected(statement *sql.Stmt, args ...interface{}) (int64, error) {
-	res, err := statement.Exec(args...)
-	if err != nil {
-		return 0, err
-	}
+// NewTriggerType inserts a trigger_type object in the database. Empty
+// parameters schema means that parameters of triggers are not validated.
+func (storage DBStorage) NewTriggerType(ctx context.Context, ttype, description, parametersSchema string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		return 0, err
+	if err := checkParametersSchema(ttype, parametersSchema); err != nil {
+		return err
 	}
 
-	return rowsAffected, nil
-}
+	_, err := storage.GetTriggerType(ctx, ttype)
+	if err == nil {
+		return &ItemAlreadyExistsError{ItemID: ttype}
+	}
+	if _, notFound := err.(*ItemNotFoundError); !notFound {
+		return err
+	}
 
-// DeleteTriggerByID deletes trigger specified by its ID
-// returns ItemNotFoundError if trigger didn't exist
-func (storage Storage) DeleteTriggerByID(id int64) error {
-	statement, err := storage.connections.Prepare(`
-DELETE FROM trigger WHERE trigger.id = $1`)
+	statement, err := storage.connections.PrepareContext(ctx, "INSERT INTO trigger_type(type, description, parameters_schema) VALUES ($1, $2, $3)")
 	if err != nil {
 		log.Print(err)
-		return err
+		return queryError(ctx, err)
 	}
 
 	// statement has to be closed at function exit
@@ -1154,279 +3674,349 @@ DELETE FROM trigger WHERE trigger.id = $1`)
 		}
 	}()
 
-	rowsAffected, err := execStatementAndGetRowsAffected(statement, id)
+	_, err = statement.ExecContext(ctx, ttype, description, parametersSchema)
 	if err != nil {
 		log.Print(err)
-		return err
+		return queryError(ctx, err)
 	}
+	return nil
+}
 
-	// non-existent trigger ID has been used
+// ChangeTriggerTypeDescription changes description of trigger type specified
+// by its name.
+func (storage DBStorage) ChangeTriggerTypeDescription(ctx context.Context, ttype, description string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx,
+		"UPDATE trigger_type SET description = $1 WHERE type = $2", description, ttype)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
 	if rowsAffected == 0 {
-		// convert ID (numeric value) to string for proper logging
-		IDstr := strconv.Itoa(int(id))
 		return &ItemNotFoundError{
-			ItemID: IDstr,
+			ItemID: ttype,
 		}
 	}
-
 	return nil
 }
 
-// ChangeStateOfTriggerByID change the state ('active', 'inactive') of trigger specified by its ID.
-// returns ItemNotFoundError if there weren't rows with such id
-func (storage Storage) ChangeStateOfTriggerByID(id int64, active int) error {
-	statement, err := storage.connections.Prepare(`
-UPDATE trigger SET active = $1 WHERE trigger.id = $2`)
-	if err != nil {
-		return err
+// SetTriggerTypeDefaultTTL sets default time to live of new triggers of
+// trigger type specified by its name. Zero TTL means that new triggers do
+// not expire.
+func (storage DBStorage) SetTriggerTypeDefaultTTL(ctx context.Context, ttype string, ttl time.Duration) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	// NULL is stored for trigger types without default TTL
+	var defaultTTL interface{}
+	if ttl > 0 {
+		defaultTTL = int64(ttl / time.Second)
 	}
 
-	// statement has to be closed at function exit
-	defer func() {
-		// try to close the statement
-		err := statement.Close()
-		// in case of error all we can do is to just log the error
-		if err != nil {
-			log.Println(err)
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx,
+		"UPDATE trigger_type SET default_ttl = $1 WHERE type = $2", defaultTTL, ttype)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{
+			ItemID: ttype,
 		}
-	}()
+	}
+	return nil
+}
+
+// SetTriggerTypeRequiresCase sets whether new triggers of trigger type
+// specified by its name need to be linked to open support case.
+func (storage DBStorage) SetTriggerTypeRequiresCase(ctx context.Context, ttype string, required bool) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-	rowsAffected, err := execStatementAndGetRowsAffected(statement, active, id)
+	requiresCase := 0
+	if required {
+		requiresCase = 1
+	}
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx,
+		"UPDATE trigger_type SET requires_case = $1 WHERE type = $2", requiresCase, ttype)
 	if err != nil {
 		log.Print(err)
-		return err
+		return queryError(ctx, err)
 	}
-
-	// non-existent trigger ID has been used
 	if rowsAffected == 0 {
-		// convert ID (numeric value) to string for proper logging
-		IDstr := strconv.Itoa(int(id))
 		return &ItemNotFoundError{
-			ItemID: IDstr,
+			ItemID: ttype,
 		}
 	}
-
 	return nil
 }
 
-// ListAllTriggers selects all triggers from the database.
-func (storage Storage) ListAllTriggers() ([]Trigger, error) {
-	triggers := []Trigger{}
+// ListSupportCases selects all support cases from the database.
+func (storage DBStorage) ListSupportCases(ctx context.Context) ([]SupportCase, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-	rows, err := storage.connections.Query(`
-SELECT trigger.id, trigger_type.type, cluster.name,
-       trigger.reason, trigger.link, trigger.triggered_at, trigger.triggered_by,
-       trigger.parameters, trigger.active, trigger.acked_at
-FROM trigger JOIN trigger_type ON trigger.type=trigger_type.id
-               JOIN cluster ON trigger.cluster=cluster.id
-ORDER BY trigger.id`)
+	supportCases := []SupportCase{}
 
+	rows, err := storage.connections.QueryContext(ctx,
+		"SELECT "+supportCaseColumns+" FROM support_case ORDER BY id")
 	if err != nil {
-		return triggers, err
+		return supportCases, queryError(ctx, err)
 	}
 
-	return storage.getTriggers(rows)
+	// rows has to be closed at function exit
+	defer func() {
+		// try to close the statement
+		err := rows.Close()
+		// in case of error all we can do is to just log the error
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		supportCase, err := scanSupportCase(rows.Scan)
+		if err != nil {
+			log.Println("error", err)
+			return supportCases, queryError(ctx, err)
+		}
+		supportCases = append(supportCases, supportCase)
+	}
+	return supportCases, queryError(ctx, rows.Err())
 }
 
-// ListClusterTriggers selects all triggers assigned to the specified cluster.
-func (storage Storage) ListClusterTriggers(clusterName string) ([]Trigger, error) {
-	triggers := []Trigger{}
+// GetSupportCase selects support case specified by its number.
+func (storage DBStorage) GetSupportCase(ctx context.Context, caseNumber string) (SupportCase, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-	// check that cluster exist
-	if _, err := storage.GetClusterByName(clusterName); err != nil {
-		return triggers, err
+	row := storage.connections.QueryRowContext(ctx,
+		"SELECT "+supportCaseColumns+" FROM support_case WHERE case_number = $1", caseNumber)
+
+	result, err := scanSupportCase(row.Scan)
+	if err == sql.ErrNoRows {
+		return SupportCase{}, &ItemNotFoundError{ItemID: caseNumber}
 	}
+	return result, queryError(ctx, err)
+}
 
-	rows, err := storage.connections.Query(`
-SELECT trigger.id, trigger_type.type, cluster.name,
-       trigger.reason, trigger.link, trigger.triggered_at, trigger.triggered_by,
-       trigger.parameters, trigger.active, trigger.acked_at
-  FROM trigger JOIN trigger_type ON trigger.type=trigger_type.id
-               JOIN cluster ON trigger.cluster=cluster.id
- WHERE cluster.name = $1
- ORDER BY trigger.id`, clusterName)
+// NewSupportCase registers new open support case. Case numbers are unique.
+func (storage DBStorage) NewSupportCase(ctx context.Context, caseNumber, account, consentLink, username string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := storage.GetSupportCase(ctx, caseNumber)
+	if err == nil {
+		return &ItemAlreadyExistsError{ItemID: caseNumber}
+	}
+	if _, notFound := err.(*ItemNotFoundError); !notFound {
+		return err
+	}
 
+	_, err = storage.connections.ExecContext(ctx, "INSERT INTO support_case(case_number, account, consent_link, status, created_at, created_by) VALUES ($1, $2, $3, $4, $5, $6)",
+		caseNumber, account, consentLink, SupportCaseOpen, utcTime(time.Now()), username)
 	if err != nil {
-		return triggers, err
+		log.Print(err)
+		return queryError(ctx, err)
 	}
+	return nil
+}
 
-	return storage.getTriggers(rows)
+// ChangeSupportCaseStatus changes status of support case specified by its
+// number. New triggers can not be linked to closed cases.
+func (storage DBStorage) ChangeSupportCaseStatus(ctx context.Context, caseNumber string, status SupportCaseStatus) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx,
+		"UPDATE support_case SET status = $1 WHERE case_number = $2", status, caseNumber)
+	if err != nil {
+		log.Print(err)
+		return queryError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{
+			ItemID: caseNumber,
+		}
+	}
+	return nil
 }
 
-// ListActiveClusterTriggers selects all active triggers assigned to the specified cluster.
-func (storage Storage) ListActiveClusterTriggers(clusterName string) ([]Trigger, error) {
+// ListSupportCaseTriggers selects all triggers linked to support case
+// specified by its number, including results reported by the operator.
+func (storage DBStorage) ListSupportCaseTriggers(ctx context.Context, caseNumber string) ([]Trigger, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
 	triggers := []Trigger{}
 
-	// check that cluster exist
-	if _, err := storage.GetClusterByName(clusterName); err != nil {
+	// check that the case exist
+	if _, err := storage.GetSupportCase(ctx, caseNumber); err != nil {
 		return triggers, err
 	}
 
-	rows, err := storage.connections.Query(`
-SELECT trigger.id, trigger_type.type, cluster.name,
-       trigger.reason, trigger.link, trigger.triggered_at, trigger.triggered_by,
-       trigger.parameters, trigger.active, trigger.acked_at
+	rows, err := storage.connections.QueryContext(ctx, `
+SELECT `+triggerColumns+`
   FROM trigger JOIN trigger_type ON trigger.type=trigger_type.id
                JOIN cluster ON trigger.cluster=cluster.id
- WHERE trigger.active = 1
-   AND cluster.name = $1`, clusterName)
+               JOIN support_case ON trigger.support_case=support_case.id
+ WHERE support_case.case_number = $1
+ ORDER BY trigger.id`, caseNumber)
 
 	if err != nil {
-		return triggers, err
+		return triggers, queryError(ctx, err)
 	}
 
-	return storage.getTriggers(rows)
+	return storage.getTriggers(ctx, rows)
 }
 
-// GetTriggerID select ID for specified trigger type (name).
-func (storage Storage) GetTriggerID(triggerType string) (int, error) {
-	var id int
-
-	rows, err := storage.connections.Query("SELECT id FROM trigger_type WHERE type = $1", triggerType)
-	if err != nil {
-		return 0, err
-	}
-
-	// rows has to be closed at function exit
-	defer func() {
-		// try to close the statement
-		err := rows.Close()
-		// in case of error all we can do is to just log the error
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-
-	if rows.Next() {
-
-		err = rows.Scan(&id)
-		if err == nil {
-			log.Printf("Trigger type %s has id %d\n", triggerType, id)
-		} else {
-			log.Println("error", err)
-		}
-	} else {
-		return 0, errors.New("Unknown trigger type provided")
-	}
-	return id, err
-}
+// ExpireTriggers moves all pending triggers that expired before the given
+// time into the expired state. Number of expired triggers is returned.
+func (storage DBStorage) ExpireTriggers(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
-// NewTrigger constructs new trigger in a database.
-func (storage Storage) NewTrigger(clusterName, triggerType, userName, reason, link string) error {
-	// retrieve cluster ID
-	clusterInfo, err := storage.GetClusterByName(clusterName)
-	clusterID := clusterInfo.ID
+	now = utcTime(now)
 
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
 	if err != nil {
 		log.Print(err)
-		return err
+		return 0, queryError(ctx, err)
 	}
 
-	triggerTypeID, err := storage.GetTriggerID(triggerType)
-
+	// transitions are recorded before the state is changed
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO trigger_transition(trigger, state, changed_at, changed_by)
+SELECT id, 'expired', $1, ''
+  FROM trigger
+ WHERE state = 'pending' AND expires_at IS NOT NULL AND expires_at <= $1`, now)
 	if err != nil {
 		log.Print(err)
-		return err
+		_ = tx.Rollback()
+		return 0, queryError(ctx, err)
 	}
-	t := time.Now()
-	ackedAt := time.Unix(0, 0).UTC()
 
-	statement, err := storage.connections.Prepare("INSERT INTO trigger(type, cluster, reason, link, triggered_at, triggered_by, parameters, active, acked_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)")
+	result, err := tx.ExecContext(ctx, `
+UPDATE trigger
+   SET state = 'expired'
+ WHERE state = 'pending' AND expires_at IS NOT NULL AND expires_at <= $1`, now)
 	if err != nil {
 		log.Print(err)
-		return err
+		_ = tx.Rollback()
+		return 0, queryError(ctx, err)
 	}
 
-	// statement has to be closed at function exit
-	defer func() {
-		// try to close the statement
-		err := statement.Close()
-		// in case of error all we can do is to just log the error
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-
-	_, err = statement.Exec(triggerTypeID, clusterID, reason, link, t, userName, "", 1, ackedAt)
+	expired, err := result.RowsAffected()
 	if err != nil {
+		_ = tx.Rollback()
+		return 0, queryError(ctx, err)
+	}
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
 		log.Print(err)
-		return err
+		return 0, queryError(ctx, err)
 	}
-	return nil
+	return expired, nil
 }
 
-// NewTriggerType inserts a trigger_type object in the database
-func (storage Storage) NewTriggerType(ttype, description string) error {
-	statement, err := storage.connections.Prepare("INSERT INTO trigger_type(type, description) VALUES ($1, $2)")
+// DeprecateTriggerType marks trigger type specified by its name as
+// deprecated, so new triggers of this type can not be created. Trigger type
+// can not be deprecated twice.
+func (storage DBStorage) DeprecateTriggerType(ctx context.Context, ttype, username string) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rowsAffected, err := storage.execAndGetRowsAffected(ctx, `
+UPDATE trigger_type
+   SET deprecated_at = $1, deprecated_by = $2
+ WHERE type = $3 AND deprecated_at IS NULL`,
+		utcTime(time.Now()), username, ttype)
 	if err != nil {
 		log.Print(err)
-		return err
+		return queryError(ctx, err)
 	}
-
-	// statement has to be closed at function exit
-	defer func() {
-		// try to close the statement
-		err := statement.Close()
-		// in case of error all we can do is to just log the error
-		if err != nil {
-			log.Println(err)
+	if rowsAffected == 0 {
+		return &ItemNotFoundError{
+			ItemID: ttype,
 		}
-	}()
-
-	_, err = statement.Exec(ttype, description)
-	if err != nil {
-		log.Print(err)
-		return err
 	}
 	return nil
 }
 
-// AckTrigger sets a timestamp to the selected trigger + updates the 'active' flag.
-// and returns error if trigger wasn't found
-func (storage Storage) AckTrigger(clusterName string, triggerID int64) error {
-	t := time.Now()
+// AckTrigger marks the selected trigger as delivered to the operator, the
+// transition is recorded with its timestamp. It returns error if trigger
+// wasn't found.
+func (storage DBStorage) AckTrigger(ctx context.Context, clusterName string, triggerID int64) error {
+	return storage.ChangeClusterTriggerState(ctx, clusterName, triggerID, TriggerDelivered)
+}
 
-	// retrieve cluster ID
-	clusterInfo, err := storage.GetClusterByName(clusterName)
-	clusterID := clusterInfo.ID
+// PurgeDeleted permanently removes cluster configurations, clusters, and
+// configuration profiles that have been deleted before the specified time.
+// Records that refer to purged items are removed too ("on delete cascade").
+// Profiles still referred by configurations of clusters are kept, so the
+// configurations are not removed together with them. Number of purged items
+// is returned.
+func (storage DBStorage) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
 
+	// begin transaction
+	tx, err := storage.connections.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		log.Print(err)
+		return 0, queryError(ctx, err)
 	}
 
-	statement, err := storage.connections.Prepare("UPDATE trigger SET acked_at = $1, active=0 WHERE cluster = $2 AND id = $3")
+	var purged int64
 
-	if err != nil {
-		return err
+	// dependent records are purged first
+	purges := []struct {
+		table     string
+		condition string
+	}{
+		{"operator_configuration", ""},
+		{"cluster", ""},
+		{"configuration_profile", " AND NOT EXISTS (SELECT 1 FROM operator_configuration WHERE configuration = configuration_profile.id)"},
 	}
-
-	// statement has to be closed at function exit
-	defer func() {
-		// try to close the statement
-		err := statement.Close()
-		// in case of error all we can do is to just log the error
+	for _, purge := range purges {
+		table := purge.table
+		result, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE deleted_at < $1"+purge.condition, utcTime(deletedBefore))
 		if err != nil {
-			log.Println(err)
+			log.Print(err)
+			_ = tx.Rollback()
+			return 0, queryError(ctx, err)
 		}
-	}()
 
-	rowsAffected, err := execStatementAndGetRowsAffected(statement, t, clusterID, triggerID)
-	if err != nil {
-		return err
-	}
-	if rowsAffected == 0 {
-		return &ItemNotFoundError{
-			ItemID: fmt.Sprintf("%v/%v", clusterName, triggerID),
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, queryError(ctx, err)
 		}
+		log.Printf("%d deleted records have been purged from table %s\n", rowsAffected, table)
+		purged += rowsAffected
 	}
-	return nil
+
+	// end the transaction
+	if err := tx.Commit(); err != nil {
+		log.Print(err)
+		return 0, queryError(ctx, err)
+	}
+	return purged, nil
 }
 
 // QueryOne is generating Sql query using squirell sql builder, querying it with db store and mapping result to destination object with provided mapper
-func (storage Storage) QueryOne(ctx context.Context, selectCols []Column, selectBuilder sq.SelectBuilder, mapper func(Column, interface{}) (interface{}, error), res interface{}) error {
+func (storage DBStorage) QueryOne(ctx context.Context, selectCols []Column, selectBuilder sq.SelectBuilder, mapper func(Column, interface{}) (interface{}, error), res interface{}) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
 	q, args, err := selectBuilder.ToSql()
 	if err != nil {
-		return err
+		return queryError(ctx, err)
 	}
 	rowScanner := storage.connections.QueryRowContext(ctx, q, args...)
 	if rowScanner == nil {
@@ -1435,20 +4025,56 @@ func (storage Storage) QueryOne(ctx context.Context, selectCols []Column, select
 
 	resMap, err := storage.Map(selectCols, mapper, res)
 	if err != nil {
-		return err
+		return queryError(ctx, err)
 	}
 	err = rowScanner.Scan(resMap...)
 	if err == sql.ErrNoRows {
 		return ErrNoSuchObj
 	}
 	if err != nil {
-		return err
+		return queryError(ctx, err)
 	}
 	return nil
 }
 
+// QueryMany is generating Sql query using squirell sql builder, querying it with db store and mapping all result rows
+// to destination objects with provided mapper. New destination object is requested by calling next for each row.
+func (storage DBStorage) QueryMany(ctx context.Context, selectCols []Column, selectBuilder sq.SelectBuilder, mapper func(Column, interface{}) (interface{}, error), next func() interface{}) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	q, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return queryError(ctx, err)
+	}
+	rows, err := storage.connections.QueryContext(ctx, q, args...)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+
+	// close the query at function exit
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	for rows.Next() {
+		resMap, err := storage.Map(selectCols, mapper, next())
+		if err != nil {
+			return queryError(ctx, err)
+		}
+		err = rows.Scan(resMap...)
+		if err != nil {
+			return queryError(ctx, err)
+		}
+	}
+	return queryError(ctx, rows.Err())
+}
+
 // Map creates a list of destination struct fields using columns to select
-func (storage Storage) Map(cols []Column, mapper func(Column, interface{}) (interface{}, error), r interface{}) ([]interface{}, error) {
+func (storage DBStorage) Map(cols []Column, mapper func(Column, interface{}) (interface{}, error), r interface{}) ([]interface{}, error) {
 	var mappedCols []interface{}
 	for _, c := range cols {
 		mc, err := mapper(c, r)
@@ -1461,15 +4087,18 @@ func (storage Storage) Map(cols []Column, mapper func(Column, interface{}) (inte
 }
 
 // Ping checks whether the database connection is really configured properly
-func (storage Storage) Ping() error {
-	rows, err := storage.connections.Query("SELECT id, name FROM cluster LIMIT 1")
+func (storage DBStorage) Ping(ctx context.Context) error {
+	ctx, cancel := storage.withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := storage.connections.QueryContext(ctx, "SELECT id, name FROM cluster LIMIT 1")
 	if err != nil {
-		return err
+		return queryError(ctx, err)
 	}
 
 	err = rows.Close()
 	if err != nil {
-		return err
+		return queryError(ctx, err)
 	}
 
 	return nil
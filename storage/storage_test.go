@@ -20,8 +20,11 @@ package storage_test
 // https://redhatinsights.github.io/insights-operator-controller/packages/storage/storage_test.html
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -32,7 +35,7 @@ const sqlite3 = "sqlite3"
 const dataSource = ":memory:"
 
 // MustGetMockStorage creates test sqlite storage in file or in memory
-func MustGetMockStorage(tb testing.TB, init bool) (storageImpl storage.Storage, closer func()) {
+func MustGetMockStorage(tb testing.TB, init bool) (storageImpl storage.DBStorage, closer func()) {
 	sqliteStorage, _ := mustGetSqliteStorage(tb, dataSource, init)
 
 	return sqliteStorage, func() {
@@ -41,7 +44,7 @@ func MustGetMockStorage(tb testing.TB, init bool) (storageImpl storage.Storage,
 }
 
 // MustCloseStorage closes the storage and calls t.Fatal on error
-func MustCloseStorage(tb testing.TB, s storage.Storage) {
+func MustCloseStorage(tb testing.TB, s storage.DBStorage) {
 	s.Close()
 }
 
@@ -56,6 +59,8 @@ func FailOnError(t testing.TB, err error) {
 	}
 }
 
+// initializeDatabase creates the schema the same way as scripts from
+// local_storage directory do, ie. without schema migrations
 func initializeDatabase(tb testing.TB, connections *sql.DB) {
 	statements := []string{
 		`
@@ -135,7 +140,7 @@ create table trigger (
 }
 
 // mustGetSqliteStorage creates a mock DB storage based on SQLite engine
-func mustGetSqliteStorage(tb testing.TB, datasource string, init bool) (storage.Storage, *sql.DB) {
+func mustGetSqliteStorage(tb testing.TB, datasource string, init bool) (storage.DBStorage, *sql.DB) {
 	db, err := sql.Open(sqlite3, datasource)
 	FailOnError(tb, err)
 
@@ -145,8 +150,7 @@ func mustGetSqliteStorage(tb testing.TB, datasource string, init bool) (storage.
 	sqliteStorage := storage.NewFromConnection(db, sqlite3)
 
 	if init {
-		initializeDatabase(tb, db)
-
+		FailOnError(tb, sqliteStorage.MigrateToLatest(context.Background()))
 	}
 	return sqliteStorage, db
 }
@@ -178,7 +182,7 @@ func TestDBStorageListOfClustersSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.ListOfClusters()
+	_, err := mockStorage.ListOfClusters(context.Background(), false)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -189,7 +193,7 @@ func TestDBStorageListOfClustersEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.ListOfClusters()
+	_, err := mockStorage.ListOfClusters(context.Background(), false)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -200,7 +204,7 @@ func TestDBStorageGetClusterSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.GetCluster(0)
+	_, err := mockStorage.GetCluster(context.Background(), 0)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -211,7 +215,7 @@ func TestDBStorageGetClusterEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.GetCluster(0)
+	_, err := mockStorage.GetCluster(context.Background(), 0)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -222,7 +226,7 @@ func TestDBStorageRegisterNewClusterSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster("foobar")
+	err := mockStorage.RegisterNewCluster(context.Background(), "foobar")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -233,7 +237,7 @@ func TestDBStorageRegisterNewClusterEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster("foobar")
+	err := mockStorage.RegisterNewCluster(context.Background(), "foobar")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -244,7 +248,7 @@ func TestDBStorageCreateNewClusterSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.CreateNewCluster(0, "foobar")
+	err := mockStorage.CreateNewCluster(context.Background(), 0, "foobar")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -255,7 +259,7 @@ func TestDBStorageCreateNewClusterEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.CreateNewCluster(0, "foobar")
+	err := mockStorage.CreateNewCluster(context.Background(), 0, "foobar")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -266,7 +270,7 @@ func TestDBStorageDeleteClusterSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.DeleteCluster(0)
+	err := mockStorage.DeleteCluster(context.Background(), 0, "user")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -277,7 +281,7 @@ func TestDBStorageDeleteClusterEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.DeleteCluster(0)
+	err := mockStorage.DeleteCluster(context.Background(), 0, "user")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -288,7 +292,7 @@ func TestDBStorageDeleteClusterByNameSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.DeleteClusterByName("foobar")
+	err := mockStorage.DeleteClusterByName(context.Background(), "foobar", "user")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -299,7 +303,7 @@ func TestDBStorageDeleteClusterByNameEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.DeleteClusterByName("foobar")
+	err := mockStorage.DeleteClusterByName(context.Background(), "foobar", "user")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -310,7 +314,7 @@ func TestDBStorageGetClusterByNameSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.GetClusterByName("foobar")
+	_, err := mockStorage.GetClusterByName(context.Background(), "foobar")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -321,7 +325,7 @@ func TestDBStorageGetClusterByNameEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.GetClusterByName("foobar")
+	_, err := mockStorage.GetClusterByName(context.Background(), "foobar")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -332,7 +336,7 @@ func TestDBStorageListConfigurationProfilesSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.ListConfigurationProfiles()
+	_, err := mockStorage.ListConfigurationProfiles(context.Background(), false)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -343,7 +347,7 @@ func TestDBStorageListConfigurationProfilesEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.ListConfigurationProfiles()
+	_, err := mockStorage.ListConfigurationProfiles(context.Background(), false)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -354,7 +358,7 @@ func TestDBStorageGetConfigurationProfileSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.GetConfigurationProfile(0)
+	_, err := mockStorage.GetConfigurationProfile(context.Background(), 0)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -365,7 +369,7 @@ func TestDBStorageGetConfigurationProfileEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.GetConfigurationProfile(0)
+	_, err := mockStorage.GetConfigurationProfile(context.Background(), 0)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -376,7 +380,7 @@ func TestDBStorageStoreConfigurationProfileSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.StoreConfigurationProfile("username0", "description0", "configuration0")
+	_, err := mockStorage.StoreConfigurationProfile(context.Background(), "username0", "description0", "configuration0")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -387,7 +391,7 @@ func TestDBStorageStoreConfigurationProfileEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.StoreConfigurationProfile("username0", "description0", "configuration0")
+	_, err := mockStorage.StoreConfigurationProfile(context.Background(), "username0", "description0", "configuration0")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -398,7 +402,7 @@ func TestDBStorageChangeConfigurationProfileSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.ChangeConfigurationProfile(42, "username0", "description0", "configuration0")
+	_, err := mockStorage.ChangeConfigurationProfile(context.Background(), 42, storage.AnyVersion, "username0", "description0", "configuration0")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -409,7 +413,7 @@ func TestDBStorageChangeConfigurationProfileEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.ChangeConfigurationProfile(42, "username0", "description0", "configuration0")
+	_, err := mockStorage.ChangeConfigurationProfile(context.Background(), 42, storage.AnyVersion, "username0", "description0", "configuration0")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -420,7 +424,7 @@ func TestDBStorageDeleteConfigurationProfileSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.DeleteConfigurationProfile(42)
+	_, err := mockStorage.DeleteConfigurationProfile(context.Background(), 42, storage.AnyVersion, "user")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -431,7 +435,7 @@ func TestDBStorageDeleteConfigurationProfileEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.DeleteConfigurationProfile(42)
+	_, err := mockStorage.DeleteConfigurationProfile(context.Background(), 42, storage.AnyVersion, "user")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -442,7 +446,7 @@ func TestDBStorageListAllClusterConfigurationsSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.ListAllClusterConfigurations()
+	_, err := mockStorage.ListAllClusterConfigurations(context.Background(), false)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -453,7 +457,7 @@ func TestDBStorageListAllClusterConfigurationsEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.ListAllClusterConfigurations()
+	_, err := mockStorage.ListAllClusterConfigurations(context.Background(), false)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -464,7 +468,7 @@ func TestDBStorageListClusterConfigurationSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.ListClusterConfiguration("000ffff")
+	_, err := mockStorage.ListClusterConfiguration(context.Background(), "000ffff", false)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -475,7 +479,7 @@ func TestDBStorageListClusterConfigurationEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.ListClusterConfiguration("000ffff")
+	_, err := mockStorage.ListClusterConfiguration(context.Background(), "000ffff", false)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -486,7 +490,7 @@ func TestDBStorageGetClusterConfigurationByIDSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.GetClusterConfigurationByID(0x0001111)
+	_, _, err := mockStorage.GetClusterConfigurationByID(context.Background(), 0x0001111)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -497,7 +501,7 @@ func TestDBStorageGetClusterConfigurationByIDEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.GetClusterConfigurationByID(0x0001111)
+	_, _, err := mockStorage.GetClusterConfigurationByID(context.Background(), 0x0001111)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -508,7 +512,7 @@ func TestDBStorageGetClusterActiveConfigurationSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.GetClusterActiveConfiguration("0x0002222")
+	_, err := mockStorage.GetClusterActiveConfiguration(context.Background(), "0x0002222")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -519,7 +523,7 @@ func TestDBStorageGetClusterActiveConfigurationEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.GetClusterActiveConfiguration("0x0002222")
+	_, err := mockStorage.GetClusterActiveConfiguration(context.Background(), "0x0002222")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -530,7 +534,7 @@ func TestDBStorageGetConfigurationIDForClusterSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.GetConfigurationIDForCluster("0x0002222")
+	_, err := mockStorage.GetConfigurationIDForCluster(context.Background(), "0x0002222")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -541,7 +545,7 @@ func TestDBStorageGetConfigurationIDForClusterEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.GetConfigurationIDForCluster("0x0002222")
+	_, err := mockStorage.GetConfigurationIDForCluster(context.Background(), "0x0002222")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -552,7 +556,7 @@ func TestDBStorageCreateClusterConfigurationSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.CreateClusterConfiguration("cluster1", "user1", "reason1", "description1", "configuration1")
+	_, err := mockStorage.CreateClusterConfiguration(context.Background(), "cluster1", "user1", "reason1", "description1", "configuration1")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -563,18 +567,38 @@ func TestDBStorageCreateClusterConfigurationEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.CreateClusterConfiguration("cluster1", "user1", "reason1", "description1", "configuration1")
+	_, err := mockStorage.CreateClusterConfiguration(context.Background(), "cluster1", "user1", "reason1", "description1", "configuration1")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
 }
 
+// TestDBStorageCreateClusterConfigurationInsertError check that failed insertion of configuration profile is reported by method CreateClusterConfiguration
+func TestDBStorageCreateClusterConfigurationInsertError(t *testing.T) {
+	mockStorage, db := mustGetSqliteStorage(t, ":memory:", false)
+	defer MustCloseStorage(t, mockStorage)
+
+	// in-memory database is not shared between connections
+	db.SetMaxOpenConns(1)
+	FailOnError(t, mockStorage.MigrateToLatest(context.Background()))
+	FailOnError(t, mockStorage.RegisterNewCluster(context.Background(), "cluster1"))
+
+	// configuration profile can not be inserted
+	_, err := db.Exec("DROP TABLE configuration_profile")
+	FailOnError(t, err)
+
+	_, err = mockStorage.CreateClusterConfiguration(context.Background(), "cluster1", "user1", "reason1", "description1", "configuration1")
+	if err == nil {
+		t.Fatal("Error should be reported when configuration profile can not be inserted")
+	}
+}
+
 // TestDBStorageEnableClusterConfigurationSchemalessDB check the behaviour of method EnableClusterConfiguration on DB without schema
 func TestDBStorageEnableClusterConfigurationSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.EnableClusterConfiguration("cluster1", "user1", "reason1")
+	_, err := mockStorage.EnableClusterConfiguration(context.Background(), "cluster1", storage.AnyVersion, "user1", "reason1")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -585,7 +609,7 @@ func TestDBStorageEnableClusterConfigurationEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.EnableClusterConfiguration("cluster1", "user1", "reason1")
+	_, err := mockStorage.EnableClusterConfiguration(context.Background(), "cluster1", storage.AnyVersion, "user1", "reason1")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -596,7 +620,7 @@ func TestDBStorageDisableClusterConfigurationSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.DisableClusterConfiguration("cluster1", "user1", "reason1")
+	_, err := mockStorage.DisableClusterConfiguration(context.Background(), "cluster1", storage.AnyVersion, "user1", "reason1")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -607,7 +631,7 @@ func TestDBStorageDisableClusterConfigurationEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.DisableClusterConfiguration("cluster1", "user1", "reason1")
+	_, err := mockStorage.DisableClusterConfiguration(context.Background(), "cluster1", storage.AnyVersion, "user1", "reason1")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -618,12 +642,12 @@ func TestDBStorageEnableOrDisableClusterConfigurationByIDSchemalessDB(t *testing
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.EnableOrDisableClusterConfigurationByID(1, "1")
+	err := mockStorage.EnableOrDisableClusterConfigurationByID(context.Background(), 1, storage.AnyVersion, "1")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
 
-	err = mockStorage.EnableOrDisableClusterConfigurationByID(2, "0")
+	err = mockStorage.EnableOrDisableClusterConfigurationByID(context.Background(), 2, storage.AnyVersion, "0")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -634,12 +658,12 @@ func TestDBStorageEnableOrDisableClusterConfigurationByIDEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.EnableOrDisableClusterConfigurationByID(1, "1")
+	err := mockStorage.EnableOrDisableClusterConfigurationByID(context.Background(), 1, storage.AnyVersion, "1")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
 
-	err = mockStorage.EnableOrDisableClusterConfigurationByID(2, "0")
+	err = mockStorage.EnableOrDisableClusterConfigurationByID(context.Background(), 2, storage.AnyVersion, "0")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -650,7 +674,7 @@ func TestDBStorageDeleteClusterConfigurationByIDSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.DeleteClusterConfigurationByID(1)
+	err := mockStorage.DeleteClusterConfigurationByID(context.Background(), 1, storage.AnyVersion, "user")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -661,7 +685,7 @@ func TestDBStorageDeleteClusterConfigurationByIDEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.DeleteClusterConfigurationByID(1)
+	err := mockStorage.DeleteClusterConfigurationByID(context.Background(), 1, storage.AnyVersion, "user")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -672,7 +696,7 @@ func TestDBStorageGetTriggerByIDSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.GetTriggerByID(1)
+	_, err := mockStorage.GetTriggerByID(context.Background(), 1)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -683,7 +707,7 @@ func TestDBStorageGetTriggerByIDEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.GetTriggerByID(1)
+	_, err := mockStorage.GetTriggerByID(context.Background(), 1)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -694,7 +718,7 @@ func TestDBStorageDeleteTriggerByIDSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.DeleteTriggerByID(1)
+	err := mockStorage.DeleteTriggerByID(context.Background(), 1)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -705,7 +729,7 @@ func TestDBStorageDeleteTriggerByIDEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.DeleteTriggerByID(1)
+	err := mockStorage.DeleteTriggerByID(context.Background(), 1)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -716,12 +740,12 @@ func TestDBStorageChangeStateOfTriggerByIDSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.ChangeStateOfTriggerByID(1, 0)
+	err := mockStorage.ChangeStateOfTriggerByID(context.Background(), 1, 0)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
 
-	err = mockStorage.ChangeStateOfTriggerByID(1, 1)
+	err = mockStorage.ChangeStateOfTriggerByID(context.Background(), 1, 1)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -732,12 +756,12 @@ func TestDBStorageChangeStateOfTriggerByIDEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.ChangeStateOfTriggerByID(1, 0)
+	err := mockStorage.ChangeStateOfTriggerByID(context.Background(), 1, 0)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
 
-	err = mockStorage.ChangeStateOfTriggerByID(1, 1)
+	err = mockStorage.ChangeStateOfTriggerByID(context.Background(), 1, 1)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -748,7 +772,7 @@ func TestDBStorageListAllTriggersSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.ListAllTriggers()
+	_, err := mockStorage.ListAllTriggers(context.Background())
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -759,7 +783,7 @@ func TestDBStorageListAllTriggersEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.ListAllTriggers()
+	_, err := mockStorage.ListAllTriggers(context.Background())
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -770,7 +794,7 @@ func TestDBStorageListClusterTriggersSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.ListClusterTriggers("clusterX")
+	_, err := mockStorage.ListClusterTriggers(context.Background(), "clusterX")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -781,7 +805,7 @@ func TestDBStorageListClusterTriggersEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.ListClusterTriggers("clusterX")
+	_, err := mockStorage.ListClusterTriggers(context.Background(), "clusterX")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -792,7 +816,7 @@ func TestDBStorageListActiveClusterTriggersSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.ListActiveClusterTriggers("clusterX")
+	_, err := mockStorage.ListActiveClusterTriggers(context.Background(), "clusterX")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -803,7 +827,7 @@ func TestDBStorageListActiveClusterTriggersEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.ListActiveClusterTriggers("clusterX")
+	_, err := mockStorage.ListActiveClusterTriggers(context.Background(), "clusterX")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -814,7 +838,7 @@ func TestDBStorageGetTriggerIDSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	_, err := mockStorage.GetTriggerID("trigger1")
+	_, err := mockStorage.GetTriggerID(context.Background(), "trigger1")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -825,7 +849,7 @@ func TestDBStorageGetTriggerIDEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.GetTriggerID("trigger1")
+	_, err := mockStorage.GetTriggerID(context.Background(), "trigger1")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -836,7 +860,7 @@ func TestDBStorageNewTriggerSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.NewTrigger("clusterY", "triggerType1", "user3", "reason3", "link3")
+	err := mockStorage.NewTrigger(context.Background(), "clusterY", "triggerType1", "user3", "reason3", "link3", "", time.Time{}, "")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -847,7 +871,7 @@ func TestDBStorageNewTriggerEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.NewTrigger("clusterY", "triggerType1", "user3", "reason3", "link3")
+	err := mockStorage.NewTrigger(context.Background(), "clusterY", "triggerType1", "user3", "reason3", "link3", "", time.Time{}, "")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -858,7 +882,7 @@ func TestDBStorageNewTriggerTypeSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.NewTriggerType("trigger-type-X", "description-of-new-trigger-type")
+	err := mockStorage.NewTriggerType(context.Background(), "trigger-type-X", "description-of-new-trigger-type", "")
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -869,7 +893,7 @@ func TestDBStorageNewTriggerTypeEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.NewTriggerType("trigger-type-X", "description-of-new-trigger-type")
+	err := mockStorage.NewTriggerType(context.Background(), "trigger-type-X", "description-of-new-trigger-type", "")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -880,7 +904,7 @@ func TestDBStorageAckTriggerSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.AckTrigger("cluster-to-ack", 42)
+	err := mockStorage.AckTrigger(context.Background(), "cluster-to-ack", 42)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -891,7 +915,7 @@ func TestDBStorageAckTriggerEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.AckTrigger("cluster-to-ack", 42)
+	err := mockStorage.AckTrigger(context.Background(), "cluster-to-ack", 42)
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -902,7 +926,7 @@ func TestDBStoragePingSchemalessDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, false)
 	defer closer()
 
-	err := mockStorage.Ping()
+	err := mockStorage.Ping(context.Background())
 	if err == nil {
 		emptyDatabaseError(t)
 	}
@@ -913,7 +937,7 @@ func TestDBStoragePingEmptyDB(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.Ping()
+	err := mockStorage.Ping(context.Background())
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -925,22 +949,22 @@ func TestDBStorageClusterOperation1(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.ListOfClusters()
+	_, err = mockStorage.ListOfClusters(context.Background(), false)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.GetCluster(1)
+	_, err = mockStorage.GetCluster(context.Background(), 1)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.DeleteCluster(1)
+	err = mockStorage.DeleteCluster(context.Background(), 1, "user")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -952,17 +976,17 @@ func TestDBStorageClusterOperation2(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.GetClusterByName(clusterName)
+	_, err = mockStorage.GetClusterByName(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.DeleteClusterByName(clusterName)
+	err = mockStorage.DeleteClusterByName(context.Background(), clusterName, "user")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -974,12 +998,12 @@ func TestDBStorageClusterOperation3(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	clusters, err := mockStorage.ListOfClusters()
+	clusters, err := mockStorage.ListOfClusters(context.Background(), false)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -994,12 +1018,12 @@ func TestDBStorageConfigurationProfiles1(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.StoreConfigurationProfile("username1", "description1", "configuration1")
+	_, err := mockStorage.StoreConfigurationProfile(context.Background(), "username1", "description1", "configuration1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.GetConfigurationProfile(1)
+	_, err = mockStorage.GetConfigurationProfile(context.Background(), 1)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1010,12 +1034,12 @@ func TestDBStorageConfigurationProfiles2(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.StoreConfigurationProfile("username1", "description1", "configuration1")
+	_, err := mockStorage.StoreConfigurationProfile(context.Background(), "username1", "description1", "configuration1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	profiles, err := mockStorage.ListConfigurationProfiles()
+	profiles, err := mockStorage.ListConfigurationProfiles(context.Background(), false)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1030,12 +1054,12 @@ func TestDBStorageConfigurationProfiles3(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.StoreConfigurationProfile("username1", "description1", "configuration1")
+	_, err := mockStorage.StoreConfigurationProfile(context.Background(), "username1", "description1", "configuration1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.ChangeConfigurationProfile(1, "username1", "description12", "configuration12")
+	_, err = mockStorage.ChangeConfigurationProfile(context.Background(), 1, storage.AnyVersion, "username1", "description12", "configuration12")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1046,12 +1070,12 @@ func TestDBStorageConfigurationProfiles4(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	_, err := mockStorage.StoreConfigurationProfile("username1", "description1", "configuration1")
+	_, err := mockStorage.StoreConfigurationProfile(context.Background(), "username1", "description1", "configuration1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.DeleteConfigurationProfile(1)
+	_, err = mockStorage.DeleteConfigurationProfile(context.Background(), 1, storage.AnyVersion, "user")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1063,12 +1087,12 @@ func TestDBClusterConfiguration1(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.CreateClusterConfiguration(clusterName, "user1", "reason1", "description1", "configuration1")
+	_, err = mockStorage.CreateClusterConfiguration(context.Background(), clusterName, "user1", "reason1", "description1", "configuration1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1080,17 +1104,17 @@ func TestDBClusterConfiguration2(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.CreateClusterConfiguration(clusterName, "user1", "reason1", "description1", "configuration1")
+	_, err = mockStorage.CreateClusterConfiguration(context.Background(), clusterName, "user1", "reason1", "description1", "configuration1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.EnableOrDisableClusterConfigurationByID(1, "0")
+	err = mockStorage.EnableOrDisableClusterConfigurationByID(context.Background(), 1, storage.AnyVersion, "0")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1102,17 +1126,17 @@ func TestDBClusterConfiguration3(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.CreateClusterConfiguration(clusterName, "user1", "reason1", "description1", "configuration1")
+	_, err = mockStorage.CreateClusterConfiguration(context.Background(), clusterName, "user1", "reason1", "description1", "configuration1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.EnableClusterConfiguration(clusterName, "user1", "reason1")
+	_, err = mockStorage.EnableClusterConfiguration(context.Background(), clusterName, storage.AnyVersion, "user1", "reason1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1124,17 +1148,17 @@ func TestDBClusterConfiguration4(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.CreateClusterConfiguration(clusterName, "user1", "reason1", "description1", "configuration1")
+	_, err = mockStorage.CreateClusterConfiguration(context.Background(), clusterName, "user1", "reason1", "description1", "configuration1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.DisableClusterConfiguration(clusterName, "user2", "reason2")
+	_, err = mockStorage.DisableClusterConfiguration(context.Background(), clusterName, storage.AnyVersion, "user2", "reason2")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1146,17 +1170,17 @@ func TestDBClusterConfiguration5(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.CreateClusterConfiguration(clusterName, "user1", "reason1", "description1", "configuration1")
+	_, err = mockStorage.CreateClusterConfiguration(context.Background(), clusterName, "user1", "reason1", "description1", "configuration1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.GetClusterConfigurationByID(1)
+	_, _, err = mockStorage.GetClusterConfigurationByID(context.Background(), 1)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1168,17 +1192,17 @@ func TestDBClusterConfiguration6(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.CreateClusterConfiguration(clusterName, "user1", "reason1", "description1", "configuration1")
+	_, err = mockStorage.CreateClusterConfiguration(context.Background(), clusterName, "user1", "reason1", "description1", "configuration1")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.GetClusterActiveConfiguration(clusterName)
+	_, err = mockStorage.GetClusterActiveConfiguration(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1192,17 +1216,17 @@ func TestDBStorageTrigger1(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.NewTriggerType(triggerType, "description-of-new-trigger-type")
+	err = mockStorage.NewTriggerType(context.Background(), triggerType, "description-of-new-trigger-type", "")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.NewTrigger(clusterName, triggerType, "user3", "reason3", "link3")
+	err = mockStorage.NewTrigger(context.Background(), clusterName, triggerType, "user3", "reason3", "link3", "", time.Time{}, "")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1216,22 +1240,22 @@ func TestDBStorageTrigger2(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.NewTriggerType(triggerType, "description-of-new-trigger-type")
+	err = mockStorage.NewTriggerType(context.Background(), triggerType, "description-of-new-trigger-type", "")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.NewTrigger(clusterName, triggerType, "user3", "reason3", "link3")
+	err = mockStorage.NewTrigger(context.Background(), clusterName, triggerType, "user3", "reason3", "link3", "", time.Time{}, "")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.ListClusterTriggers(clusterName)
+	_, err = mockStorage.ListClusterTriggers(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1245,22 +1269,22 @@ func TestDBStorageTrigger3(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.NewTriggerType(triggerType, "description-of-new-trigger-type")
+	err = mockStorage.NewTriggerType(context.Background(), triggerType, "description-of-new-trigger-type", "")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.NewTrigger(clusterName, triggerType, "user3", "reason3", "link3")
+	err = mockStorage.NewTrigger(context.Background(), clusterName, triggerType, "user3", "reason3", "link3", "", time.Time{}, "")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	_, err = mockStorage.ListActiveClusterTriggers(clusterName)
+	_, err = mockStorage.ListActiveClusterTriggers(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1274,27 +1298,27 @@ func TestDBStorageTrigger4(t *testing.T) {
 	mockStorage, closer := MustGetMockStorage(t, true)
 	defer closer()
 
-	err := mockStorage.RegisterNewCluster(clusterName)
+	err := mockStorage.RegisterNewCluster(context.Background(), clusterName)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.NewTriggerType(triggerType, "description-of-new-trigger-type")
+	err = mockStorage.NewTriggerType(context.Background(), triggerType, "description-of-new-trigger-type", "")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.NewTrigger(clusterName, triggerType, "user3", "reason3", "link3")
+	err = mockStorage.NewTrigger(context.Background(), clusterName, triggerType, "user3", "reason3", "link3", "", time.Time{}, "")
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.AckTrigger(clusterName, 1)
+	err = mockStorage.AckTrigger(context.Background(), clusterName, 1)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
 
-	err = mockStorage.ChangeStateOfTriggerByID(1, 0)
+	err = mockStorage.ChangeStateOfTriggerByID(context.Background(), 1, 0)
 	if err != nil {
 		unexpectedDatabaseError(t, err)
 	}
@@ -1323,3 +1347,53 @@ func TestDBConnections(t *testing.T) {
 		t.Fatal("Connections should not be empty")
 	}
 }
+
+// TestCancelledContext checks that operations with cancelled context are
+// reported by QueryCancelledError
+func TestCancelledContext(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := mockStorage.ListOfClusters(ctx, false)
+	assert.IsType(t, &storage.QueryCancelledError{}, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.EqualError(t, err, "Storage operation has been cancelled")
+
+	err = mockStorage.RegisterNewCluster(ctx, "cluster")
+	assert.IsType(t, &storage.QueryCancelledError{}, err)
+
+	_, err = mockStorage.CreateClusterConfiguration(ctx, "cluster", "user", "reason", "description", "configuration")
+	assert.IsType(t, &storage.QueryCancelledError{}, err)
+}
+
+// TestExceededDeadline checks that operations with exceeded deadline are
+// reported by QueryCancelledError
+func TestExceededDeadline(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := mockStorage.GetTriggerByID(ctx, 1)
+	assert.IsType(t, &storage.QueryCancelledError{}, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.EqualError(t, err, "Storage operation timed out")
+}
+
+// TestQueryTimeout checks that storage with query timeout set works as expected
+func TestQueryTimeout(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	mockStorage = mockStorage.WithQueryTimeout(time.Minute)
+
+	FailOnError(t, mockStorage.RegisterNewCluster(context.Background(), "cluster"))
+
+	clusters, err := mockStorage.ListOfClusters(context.Background(), false)
+	FailOnError(t, err)
+	assert.Len(t, clusters, 1)
+}
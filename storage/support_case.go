@@ -0,0 +1,108 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/support_case.html
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SupportCaseStatus represents status of customer support case
+type SupportCaseStatus string
+
+// Statuses of support cases, triggers can be linked to open cases only
+const (
+	SupportCaseOpen   SupportCaseStatus = "open"
+	SupportCaseClosed SupportCaseStatus = "closed"
+)
+
+// ParseSupportCaseStatus converts string into SupportCaseStatus, error is
+// returned for unknown statuses
+func ParseSupportCaseStatus(status string) (SupportCaseStatus, error) {
+	switch s := SupportCaseStatus(status); s {
+	case SupportCaseOpen, SupportCaseClosed:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown support case status %s", status)
+}
+
+// SupportCase represents customer support case that triggers are attached to
+//     ID: unique key
+//     CaseNumber: number of the case in customer portal
+//     Account: customer account number
+//     ConsentLink: link to document with customer consent with the triggers
+//     Status: status of the case, open or closed
+//     CreatedAt: timestamp when the case has been registered
+//     CreatedBy: username of admin that registered the case
+type SupportCase struct {
+	ID          int               `json:"id"`
+	CaseNumber  string            `json:"case_number"`
+	Account     string            `json:"account"`
+	ConsentLink string            `json:"consent_link"`
+	Status      SupportCaseStatus `json:"status"`
+	CreatedAt   string            `json:"created_at"`
+	CreatedBy   string            `json:"created_by"`
+}
+
+// triggerSupportCase returns support case that new trigger of the given type
+// is linked to. Zero case is returned when the case number is empty and the
+// trigger type does not require case. The case is read by the provided
+// function.
+func triggerSupportCase(triggerType TriggerType, caseNumber string, getSupportCase func(string) (SupportCase, error)) (SupportCase, error) {
+	if caseNumber == "" {
+		if triggerType.RequiresCase {
+			return SupportCase{}, &InvalidSupportCaseError{
+				CaseNumber: caseNumber,
+				Reason:     "triggers of type " + triggerType.Type + " need to be linked to support case",
+			}
+		}
+		return SupportCase{}, nil
+	}
+
+	supportCase, err := getSupportCase(caseNumber)
+	if _, notFound := err.(*ItemNotFoundError); notFound {
+		return SupportCase{}, &InvalidSupportCaseError{CaseNumber: caseNumber, Reason: "the case is not registered"}
+	}
+	if err != nil {
+		return SupportCase{}, err
+	}
+	if supportCase.Status != SupportCaseOpen {
+		return SupportCase{}, &InvalidSupportCaseError{CaseNumber: caseNumber, Reason: "the case is not open"}
+	}
+	return supportCase, nil
+}
+
+// supportCaseColumns are columns of support_case table read by
+// scanSupportCase
+const supportCaseColumns = "id, case_number, account, consent_link, status, created_at, created_by"
+
+// scanSupportCase reads one support case using the provided scan function of
+// selected row
+func scanSupportCase(scan func(dest ...interface{}) error) (SupportCase, error) {
+	var result SupportCase
+	var consentLink, createdAt, createdBy sql.NullString
+
+	err := scan(&result.ID, &result.CaseNumber, &result.Account, &consentLink, &result.Status, &createdAt, &createdBy)
+	result.ConsentLink = consentLink.String
+	result.CreatedAt = createdAt.String
+	result.CreatedBy = createdBy.String
+	return result, err
+}
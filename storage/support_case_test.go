@@ -0,0 +1,122 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/support_case_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkSupportCases checks that triggers can be linked to open support cases
+// only and that trigger types can require the case
+func checkSupportCases(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.NewTriggerType(ctx, "must-gather", "must-gather", ""))
+	FailOnError(t, s.NewTriggerType(ctx, "other", "other", ""))
+	FailOnError(t, s.SetTriggerTypeRequiresCase(ctx, "must-gather", true))
+
+	err := s.SetTriggerTypeRequiresCase(ctx, "unknown", true)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	triggerType, err := s.GetTriggerType(ctx, "must-gather")
+	FailOnError(t, err)
+	assert.True(t, triggerType.RequiresCase)
+
+	FailOnError(t, s.NewSupportCase(ctx, "01234567", "1234", "consent", "user"))
+	FailOnError(t, s.NewSupportCase(ctx, "76543210", "4321", "", "user"))
+
+	err = s.NewSupportCase(ctx, "01234567", "1234", "", "user")
+	assert.IsType(t, &storage.ItemAlreadyExistsError{}, err)
+
+	supportCase, err := s.GetSupportCase(ctx, "01234567")
+	FailOnError(t, err)
+	assert.Equal(t, "1234", supportCase.Account)
+	assert.Equal(t, "consent", supportCase.ConsentLink)
+	assert.Equal(t, storage.SupportCaseOpen, supportCase.Status)
+	assert.Equal(t, "user", supportCase.CreatedBy)
+
+	_, err = s.GetSupportCase(ctx, "unknown")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	supportCases, err := s.ListSupportCases(ctx)
+	FailOnError(t, err)
+	assert.Len(t, supportCases, 2)
+
+	// must-gather triggers need to be linked to registered case
+	err = s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", "", time.Time{}, "")
+	assert.IsType(t, &storage.InvalidSupportCaseError{}, err)
+	err = s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", "", time.Time{}, "unknown")
+	assert.IsType(t, &storage.InvalidSupportCaseError{}, err)
+
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "first", "link", "", time.Time{}, "01234567"))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "other", "user", "second", "link", "", time.Time{}, "01234567"))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "other", "user", "third", "link", "", time.Time{}, ""))
+
+	triggers, err := s.ListSupportCaseTriggers(ctx, "01234567")
+	FailOnError(t, err)
+	if assert.Len(t, triggers, 2) {
+		assert.Equal(t, "first", triggers[0].Reason)
+		assert.Equal(t, "01234567", triggers[0].CaseNumber)
+		assert.Equal(t, "second", triggers[1].Reason)
+	}
+
+	triggers, err = s.ListSupportCaseTriggers(ctx, "76543210")
+	FailOnError(t, err)
+	assert.Empty(t, triggers)
+
+	_, err = s.ListSupportCaseTriggers(ctx, "unknown")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	trigger, err := s.GetTriggerByID(ctx, 3)
+	FailOnError(t, err)
+	assert.Empty(t, trigger.CaseNumber)
+
+	// closed cases do not accept new triggers
+	FailOnError(t, s.ChangeSupportCaseStatus(ctx, "01234567", storage.SupportCaseClosed))
+	err = s.NewTrigger(ctx, "cluster", "other", "user", "reason", "link", "", time.Time{}, "01234567")
+	assert.IsType(t, &storage.InvalidSupportCaseError{}, err)
+
+	err = s.ChangeSupportCaseStatus(ctx, "unknown", storage.SupportCaseClosed)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// case is optional when the trigger type does not require it
+	FailOnError(t, s.SetTriggerTypeRequiresCase(ctx, "must-gather", false))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", "", time.Time{}, ""))
+}
+
+// TestSupportCases checks support cases stored in SQL database
+func TestSupportCases(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkSupportCases(t, mockStorage)
+}
+
+// TestMemoryStorageSupportCases checks support cases stored in memory
+func TestMemoryStorageSupportCases(t *testing.T) {
+	checkSupportCases(t, storage.NewMemoryStorage())
+}
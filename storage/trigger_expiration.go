@@ -0,0 +1,80 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/trigger_expiration.html
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// MustGatherDefaultTTL is default time to live of must-gather triggers. The
+// same value is registered by database migration.
+const MustGatherDefaultTTL = 7 * 24 * time.Hour
+
+// triggerExpiration returns time when new trigger expires. Explicitly
+// requested expiration is used when it is set, otherwise the expiration is
+// computed from default time to live of the trigger type. Zero time is
+// returned for triggers that do not expire.
+func triggerExpiration(triggerType TriggerType, triggeredAt, expiresAt time.Time) time.Time {
+	if !expiresAt.IsZero() || triggerType.DefaultTTL <= 0 {
+		return expiresAt
+	}
+	return triggeredAt.Add(time.Duration(triggerType.DefaultTTL) * time.Second)
+}
+
+// nullableString scans nullable column into string field, NULL is stored as
+// empty string
+type nullableString struct {
+	target *string
+}
+
+// Scan implements sql.Scanner interface
+func (n nullableString) Scan(value interface{}) error {
+	var result sql.NullString
+	if err := result.Scan(value); err != nil {
+		return err
+	}
+	*n.target = result.String
+	return nil
+}
+
+// RunTriggerSweeper periodically marks expired triggers until the context is
+// cancelled
+func RunTriggerSweeper(ctx context.Context, storage Storage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			expired, err := storage.ExpireTriggers(ctx, now)
+			if err != nil {
+				log.Println("Unable to expire triggers", err)
+			} else if expired > 0 {
+				log.Printf("%d triggers expired", expired)
+			}
+		}
+	}
+}
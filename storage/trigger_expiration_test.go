@@ -0,0 +1,181 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/trigger_expiration_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkTriggerExpiration checks that expired triggers are not returned to the
+// operator and that they are marked as expired
+func checkTriggerExpiration(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.NewTriggerType(ctx, "must-gather", "must-gather", ""))
+	FailOnError(t, s.NewTriggerType(ctx, "other", "other", ""))
+	FailOnError(t, s.SetTriggerTypeDefaultTTL(ctx, "must-gather", time.Hour))
+
+	err := s.SetTriggerTypeDefaultTTL(ctx, "unknown", time.Hour)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	triggerType, err := s.GetTriggerType(ctx, "must-gather")
+	FailOnError(t, err)
+	assert.Equal(t, 3600, triggerType.DefaultTTL)
+
+	// default TTL of trigger type, no TTL, and explicit expiration
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "default", "link", "", time.Time{}, ""))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "other", "user", "never", "link", "", time.Time{}, ""))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "other", "user", "expired", "link", "", time.Now().Add(-time.Minute), ""))
+
+	triggers, err := s.ListClusterTriggers(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Len(t, triggers, 3)
+	for _, trigger := range triggers {
+		switch trigger.Reason {
+		case "never":
+			assert.Empty(t, trigger.ExpiresAt)
+		default:
+			assert.NotEmpty(t, trigger.ExpiresAt)
+		}
+		assert.Equal(t, 0, trigger.Expired)
+	}
+
+	// expired trigger is not returned to the operator even before it is marked
+	triggers, err = s.ListActiveClusterTriggers(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Len(t, triggers, 2)
+
+	expired, err := s.ExpireTriggers(ctx, time.Now())
+	FailOnError(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	// triggers are expired only once
+	expired, err = s.ExpireTriggers(ctx, time.Now())
+	FailOnError(t, err)
+	assert.Equal(t, int64(0), expired)
+
+	triggers, err = s.ListClusterTriggers(ctx, "cluster")
+	FailOnError(t, err)
+	for _, trigger := range triggers {
+		if trigger.Reason == "expired" {
+			assert.Equal(t, 0, trigger.Active)
+			assert.Equal(t, 1, trigger.Expired)
+		} else {
+			assert.Equal(t, 1, trigger.Active)
+			assert.Equal(t, 0, trigger.Expired)
+		}
+	}
+
+	// all triggers with TTL expire eventually
+	expired, err = s.ExpireTriggers(ctx, time.Now().Add(2*time.Hour))
+	FailOnError(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	// new triggers do not expire when TTL is reset
+	FailOnError(t, s.SetTriggerTypeDefaultTTL(ctx, "must-gather", 0))
+	triggerType, err = s.GetTriggerType(ctx, "must-gather")
+	FailOnError(t, err)
+	assert.Equal(t, 0, triggerType.DefaultTTL)
+}
+
+// checkTriggerExpirationTimeZones checks that expiration of triggers does not
+// depend on time zones of the timestamps
+func checkTriggerExpirationTimeZones(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	west := time.FixedZone("west", -10*60*60)
+	east := time.FixedZone("east", 10*60*60)
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.NewTriggerType(ctx, "other", "other", ""))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "other", "user", "reason", "link", "", time.Now().In(west).Add(time.Hour), ""))
+
+	triggers, err := s.ListActiveClusterTriggers(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Len(t, triggers, 1)
+
+	expired, err := s.ExpireTriggers(ctx, time.Now().In(east))
+	FailOnError(t, err)
+	assert.Equal(t, int64(0), expired)
+
+	expired, err = s.ExpireTriggers(ctx, time.Now().In(east).Add(2*time.Hour))
+	FailOnError(t, err)
+	assert.Equal(t, int64(1), expired)
+}
+
+// TestTriggerExpiration checks expiration of triggers stored in SQL database
+func TestTriggerExpiration(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkTriggerExpiration(t, mockStorage)
+}
+
+// TestMemoryStorageTriggerExpiration checks expiration of triggers stored in memory
+func TestMemoryStorageTriggerExpiration(t *testing.T) {
+	checkTriggerExpiration(t, storage.NewMemoryStorage())
+}
+
+// TestTriggerExpirationTimeZones checks expiration of triggers stored in SQL
+// database with timestamps in different time zones
+func TestTriggerExpirationTimeZones(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkTriggerExpirationTimeZones(t, mockStorage)
+}
+
+// TestMemoryStorageTriggerExpirationTimeZones checks expiration of triggers
+// stored in memory with timestamps in different time zones
+func TestMemoryStorageTriggerExpirationTimeZones(t *testing.T) {
+	checkTriggerExpirationTimeZones(t, storage.NewMemoryStorage())
+}
+
+// TestRunTriggerSweeper checks that the sweeper marks expired triggers and
+// that it stops when its context is cancelled
+func TestRunTriggerSweeper(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.NewTriggerType(ctx, "must-gather", "must-gather", ""))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", "", time.Now().Add(-time.Minute), ""))
+
+	sweeperCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		storage.RunTriggerSweeper(sweeperCtx, s, time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		trigger, err := s.GetTriggerByID(ctx, 1)
+		return err == nil && trigger.Expired == 1
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/trigger_parameters.html
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/RedHatInsights/insights-operator-controller/utils"
+)
+
+// MustGatherParametersSchema is JSON schema of parameters of must-gather
+// triggers. The same schema is registered by database migration.
+const MustGatherParametersSchema = `{"type": "object", "properties": {"image": {"type": "string", "minLength": 1, "description": "must-gather image"}, "timeout": {"type": "integer", "minimum": 1, "description": "timeout in seconds"}, "namespaces": {"type": "array", "items": {"type": "string"}, "description": "namespaces to gather data from"}}, "additionalProperties": false}`
+
+// emptyParameters are stored for triggers created without parameters
+const emptyParameters = "{}"
+
+// checkParametersSchema checks that parameters schema of trigger type is
+// valid JSON schema. Empty schema is accepted too.
+func checkParametersSchema(triggerType, parametersSchema string) error {
+	if parametersSchema == "" {
+		return nil
+	}
+	if err := utils.CheckJSONSchema(parametersSchema); err != nil {
+		return &InvalidParametersError{TriggerType: triggerType, Reason: err}
+	}
+	return nil
+}
+
+// triggerParameters checks that parameters of new trigger are JSON object
+// conforming to parameters schema of the trigger type and returns them in
+// compact form that is stored in the database
+func triggerParameters(triggerType TriggerType, parameters string) (string, error) {
+	if parameters == "" {
+		return emptyParameters, nil
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(parameters), &object); err != nil || object == nil {
+		return "", &InvalidParametersError{
+			TriggerType: triggerType.Type,
+			Reason:      errors.New("parameters need to be a JSON object"),
+		}
+	}
+
+	if triggerType.ParametersSchema != "" {
+		err := utils.ValidateJSONSchema(triggerType.ParametersSchema, parameters)
+		if err != nil {
+			return "", &InvalidParametersError{TriggerType: triggerType.Type, Reason: err}
+		}
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(parameters)); err != nil {
+		return "", &InvalidParametersError{TriggerType: triggerType.Type, Reason: err}
+	}
+	return compacted.String(), nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/trigger_parameters_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkTriggerParameters checks that trigger parameters are validated against
+// JSON schema of the trigger type and stored
+func checkTriggerParameters(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.NewTriggerType(ctx, "must-gather", "must-gather", storage.MustGatherParametersSchema))
+	FailOnError(t, s.NewTriggerType(ctx, "other", "other", ""))
+
+	triggerType, err := s.GetTriggerType(ctx, "must-gather")
+	FailOnError(t, err)
+	assert.Equal(t, "must-gather", triggerType.Type)
+	assert.Equal(t, storage.MustGatherParametersSchema, triggerType.ParametersSchema)
+
+	_, err = s.GetTriggerType(ctx, "unknown")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// schema of trigger type needs to be valid
+	err = s.NewTriggerType(ctx, "wrong", "wrong", `{"type": "unknown"}`)
+	assert.IsType(t, &storage.InvalidParametersError{}, err)
+
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link",
+		`{ "image": "quay.io/must-gather", "timeout": 60 }`, time.Time{}, ""))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", "", time.Time{}, ""))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "other", "user", "reason", "link", `{"anything": [1, 2]}`, time.Time{}, ""))
+
+	// parameters need to conform to the schema
+	for _, parameters := range []string{
+		`{"image": ""}`,
+		`{"timeout": "60"}`,
+		`{"unknown": true}`,
+		`["image"]`,
+		`null`,
+		`{"image": `,
+	} {
+		err = s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", parameters, time.Time{}, "")
+		assert.IsType(t, &storage.InvalidParametersError{}, err, parameters)
+	}
+
+	// parameters need to be JSON object even when there is no schema
+	err = s.NewTrigger(ctx, "cluster", "other", "user", "reason", "link", `"string"`, time.Time{}, "")
+	assert.IsType(t, &storage.InvalidParametersError{}, err)
+
+	err = s.NewTrigger(ctx, "cluster", "unknown", "user", "reason", "link", "", time.Time{}, "")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	triggers, err := s.ListActiveClusterTriggers(ctx, "cluster")
+	FailOnError(t, err)
+	parameters := []string{}
+	for _, trigger := range triggers {
+		parameters = append(parameters, trigger.Parameters)
+	}
+	assert.ElementsMatch(t, []string{
+		`{"image":"quay.io/must-gather","timeout":60}`,
+		`{}`,
+		`{"anything":[1,2]}`,
+	}, parameters)
+}
+
+// TestTriggerParameters checks parameters of triggers stored in SQL database
+func TestTriggerParameters(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkTriggerParameters(t, mockStorage)
+}
+
+// TestMemoryStorageTriggerParameters checks parameters of triggers stored in memory
+func TestMemoryStorageTriggerParameters(t *testing.T) {
+	checkTriggerParameters(t, storage.NewMemoryStorage())
+}
@@ -0,0 +1,238 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/trigger_query.html
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RedHatInsights/insights-operator-controller/utils"
+)
+
+// SearchTriggerRequest defines type safe SearchTrigger request, it is reused and defines request validation tags
+type SearchTriggerRequest struct {
+	utils.Pagination
+	ID            int    `schema:"id"`
+	Cluster       string `schema:"cluster"`
+	Type          string `schema:"type"`
+	Active        string `schema:"active"`
+	Expired       string `schema:"expired"`
+	State         string `schema:"state"`
+	Case          string `schema:"case"`
+	Reason        string `schema:"reason"`
+	TriggeredBy   string `schema:"triggered_by"`
+	TriggeredFrom string `schema:"triggered_from"`
+	TriggeredTo   string `schema:"triggered_to"`
+	OrderBy       string `schema:"order_by"`
+	Order         string `schema:"order"`
+}
+
+// TriggerQuery is Sql query model for Trigger
+type TriggerQuery struct {
+	storage Storager
+
+	Cols          TriggerCols
+	selectColumns []TriggerCol
+	From          string
+}
+
+// triggerFrom contains all tables that need to be joined to read triggers
+var triggerFrom = `trigger JOIN trigger_type ON trigger.type=trigger_type.id JOIN cluster ON trigger.cluster=cluster.id LEFT JOIN support_case ON trigger.support_case=support_case.id`
+
+// TriggerCols defines which columns can be used in Trigger queries, just for type safe operations with them
+type TriggerCols struct {
+	ID          TriggerCol
+	Type        TriggerCol
+	Cluster     TriggerCol
+	Reason      TriggerCol
+	Link        TriggerCol
+	TriggeredAt TriggerCol
+	TriggeredBy TriggerCol
+	Parameters  TriggerCol
+	Active      TriggerCol
+	ExpiresAt   TriggerCol
+	Expired     TriggerCol
+	State       TriggerCol
+	Result      TriggerCol
+	CaseNumber  TriggerCol
+}
+
+// TriggerCol is type of trigger column
+type TriggerCol Column
+
+// ColumnName returns name of the column used in SQL queries
+func (c TriggerCol) ColumnName() Column {
+	return Column(c)
+}
+
+// triggerColsDef is filling TriggerCols structure with actual column names
+var triggerColsDef = TriggerCols{
+	ID:          TriggerCol("trigger.id"),
+	Type:        TriggerCol("trigger_type.type"),
+	Cluster:     TriggerCol("cluster.name"),
+	Reason:      TriggerCol("trigger.reason"),
+	Link:        TriggerCol("trigger.link"),
+	TriggeredAt: TriggerCol("trigger.triggered_at"),
+	TriggeredBy: TriggerCol("trigger.triggered_by"),
+	Parameters:  TriggerCol("trigger.parameters"),
+	Active:      TriggerCol(triggerActiveColumn),
+	ExpiresAt:   TriggerCol("trigger.expires_at"),
+	Expired:     TriggerCol(triggerExpiredColumn),
+	State:       TriggerCol("trigger.state"),
+	Result:      TriggerCol("trigger.result"),
+	CaseNumber:  TriggerCol("support_case.case_number"),
+}
+
+var triggerCols = []TriggerCol{
+	triggerColsDef.ID, triggerColsDef.Type, triggerColsDef.Cluster,
+	triggerColsDef.Reason, triggerColsDef.Link,
+	triggerColsDef.TriggeredAt, triggerColsDef.TriggeredBy,
+	triggerColsDef.Parameters, triggerColsDef.Active,
+	triggerColsDef.ExpiresAt, triggerColsDef.Expired, triggerColsDef.State,
+	triggerColsDef.Result, triggerColsDef.CaseNumber,
+}
+
+// TriggerOrderColumns contains columns that triggers can be ordered by,
+// indexed by names used in search requests
+var TriggerOrderColumns = map[string]TriggerCol{
+	"id":           triggerColsDef.ID,
+	"type":         triggerColsDef.Type,
+	"cluster":      triggerColsDef.Cluster,
+	"triggered_at": triggerColsDef.TriggeredAt,
+	"triggered_by": triggerColsDef.TriggeredBy,
+	"active":       triggerColsDef.Active,
+	"expires_at":   triggerColsDef.ExpiresAt,
+	"state":        triggerColsDef.State,
+}
+
+// NewTriggerQuery creates new TriggerQuery Sql model
+func NewTriggerQuery(s Storager) *TriggerQuery {
+	return &TriggerQuery{
+		storage:       s,
+		Cols:          triggerColsDef,
+		selectColumns: triggerCols,
+		From:          triggerFrom,
+	}
+}
+
+// columns converts list of selected TriggerCols to columns accepted by QueryBuilder
+func (c *TriggerQuery) columns() []QueryColumn {
+	cols := []QueryColumn{}
+	for _, col := range c.selectColumns {
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// Query exposes generic queryBuilder that selects Trigger columns
+func (c *TriggerQuery) Query() QueryBuilder {
+	return NewQueryBuilder(c.storage, c.From, c.columns()...)
+}
+
+// mapCol defines mapping from type safe column to a struct field in result Trigger.
+// Used by db row.Scan
+func (c *TriggerQuery) mapCol(storageCol Column, trigger interface{}) (interface{}, error) {
+	col := TriggerCol(storageCol)
+	r := trigger.(*Trigger)
+	switch col {
+	case triggerColsDef.ID:
+		return &r.ID, nil
+	case triggerColsDef.Type:
+		return &r.Type, nil
+	case triggerColsDef.Cluster:
+		return &r.Cluster, nil
+	case triggerColsDef.Reason:
+		return &r.Reason, nil
+	case triggerColsDef.Link:
+		return &r.Link, nil
+	case triggerColsDef.TriggeredAt:
+		return &r.TriggeredAt, nil
+	case triggerColsDef.TriggeredBy:
+		return &r.TriggeredBy, nil
+	case triggerColsDef.Parameters:
+		return &r.Parameters, nil
+	case triggerColsDef.Active:
+		return &r.Active, nil
+	case triggerColsDef.ExpiresAt:
+		// triggers that do not expire have NULL expiration
+		return nullableString{&r.ExpiresAt}, nil
+	case triggerColsDef.Expired:
+		return &r.Expired, nil
+	case triggerColsDef.State:
+		return &r.State, nil
+	case triggerColsDef.Result:
+		// result is stored as JSON document, NULL for triggers without result
+		return triggerResultColumn{&r.Result}, nil
+	case triggerColsDef.CaseNumber:
+		// triggers that are not linked to support case have NULL case number
+		return nullableString{&r.CaseNumber}, nil
+	default:
+		return nil, fmt.Errorf("unknown col %s", col)
+	}
+}
+
+// QueryMany will query DB with generated command and return all triggers that match the request
+func (c *TriggerQuery) QueryMany(ctx context.Context, req SearchTriggerRequest) ([]Trigger, error) {
+	if c == nil {
+		panic("TriggerQuery must not be nil. Make sure the Server has a pointer reference to TriggerQuery by calling NewTriggerQuery().")
+	}
+	orderBy, found := TriggerOrderColumns[req.OrderBy]
+	if req.OrderBy == "" {
+		orderBy, found = c.Cols.ID, true
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown col %s", req.OrderBy)
+	}
+
+	qb := c.Query().
+		Equals(c.Cols.ID, req.ID).
+		Equals(c.Cols.Cluster, req.Cluster).
+		In(c.Cols.Type, SplitValues(req.Type)...).
+		Equals(c.Cols.Active, req.Active).
+		Equals(c.Cols.Expired, req.Expired).
+		In(c.Cols.State, SplitValues(req.State)...).
+		Equals(c.Cols.CaseNumber, req.Case).
+		Like(c.Cols.Reason, LikePattern(req.Reason)).
+		Equals(c.Cols.TriggeredBy, req.TriggeredBy).
+		Range(c.Cols.TriggeredAt, req.TriggeredFrom, req.TriggeredTo).
+		OrderBy(orderBy, SortOrder(req.Order)).
+		WithPaging(req.Limit, req.Offset)
+
+	triggers := []*Trigger{}
+	next := func() interface{} {
+		trigger := &Trigger{}
+		triggers = append(triggers, trigger)
+		return trigger
+	}
+
+	err := c.storage.QueryMany(ctx, storageColumns(c.columns()), qb.sb, c.mapCol, next)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Trigger{}
+	for _, trigger := range triggers {
+		result = append(result, *trigger)
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/trigger_result.html
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// TriggerResult represents result of trigger reported by the operator
+//     Status: final state of the trigger, succeeded or failed
+//     Error: error message of failed trigger
+//     Duration: how long the trigger has been running (in seconds)
+//     Location: location of gathered data, for example S3 key
+//     Size: size of gathered data in bytes
+//     ReportedAt: timestamp when the result has been reported
+type TriggerResult struct {
+	Status     TriggerState `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	Duration   float64      `json:"duration"`
+	Location   string       `json:"location,omitempty"`
+	Size       int64        `json:"size"`
+	ReportedAt string       `json:"reported_at"`
+}
+
+// checkTriggerResult checks that the result can be stored with trigger
+func checkTriggerResult(result TriggerResult) error {
+	switch {
+	case result.Status != TriggerSucceeded && result.Status != TriggerFailed:
+		return &InvalidTriggerResultError{Reason: errors.New("status needs to be succeeded or failed")}
+	case result.Duration < 0:
+		return &InvalidTriggerResultError{Reason: errors.New("duration can not be negative")}
+	case result.Size < 0:
+		return &InvalidTriggerResultError{Reason: errors.New("size can not be negative")}
+	}
+	return nil
+}
+
+// triggerResultColumn scans nullable column with JSON document into trigger
+// result, NULL is used for triggers without result
+type triggerResultColumn struct {
+	target **TriggerResult
+}
+
+// Scan implements sql.Scanner interface
+func (c triggerResultColumn) Scan(value interface{}) error {
+	var document sql.NullString
+	if err := document.Scan(value); err != nil {
+		return err
+	}
+	if !document.Valid || document.String == "" {
+		*c.target = nil
+		return nil
+	}
+
+	result := &TriggerResult{}
+	if err := json.Unmarshal([]byte(document.String), result); err != nil {
+		return err
+	}
+	*c.target = result
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/trigger_result_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkTriggerResults checks that results reported by the operator are
+// stored with triggers and that they finish the triggers
+func checkTriggerResults(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "other"))
+	FailOnError(t, s.NewTriggerType(ctx, "must-gather", "must-gather", ""))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", "", time.Time{}, ""))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", "", time.Time{}, ""))
+
+	trigger, err := s.GetTriggerByID(ctx, 1)
+	FailOnError(t, err)
+	assert.Nil(t, trigger.Result)
+
+	result := storage.TriggerResult{
+		Status:   storage.TriggerSucceeded,
+		Duration: 42.5,
+		Location: "must-gather/cluster/1.tar.gz",
+		Size:     1024,
+	}
+
+	// pending trigger can not be finished
+	err = s.SetTriggerResult(ctx, "cluster", 1, result)
+	assert.IsType(t, &storage.InvalidTriggerTransitionError{}, err)
+
+	// result can be reported for triggers of the cluster only
+	err = s.SetTriggerResult(ctx, "other", 1, result)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	err = s.SetTriggerResult(ctx, "cluster", 1, storage.TriggerResult{Status: storage.TriggerRunning})
+	assert.IsType(t, &storage.InvalidTriggerResultError{}, err)
+	err = s.SetTriggerResult(ctx, "cluster", 1, storage.TriggerResult{Status: storage.TriggerFailed, Size: -1})
+	assert.IsType(t, &storage.InvalidTriggerResultError{}, err)
+
+	FailOnError(t, s.ChangeClusterTriggerState(ctx, "cluster", 1, storage.TriggerRunning))
+	FailOnError(t, s.SetTriggerResult(ctx, "cluster", 1, result))
+
+	trigger, err = s.GetTriggerByID(ctx, 1)
+	FailOnError(t, err)
+	assert.Equal(t, storage.TriggerSucceeded, trigger.State)
+	if assert.NotNil(t, trigger.Result) {
+		assert.Equal(t, result.Location, trigger.Result.Location)
+		assert.Equal(t, result.Size, trigger.Result.Size)
+		assert.Equal(t, result.Duration, trigger.Result.Duration)
+		assert.NotEmpty(t, trigger.Result.ReportedAt)
+	}
+
+	// result of failed trigger is shown in the cluster trigger list
+	FailOnError(t, s.AckTrigger(ctx, "cluster", 2))
+	FailOnError(t, s.SetTriggerResult(ctx, "cluster", 2, storage.TriggerResult{
+		Status: storage.TriggerFailed,
+		Error:  "unable to upload data",
+	}))
+
+	triggers, err := s.ListClusterTriggers(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Len(t, triggers, 2)
+	assert.Equal(t, storage.TriggerFailed, triggers[1].State)
+	if assert.NotNil(t, triggers[1].Result) {
+		assert.Equal(t, "unable to upload data", triggers[1].Result.Error)
+	}
+}
+
+// TestTriggerResults checks results of triggers stored in SQL database
+func TestTriggerResults(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkTriggerResults(t, mockStorage)
+}
+
+// TestMemoryStorageTriggerResults checks results of triggers stored in memory
+func TestMemoryStorageTriggerResults(t *testing.T) {
+	checkTriggerResults(t, storage.NewMemoryStorage())
+}
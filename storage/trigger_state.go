@@ -0,0 +1,124 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/storage
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/trigger_state.html
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TriggerState represents state of trigger in its lifecycle
+type TriggerState string
+
+// States of triggers. New triggers are pending until the operator picks them
+// up. Succeeded, failed, cancelled, and expired triggers are finished, but
+// cancelled and failed triggers can be activated again.
+const (
+	TriggerPending   TriggerState = "pending"
+	TriggerDelivered TriggerState = "delivered"
+	TriggerRunning   TriggerState = "running"
+	TriggerSucceeded TriggerState = "succeeded"
+	TriggerFailed    TriggerState = "failed"
+	TriggerCancelled TriggerState = "cancelled"
+	TriggerExpired   TriggerState = "expired"
+)
+
+// triggerTransitions contains states that trigger can move to from its
+// current state
+var triggerTransitions = map[TriggerState][]TriggerState{
+	TriggerPending:   {TriggerDelivered, TriggerRunning, TriggerCancelled, TriggerExpired},
+	TriggerDelivered: {TriggerRunning, TriggerSucceeded, TriggerFailed, TriggerCancelled},
+	TriggerRunning:   {TriggerSucceeded, TriggerFailed, TriggerCancelled},
+	TriggerFailed:    {TriggerPending},
+	TriggerCancelled: {TriggerPending},
+}
+
+// TriggerTransition represents one change of trigger state
+//     State: state the trigger moved to
+//     ChangedAt: timestamp of the change
+//     ChangedBy: username of admin or name of cluster which changed the state
+type TriggerTransition struct {
+	State     TriggerState `json:"state"`
+	ChangedAt string       `json:"changed_at"`
+	ChangedBy string       `json:"changed_by"`
+}
+
+// ParseTriggerState converts name of state into TriggerState, error is
+// returned for unknown states
+func ParseTriggerState(state string) (TriggerState, error) {
+	switch s := TriggerState(state); s {
+	case TriggerPending, TriggerDelivered, TriggerRunning, TriggerSucceeded,
+		TriggerFailed, TriggerCancelled, TriggerExpired:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown trigger state %s", state)
+}
+
+// ParseTriggerStates converts comma separated list of states into
+// TriggerStates, error is returned when any of states is unknown
+func ParseTriggerStates(states string) ([]TriggerState, error) {
+	result := []TriggerState{}
+	for _, state := range strings.Split(states, ",") {
+		if state = strings.TrimSpace(state); state == "" {
+			continue
+		}
+		s, err := ParseTriggerState(state)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// CanChangeTriggerState checks whether trigger in state from can move to
+// state to
+func CanChangeTriggerState(from, to TriggerState) bool {
+	for _, state := range triggerTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// triggerActiveColumn and triggerExpiredColumn derive active and expired
+// flags of triggers from their states in SQL queries the same way as
+// triggerFlags does. The flags are cast to integer, so SQLite compares them
+// with values of query parameters as numbers. Legacy active and expired
+// columns of the trigger table are neither read nor written.
+const (
+	triggerActiveColumn  = "CAST(CASE WHEN trigger.state = 'pending' THEN 1 ELSE 0 END AS INTEGER)"
+	triggerExpiredColumn = "CAST(CASE WHEN trigger.state = 'expired' THEN 1 ELSE 0 END AS INTEGER)"
+)
+
+// triggerFlags returns values of active and expired flags of trigger in the
+// given state. The flags are kept for clients that do not know trigger states
+// yet, only pending triggers are active.
+func triggerFlags(state TriggerState) (active, expired int) {
+	if state == TriggerPending {
+		active = 1
+	}
+	if state == TriggerExpired {
+		expired = 1
+	}
+	return active, expired
+}
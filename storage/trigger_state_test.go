@@ -0,0 +1,139 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/trigger_state_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkTriggerStates checks that triggers go through their lifecycle states,
+// that invalid transitions are refused, and that all transitions are recorded
+func checkTriggerStates(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.RegisterNewCluster(ctx, "other"))
+	FailOnError(t, s.NewTriggerType(ctx, "must-gather", "must-gather", ""))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", "", time.Time{}, ""))
+
+	trigger, err := s.GetTriggerByID(ctx, 1)
+	FailOnError(t, err)
+	assert.Equal(t, storage.TriggerPending, trigger.State)
+	assert.Equal(t, 1, trigger.Active)
+
+	// operator of other cluster can not change the trigger
+	err = s.ChangeClusterTriggerState(ctx, "other", 1, storage.TriggerRunning)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// pending trigger can not succeed before it is delivered or started
+	err = s.ChangeClusterTriggerState(ctx, "cluster", 1, storage.TriggerSucceeded)
+	assert.IsType(t, &storage.InvalidTriggerTransitionError{}, err)
+
+	FailOnError(t, s.AckTrigger(ctx, "cluster", 1))
+	// reporting the current state again does nothing
+	FailOnError(t, s.AckTrigger(ctx, "cluster", 1))
+
+	trigger, err = s.GetTriggerByID(ctx, 1)
+	FailOnError(t, err)
+	assert.Equal(t, storage.TriggerDelivered, trigger.State)
+	assert.Equal(t, 0, trigger.Active)
+
+	// delivered triggers are not returned to the operator again
+	triggers, err := s.ListActiveClusterTriggers(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Len(t, triggers, 0)
+
+	FailOnError(t, s.ChangeClusterTriggerState(ctx, "cluster", 1, storage.TriggerRunning))
+	FailOnError(t, s.ChangeClusterTriggerState(ctx, "cluster", 1, storage.TriggerFailed))
+
+	// failed trigger can be activated again
+	FailOnError(t, s.ChangeTriggerState(ctx, 1, storage.TriggerPending, "admin"))
+	FailOnError(t, s.ChangeTriggerState(ctx, 1, storage.TriggerCancelled, "admin"))
+
+	// finished trigger can not be started
+	err = s.ChangeClusterTriggerState(ctx, "cluster", 1, storage.TriggerRunning)
+	assert.IsType(t, &storage.InvalidTriggerTransitionError{}, err)
+
+	err = s.ChangeTriggerState(ctx, 42, storage.TriggerCancelled, "admin")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	transitions, err := s.ListTriggerTransitions(ctx, 1)
+	FailOnError(t, err)
+	states := []storage.TriggerState{}
+	for _, transition := range transitions {
+		assert.NotEmpty(t, transition.ChangedAt)
+		states = append(states, transition.State)
+	}
+	assert.Equal(t, []storage.TriggerState{
+		storage.TriggerPending, storage.TriggerDelivered, storage.TriggerRunning,
+		storage.TriggerFailed, storage.TriggerPending, storage.TriggerCancelled,
+	}, states)
+	assert.Equal(t, "user", transitions[0].ChangedBy)
+	assert.Equal(t, "cluster", transitions[1].ChangedBy)
+	assert.Equal(t, "admin", transitions[5].ChangedBy)
+
+	_, err = s.ListTriggerTransitions(ctx, 42)
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// expiration is recorded as transition too
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "must-gather", "user", "reason", "link", "", time.Now().Add(-time.Minute), ""))
+	expired, err := s.ExpireTriggers(ctx, time.Now())
+	FailOnError(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	trigger, err = s.GetTriggerByID(ctx, 2)
+	FailOnError(t, err)
+	assert.Equal(t, storage.TriggerExpired, trigger.State)
+	assert.Equal(t, 1, trigger.Expired)
+
+	transitions, err = s.ListTriggerTransitions(ctx, 2)
+	FailOnError(t, err)
+	assert.Len(t, transitions, 2)
+	assert.Equal(t, storage.TriggerExpired, transitions[1].State)
+}
+
+// TestTriggerStates checks lifecycle of triggers stored in SQL database
+func TestTriggerStates(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkTriggerStates(t, mockStorage)
+}
+
+// TestMemoryStorageTriggerStates checks lifecycle of triggers stored in memory
+func TestMemoryStorageTriggerStates(t *testing.T) {
+	checkTriggerStates(t, storage.NewMemoryStorage())
+}
+
+// TestParseTriggerStates checks parsing of comma separated list of states
+func TestParseTriggerStates(t *testing.T) {
+	states, err := storage.ParseTriggerStates("pending, running,,failed")
+	FailOnError(t, err)
+	assert.Equal(t, []storage.TriggerState{storage.TriggerPending, storage.TriggerRunning, storage.TriggerFailed}, states)
+
+	_, err = storage.ParseTriggerStates("pending,unknown")
+	assert.Error(t, err)
+}
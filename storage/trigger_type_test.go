@@ -0,0 +1,102 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/trigger_type_test.html
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkTriggerTypes checks listing, creation, change, and deprecation of
+// trigger types
+func checkTriggerTypes(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	triggerTypes, err := s.ListTriggerTypes(ctx)
+	FailOnError(t, err)
+	assert.Len(t, triggerTypes, 0)
+
+	FailOnError(t, s.NewTriggerType(ctx, "must-gather", "must-gather", storage.MustGatherParametersSchema))
+	FailOnError(t, s.NewTriggerType(ctx, "other", "other", ""))
+
+	// trigger type can not be created twice
+	err = s.NewTriggerType(ctx, "other", "other", "")
+	assert.IsType(t, &storage.ItemAlreadyExistsError{}, err)
+
+	triggerTypes, err = s.ListTriggerTypes(ctx)
+	FailOnError(t, err)
+	assert.Len(t, triggerTypes, 2)
+	assert.Equal(t, "must-gather", triggerTypes[0].Type)
+	assert.Equal(t, "other", triggerTypes[1].Type)
+
+	_, err = s.GetTriggerID(ctx, "unknown")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	FailOnError(t, s.ChangeTriggerTypeDescription(ctx, "other", "new description"))
+	triggerType, err := s.GetTriggerType(ctx, "other")
+	FailOnError(t, err)
+	assert.Equal(t, "new description", triggerType.Description)
+
+	err = s.ChangeTriggerTypeDescription(ctx, "unknown", "description")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// new triggers of deprecated trigger type can not be created
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+	FailOnError(t, s.NewTrigger(ctx, "cluster", "other", "user", "reason", "link", "", time.Time{}, ""))
+	FailOnError(t, s.DeprecateTriggerType(ctx, "other", "admin"))
+
+	err = s.NewTrigger(ctx, "cluster", "other", "user", "reason", "link", "", time.Time{}, "")
+	assert.IsType(t, &storage.DeprecatedTriggerTypeError{}, err)
+
+	triggerType, err = s.GetTriggerType(ctx, "other")
+	FailOnError(t, err)
+	assert.NotEmpty(t, triggerType.DeprecatedAt)
+	assert.Equal(t, "admin", triggerType.DeprecatedBy)
+
+	// existing triggers are kept
+	triggers, err := s.ListClusterTriggers(ctx, "cluster")
+	FailOnError(t, err)
+	assert.Len(t, triggers, 1)
+
+	// trigger type can not be deprecated twice
+	err = s.DeprecateTriggerType(ctx, "other", "admin")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	err = s.DeprecateTriggerType(ctx, "unknown", "admin")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+}
+
+// TestTriggerTypes checks trigger types stored in SQL database
+func TestTriggerTypes(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkTriggerTypes(t, mockStorage)
+}
+
+// TestMemoryStorageTriggerTypes checks trigger types stored in memory
+func TestMemoryStorageTriggerTypes(t *testing.T) {
+	checkTriggerTypes(t, storage.NewMemoryStorage())
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/storage/version_test.html
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/storage"
+)
+
+// checkConfigurationProfileVersions checks that configuration profile is
+// changed or deleted only when it is at the expected version
+func checkConfigurationProfileVersions(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	profiles, err := s.StoreConfigurationProfile(ctx, "user", "description", "{}")
+	FailOnError(t, err)
+	id := int(profiles[len(profiles)-1].ID)
+	assert.Equal(t, 1, profiles[len(profiles)-1].Version)
+
+	_, err = s.ChangeConfigurationProfile(ctx, id, 1, "user", "description", `{"a":1}`)
+	FailOnError(t, err)
+
+	profile, err := s.GetConfigurationProfile(ctx, id)
+	FailOnError(t, err)
+	assert.Equal(t, 2, profile.Version)
+
+	// version 1 is not the current one anymore
+	_, err = s.ChangeConfigurationProfile(ctx, id, 1, "user", "description", `{"a":2}`)
+	assert.IsType(t, &storage.VersionMismatchError{}, err)
+
+	_, err = s.DeleteConfigurationProfile(ctx, id, 1, "user")
+	assert.IsType(t, &storage.VersionMismatchError{}, err)
+
+	// refused change does not create any revision
+	revisions, err := s.ListConfigurationProfileRevisions(ctx, id)
+	FailOnError(t, err)
+	assert.Len(t, revisions, 2)
+
+	// not found error takes precedence over version mismatch
+	_, err = s.ChangeConfigurationProfile(ctx, id+1, 1, "user", "description", "{}")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	_, err = s.ChangeConfigurationProfile(ctx, id, storage.AnyVersion, "user", "description", `{"a":3}`)
+	FailOnError(t, err)
+
+	_, err = s.DeleteConfigurationProfile(ctx, id, 3, "user")
+	FailOnError(t, err)
+}
+
+// checkClusterConfigurationVersions checks that cluster configuration is
+// changed or deleted only when it is at the expected version
+func checkClusterConfigurationVersions(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	FailOnError(t, s.RegisterNewCluster(ctx, "cluster"))
+
+	configurations, err := s.CreateClusterConfiguration(ctx, "cluster", "user", "reason", "description", "{}")
+	FailOnError(t, err)
+	id := int64(configurations[0].ID)
+	assert.Equal(t, 1, configurations[0].Version)
+
+	FailOnError(t, s.EnableOrDisableClusterConfigurationByID(ctx, id, 1, "0"))
+
+	_, version, err := s.GetClusterConfigurationByID(ctx, id)
+	FailOnError(t, err)
+	assert.Equal(t, 2, version)
+
+	err = s.EnableOrDisableClusterConfigurationByID(ctx, id, 1, "1")
+	assert.IsType(t, &storage.VersionMismatchError{}, err)
+
+	err = s.DeleteClusterConfigurationByID(ctx, id, 1, "user")
+	assert.IsType(t, &storage.VersionMismatchError{}, err)
+
+	err = s.DeleteClusterConfigurationByID(ctx, id+1, 1, "user")
+	assert.IsType(t, &storage.ItemNotFoundError{}, err)
+
+	// enabling configuration for the whole cluster is checked against the version too
+	_, err = s.EnableClusterConfiguration(ctx, "cluster", 1, "user", "reason")
+	assert.IsType(t, &storage.VersionMismatchError{}, err)
+
+	// enabling configuration for the whole cluster changes the version too
+	configurations, err = s.EnableClusterConfiguration(ctx, "cluster", 2, "user", "reason")
+	FailOnError(t, err)
+	assert.Equal(t, 3, configurations[0].Version)
+
+	_, err = s.DisableClusterConfiguration(ctx, "cluster", 2, "user", "reason")
+	assert.IsType(t, &storage.VersionMismatchError{}, err)
+
+	configurations, err = s.DisableClusterConfiguration(ctx, "cluster", 3, "user", "reason")
+	FailOnError(t, err)
+	assert.Equal(t, 4, configurations[0].Version)
+
+	FailOnError(t, s.DeleteClusterConfigurationByID(ctx, id, 4, "user"))
+}
+
+// TestConfigurationProfileVersions checks versions of profiles stored in SQL database
+func TestConfigurationProfileVersions(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkConfigurationProfileVersions(t, mockStorage)
+}
+
+// TestClusterConfigurationVersions checks versions of cluster configurations stored in SQL database
+func TestClusterConfigurationVersions(t *testing.T) {
+	mockStorage, closer := MustGetMockStorage(t, true)
+	defer closer()
+
+	checkClusterConfigurationVersions(t, mockStorage)
+}
+
+// TestMemoryStorageConfigurationProfileVersions checks versions of profiles stored in memory
+func TestMemoryStorageConfigurationProfileVersions(t *testing.T) {
+	checkConfigurationProfileVersions(t, storage.NewMemoryStorage())
+}
+
+// TestMemoryStorageClusterConfigurationVersions checks versions of cluster configurations stored in memory
+func TestMemoryStorageClusterConfigurationVersions(t *testing.T) {
+	checkClusterConfigurationVersions(t, storage.NewMemoryStorage())
+}
@@ -131,6 +131,7 @@ func checkEnableExistingConfiguration() {
 	compareConfigurations(f, configurations, expected)
 
 	f.Put(API_URL + "client/configuration/0/enable")
+	f.SetHeader("If-Match", "*")
 	f.Send()
 	f.ExpectStatus(200)
 
@@ -167,6 +168,7 @@ func checkDisableExistingConfiguration() {
 	compareConfigurations(f, configurations, expected)
 
 	f.Put(API_URL + "client/configuration/0/disable")
+	f.SetHeader("If-Match", "*")
 	f.Send()
 	f.ExpectStatus(200)
 
@@ -203,6 +205,7 @@ func checkEnableNonExistingConfiguration() {
 	compareConfigurations(f, configurations, expected)
 
 	f.Put(API_URL + "client/configuration/42/enable")
+	f.SetHeader("If-Match", "*")
 	f.Send()
 	f.ExpectStatus(404)
 
@@ -239,6 +242,7 @@ func checkDisableNonExistingConfiguration() {
 	compareConfigurations(f, configurations, expected)
 
 	f.Put(API_URL + "client/configuration/42/enable")
+	f.SetHeader("If-Match", "*")
 	f.Send()
 	f.ExpectStatus(404)
 
@@ -275,6 +279,7 @@ func checkDeleteExistingConfiguration() {
 	compareConfigurations(f, configurations, expected)
 
 	f.Delete(API_URL + "client/configuration/0")
+	f.SetHeader("If-Match", "*")
 	f.Send()
 	f.ExpectStatus(200)
 
@@ -309,6 +314,7 @@ func checkDeleteNonExistingConfiguration() {
 	compareConfigurations(f, configurations, expected)
 
 	f.Delete(API_URL + "client/configuration/42")
+	f.SetHeader("If-Match", "*")
 	f.Send()
 	f.ExpectStatus(404)
 
@@ -184,6 +184,7 @@ func checkGetNonexistentConfigurationProfile() {
 func checkChangeConfigurationProfile() {
 	f := frisby.Create("Check changing configuration profile")
 	f.Put(API_URL + "client/profile/3?username=foo&description=bar")
+	f.SetHeader("If-Match", "*")
 	f.Req.Body = strings.NewReader(`{"no_op":"Z", "watch":[]}`)
 
 	f.Send()
@@ -215,6 +216,7 @@ func checkListOfConfigurationProfilesWithUpdatedItem() {
 func checkChangeNonExistingConfigurationProfile() {
 	f := frisby.Create("Check changing non existing configuration profile")
 	f.Put(API_URL + "client/profile/35?username=foo&description=bar")
+	f.SetHeader("If-Match", "*")
 	f.Req.Body = strings.NewReader(`{"no_op":"Z", "watch":[]}`)
 
 	f.Send()
@@ -225,6 +227,7 @@ func checkChangeNonExistingConfigurationProfile() {
 func checkDeleteConfigurationProfile() {
 	f := frisby.Create("Check deletion of configuration profile")
 	f.Delete(API_URL + "client/profile/3?")
+	f.SetHeader("If-Match", "*")
 
 	f.Send()
 	f.ExpectStatus(200)
@@ -234,6 +237,7 @@ func checkDeleteConfigurationProfile() {
 func checkDeleteNonexistingConfigurationProfile() {
 	f := frisby.Create("Check deletion of non existing configuration profile")
 	f.Delete(API_URL + "client/profile/35?")
+	f.SetHeader("If-Match", "*")
 
 	f.Send()
 	f.ExpectStatus(404)
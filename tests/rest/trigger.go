@@ -33,7 +33,6 @@ import (
 //     Link: link to any document with customer ACK with the trigger
 //     TriggeredAt: timestamp of the last configuration change
 //     TriggeredBy: username of admin that created or updated the trigger
-//     AckedAt: timestamp where the insights operator acked the trigger
 //     Parameters: parameters that needs to be pass to trigger code
 //     Active: flag indicating whether the trigger is still active or not
 type Trigger struct {
@@ -44,7 +43,6 @@ type Trigger struct {
 	Link        string `json:"link"`
 	TriggeredAt string `json:"triggered_at"`
 	TriggeredBy string `json:"triggered_by"`
-	AckedAt     string `json:"acked_at"`
 	Parameters  string `json:"parameters"`
 	Active      int    `json:"active"`
 }
@@ -110,10 +108,10 @@ func checkInitialListOfTriggers() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected := []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -127,10 +125,10 @@ func checkActivateExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected := []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -143,10 +141,10 @@ func checkActivateExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected = []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -160,10 +158,10 @@ func checkDeactivateExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected := []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -176,10 +174,10 @@ func checkDeactivateExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected = []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -193,10 +191,10 @@ func checkActivateAlreadyActivatedTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected := []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -209,10 +207,10 @@ func checkActivateAlreadyActivatedTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected = []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -226,10 +224,10 @@ func checkDeactivateAlreadyDeactivatedTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected := []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -242,10 +240,10 @@ func checkDeactivateAlreadyDeactivatedTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected = []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -259,10 +257,10 @@ func checkActivateNonExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected := []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -274,10 +272,10 @@ func checkActivateNonExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected = []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -291,10 +289,10 @@ func checkDeactivateNonExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected := []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -306,10 +304,10 @@ func checkDeactivateNonExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected = []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -323,10 +321,10 @@ func checkDeleteExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected := []Trigger{
-		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{1, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -338,9 +336,9 @@ func checkDeleteExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 3)
 
 	expected = []Trigger{
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -354,9 +352,9 @@ func checkDeleteNonExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 3)
 
 	expected := []Trigger{
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -368,9 +366,9 @@ func checkDeleteNonExistingTrigger() {
 	checkNumberOfTriggers(f, triggers, 3)
 
 	expected = []Trigger{
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers, expected)
 
@@ -402,7 +400,7 @@ func checkGetTriggersForCluster0() {
 	}
 
 	expected := []Trigger{
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers.Triggers, expected)
 
@@ -434,8 +432,8 @@ func checkGetTriggersForCluster1() {
 	}
 
 	expected := []Trigger{
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
 	}
 	compareTriggers(f, triggers.Triggers, expected)
 
@@ -473,8 +471,15 @@ func checkGetTriggersForClusterX() {
 }
 
 func checkCreateNewTrigger() {
-	f := frisby.Create("Check create new trigger")
-	f.Post(API_URL + "client/cluster/00000000-0000-0000-0000-000000000001/trigger/must-gather?username=tester&reason=r&link=l")
+	// must-gather triggers need to be linked to open support case
+	f := frisby.Create("Check create new support case")
+	f.Post(API_URL + "client/case/01234567?username=tester&account=1234")
+	f.Send()
+	f.ExpectStatus(201)
+	f.PrintReport()
+
+	f = frisby.Create("Check create new trigger")
+	f.Post(API_URL + "client/cluster/00000000-0000-0000-0000-000000000001/trigger/must-gather?username=tester&reason=r&link=l&case=01234567")
 	f.Send()
 	f.ExpectStatus(200)
 
@@ -482,10 +487,10 @@ func checkCreateNewTrigger() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected := []Trigger{
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{5, "must-gather", "00000000-0000-0000-0000-000000000001", "r", "l", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "", 1},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{5, "must-gather", "00000000-0000-0000-0000-000000000001", "r", "l", "1970-01-01T00:00:00Z", "tester", "", 1},
 	}
 
 	compareTriggers(f, triggers, expected)
@@ -502,10 +507,10 @@ func checkCreateNewTriggerForWrongCluster() {
 	checkNumberOfTriggers(f, triggers, 4)
 
 	expected := []Trigger{
-		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 0},
-		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "{}", 1},
-		{5, "must-gather", "00000000-0000-0000-0000-000000000001", "r", "l", "1970-01-01T00:00:00Z", "tester", "1970-01-01T00:00:00Z", "", 1},
+		{2, "must-gather", "00000000-0000-0000-0000-000000000000", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{3, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 0},
+		{4, "must-gather", "00000000-0000-0000-0000-000000000001", "reason", "link", "1970-01-01T00:00:00Z", "tester", "{}", 1},
+		{5, "must-gather", "00000000-0000-0000-0000-000000000001", "r", "l", "1970-01-01T00:00:00Z", "tester", "", 1},
 	}
 	compareTriggers(f, triggers, expected)
 	f.PrintReport()
@@ -20,7 +20,9 @@ package testdata
 // https://redhatinsights.github.io/insights-operator-controller/packages/tests/setup/testdata.html
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/RedHatInsights/insights-operator-controller/storage"
 	"github.com/brianvoe/gofakeit"
@@ -48,7 +50,14 @@ type dataConfiguration struct {
 // in your test to have meaningful and coherent tests
 func NewDataGenerator(dbDriver, storageSpecification string) DataGenerator {
 	var returnMe DataGenerator
-	returnMe.storage, _ = storage.New(dbDriver, storageSpecification)
+	dbStorage, _ := storage.New(dbDriver, storageSpecification)
+	returnMe.storage = dbStorage
+
+	// database created by scripts from local_storage needs to be migrated
+	err := dbStorage.MigrateToLatest(context.Background())
+	if err != nil {
+		panic(fmt.Errorf("database migration failed: %s ", err))
+	}
 
 	viper.SetConfigName("testconfig")
 
@@ -56,7 +65,7 @@ func NewDataGenerator(dbDriver, storageSpecification string) DataGenerator {
 	viper.AddConfigPath("./setup")
 	viper.AddConfigPath(".")
 
-	err := viper.ReadInConfig()
+	err = viper.ReadInConfig()
 	if err != nil {
 		panic(fmt.Errorf("fatal error config file: %s ", err))
 	}
@@ -84,7 +93,7 @@ func (g DataGenerator) PopulateCluster() []error {
 
 	for i := 0; i < g.config.ClusterNo; i++ {
 
-		err = g.storage.RegisterNewCluster(GetClusterName(i))
+		err = g.storage.RegisterNewCluster(context.Background(), GetClusterName(i))
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -113,7 +122,7 @@ func (g DataGenerator) PopulateConfigurationProfile() []error {
 
 	confStr := "{\"no_op\":\"X\", \"watch\":[\"a\",\"b\",\"c\"]}"
 	for i := 0; i < g.config.ConfProfileNo; i++ {
-		_, err = g.storage.StoreConfigurationProfile(gofakeit.Username(), gofakeit.Sentence(1), confStr)
+		_, err = g.storage.StoreConfigurationProfile(context.Background(), gofakeit.Username(), gofakeit.Sentence(1), confStr)
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -126,7 +135,7 @@ func (g DataGenerator) PopulateConfigurationProfile() []error {
 func (g DataGenerator) PopulateOperatorConfiguration() []error {
 	var errs []error
 	gofakeit.Seed(0)
-	clusters, queryErr := g.storage.ListOfClusters()
+	clusters, queryErr := g.storage.ListOfClusters(context.Background(), false)
 	if queryErr != nil {
 		errs = append(errs, queryErr)
 		return errs
@@ -134,7 +143,7 @@ func (g DataGenerator) PopulateOperatorConfiguration() []error {
 
 	for i := 0; i < g.config.OperatorConfigurationNo; i++ {
 		// creates configuration profiles and operator Configuration
-		_, err := g.storage.CreateClusterConfiguration(
+		_, err := g.storage.CreateClusterConfiguration(context.Background(),
 			string(clusters[i%len(clusters)].Name),
 			gofakeit.Username(),
 			gofakeit.Sentence(1),
@@ -154,19 +163,22 @@ func (g DataGenerator) PopulateOperatorConfiguration() []error {
 func (g DataGenerator) PopulateTrigger(triggerType string) []error {
 	var errs []error
 	gofakeit.Seed(0)
-	clusters, queryErr := g.storage.ListOfClusters()
+	clusters, queryErr := g.storage.ListOfClusters(context.Background(), false)
 	if queryErr != nil {
 		errs = append(errs, queryErr)
 		return errs
 	}
 
 	for i := 0; i < g.config.TriggerNo; i++ {
-		err := g.storage.NewTrigger(
+		err := g.storage.NewTrigger(context.Background(),
 			string(clusters[i%len(clusters)].Name),
 			triggerType,
 			gofakeit.Username(),
 			gofakeit.Sentence(2),
-			gofakeit.URL())
+			gofakeit.URL(),
+			"",
+			time.Time{},
+			"")
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -178,5 +190,5 @@ func (g DataGenerator) PopulateTrigger(triggerType string) []error {
 // InsertTriggerType inserts one trigger_type object with ttype type and
 // description
 func (g DataGenerator) InsertTriggerType(ttype, description string) error {
-	return g.storage.NewTriggerType(ttype, description)
+	return g.storage.NewTriggerType(context.Background(), ttype, description, "")
 }
@@ -0,0 +1,145 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/utils
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/utils/jsondiff.html
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operations used in JSONChange
+const (
+	JSONChangeAdded    = "added"
+	JSONChangeRemoved  = "removed"
+	JSONChangeModified = "modified"
+)
+
+// JSONChange represents one difference between two JSON documents.
+//     Path: JSON pointer (RFC 6901) to the changed value
+//     Operation: one of added, removed, or modified
+//     From: original value (not set for added values)
+//     To: new value (not set for removed values)
+type JSONChange struct {
+	Path      string      `json:"path"`
+	Operation string      `json:"op"`
+	From      interface{} `json:"from,omitempty"`
+	To        interface{} `json:"to,omitempty"`
+}
+
+// DiffJSON returns structural differences between two JSON documents. Objects
+// are compared key by key, arrays item by item, all other values are
+// compared as a whole. Changes are sorted by their paths.
+func DiffJSON(from, to string) ([]JSONChange, error) {
+	var fromValue, toValue interface{}
+
+	if err := json.Unmarshal([]byte(from), &fromValue); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(to), &toValue); err != nil {
+		return nil, err
+	}
+
+	changes := []JSONChange{}
+	changes = diffJSONValues("", fromValue, toValue, changes)
+	return changes, nil
+}
+
+// jsonPointerToken escapes one token of JSON pointer according to RFC 6901
+func jsonPointerToken(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return strings.ReplaceAll(token, "/", "~1")
+}
+
+// diffJSONValues compares two decoded JSON values and appends all changes
+func diffJSONValues(path string, from, to interface{}, changes []JSONChange) []JSONChange {
+	switch fromTyped := from.(type) {
+	case map[string]interface{}:
+		if toTyped, ok := to.(map[string]interface{}); ok {
+			return diffJSONObjects(path, fromTyped, toTyped, changes)
+		}
+	case []interface{}:
+		if toTyped, ok := to.([]interface{}); ok {
+			return diffJSONArrays(path, fromTyped, toTyped, changes)
+		}
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		changes = append(changes, JSONChange{
+			Path:      path,
+			Operation: JSONChangeModified,
+			From:      from,
+			To:        to,
+		})
+	}
+	return changes
+}
+
+// diffJSONObjects compares two JSON objects key by key
+func diffJSONObjects(path string, from, to map[string]interface{}, changes []JSONChange) []JSONChange {
+	keys := []string{}
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, found := from[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := path + "/" + jsonPointerToken(key)
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+
+		switch {
+		case !inTo:
+			changes = append(changes, JSONChange{Path: keyPath, Operation: JSONChangeRemoved, From: fromValue})
+		case !inFrom:
+			changes = append(changes, JSONChange{Path: keyPath, Operation: JSONChangeAdded, To: toValue})
+		default:
+			changes = diffJSONValues(keyPath, fromValue, toValue, changes)
+		}
+	}
+	return changes
+}
+
+// diffJSONArrays compares two JSON arrays item by item
+func diffJSONArrays(path string, from, to []interface{}, changes []JSONChange) []JSONChange {
+	for i := 0; i < len(from) || i < len(to); i++ {
+		itemPath := path + "/" + strconv.Itoa(i)
+
+		switch {
+		case i >= len(to):
+			changes = append(changes, JSONChange{Path: itemPath, Operation: JSONChangeRemoved, From: from[i]})
+		case i >= len(from):
+			changes = append(changes, JSONChange{Path: itemPath, Operation: JSONChangeAdded, To: to[i]})
+		default:
+			changes = diffJSONValues(itemPath, from[i], to[i], changes)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/utils/jsondiff_test.html
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/utils"
+)
+
+// TestDiffJSONSameDocuments checks that no changes are reported for equal documents
+func TestDiffJSONSameDocuments(t *testing.T) {
+	changes, err := utils.DiffJSON(`{"a": 1, "b": [1, 2]}`, `{"b": [1, 2], "a": 1}`)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 0)
+}
+
+// TestDiffJSONObjects checks differences between JSON objects
+func TestDiffJSONObjects(t *testing.T) {
+	changes, err := utils.DiffJSON(
+		`{"no_op": "X", "watch": ["a", "b", "c"], "nested": {"x/y": 1}}`,
+		`{"no_op": "Y", "watch": ["a", "d"], "nested": {"x/y": 1, "z": true}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, []utils.JSONChange{
+		{Path: "/nested/z", Operation: utils.JSONChangeAdded, To: true},
+		{Path: "/no_op", Operation: utils.JSONChangeModified, From: "X", To: "Y"},
+		{Path: "/watch/1", Operation: utils.JSONChangeModified, From: "b", To: "d"},
+		{Path: "/watch/2", Operation: utils.JSONChangeRemoved, From: "c"},
+	}, changes)
+}
+
+// TestDiffJSONDifferentTypes checks that value with different type is reported as modified
+func TestDiffJSONDifferentTypes(t *testing.T) {
+	changes, err := utils.DiffJSON(`{"a": {"b": 1}}`, `{"a": [1], "c~": null}`)
+	assert.NoError(t, err)
+	assert.Equal(t, []utils.JSONChange{
+		{Path: "/a", Operation: utils.JSONChangeModified, From: map[string]interface{}{"b": 1.0}, To: []interface{}{1.0}},
+		{Path: "/c~0", Operation: utils.JSONChangeAdded, To: nil},
+	}, changes)
+}
+
+// TestDiffJSONInvalidDocument checks that invalid JSON is reported
+func TestDiffJSONInvalidDocument(t *testing.T) {
+	_, err := utils.DiffJSON(`{`, `{}`)
+	assert.Error(t, err)
+
+	_, err = utils.DiffJSON(`{}`, `not a JSON`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+// Generated documentation is available at:
+// https://godoc.org/github.com/RedHatInsights/insights-operator-controller/utils
+//
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/utils/jsonschema.html
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// JSONSchemaError is reported when JSON document does not conform to JSON
+// schema. It contains description of all violations found in the document.
+type JSONSchemaError struct {
+	Violations []string
+}
+
+// Error returns description of all violations
+func (e *JSONSchemaError) Error() string {
+	return fmt.Sprintf("document does not conform to schema: %s", strings.Join(e.Violations, "; "))
+}
+
+// CheckJSONSchema checks that the schema itself is a valid JSON schema
+func CheckJSONSchema(schema string) error {
+	_, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+	if err != nil {
+		return fmt.Errorf("invalid JSON schema: %v", err)
+	}
+	return nil
+}
+
+// ValidateJSONSchema validates JSON document against JSON schema.
+// JSONSchemaError is returned when the document does not conform to the
+// schema, other errors are returned for malformed schema or document.
+func ValidateJSONSchema(schema, document string) error {
+	result, err := gojsonschema.Validate(
+		gojsonschema.NewStringLoader(schema),
+		gojsonschema.NewStringLoader(document))
+	if err != nil {
+		return err
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	violations := []string{}
+	for _, violation := range result.Errors() {
+		violations = append(violations, violationPointer(violation.Context())+": "+violation.Description())
+	}
+	return &JSONSchemaError{Violations: violations}
+}
+
+// violationPointer converts context of schema violation into JSON pointer
+// to the invalid value, for example /watch/1. Violations of the whole
+// document are reported as (root).
+func violationPointer(context *gojsonschema.JsonContext) string {
+	pointer := strings.TrimPrefix(context.String("/"), gojsonschema.STRING_CONTEXT_ROOT)
+	if pointer == "" {
+		return gojsonschema.STRING_CONTEXT_ROOT
+	}
+	return pointer
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils_test
+
+// Documentation in literate-programming-style is available at:
+// https://redhatinsights.github.io/insights-operator-controller/packages/utils/jsonschema_test.html
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-controller/utils"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"image": {"type": "string"},
+		"timeout": {"type": "integer", "minimum": 1}
+	},
+	"required": ["image"],
+	"additionalProperties": false
+}`
+
+// TestValidateJSONSchemaValidDocument checks that conforming document is accepted
+func TestValidateJSONSchemaValidDocument(t *testing.T) {
+	assert.NoError(t, utils.ValidateJSONSchema(testSchema, `{"image": "quay.io/image", "timeout": 10}`))
+}
+
+// TestValidateJSONSchemaInvalidDocument checks that all violations are reported
+func TestValidateJSONSchemaInvalidDocument(t *testing.T) {
+	err := utils.ValidateJSONSchema(testSchema, `{"timeout": 0, "unknown": true}`)
+
+	schemaErr, ok := err.(*utils.JSONSchemaError)
+	if !ok {
+		t.Fatalf("unexpected error %v", err)
+	}
+	assert.Len(t, schemaErr.Violations, 3)
+}
+
+// TestValidateJSONSchemaViolationPaths checks that violations contain JSON
+// pointer to the invalid value
+func TestValidateJSONSchemaViolationPaths(t *testing.T) {
+	schema := `{"type": "object", "properties": {"watch": {"type": "array", "items": {"type": "string"}}}}`
+
+	err := utils.ValidateJSONSchema(schema, `{"watch": ["a", 1]}`)
+	assert.EqualError(t, err, "document does not conform to schema: /watch/1: Invalid type. Expected: string, given: integer")
+
+	err = utils.ValidateJSONSchema(schema, `[]`)
+	assert.EqualError(t, err, "document does not conform to schema: (root): Invalid type. Expected: object, given: array")
+}
+
+// TestValidateJSONSchemaMalformedDocument checks that malformed document is refused
+func TestValidateJSONSchemaMalformedDocument(t *testing.T) {
+	err := utils.ValidateJSONSchema(testSchema, `{"image": `)
+	assert.Error(t, err)
+	_, isSchemaErr := err.(*utils.JSONSchemaError)
+	assert.False(t, isSchemaErr)
+}
+
+// TestCheckJSONSchema checks validation of the schema itself
+func TestCheckJSONSchema(t *testing.T) {
+	assert.NoError(t, utils.CheckJSONSchema(testSchema))
+	assert.Error(t, utils.CheckJSONSchema(`{"type": "unknown"}`))
+	assert.Error(t, utils.CheckJSONSchema(`{"type": `))
+}